@@ -3,9 +3,11 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/futurematic/kernel/internal/domain"
 	"github.com/futurematic/kernel/pkg/ctrldot"
@@ -20,9 +22,12 @@ func main() {
 		fmt.Println("\nCommands:")
 		fmt.Println("  capabilities          — GET /v1/capabilities (agent discovery)")
 		fmt.Println("  propose <agent_id> <action_type> <target_json> <cost_gbp>")
+		fmt.Println("  evaluate <agent_id> <action_type> <target_json> <cost_gbp>  — dry run, records nothing")
 		fmt.Println("  register <agent_id> <display_name>")
-		fmt.Println("  events <agent_id> [limit]")
+		fmt.Println("  events [agent_id] [limit] [--session ID] [--type T,...] [--severity S,...] [--decision D]")
+		fmt.Println("         [--action-type T] [--action-hash H] [--since-ts MS] [--until-ts MS] [--cursor C] [--page]")
 		fmt.Println("  agents")
+		fmt.Println("\nEnvironment: CTRLDOT_URL (default http://127.0.0.1:7777), CTRLDOT_AUTH_TOKEN (API token when auth is enabled)")
 		os.Exit(1)
 	}
 
@@ -31,6 +36,7 @@ func main() {
 		baseURL = "http://127.0.0.1:7777"
 	}
 	client := ctrldot.NewClient(baseURL)
+	client.Token = os.Getenv("CTRLDOT_AUTH_TOKEN")
 	ctx := context.Background()
 
 	command := os.Args[1]
@@ -50,9 +56,9 @@ func main() {
 	}
 
 	switch command {
-	case "propose":
+	case "propose", "evaluate":
 		if len(os.Args) < 6 {
-			log.Fatal("Usage: propose <agent_id> <action_type> <target_json> <cost_gbp>")
+			log.Fatalf("Usage: %s <agent_id> <action_type> <target_json> <cost_gbp>", command)
 		}
 		agentID := os.Args[2]
 		actionType := os.Args[3]
@@ -86,9 +92,19 @@ func main() {
 			},
 		}
 
-		decision, err := client.ProposeAction(ctx, proposal)
-		if err != nil {
-			log.Fatal(err)
+		var decision *domain.DecisionResponse
+		if command == "evaluate" {
+			result, err := client.EvaluateActions(ctx, proposal)
+			if err != nil {
+				log.Fatal(err)
+			}
+			decision = &result.Decisions[0]
+		} else {
+			var err error
+			decision, err = client.ProposeAction(ctx, proposal)
+			if err != nil {
+				log.Fatal(err)
+			}
 		}
 
 		// Output in MCP-friendly format (JSON)
@@ -110,25 +126,51 @@ func main() {
 		json.NewEncoder(os.Stdout).Encode(agent)
 
 	case "events":
-		agentID := ""
-		limit := 10
-		if len(os.Args) >= 3 {
-			agentID = os.Args[2]
+		// Positional form (events <agent_id> [limit]) is kept; filters are flags.
+		fs := flag.NewFlagSet("events", flag.ExitOnError)
+		q := ctrldot.EventQuery{Limit: 10}
+		var types, severities string
+		fs.StringVar(&q.AgentID, "agent", "", "agent ID")
+		fs.StringVar(&q.SessionID, "session", "", "session ID")
+		fs.StringVar(&types, "type", "", "event types (comma-separated)")
+		fs.StringVar(&severities, "severity", "", "severities (comma-separated)")
+		fs.StringVar(&q.Decision, "decision", "", "decision (e.g. DENY)")
+		fs.StringVar(&q.ActionType, "action-type", "", "action type")
+		fs.StringVar(&q.ActionHash, "action-hash", "", "action hash")
+		fs.Int64Var(&q.SinceTS, "since-ts", 0, "unix ms, inclusive")
+		fs.Int64Var(&q.UntilTS, "until-ts", 0, "unix ms, exclusive")
+		fs.StringVar(&q.Cursor, "cursor", "", "next_cursor from a previous page")
+		fs.IntVar(&q.Limit, "limit", q.Limit, "max events")
+		withCursor := fs.Bool("page", false, "print {events, next_cursor} instead of the events array")
+		args := os.Args[2:]
+		if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+			q.AgentID = args[0]
+			args = args[1:]
+			if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+				fmt.Sscanf(args[0], "%d", &q.Limit)
+				args = args[1:]
+			}
 		}
-		if len(os.Args) >= 4 {
-			fmt.Sscanf(os.Args[3], "%d", &limit)
+		fs.Parse(args)
+		if types != "" {
+			q.Types = strings.Split(types, ",")
 		}
-
-		var agentIDPtr *string
-		if agentID != "" {
-			agentIDPtr = &agentID
+		if severities != "" {
+			q.Severities = strings.Split(severities, ",")
 		}
 
-		events, err := client.GetEvents(ctx, agentIDPtr, nil, limit)
+		page, err := client.ListEvents(ctx, q)
 		if err != nil {
 			log.Fatal(err)
 		}
-		json.NewEncoder(os.Stdout).Encode(events)
+		if page.Events == nil {
+			page.Events = []domain.Event{}
+		}
+		if *withCursor {
+			json.NewEncoder(os.Stdout).Encode(page)
+		} else {
+			json.NewEncoder(os.Stdout).Encode(page.Events)
+		}
 
 	case "agents":
 		agents, err := client.ListAgents(ctx)
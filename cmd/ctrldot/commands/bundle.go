@@ -1,9 +1,12 @@
 package commands
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/futurematic/kernel/internal/config"
 	"github.com/futurematic/kernel/internal/ledger/sink/bundle"
@@ -13,10 +16,14 @@ import (
 func bundleCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "bundle",
-		Short: "List and verify signed bundle artefacts",
+		Short: "List, verify, pack and unpack signed bundle artefacts",
 	}
 	cmd.AddCommand(bundleLsCmd())
 	cmd.AddCommand(bundleVerifyCmd())
+	cmd.AddCommand(bundleAuditCmd())
+	cmd.AddCommand(bundlePackCmd())
+	cmd.AddCommand(bundleUnpackCmd())
+	cmd.AddCommand(bundleDecryptCmd())
 	return cmd
 }
 
@@ -66,12 +73,17 @@ func runBundleLs(cmd *cobra.Command, args []string) error {
 }
 
 func bundleVerifyCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "verify [path]",
-		Short: "Verify signature and manifest hashes of a bundle directory",
+		Short: "Verify manifest hashes and that a trusted key signed the bundle (directory or .tar.gz)",
 		Args:  cobra.ExactArgs(1),
 		RunE:  runBundleVerify,
 	}
+	addBundleVerifyFlags(cmd)
+	cmd.Flags().String("log", "", "Transparency log directory (default: ledger_sink.bundle.sign.transparency_log_dir)")
+	cmd.Flags().Bool("require-log", false, "Fail for a bundle that is not in a transparency log (default: on when a log is configured or passed with --log)")
+	cmd.Flags().Bool("no-log", false, "Only check the bundle's own inclusion proof, not the local transparency log (retired keys are then dated by the manifest's created_at)")
+	return cmd
 }
 
 func runBundleVerify(cmd *cobra.Command, args []string) error {
@@ -80,17 +92,200 @@ func runBundleVerify(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	if !info.IsDir() {
-		return fmt.Errorf("not a directory: %s", dir)
+	if !info.IsDir() && !bundle.IsArchive(dir) {
+		return fmt.Errorf("not a directory or %s archive: %s", bundle.ArchiveExt, dir)
 	}
 	abs, err := filepath.Abs(dir)
 	if err != nil {
 		abs = dir
 	}
-	if err := bundle.VerifyBundle(abs); err != nil {
+	opts, err := bundleVerifyOptions(cmd)
+	if err != nil {
+		return err
+	}
+	log, err := bundleLog(cmd)
+	if err != nil {
+		return err
+	}
+	keyID, err := bundle.VerifyBundleWith(abs, opts)
+	if err != nil {
 		fmt.Printf("✗ Verify failed: %v\n", err)
 		return err
 	}
-	fmt.Printf("✓ Bundle verified: %s\n", abs)
+	if opts.Trust == nil {
+		fmt.Printf("✓ Bundle verified against its embedded key %s (not checked against trusted keys): %s\n", keyID, abs)
+	} else {
+		fmt.Printf("✓ Bundle verified: %s (signed by %s)\n", abs, keyID)
+	}
+
+	res, err := bundle.VerifyTransparency(abs, log, opts)
+	if errors.Is(err, bundle.ErrNotLogged) {
+		requireLog := log != nil
+		if cmd.Flags().Changed("require-log") {
+			requireLog, _ = cmd.Flags().GetBool("require-log")
+		}
+		if requireLog {
+			fmt.Printf("✗ Transparency log: %v\n", err)
+			return err
+		}
+		fmt.Printf("! %v\n", err)
+		return nil
+	}
+	if err != nil {
+		fmt.Printf("✗ Transparency log: %v\n", err)
+		return err
+	}
+	if res.Latest == nil {
+		fmt.Printf("✓ Included in the transparency log at index %d (tree head %d)\n", res.LogIndex, res.TreeHead.TreeSize)
+		return nil
+	}
+	fmt.Printf("✓ Included in the transparency log at index %d; tree head %d is consistent with the latest head %d (%s)\n",
+		res.LogIndex, res.TreeHead.TreeSize, res.Latest.TreeSize, log.Dir())
+	return nil
+}
+
+func bundleAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit [bundle-dir...]",
+		Short: "Check the bundle transparency log for deleted, changed or unlogged bundles",
+		Long: `Check the transparency log against its signed tree heads and against the bundles on disk.
+Every logged bundle must still exist unchanged, and every bundle found must be logged.
+Bundle directories default to ledger_sink.bundle.output_dir and autobundle.output_dir.`,
+		RunE: runBundleAudit,
+	}
+	cmd.Flags().String("log", "", "Transparency log directory (default: ledger_sink.bundle.sign.transparency_log_dir)")
+	addBundleVerifyFlags(cmd)
+	return cmd
+}
+
+func runBundleAudit(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cliConfigPath())
+	if err != nil {
+		return err
+	}
+	logDir, _ := cmd.Flags().GetString("log")
+	if logDir == "" {
+		logDir = bundle.TransparencyLogDir(cfg.LedgerSink.Bundle.Sign)
+	}
+	if logDir == "" {
+		return fmt.Errorf("transparency log is disabled (ledger_sink.bundle.sign.transparency_log_dir); pass --log")
+	}
+	dirs := args
+	if len(dirs) == 0 {
+		dirs = bundle.BundleDirs(cfg)
+	}
+	opts, err := bundleVerifyOptions(cmd)
+	if err != nil {
+		return err
+	}
+	rep, err := bundle.OpenTransparencyLog(logDir).Audit(dirs, opts)
+	if err != nil {
+		return err
+	}
+
+	if outputJSON, _ := cmd.Flags().GetBool("json"); outputJSON {
+		if err := json.NewEncoder(os.Stdout).Encode(rep); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Transparency log: %s (%d entries, %d signed tree heads)\n", rep.LogDir, rep.Entries, rep.TreeHeads)
+		fmt.Printf("Bundles scanned: %d\n", rep.Bundles)
+		for _, p := range rep.Problems {
+			where := p.Bundle
+			if p.Index >= 0 {
+				where = fmt.Sprintf("entry %d %s", p.Index, p.Bundle)
+			}
+			fmt.Printf("  ✗ %s: %s\n", where, p.Problem)
+		}
+		if rep.OK {
+			fmt.Println("✓ No gaps: every logged bundle is present and every bundle is logged")
+		}
+	}
+	if !rep.OK {
+		return fmt.Errorf("transparency log audit found %d problem(s)", len(rep.Problems))
+	}
 	return nil
 }
+
+func bundlePackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pack <dir>",
+		Short: "Pack a bundle directory into a single deterministic .tar.gz",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, _ := cmd.Flags().GetString("out")
+			path, err := bundle.PackFile(args[0], out)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✓ Packed %s\n", path)
+			return nil
+		},
+	}
+	cmd.Flags().String("out", "", "Archive file or directory (default: <dir>.tar.gz)")
+	return cmd
+}
+
+func bundleUnpackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unpack <archive.tar.gz>",
+		Short: "Unpack a bundle archive into a bundle directory",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dest, _ := cmd.Flags().GetString("dest")
+			if dest == "" {
+				dest = filepath.Dir(args[0])
+			}
+			dir, err := bundle.Unpack(args[0], dest)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✓ Unpacked %s\n", dir)
+			fmt.Printf("  Verify with: ctrldot bundle verify %s\n", dir)
+			return nil
+		},
+	}
+	cmd.Flags().String("dest", "", "Directory to unpack into (default: next to the archive)")
+	return cmd
+}
+
+// defaultRecipientKeyPath is where `ctrldot keys recipient` writes and `bundle decrypt` reads.
+const defaultRecipientKeyPath = "~/.ctrldot/keys/bundle_recipient_x25519"
+
+func bundleDecryptCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "decrypt <bundle>",
+		Short: "Verify an encrypted bundle and decrypt its payload files with a recipient key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := filepath.Clean(args[0])
+			opts, err := bundleVerifyOptions(cmd)
+			if err != nil {
+				return err
+			}
+			keyID, err := bundle.VerifyBundleWith(path, opts)
+			if err != nil {
+				fmt.Printf("✗ Verify failed: %v\n", err)
+				return err
+			}
+			keyPath, _ := cmd.Flags().GetString("key")
+			priv, err := bundle.ReadRecipientKey(expandHome(keyPath))
+			if err != nil {
+				return err
+			}
+			out, _ := cmd.Flags().GetString("out")
+			if out == "" {
+				out = strings.TrimSuffix(path, bundle.ArchiveExt) + "_decrypted"
+			}
+			if err := bundle.Decrypt(path, priv, out); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Verified (signed by %s) and decrypted to %s\n", keyID, out)
+			return nil
+		},
+	}
+	cmd.Flags().String("key", defaultRecipientKeyPath, "Recipient X25519 private key")
+	cmd.Flags().String("out", "", "Output directory (default: <bundle>_decrypted)")
+	addBundleVerifyFlags(cmd)
+	return cmd
+}
@@ -6,6 +6,8 @@ import (
 
 // RegisterCommands registers all CLI commands
 func RegisterCommands(rootCmd *cobra.Command) {
+	rootCmd.PersistentPreRun = useAPIToken
+
 	// Status
 	rootCmd.AddCommand(statusCmd())
 
@@ -26,7 +28,7 @@ func RegisterCommands(rootCmd *cobra.Command) {
 	rootCmd.AddCommand(budgetCmd())
 
 	// Events
-	rootCmd.AddCommand(eventsTailCmd())
+	rootCmd.AddCommand(eventsCmd())
 
 	// Rules
 	rootCmd.AddCommand(rulesShowCmd())
@@ -46,6 +48,11 @@ func RegisterCommands(rootCmd *cobra.Command) {
 
 	// Bundle
 	rootCmd.AddCommand(bundleCmd())
+	rootCmd.AddCommand(keysCmd())
+	rootCmd.AddCommand(replayCmd())
+
+	// Shadow policy
+	rootCmd.AddCommand(shadowCmd())
 
 	// Panic
 	rootCmd.AddCommand(panicCmd())
@@ -53,6 +60,12 @@ func RegisterCommands(rootCmd *cobra.Command) {
 	// Autobundle
 	rootCmd.AddCommand(autobundleCmd())
 
+	// Webhooks
+	rootCmd.AddCommand(webhooksCmd())
+
 	// Config
 	rootCmd.AddCommand(configCmd())
+
+	// API tokens
+	rootCmd.AddCommand(tokensCmd())
 }
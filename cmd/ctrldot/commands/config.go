@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Validate the config file, show the config in force and its revision history",
+	}
+	cmd.AddCommand(configValidateCmd())
+	cmd.AddCommand(configEffectiveCmd())
+	cmd.AddCommand(configRevisionsCmd())
+	cmd.AddCommand(configRollbackCmd())
+	return cmd
+}
+
+func configValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Check a config file for unknown keys and invalid values",
+		Long: `Load a config file the way the daemon does and list every problem with its line: unknown
+(e.g. misspelt) keys, values of the wrong type and settings that cannot work, such as
+throttle_pct above hard_stop_pct or a negative budget. Exits non-zero when the file is invalid.
+Environment overrides (CTRLDOT_*) are applied and checked too.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runConfigValidate,
+	}
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	configPath := cliConfigPath()
+	if len(args) == 1 {
+		configPath = expandHome(args[0])
+	}
+	// Load would create a missing file with defaults
+	if _, err := os.Stat(configPath); err != nil {
+		return err
+	}
+	_, err := config.Load(configPath)
+	problems := config.Problems(err)
+
+	if outputJSON, _ := cmd.Flags().GetBool("json"); outputJSON {
+		if problems == nil {
+			problems = []*config.FieldError{}
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"path":   configPath,
+			"valid":  err == nil,
+			"errors": problems,
+		}); err != nil {
+			return err
+		}
+	} else if err == nil {
+		fmt.Printf("✓ %s is valid\n", configPath)
+	} else {
+		fmt.Printf("✗ %s: %d problem(s)\n", configPath, len(problems))
+		for _, p := range problems {
+			fmt.Printf("  %s\n", p)
+		}
+	}
+	if err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("invalid config")
+	}
+	return nil
+}
+
+func configEffectiveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "effective",
+		Short: "Print the config the engines use, with defaults, env overrides and (--panic) the panic overlay",
+		Long: `Print the loaded config as YAML after defaults and CTRLDOT_* environment overrides. With
+--panic, the panic overlay is applied (config.Effective) so you can see what panic mode actually
+enforces: clamped budget, panic thresholds, forced resolution, workspace roots, network allowlist
+and the tighter loop limits. Without --panic the overlay applies only if panic.enabled is set.
+The daemon's runtime panic state (ctrldot panic on) is not consulted.`,
+		Args: cobra.NoArgs,
+		RunE: runConfigEffective,
+	}
+	cmd.Flags().Bool("panic", false, "Apply the panic overlay as if panic mode were on")
+	return cmd
+}
+
+func runConfigEffective(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cliConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	panicOn, _ := cmd.Flags().GetBool("panic")
+	effective := config.Effective(cfg, &domain.PanicState{Enabled: panicOn || cfg.Panic.Enabled})
+
+	data, err := yaml.Marshal(effective)
+	if err != nil {
+		return err
+	}
+	if outputJSON, _ := cmd.Flags().GetBool("json"); outputJSON {
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		if effective.Loop != nil {
+			doc["panic_loop_overlay"] = map[string]int{"window_seconds": effective.Loop.WindowSeconds, "stop_repeats": effective.Loop.StopRepeats}
+		}
+		return json.NewEncoder(os.Stdout).Encode(doc)
+	}
+	os.Stdout.Write(data)
+	if effective.Loop != nil {
+		// Not part of the config schema: the default loop window, and a cap on rules' stop_repeats
+		fmt.Printf("# panic loop overlay (default window; caps loop_detection stop_repeats):\n")
+		fmt.Printf("#   window_seconds: %d\n#   stop_repeats: %d\n", effective.Loop.WindowSeconds, effective.Loop.StopRepeats)
+	}
+	return nil
+}
+
+func configRevisionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "revisions",
+		Short: "List the config revisions the daemon has applied, newest first",
+		Long: `List the config revisions recorded by the daemon: one per applied change to the config
+file (at startup, on reload or on rollback), with its content hash, author and diff. Decision
+events, decision records and bundle manifests carry the revision (config_rev) in force.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serverURL, _ := cmd.Flags().GetString("server")
+			limit, _ := cmd.Flags().GetInt("n")
+			resp, err := http.Get(serverURL + "/v1/config/revisions?limit=" + strconv.Itoa(limit))
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				body, _ := io.ReadAll(resp.Body)
+				return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+			}
+			var revisions []domain.ConfigRevision
+			if err := json.NewDecoder(resp.Body).Decode(&revisions); err != nil {
+				return err
+			}
+			if outputJSON, _ := cmd.Flags().GetBool("json"); outputJSON {
+				return json.NewEncoder(os.Stdout).Encode(revisions)
+			}
+			if len(revisions) == 0 {
+				fmt.Println("No config revisions recorded")
+				return nil
+			}
+			for _, rev := range revisions {
+				author := rev.Author
+				if author == "" {
+					author = "-"
+				}
+				trigger := rev.Trigger
+				if rev.RollbackOf > 0 {
+					trigger = fmt.Sprintf("%s of %d", trigger, rev.RollbackOf)
+				}
+				fmt.Printf("%d  %s  %s  %s  %s\n", rev.Rev, rev.CreatedAt.Local().Format(time.DateTime), trigger, author, rev.ContentHash)
+				for _, c := range rev.Changes {
+					fmt.Printf("    %s\n", c)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Int("n", 20, "Number of revisions to show")
+	return cmd
+}
+
+func configRollbackCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rollback <rev>",
+		Short: "Restore the config file of an earlier revision and apply it",
+		Long: `Write the config file recorded for revision <rev> back to the daemon's config path and apply
+it, recording a new revision. The file is left untouched if the old content no longer validates.
+Sections that need a restart (server, runtime_store, ledger_sink, ...) are reported, not applied.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rev, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil || rev <= 0 {
+				return fmt.Errorf("invalid revision %q", args[0])
+			}
+			serverURL, _ := cmd.Flags().GetString("server")
+			res, err := postConfigChange(serverURL + "/v1/config/rollback/" + args[0])
+			if err != nil {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("rollback rejected, running config kept: %w", err)
+			}
+			return printReloadResult(cmd, fmt.Sprintf("Rolled back to revision %d", rev), res)
+		},
+	}
+}
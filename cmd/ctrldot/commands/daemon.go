@@ -1,10 +1,14 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/futurematic/kernel/internal/config"
 	"github.com/spf13/cobra"
@@ -112,8 +116,62 @@ func daemonCmd() *cobra.Command {
 		},
 	}
 
+	reloadCmd := &cobra.Command{
+		Use:   "reload",
+		Short: "Reload the daemon's config file (like SIGHUP) and show what changed",
+		RunE:  runDaemonReload,
+	}
+
 	cmd.AddCommand(startCmd)
 	cmd.AddCommand(stopCmd)
 	cmd.AddCommand(logsCmd)
+	cmd.AddCommand(reloadCmd)
 	return cmd
 }
+
+func runDaemonReload(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	res, err := postConfigChange(serverURL + "/v1/config/reload")
+	if err != nil {
+		return fmt.Errorf("reload rejected, running config kept: %w", err)
+	}
+	return printReloadResult(cmd, "Config reloaded", res)
+}
+
+// postConfigChange POSTs to a config reload/rollback endpoint with the current user as author.
+func postConfigChange(url string) (*config.ReloadResult, error) {
+	body, _ := json.Marshal(map[string]string{"author": os.Getenv("USER")})
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var errBody map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&errBody)
+		return nil, fmt.Errorf("%s", errBody["error"])
+	}
+	var res config.ReloadResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func printReloadResult(cmd *cobra.Command, what string, res *config.ReloadResult) error {
+	if outputJSON, _ := cmd.Flags().GetBool("json"); outputJSON {
+		return json.NewEncoder(os.Stdout).Encode(res)
+	}
+	if len(res.Changes) == 0 {
+		fmt.Printf("%s: no changes\n", what)
+	} else {
+		fmt.Printf("%s as revision %d: %d change(s)\n", what, res.Revision, len(res.Changes))
+		for _, c := range res.Changes {
+			fmt.Printf("  %s\n", c)
+		}
+	}
+	if len(res.RestartRequired) > 0 {
+		fmt.Printf("Restart the daemon to apply: %s\n", strings.Join(res.RestartRequired, ", "))
+	}
+	return nil
+}
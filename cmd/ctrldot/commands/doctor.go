@@ -6,9 +6,15 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/eventchain"
+	"github.com/futurematic/kernel/internal/ledger/sink/file"
+	"github.com/futurematic/kernel/internal/ledger/sink/syslog"
+	"github.com/futurematic/kernel/internal/runtime"
 	"github.com/futurematic/kernel/internal/runtime/sqlite"
 	"github.com/futurematic/kernel/internal/store"
 	"github.com/spf13/cobra"
@@ -18,24 +24,14 @@ func doctorCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "doctor",
 		Short: "Run checks and print actionable fixes",
-		Long:  "Checks: runtime DB (open + migrate), ledger sink (kernel_http reachable, bundle keys).",
+		Long:  "Checks: runtime DB (open + migrate), ledger sink (kernel_http reachable, bundle keys, file writable, syslog reachable).",
 		RunE:  runDoctor,
 	}
 	return cmd
 }
 
 func runDoctor(cmd *cobra.Command, args []string) error {
-	configPath := os.Getenv("CTRLDOT_CONFIG")
-	if configPath == "" {
-		configPath = "~/.ctrldot/config.yaml"
-	}
-	if len(configPath) >= 2 && configPath[:2] == "~/" {
-		home, _ := os.UserHomeDir()
-		if home != "" {
-			configPath = filepath.Join(home, configPath[2:])
-		}
-	}
-
+	configPath := cliConfigPath()
 	cfg, err := config.Load(configPath)
 	if err != nil {
 		fmt.Printf("✗ Load config: %v\n", err)
@@ -50,32 +46,18 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	}
 	switch kind {
 	case "sqlite":
-		sqlitePath := cfg.RuntimeStore.SQLitePath
-		if sqlitePath == "" {
-			home, _ := os.UserHomeDir()
-			if home != "" {
-				sqlitePath = filepath.Join(home, ".ctrldot", "ctrldot.sqlite")
-			}
-		}
-		if len(sqlitePath) >= 2 && sqlitePath[:2] == "~/" {
-			home, _ := os.UserHomeDir()
-			if home != "" {
-				sqlitePath = filepath.Join(home, sqlitePath[2:])
-			}
-		}
+		sqlitePath := runtimeSQLitePath(cfg)
 		st, err := sqlite.Open(context.Background(), sqlitePath)
 		if err != nil {
 			fmt.Printf("✗ Runtime store (SQLite): %v\n", err)
 			fmt.Printf("  Fix: ensure directory exists and path is writable: %s\n", filepath.Dir(sqlitePath))
 		} else {
-			_ = st.Close()
 			fmt.Printf("✓ Runtime store (SQLite): %s\n", sqlitePath)
+			printEventLogStatus(st, cfg)
+			_ = st.Close()
 		}
 	case "postgres":
-		dbURL := cfg.RuntimeStore.DBURL
-		if dbURL == "" {
-			dbURL = cfg.Ledger.DBURL
-		}
+		dbURL := runtimeDBURL(cfg)
 		if dbURL == "" {
 			fmt.Printf("✗ Runtime store (Postgres): no db_url in config or DB_URL in env\n")
 		} else {
@@ -84,8 +66,9 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 				fmt.Printf("✗ Runtime store (Postgres): %v\n", err)
 				fmt.Printf("  Fix: start Postgres, set DB_URL or runtime_store.db_url\n")
 			} else {
-				_ = st.Close()
 				fmt.Printf("✓ Runtime store (Postgres): connected\n")
+				printEventLogStatus(runtime.NewPostgresStore(st), cfg)
+				_ = st.Close()
 			}
 		}
 	default:
@@ -97,8 +80,13 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	if sinkKind == "" {
 		sinkKind = "none"
 	}
-	fmt.Printf("  Ledger sink: %s\n", sinkKind)
-	if sinkKind == "kernel_http" {
+	sinkKinds := cfg.LedgerSink.Kinds()
+	if sinkKind == "multi" {
+		fmt.Printf("  Ledger sink: multi (%s)\n", strings.Join(sinkKinds, ", "))
+	} else {
+		fmt.Printf("  Ledger sink: %s\n", sinkKind)
+	}
+	if slices.Contains(sinkKinds, "kernel_http") {
 		// Would need ledger_kernel_http.base_url from config; spec says CTRLDOT_KERNEL_URL
 		kernelURL := os.Getenv("CTRLDOT_KERNEL_URL")
 		if kernelURL == "" {
@@ -118,7 +106,7 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 			}
 		}
 	}
-	if sinkKind == "bundle" {
+	if slices.Contains(sinkKinds, "bundle") {
 		keysDir := filepath.Join(os.Getenv("HOME"), ".ctrldot", "keys")
 		if os.Getenv("HOME") == "" {
 			keysDir = ".ctrldot/keys"
@@ -129,7 +117,137 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 			fmt.Printf("✓ Bundle: keys dir exists: %s\n", keysDir)
 		}
 	}
+	if slices.Contains(sinkKinds, "file") {
+		if f, err := file.NewSink(cfg.LedgerSink.File); err != nil {
+			fmt.Printf("✗ File sink: %v\n", err)
+			fmt.Printf("  Fix: set ledger_sink.file.path to a writable location\n")
+		} else {
+			_ = f.Close()
+			fmt.Printf("✓ File sink: %s writable\n", cfg.LedgerSink.File.Path)
+		}
+	}
+	if slices.Contains(sinkKinds, "syslog") {
+		if sl, err := syslog.NewSink(cfg.LedgerSink.Syslog); err != nil {
+			fmt.Printf("✗ Syslog sink: %v\n", err)
+			fmt.Printf("  Fix: start a syslog daemon or set ledger_sink.syslog.network/address\n")
+		} else {
+			_ = sl.Close()
+			fmt.Printf("✓ Syslog sink: connected\n")
+		}
+	}
 
 	fmt.Printf("  Server: %s:%d\n", cfg.Server.Host, cfg.Server.Port)
 	return nil
 }
+
+// cliConfigPath is $CTRLDOT_CONFIG or ~/.ctrldot/config.yaml, with ~ expanded.
+func cliConfigPath() string {
+	configPath := os.Getenv("CTRLDOT_CONFIG")
+	if configPath == "" {
+		configPath = "~/.ctrldot/config.yaml"
+	}
+	return expandHome(configPath)
+}
+
+// expandHome expands a leading ~/ in p.
+func expandHome(p string) string {
+	if len(p) >= 2 && p[:2] == "~/" {
+		home, _ := os.UserHomeDir()
+		if home != "" {
+			return filepath.Join(home, p[2:])
+		}
+	}
+	return p
+}
+
+// runtimeSQLitePath is runtime_store.sqlite_path (default ~/.ctrldot/ctrldot.sqlite), with ~ expanded.
+func runtimeSQLitePath(cfg *config.Config) string {
+	sqlitePath := cfg.RuntimeStore.SQLitePath
+	if sqlitePath == "" {
+		home, _ := os.UserHomeDir()
+		if home != "" {
+			sqlitePath = filepath.Join(home, ".ctrldot", "ctrldot.sqlite")
+		}
+	}
+	if len(sqlitePath) >= 2 && sqlitePath[:2] == "~/" {
+		home, _ := os.UserHomeDir()
+		if home != "" {
+			sqlitePath = filepath.Join(home, sqlitePath[2:])
+		}
+	}
+	return sqlitePath
+}
+
+// runtimeDBURL is runtime_store.db_url, falling back to ledger.db_url.
+func runtimeDBURL(cfg *config.Config) string {
+	if cfg.RuntimeStore.DBURL != "" {
+		return cfg.RuntimeStore.DBURL
+	}
+	return cfg.Ledger.DBURL
+}
+
+// openRuntimeStore opens the runtime store configured in cfg directly (no daemon needed).
+// The returned description names the backend for messages.
+func openRuntimeStore(ctx context.Context, cfg *config.Config) (runtime.RuntimeStore, string, error) {
+	switch kind := cfg.RuntimeStore.Kind; kind {
+	case "", "sqlite":
+		path := runtimeSQLitePath(cfg)
+		st, err := sqlite.Open(ctx, path)
+		if err != nil {
+			return nil, "", fmt.Errorf("runtime store (SQLite): %w", err)
+		}
+		return st, "SQLite " + path, nil
+	case "postgres":
+		dbURL := runtimeDBURL(cfg)
+		if dbURL == "" {
+			return nil, "", fmt.Errorf("runtime store (Postgres): no db_url in config or DB_URL in env")
+		}
+		st, err := store.NewPostgresStore(dbURL)
+		if err != nil {
+			return nil, "", fmt.Errorf("runtime store (Postgres): %w", err)
+		}
+		return &closingRuntimeStore{runtime.NewPostgresStore(st), st}, "Postgres", nil
+	default:
+		return nil, "", fmt.Errorf("unknown runtime_store.kind: %q (use sqlite or postgres)", kind)
+	}
+}
+
+// closingRuntimeStore closes the underlying Postgres store (runtime.PostgresStore.Close is a no-op).
+type closingRuntimeStore struct {
+	runtime.RuntimeStore
+	st *store.PostgresStore
+}
+
+func (s *closingRuntimeStore) Close() error {
+	return s.st.Close()
+}
+
+// printEventLogStatus reports event log size, rollups, the last compaction run and the hash chain.
+func printEventLogStatus(st runtime.RuntimeStore, cfg *config.Config) {
+	stats, err := st.EventStats(context.Background())
+	if err != nil {
+		fmt.Printf("✗ Event log: %v\n", err)
+		return
+	}
+	fmt.Printf("  Event log: %d events, %d hourly rollups (retention_days=%d, max_rows=%d)\n",
+		stats.EventCount, stats.RollupCount, cfg.Events.RetentionDays, cfg.Events.MaxRows)
+	if stats.LastCompaction == nil {
+		fmt.Printf("  Last compaction: never (runs when the daemon is up)\n")
+	} else {
+		deleted, _ := stats.LastCompaction.PayloadJSON["deleted"].(float64)
+		fmt.Printf("✓ Last compaction: %s (pruned %.0f events)\n", stats.LastCompaction.TS.Format(time.RFC3339), deleted)
+	}
+	if cfg.Events.MaxRows > 0 && stats.EventCount > int64(cfg.Events.MaxRows) {
+		fmt.Printf("  Event log is over max_rows; it will be compacted on the next run\n")
+	}
+	res, err := eventchain.Verify(context.Background(), st)
+	switch {
+	case err != nil:
+		fmt.Printf("✗ Event chain: %v\n", err)
+	case !res.OK:
+		fmt.Printf("✗ Event chain: broken at event %d: %s\n", res.Break.Seq, res.Break.Reason)
+		fmt.Printf("  Fix: the runtime DB was modified outside ctrldot; run `ctrldot events verify --checkpoint <bundle>` and restore from a backup\n")
+	default:
+		fmt.Printf("✓ Event chain: %d events verified (head %d)\n", res.Checked, res.Head.HeadSeq)
+	}
+}
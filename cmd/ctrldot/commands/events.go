@@ -1,40 +1,96 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/eventchain"
+	"github.com/futurematic/kernel/internal/ledger/sink/bundle"
+	"github.com/futurematic/kernel/pkg/ctrldot"
 	"github.com/spf13/cobra"
 )
 
+func eventsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Tail the event feed and verify the event log hash chain",
+	}
+	cmd.AddCommand(eventsTailCmd())
+	cmd.AddCommand(eventsVerifyCmd())
+	return cmd
+}
+
 func eventsTailCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "events tail",
+		Use:   "tail",
 		Short: "Tail events feed",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			serverURL, _ := cmd.Flags().GetString("server")
 			outputJSON, _ := cmd.Flags().GetBool("json")
-			agentID, _ := cmd.Flags().GetString("agent")
 			limit, _ := cmd.Flags().GetInt("n")
 
-			url := serverURL + "/v1/events?limit=" + strconv.Itoa(limit)
-			if agentID != "" {
-				url += "&agent_id=" + agentID
+			params := url.Values{}
+			params.Set("limit", strconv.Itoa(limit))
+			for flag, param := range map[string]string{
+				"agent":       "agent_id",
+				"session":     "session_id",
+				"decision":    "decision",
+				"action-type": "action_type",
+				"action-hash": "action_hash",
+				"cursor":      "cursor",
+			} {
+				if v, _ := cmd.Flags().GetString(flag); v != "" {
+					params.Set(param, v)
+				}
+			}
+			if types, _ := cmd.Flags().GetStringSlice("type"); len(types) > 0 {
+				params.Set("type", strings.Join(types, ","))
+			}
+			if severities, _ := cmd.Flags().GetStringSlice("severity"); len(severities) > 0 {
+				params.Set("severity", strings.Join(severities, ","))
+			}
+			for flag, param := range map[string]string{"since": "since_ts", "until": "until_ts"} {
+				v, _ := cmd.Flags().GetString(flag)
+				if v == "" {
+					continue
+				}
+				ts, err := parseEventTime(v)
+				if err != nil {
+					return fmt.Errorf("--%s: %w", flag, err)
+				}
+				params.Set(param, strconv.FormatInt(ts, 10))
+			}
+
+			if follow, _ := cmd.Flags().GetBool("follow"); follow {
+				return followEvents(cmd.Context(), serverURL, params, outputJSON)
 			}
 
-			resp, err := http.Get(url)
+			resp, err := http.Get(serverURL + "/v1/events?" + params.Encode())
 			if err != nil {
 				return err
 			}
 			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				body, _ := io.ReadAll(resp.Body)
+				return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+			}
 
 			var events []map[string]interface{}
 			if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
 				return err
 			}
+			nextCursor := resp.Header.Get("X-Next-Cursor")
 
 			if outputJSON {
 				json.NewEncoder(os.Stdout).Encode(events)
@@ -52,12 +108,173 @@ func eventsTailCmd() *cobra.Command {
 					}
 					fmt.Printf("  [%s] %s: %s\n", eventID, eventType, agentID)
 				}
+				if nextCursor != "" {
+					fmt.Printf("\nMore events: --cursor %s\n", nextCursor)
+				}
 			}
 
 			return nil
 		},
 	}
 	cmd.Flags().String("agent", "", "Filter by agent ID")
+	cmd.Flags().String("session", "", "Filter by session ID")
+	cmd.Flags().StringSlice("type", nil, "Filter by event type (repeatable or comma-separated)")
+	cmd.Flags().StringSlice("severity", nil, "Filter by severity: info, warn, error (repeatable or comma-separated)")
+	cmd.Flags().String("decision", "", "Filter by decision (e.g. DENY, STOP)")
+	cmd.Flags().String("action-type", "", "Filter by action type")
+	cmd.Flags().String("action-hash", "", "Filter by action hash")
+	cmd.Flags().String("since", "", "Only events at or after this time (RFC3339, unix ms, or a duration ago like 1h)")
+	cmd.Flags().String("until", "", "Only events before this time (RFC3339, unix ms, or a duration ago like 1h)")
+	cmd.Flags().String("cursor", "", "Continue from a previous page's cursor (with --follow: resume after that event)")
 	cmd.Flags().Int("n", 50, "Number of events")
+	cmd.Flags().BoolP("follow", "f", false, "Stream new events as they happen (Ctrl-C to stop)")
+	return cmd
+}
+
+// followEvents streams events from GET /v1/events/stream until interrupted.
+// --cursor resumes after a previously printed event.
+func followEvents(ctx context.Context, serverURL string, params url.Values, outputJSON bool) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	q := ctrldot.EventQuery{
+		AgentID:    params.Get("agent_id"),
+		SessionID:  params.Get("session_id"),
+		Decision:   params.Get("decision"),
+		ActionType: params.Get("action_type"),
+		ActionHash: params.Get("action_hash"),
+		Cursor:     params.Get("cursor"),
+	}
+	if v := params.Get("type"); v != "" {
+		q.Types = strings.Split(v, ",")
+	}
+	if v := params.Get("severity"); v != "" {
+		q.Severities = strings.Split(v, ",")
+	}
+	q.SinceTS, _ = strconv.ParseInt(params.Get("since_ts"), 10, 64)
+	q.UntilTS, _ = strconv.ParseInt(params.Get("until_ts"), 10, 64)
+
+	stream := newClient(serverURL).StreamEvents(ctx, q)
+	defer stream.Close()
+	if !outputJSON {
+		fmt.Printf("Following events (Ctrl-C to stop)...\n")
+	}
+	enc := json.NewEncoder(os.Stdout)
+	for stream.Next() {
+		e := stream.Event()
+		if outputJSON {
+			enc.Encode(e)
+			continue
+		}
+		agentID := e.AgentID
+		if agentID == "" {
+			agentID = "-"
+		}
+		line := fmt.Sprintf("  %s [%s] %s: %s", e.TS.Local().Format("15:04:05"), e.EventID, e.Type, agentID)
+		if d, _ := e.PayloadJSON["decision"].(string); d != "" {
+			line += " " + d
+		}
+		fmt.Println(line)
+	}
+	return stream.Err()
+}
+
+// parseEventTime accepts RFC3339, unix milliseconds, or a duration meaning "that long ago".
+func parseEventTime(s string) (int64, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d).UnixMilli(), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UnixMilli(), nil
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ms, nil
+	}
+	return 0, fmt.Errorf("invalid time %q (use RFC3339, unix ms, or a duration like 1h)", s)
+}
+
+func eventsVerifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the runtime event log hash chain and report the first broken link",
+		Long: "Reads the runtime store from config (like doctor) and walks the event hash chain from the\n" +
+			"compaction anchor to the head. With --checkpoint, also checks the chain against the signed\n" +
+			"chain checkpoint in a bundle manifest, which catches a log rewritten end to end.",
+		Args: cobra.NoArgs,
+		RunE: runEventsVerify,
+	}
+	cmd.Flags().String("checkpoint", "", "Bundle directory whose signed chain checkpoint to check against")
+	addBundleVerifyFlags(cmd)
 	return cmd
 }
+
+func runEventsVerify(cmd *cobra.Command, args []string) error {
+	outputJSON, _ := cmd.Flags().GetBool("json")
+	checkpointDir, _ := cmd.Flags().GetString("checkpoint")
+	ctx := cmd.Context()
+
+	var cp *eventchain.Checkpoint
+	if checkpointDir != "" {
+		opts, err := bundleVerifyOptions(cmd)
+		if err != nil {
+			return err
+		}
+		if _, err := bundle.VerifyBundleWith(checkpointDir, opts); err != nil {
+			return fmt.Errorf("checkpoint bundle: %w", err)
+		}
+		m, err := bundle.ReadManifest(checkpointDir)
+		if err != nil {
+			return fmt.Errorf("checkpoint bundle: %w", err)
+		}
+		if m.ChainCheckpoint == nil {
+			return fmt.Errorf("checkpoint bundle: manifest has no chain_checkpoint")
+		}
+		cp = m.ChainCheckpoint
+	}
+
+	cfg, err := config.Load(cliConfigPath())
+	if err != nil {
+		return err
+	}
+	st, desc, err := openRuntimeStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	res, err := eventchain.Verify(ctx, st)
+	if err != nil {
+		return err
+	}
+	if cp != nil && res.OK {
+		if res.Break, err = eventchain.VerifyCheckpoint(ctx, st, *cp); err != nil {
+			return err
+		}
+		res.OK = res.Break == nil
+	}
+
+	if outputJSON {
+		json.NewEncoder(os.Stdout).Encode(res)
+	} else {
+		fmt.Printf("Event chain (%s): head %d, anchor %d, %d events checked\n", desc, res.Head.HeadSeq, res.Head.AnchorSeq, res.Checked)
+		if res.Head.Unchained > 0 {
+			fmt.Printf("  %d events are not chained (written before chaining or by Kernel operations)\n", res.Head.Unchained)
+		}
+		if cp != nil {
+			fmt.Printf("  Checkpoint: event %d at %s\n", cp.Seq, cp.At.Format(time.RFC3339))
+		}
+		if res.OK {
+			fmt.Printf("✓ Event chain verified\n")
+		} else {
+			fmt.Printf("✗ Broken at event %d", res.Break.Seq)
+			if res.Break.EventID != "" {
+				fmt.Printf(" [%s]", res.Break.EventID)
+			}
+			fmt.Printf(": %s\n", res.Break.Reason)
+		}
+	}
+	if !res.OK {
+		return fmt.Errorf("event chain broken at event %d", res.Break.Seq)
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package commands
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/ledger/sink/bundle"
+	"github.com/spf13/cobra"
+)
+
+func keysCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Bundle signing key and trusted keys for bundle verify",
+	}
+	cmd.AddCommand(keysLsCmd())
+	cmd.AddCommand(keysRotateCmd())
+	cmd.AddCommand(keysExportCmd())
+	cmd.AddCommand(keysTrustCmd())
+	cmd.AddCommand(keysRevokeCmd())
+	cmd.AddCommand(keysRecipientCmd())
+	return cmd
+}
+
+func keysLsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ls",
+		Short: "Show the signing key and the trusted keys",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load(cliConfigPath())
+			if err != nil {
+				return err
+			}
+			sign := cfg.LedgerSink.Bundle.Sign
+			ts, err := bundle.LoadTrustStore(bundle.TrustStorePath(sign))
+			if err != nil {
+				return err
+			}
+			signingID := ""
+			if priv, err := bundle.ReadSigningKey(sign.KeyPath); err == nil {
+				signingID = bundle.KeyID(priv.Public().(ed25519.PublicKey))
+			}
+			if outputJSON, _ := cmd.Flags().GetBool("json"); outputJSON {
+				return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+					"signing_key_id": signingID,
+					"trusted":        ts.Keys,
+				})
+			}
+			if signingID == "" {
+				fmt.Printf("Signing key: none at %s (generated on first signed bundle)\n", sign.KeyPath)
+			} else {
+				fmt.Printf("Signing key: %s (%s)\n", signingID, sign.KeyPath)
+				if ts.Get(signingID) == nil {
+					fmt.Printf("  Not trusted yet; bundles it signs will not verify. Run: ctrldot keys trust %s\n", sign.PublicKeyPath)
+				}
+			}
+			fmt.Printf("Trusted keys (%s):\n", bundle.TrustStorePath(sign))
+			if len(ts.Keys) == 0 {
+				fmt.Println("  none")
+			}
+			for _, k := range ts.Keys {
+				status := "active"
+				if k.RetiredAt != nil {
+					status = "retired " + k.RetiredAt.Format(time.RFC3339)
+				}
+				if k.RevokedAt != nil {
+					status = "revoked " + k.RevokedAt.Format(time.RFC3339)
+				}
+				line := fmt.Sprintf("  %s  added %s  %s", k.KeyID, k.AddedAt.Format(time.RFC3339), status)
+				if k.Label != "" {
+					line += "  " + k.Label
+				}
+				fmt.Println(line)
+			}
+			return nil
+		},
+	}
+}
+
+func keysRotateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate",
+		Short: "Replace the signing key; the old key stays trusted for bundles it already signed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load(cliConfigPath())
+			if err != nil {
+				return err
+			}
+			sign := cfg.LedgerSink.Bundle.Sign
+			oldID, newID, err := bundle.RotateSigningKey(sign.KeyPath, sign.PublicKeyPath, bundle.TrustStorePath(sign))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✓ Retired %s (private key moved to %s)\n", oldID, filepath.Join(filepath.Dir(sign.KeyPath), "retired"))
+			fmt.Printf("✓ New signing key %s; the daemon uses it for the next bundle\n", newID)
+			fmt.Printf("  Share it with verifiers: ctrldot keys export > %s.json\n", newID[len("ed25519:"):])
+			return nil
+		},
+	}
+}
+
+func keysExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export [key_id]",
+		Short: "Print a public key (default: the signing key) for `ctrldot keys trust` elsewhere",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load(cliConfigPath())
+			if err != nil {
+				return err
+			}
+			sign := cfg.LedgerSink.Bundle.Sign
+			var pub ed25519.PublicKey
+			if len(args) == 0 {
+				priv, err := bundle.ReadSigningKey(sign.KeyPath)
+				if err != nil {
+					return err
+				}
+				pub = priv.Public().(ed25519.PublicKey)
+			} else {
+				ts, err := bundle.LoadTrustStore(bundle.TrustStorePath(sign))
+				if err != nil {
+					return err
+				}
+				k := ts.Get(args[0])
+				if k == nil {
+					return fmt.Errorf("no trusted key %s (see ctrldot keys ls)", args[0])
+				}
+				if pub, err = k.Public(); err != nil {
+					return err
+				}
+			}
+			out := bundle.ExportedKey{KeyID: bundle.KeyID(pub), Algorithm: "ed25519", PublicKey: base64.StdEncoding.EncodeToString(pub)}
+			data, _ := json.MarshalIndent(out, "", "  ")
+			if path, _ := cmd.Flags().GetString("out"); path != "" {
+				return os.WriteFile(path, append(data, '\n'), 0644)
+			}
+			fmt.Println(string(data))
+			return nil
+		},
+	}
+	cmd.Flags().String("out", "", "Write to this file instead of stdout")
+	return cmd
+}
+
+func keysTrustCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trust <key-file|bundle-dir>",
+		Short: "Trust a public key (keys export JSON, raw/base64/hex key, or the key embedded in a bundle)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load(cliConfigPath())
+			if err != nil {
+				return err
+			}
+			path := args[0]
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				path = filepath.Join(path, "public_key.ed25519")
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			pub, err := bundle.ParsePublicKey(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			ts, err := bundle.LoadTrustStore(bundle.TrustStorePath(cfg.LedgerSink.Bundle.Sign))
+			if err != nil {
+				return err
+			}
+			label, _ := cmd.Flags().GetString("label")
+			if ts.Get(bundle.KeyID(pub)) != nil {
+				fmt.Printf("Already trusted: %s\n", bundle.KeyID(pub))
+				return nil
+			}
+			id := ts.Trust(pub, label)
+			if err := ts.Save(); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Trusted %s\n", id)
+			return nil
+		},
+	}
+	cmd.Flags().String("label", "", "Note stored with the key (e.g. host or owner)")
+	return cmd
+}
+
+func keysRevokeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke <key_id>",
+		Short: "Stop trusting a key for any bundle, e.g. after it leaked (retired keys still verify old bundles)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load(cliConfigPath())
+			if err != nil {
+				return err
+			}
+			ts, err := bundle.LoadTrustStore(bundle.TrustStorePath(cfg.LedgerSink.Bundle.Sign))
+			if err != nil {
+				return err
+			}
+			if err := ts.Revoke(args[0]); err != nil {
+				return fmt.Errorf("%w (see ctrldot keys ls)", err)
+			}
+			if err := ts.Save(); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Revoked %s; bundles it signed no longer verify\n", args[0])
+			return nil
+		},
+	}
+}
+
+func keysRecipientCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "recipient",
+		Short: "Create an X25519 key pair for reading encrypted bundles",
+		Long: `Create an X25519 private key for reading encrypted bundles and print its public key.
+Add the public key to ledger_sink.bundle.encrypt.recipients on the daemons whose bundles you
+need to read; decrypt with ctrldot bundle decrypt <bundle> --key <private key>.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, _ := cmd.Flags().GetString("out")
+			pub, err := bundle.GenerateRecipientKey(expandHome(out))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✓ Private key written to %s (keep it secret)\n", out)
+			fmt.Printf("  Key ID: %s\n", bundle.RecipientKeyID(pub))
+			fmt.Println("  Add to config:")
+			fmt.Println("    ledger_sink:")
+			fmt.Println("      bundle:")
+			fmt.Println("        encrypt:")
+			fmt.Println("          recipients:")
+			fmt.Printf("            - %s\n", bundle.FormatRecipient(pub))
+			return nil
+		},
+	}
+	cmd.Flags().String("out", defaultRecipientKeyPath, "Where to write the private key")
+	return cmd
+}
+
+// bundleVerifyOptions builds trust-store verification options from --pin and --embedded-key,
+// with the transparency log (see bundleLog) that retired keys are checked against.
+func bundleVerifyOptions(cmd *cobra.Command) (bundle.VerifyOptions, error) {
+	pin, _ := cmd.Flags().GetString("pin")
+	opts := bundle.VerifyOptions{PinKeyID: pin}
+	if embedded, _ := cmd.Flags().GetBool("embedded-key"); embedded {
+		return opts, nil
+	}
+	cfg, err := config.Load(cliConfigPath())
+	if err != nil {
+		return opts, err
+	}
+	if opts.Trust, err = bundle.LoadTrustStore(bundle.TrustStorePath(cfg.LedgerSink.Bundle.Sign)); err != nil {
+		return opts, err
+	}
+	if opts.Log, err = bundleLog(cmd); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// bundleLog returns the transparency log from --log, else ledger_sink.bundle.sign.transparency_log_dir;
+// nil with --no-log or when the log is disabled.
+func bundleLog(cmd *cobra.Command) (*bundle.TransparencyLog, error) {
+	if noLog, _ := cmd.Flags().GetBool("no-log"); noLog {
+		return nil, nil
+	}
+	logDir, _ := cmd.Flags().GetString("log")
+	if logDir == "" {
+		cfg, err := config.Load(cliConfigPath())
+		if err != nil {
+			return nil, err
+		}
+		logDir = bundle.TransparencyLogDir(cfg.LedgerSink.Bundle.Sign)
+	}
+	if logDir == "" {
+		return nil, nil
+	}
+	return bundle.OpenTransparencyLog(logDir), nil
+}
+
+func addBundleVerifyFlags(cmd *cobra.Command) {
+	cmd.Flags().String("pin", "", "Only accept a signature by this key ID (see ctrldot keys ls)")
+	cmd.Flags().Bool("embedded-key", false, "Check against the key shipped in the bundle instead of trusted keys (integrity only)")
+}
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/replay"
+	"github.com/spf13/cobra"
+)
+
+func replayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay <bundle>",
+		Short: "Re-decide a bundle's recorded proposals under another config (what-if)",
+		Long: `Rebuild the proposals recorded in a bundle (decision_records.jsonl and the decision.issued
+events in events.jsonl) and run them through fresh rules, loop and limits evaluators with an
+in-memory store and a clock that follows the recorded timestamps. Every decision that would
+change is listed with its old and new reason codes. Nothing is written and no daemon is needed.
+
+Budget state starts empty at the first replayed proposal. Proposals known only from events
+(no decision record) have no target, so they keep their recorded rules outcome. Encrypted
+bundles must be decrypted first (ctrldot bundle decrypt).`,
+		Args: cobra.ExactArgs(1),
+		RunE: runReplay,
+	}
+	cmd.Flags().String("config", "", "Candidate config file (default: the current config)")
+	return cmd
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	configPath, _ := cmd.Flags().GetString("config")
+	if configPath == "" {
+		configPath = cliConfigPath()
+	} else if _, err := os.Stat(expandHome(configPath)); err != nil {
+		return err
+	}
+	cfg, err := config.Load(expandHome(configPath))
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	in, err := replay.Load(expandHome(args[0]))
+	if err != nil {
+		return err
+	}
+	rep, err := replay.Run(context.Background(), cfg, in)
+	if err != nil {
+		return err
+	}
+
+	if outputJSON, _ := cmd.Flags().GetBool("json"); outputJSON {
+		return json.NewEncoder(os.Stdout).Encode(rep)
+	}
+	fmt.Printf("Replayed %d proposal(s) from %s under %s", rep.Proposals, args[0], configPath)
+	if rep.Partial > 0 {
+		fmt.Printf(" (%d from events only)", rep.Partial)
+	}
+	fmt.Println()
+	if len(rep.Changed) == 0 {
+		fmt.Println("✓ No decision would change")
+		return nil
+	}
+	fmt.Printf("%d decision(s) would change:\n", len(rep.Changed))
+	for _, c := range rep.Changed {
+		partial := ""
+		if c.Partial {
+			partial = " (events only)"
+		}
+		fmt.Printf("  %s  %s  %s%s\n", c.TS.Local().Format("2006-01-02 15:04:05"), c.AgentID, c.ActionType, partial)
+		fmt.Printf("    %s [%s] -> %s [%s]", c.OldDecision, strings.Join(c.OldReasonCodes, ","), c.NewDecision, strings.Join(c.NewReasonCodes, ","))
+		if c.NewReason != "" {
+			fmt.Printf("  %s", c.NewReason)
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/pkg/ctrldot"
+	"github.com/spf13/cobra"
+)
+
+func shadowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "shadow",
+		Short: "Inspect shadow policy evaluation (config shadow section)",
+	}
+	cmd.AddCommand(shadowReportCmd())
+	return cmd
+}
+
+func shadowReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Summarise where the shadow config would have decided differently",
+		Long: `Reads the decision.shadow_diverged events recorded when the shadow config's decision differs
+from the live one and counts them by the shadow rule that would have fired and by live -> shadow
+decision. Proposals where both configs agree are not recorded; see
+ctrldot_shadow_evaluations_total on GET /metrics for the total evaluated.`,
+		Args: cobra.NoArgs,
+		RunE: runShadowReport,
+	}
+	cmd.Flags().String("since", "24h", "Only divergences at or after this time (RFC3339, unix ms, or a duration ago like 1h)")
+	cmd.Flags().String("agent", "", "Filter by agent ID")
+	return cmd
+}
+
+// shadowReport is the summary printed by ctrldot shadow report.
+type shadowReport struct {
+	Divergences int            `json:"divergences"`
+	ByRule      map[string]int `json:"by_shadow_rule"`
+	ByDecision  map[string]int `json:"by_decision"` // "ALLOW -> DENY"
+	ByAction    map[string]int `json:"by_action_type"`
+}
+
+func runShadowReport(cmd *cobra.Command, args []string) error {
+	serverURL, _ := cmd.Flags().GetString("server")
+	since, _ := cmd.Flags().GetString("since")
+	agentID, _ := cmd.Flags().GetString("agent")
+
+	q := ctrldot.EventQuery{AgentID: agentID, Types: []string{domain.EventTypeDecisionShadowDiverged}, Limit: 500}
+	if since != "" {
+		ts, err := parseEventTime(since)
+		if err != nil {
+			return fmt.Errorf("--since: %w", err)
+		}
+		q.SinceTS = ts
+	}
+	report := shadowReport{ByRule: map[string]int{}, ByDecision: map[string]int{}, ByAction: map[string]int{}}
+	client := newClient(serverURL)
+	for {
+		page, err := client.ListEvents(cmd.Context(), q)
+		if err != nil {
+			return err
+		}
+		for _, e := range page.Events {
+			str := func(key string) string {
+				v, _ := e.PayloadJSON[key].(string)
+				return v
+			}
+			report.Divergences++
+			rule := str("shadow_rule")
+			if rule == "" {
+				// The shadow config let it through; show what stopped it live
+				rule = "none (live: " + str("live_rule") + ")"
+			}
+			report.ByRule[rule]++
+			report.ByDecision[str("live_decision")+" -> "+str("shadow_decision")]++
+			report.ByAction[str("action_type")]++
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+
+	if outputJSON, _ := cmd.Flags().GetBool("json"); outputJSON {
+		return json.NewEncoder(os.Stdout).Encode(report)
+	}
+	fmt.Printf("Shadow divergences since %s: %d\n", since, report.Divergences)
+	if report.Divergences == 0 {
+		return nil
+	}
+	for _, section := range []struct {
+		title  string
+		counts map[string]int
+	}{
+		{"By shadow rule", report.ByRule},
+		{"By decision (live -> shadow)", report.ByDecision},
+		{"By action type", report.ByAction},
+	} {
+		fmt.Printf("\n%s:\n", section.title)
+		for _, kv := range sortedCounts(section.counts) {
+			fmt.Printf("  %6d  %s\n", kv.n, kv.key)
+		}
+	}
+	return nil
+}
+
+type keyCount struct {
+	key string
+	n   int
+}
+
+// sortedCounts orders counts by count, highest first, then by key.
+func sortedCounts(counts map[string]int) []keyCount {
+	out := make([]keyCount, 0, len(counts))
+	for k, n := range counts {
+		out = append(out, keyCount{k, n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].n != out[j].n {
+			return out[i].n > out[j].n
+		}
+		return out[i].key < out[j].key
+	})
+	return out
+}
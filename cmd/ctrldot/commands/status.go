@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/futurematic/kernel/internal/domain"
 	"github.com/spf13/cobra"
 )
 
@@ -38,12 +39,28 @@ func statusCmd() *cobra.Command {
 				return err
 			}
 
+			// Ledger outbox depth (kernel_http); older daemons may not report it.
+			var outbox *domain.LedgerOutboxInfo
+			if capResp, err := http.Get(serverURL + "/v1/capabilities"); err == nil {
+				var caps domain.CapabilitiesResponse
+				if capResp.StatusCode == http.StatusOK && json.NewDecoder(capResp.Body).Decode(&caps) == nil {
+					outbox = caps.CtrlDot.LedgerSink.Outbox
+				}
+				capResp.Body.Close()
+			}
+
 			if outputJSON {
+				if outbox != nil {
+					health["ledger_outbox"] = outbox
+				}
 				json.NewEncoder(os.Stdout).Encode(health)
 			} else {
 				fmt.Printf("Daemon: running\n")
 				fmt.Printf("Version: %v\n", health["version"])
 				fmt.Printf("Server: %s\n", serverURL)
+				if outbox != nil {
+					fmt.Printf("Ledger outbox: %d pending, %d dead\n", outbox.Pending, outbox.Dead)
+				}
 			}
 
 			return nil
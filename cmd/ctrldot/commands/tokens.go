@@ -0,0 +1,195 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/futurematic/kernel/internal/auth"
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/pkg/ctrldot"
+	"github.com/spf13/cobra"
+)
+
+// apiToken is the --token flag or $CTRLDOT_AUTH_TOKEN, set before any command runs.
+var apiToken string
+
+// useAPIToken makes every request the CLI sends to the daemon carry the API token.
+func useAPIToken(cmd *cobra.Command, args []string) {
+	apiToken, _ = cmd.Flags().GetString("token")
+	if apiToken == "" {
+		apiToken = os.Getenv("CTRLDOT_AUTH_TOKEN")
+	}
+	if apiToken != "" {
+		http.DefaultClient.Transport = bearerTransport{token: apiToken, base: http.DefaultTransport}
+	}
+}
+
+type bearerTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
+
+// newClient returns a pkg/ctrldot client for serverURL that sends the CLI's API token.
+func newClient(serverURL string) *ctrldot.Client {
+	client := ctrldot.NewClient(serverURL)
+	client.Token = apiToken
+	return client
+}
+
+func tokensCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "Issue, list and revoke API tokens (auth.tokens_path)",
+		Long: `Manage the API tokens the daemon accepts when auth.enabled is set. Tokens are kept hashed in
+auth.tokens_path (default ~/.ctrldot/tokens.json); the daemon picks up changes without a restart.
+
+Roles:
+  agent      register, sessions, propose/evaluate and its own events, for one agent ID (--agent)
+  operator   everything, including panic, halt/resume and config reload/rollback
+  read-only  GET requests only (dashboards, metrics)
+
+Clients send the token as "Authorization: Bearer <token>": the CLI from --token or
+$CTRLDOT_AUTH_TOKEN, ctrldot-mcp and the adapters from $CTRLDOT_AUTH_TOKEN.`,
+	}
+	cmd.AddCommand(tokensIssueCmd())
+	cmd.AddCommand(tokensLsCmd())
+	cmd.AddCommand(tokensRevokeCmd())
+	return cmd
+}
+
+// loadTokenStore opens the token file named by the CLI's config.
+func loadTokenStore() (*auth.TokenStore, *config.Config, error) {
+	cfg, err := config.Load(cliConfigPath())
+	if err != nil {
+		return nil, nil, err
+	}
+	ts, err := auth.LoadTokenStore(cfg.Auth.TokensPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ts, cfg, nil
+}
+
+func tokensIssueCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "issue <name>",
+		Short: "Issue a token and print its secret (shown only once)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			roleFlag, _ := cmd.Flags().GetString("role")
+			role, err := auth.ParseRole(roleFlag)
+			if err != nil {
+				return err
+			}
+			agentID, _ := cmd.Flags().GetString("agent")
+			ts, cfg, err := loadTokenStore()
+			if err != nil {
+				return err
+			}
+			secret, tok, err := ts.Issue(args[0], role, agentID)
+			if err != nil {
+				return err
+			}
+			if err := ts.Save(); err != nil {
+				return err
+			}
+			if outputJSON, _ := cmd.Flags().GetBool("json"); outputJSON {
+				return json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+					"token":        secret,
+					"id":           tok.ID,
+					"name":         tok.Name,
+					"role":         tok.Role,
+					"agent_id":     tok.AgentID,
+					"auth_enabled": cfg.Auth.Enabled,
+				})
+			}
+			fmt.Printf("✓ Issued %s token %s (%s)\n", tok.Role, tok.Name, tok.ID)
+			fmt.Printf("\n  %s\n\n", secret)
+			fmt.Println("Store it now; it cannot be shown again. Send it as: Authorization: Bearer <token>")
+			if !cfg.Auth.Enabled {
+				fmt.Println("Note: auth.enabled is false, so the daemon does not require tokens yet.")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().String("role", "", "agent, operator or read-only")
+	cmd.Flags().String("agent", "", "Agent ID an agent token is scoped to")
+	_ = cmd.MarkFlagRequired("role")
+	return cmd
+}
+
+func tokensLsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List tokens (secrets are not stored)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ts, _, err := loadTokenStore()
+			if err != nil {
+				return err
+			}
+			all, _ := cmd.Flags().GetBool("all")
+			var tokens []auth.Token
+			for _, t := range ts.Tokens {
+				if all || t.Active() {
+					tokens = append(tokens, t)
+				}
+			}
+			if outputJSON, _ := cmd.Flags().GetBool("json"); outputJSON {
+				if tokens == nil {
+					tokens = []auth.Token{}
+				}
+				return json.NewEncoder(os.Stdout).Encode(tokens)
+			}
+			fmt.Printf("Tokens (%s):\n", ts.Path())
+			if len(tokens) == 0 {
+				fmt.Println("  none")
+			}
+			for _, t := range tokens {
+				line := fmt.Sprintf("  %s  %-10s %s  created %s", t.ID, t.Role, t.Name, t.CreatedAt.Format(time.RFC3339))
+				if t.AgentID != "" {
+					line += "  agent " + t.AgentID
+				}
+				if t.RevokedAt != nil {
+					line += "  revoked " + t.RevokedAt.Format(time.RFC3339)
+				}
+				fmt.Println(line)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool("all", false, "Include revoked tokens")
+	return cmd
+}
+
+func tokensRevokeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke <id|name>",
+		Short: "Revoke a token; the daemon rejects it from its next request",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ts, _, err := loadTokenStore()
+			if err != nil {
+				return err
+			}
+			tok, err := ts.Revoke(args[0])
+			if err != nil {
+				return err
+			}
+			if err := ts.Save(); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Revoked %s token %s (%s)\n", tok.Role, tok.Name, tok.ID)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/spf13/cobra"
+)
+
+func webhooksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhooks",
+		Short: "Webhooks: signed notifications for DENY/STOP, halt and panic",
+	}
+	cmd.AddCommand(webhooksStatusCmd())
+	cmd.AddCommand(webhooksTestCmd())
+	cmd.AddCommand(webhooksDeadCmd())
+	cmd.AddCommand(webhooksRetryCmd())
+	return cmd
+}
+
+func webhooksStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show configured endpoints and delivery queue",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serverURL, _ := cmd.Flags().GetString("server")
+			var status domain.WebhooksStatus
+			if err := webhooksRequest(http.MethodGet, serverURL+"/v1/webhooks", nil, &status); err != nil {
+				return err
+			}
+			if outputJSON, _ := cmd.Flags().GetBool("json"); outputJSON {
+				return json.NewEncoder(os.Stdout).Encode(status)
+			}
+			if len(status.Endpoints) == 0 {
+				fmt.Println("No webhook endpoints configured (webhooks.endpoints in config).")
+			}
+			for _, ep := range status.Endpoints {
+				signed := "unsigned"
+				if ep.Signed {
+					signed = "signed"
+				}
+				fmt.Printf("  %s  %s  format=%s  %s\n", ep.Name, ep.URL, ep.Format, signed)
+				fmt.Printf("    events: %s  decisions: %s\n", strings.Join(ep.Events, ","), strings.Join(ep.Decisions, ","))
+			}
+			fmt.Printf("Queue: %d pending, %d dead\n", status.Pending, status.Dead)
+			return nil
+		},
+	}
+}
+
+func webhooksTestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Send a signed webhook.test payload to each endpoint (or --endpoint)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serverURL, _ := cmd.Flags().GetString("server")
+			endpoint, _ := cmd.Flags().GetString("endpoint")
+			var results []domain.WebhookTestResult
+			if err := webhooksRequest(http.MethodPost, serverURL+"/v1/webhooks/test", map[string]string{"endpoint": endpoint}, &results); err != nil {
+				return err
+			}
+			if outputJSON, _ := cmd.Flags().GetBool("json"); outputJSON {
+				return json.NewEncoder(os.Stdout).Encode(results)
+			}
+			failed := 0
+			for _, r := range results {
+				if r.Error != "" {
+					failed++
+					fmt.Printf("  FAIL %s (%s): %s\n", r.Endpoint, r.URL, r.Error)
+				} else {
+					fmt.Printf("  OK   %s (%s): HTTP %d in %dms\n", r.Endpoint, r.URL, r.StatusCode, r.DurationMs)
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d endpoints failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().String("endpoint", "", "Only test this endpoint (by name)")
+	return cmd
+}
+
+func webhooksDeadCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dead",
+		Short: "List dead-lettered deliveries",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serverURL, _ := cmd.Flags().GetString("server")
+			limit, _ := cmd.Flags().GetInt("n")
+			var dead []domain.WebhookDelivery
+			if err := webhooksRequest(http.MethodGet, fmt.Sprintf("%s/v1/webhooks/dead?limit=%d", serverURL, limit), nil, &dead); err != nil {
+				return err
+			}
+			if outputJSON, _ := cmd.Flags().GetBool("json"); outputJSON {
+				return json.NewEncoder(os.Stdout).Encode(dead)
+			}
+			if len(dead) == 0 {
+				fmt.Println("No dead-lettered deliveries.")
+				return nil
+			}
+			for _, d := range dead {
+				fmt.Printf("  %s  %s  %s  attempts=%d  %s\n", d.DeliveryID, d.Endpoint, d.EventType, d.Attempts, d.LastError)
+			}
+			fmt.Println("\nRetry with: ctrldot webhooks retry <delivery_id>")
+			return nil
+		},
+	}
+	cmd.Flags().Int("n", 100, "Number of deliveries")
+	return cmd
+}
+
+func webhooksRetryCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "retry <delivery_id>",
+		Short: "Move a dead-lettered delivery back to the queue",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serverURL, _ := cmd.Flags().GetString("server")
+			if err := webhooksRequest(http.MethodPost, serverURL+"/v1/webhooks/retry", map[string]string{"delivery_id": args[0]}, nil); err != nil {
+				return err
+			}
+			fmt.Printf("Requeued %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+// webhooksRequest sends an optional JSON body and decodes the response into out (if non-nil).
+func webhooksRequest(method, url string, body interface{}, out interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var errBody map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&errBody)
+		msg := errBody["error"]
+		if msg == "" {
+			msg = resp.Status
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -23,6 +23,7 @@ func main() {
 	// Add global flags
 	rootCmd.PersistentFlags().String("server", "http://127.0.0.1:7777", "Ctrl Dot server URL")
 	rootCmd.PersistentFlags().Bool("json", false, "Output JSON")
+	rootCmd.PersistentFlags().String("token", "", "API token when the daemon has auth enabled (default $CTRLDOT_AUTH_TOKEN)")
 
 	// Register commands
 	commands.RegisterCommands(rootCmd)
@@ -3,28 +3,38 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	ctrldotapi "github.com/futurematic/kernel/internal/api/ctrldot"
+	"github.com/futurematic/kernel/internal/auth"
 	"github.com/futurematic/kernel/internal/config"
 	ctrldotsvc "github.com/futurematic/kernel/internal/ctrldot"
 	"github.com/futurematic/kernel/internal/ledger/autobundle"
 	"github.com/futurematic/kernel/internal/ledger/sink"
 	"github.com/futurematic/kernel/internal/ledger/sink/bundle"
+	"github.com/futurematic/kernel/internal/ledger/sink/file"
 	"github.com/futurematic/kernel/internal/ledger/sink/kernel_http"
+	"github.com/futurematic/kernel/internal/ledger/sink/multi"
 	"github.com/futurematic/kernel/internal/ledger/sink/noop"
+	"github.com/futurematic/kernel/internal/ledger/sink/syslog"
 	"github.com/futurematic/kernel/internal/limits"
 	"github.com/futurematic/kernel/internal/loop"
+	"github.com/futurematic/kernel/internal/metrics"
 	"github.com/futurematic/kernel/internal/resolution"
 	"github.com/futurematic/kernel/internal/rules"
 	"github.com/futurematic/kernel/internal/runtime"
+	"github.com/futurematic/kernel/internal/runtime/compactor"
 	"github.com/futurematic/kernel/internal/runtime/sqlite"
 	"github.com/futurematic/kernel/internal/store"
+	"github.com/futurematic/kernel/internal/tracing"
+	"github.com/futurematic/kernel/internal/webhooks"
 )
 
 func main() {
@@ -37,6 +47,8 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	// Created before defaults are filled in below, so edits to restart-only sections are reported
+	reloader := config.NewReloader(configPath, cfg)
 
 	var runtimeStore runtime.RuntimeStore
 	var closeStore func() error
@@ -78,6 +90,23 @@ func main() {
 		}
 	}()
 
+	// Webhooks: matching events are queued as they are appended and delivered in the background.
+	webhookDispatcher := webhooks.New(runtimeStore, cfg)
+	runtimeStore = webhookDispatcher.Wrap(runtimeStore)
+	webhookDispatcher.Start()
+	defer webhookDispatcher.Stop()
+
+	// Metrics: label toggles apply to every series; gauges are refreshed from the store on scrape.
+	metrics.Configure(cfg.Metrics)
+	metrics.Default.AddCollector(metrics.RuntimeCollector(runtimeStore))
+
+	// Tracing: propose spans are exported via OTLP/HTTP when tracing.enabled.
+	tracing.Configure(cfg.Tracing)
+
+	eventsCompactor := compactor.New(runtimeStore, cfg)
+	eventsCompactor.Start()
+	defer eventsCompactor.Stop()
+
 	limitsEngine := limits.NewEngine(runtimeStore, cfg)
 	rulesEngine := rules.NewEngine(cfg)
 	loopDetector := loop.NewDetector(runtimeStore, cfg)
@@ -85,36 +114,13 @@ func main() {
 	// Resolution manager is stateless (HMAC tokens); store is unused in current impl
 	resolutionMgr := resolution.NewManager(nil, "")
 
-	var ledgerSink sink.LedgerSink = noop.New()
 	runtimeKind := cfg.RuntimeStore.Kind
 	if runtimeKind == "" {
 		runtimeKind = "sqlite"
 	}
-	switch cfg.LedgerSink.Kind {
-	case "bundle":
-		if cfg.LedgerSink.Bundle.OutputDir == "" {
-			if home, _ := os.UserHomeDir(); home != "" {
-				cfg.LedgerSink.Bundle.OutputDir = filepath.Join(home, ".ctrldot", "bundles")
-			}
-		}
-		b, err := bundle.NewSink(cfg, runtimeKind, "0.1.0")
-		if err != nil {
-			log.Fatalf("Failed to create bundle sink: %v", err)
-		}
-		ledgerSink = b
-	case "kernel_http":
-		baseURL := cfg.LedgerSink.KernelHTTP.BaseURL
-		if baseURL == "" {
-			baseURL = "http://127.0.0.1:8080"
-		}
-		ledgerSink = kernel_http.NewSink(
-			baseURL,
-			cfg.LedgerSink.KernelHTTP.APIKey,
-			cfg.LedgerSink.KernelHTTP.TimeoutMs,
-			cfg.LedgerSink.KernelHTTP.Required,
-		)
-	default:
-		ledgerSink = noop.New()
+	ledgerSink, err := newLedgerSink(cfg, runtimeStore, cfg.LedgerSink.Kind, runtimeKind, cfg.LedgerSink.KernelHTTP.Required)
+	if err != nil {
+		log.Fatalf("Failed to create ledger sink: %v", err)
 	}
 	defer func() {
 		if ledgerSink != nil {
@@ -123,6 +129,15 @@ func main() {
 	}()
 
 	autobundleMgr := autobundle.NewManager(cfg, runtimeStore, "0.1.0")
+	autobundleMgr.Start()
+	defer autobundleMgr.Stop()
+
+	// Reloaded configs are swapped into every component that reads config after startup
+	reloader.Register(limitsEngine, rulesEngine, loopDetector, eventsCompactor, webhookDispatcher, autobundleMgr,
+		config.ReloadFunc(func(cfg *config.Config) { metrics.Configure(cfg.Metrics) }))
+	if r, ok := ledgerSink.(config.Reloadable); ok {
+		reloader.Register(r)
+	}
 
 	ctrldotService := ctrldotsvc.NewService(
 		runtimeStore,
@@ -133,9 +148,24 @@ func main() {
 		ledgerSink,
 		autobundleMgr,
 		cfg,
+		reloader,
 	)
 
-	apiServer := ctrldotapi.NewServer(cfg.Server.Port, ctrldotService, autobundleMgr)
+	apiServer := ctrldotapi.NewServer(cfg.Server.Port, ctrldotService, autobundleMgr, webhookDispatcher).
+		WithBundleDirs(bundle.BundleDirs(cfg)...)
+	if d, ok := ledgerSink.(sink.Drainer); ok {
+		apiServer.OnShutdown(d.Drain)
+	}
+	if cfg.Auth.Enabled {
+		authn, err := auth.NewAuthenticator(cfg.Auth.TokensPath)
+		if err != nil {
+			log.Fatalf("Failed to load API tokens: %v", err)
+		}
+		if authn.ActiveCount() == 0 {
+			log.Printf("API auth is enabled but %s has no active tokens; issue one with: ctrldot tokens issue", cfg.Auth.TokensPath)
+		}
+		apiServer.WithAuth(authn)
+	}
 
 	go func() {
 		if err := apiServer.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -145,6 +175,24 @@ func main() {
 
 	log.Printf("Ctrl Dot daemon started on %s:%d (runtime_store=%s)", cfg.Server.Host, cfg.Server.Port, cfg.RuntimeStore.Kind)
 
+	// Config reload: SIGHUP, the file watcher, or POST /v1/config/reload
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	defer stopReload()
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for range hupChan {
+			reloadConfig(reloadCtx, ctrldotService, "sighup")
+		}
+	}()
+	if cfg.Reload.Watch {
+		interval := 2 * time.Second
+		if cfg.Reload.WatchIntervalSeconds > 0 {
+			interval = time.Duration(cfg.Reload.WatchIntervalSeconds) * time.Second
+		}
+		go reloader.Watch(reloadCtx, interval, func() { reloadConfig(reloadCtx, ctrldotService, "watch") })
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
@@ -154,4 +202,97 @@ func main() {
 	if err := apiServer.Shutdown(ctx); err != nil {
 		log.Printf("Error shutting down server: %v", err)
 	}
+	flushCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := tracing.Shutdown(flushCtx); err != nil {
+		log.Printf("Error flushing traces: %v", err)
+	}
+}
+
+// reloadConfig reloads the config file and logs the outcome; the running config is kept on error.
+func reloadConfig(ctx context.Context, svc ctrldotsvc.Service, trigger string) {
+	res, err := svc.ReloadConfig(ctx, trigger, "")
+	if err != nil {
+		log.Printf("Config reload (%s) failed, keeping the running config: %v", trigger, err)
+		return
+	}
+	if len(res.Changes) == 0 {
+		log.Printf("Config reload (%s): no changes", trigger)
+	} else {
+		log.Printf("Config reloaded (%s) as revision %d: %d change(s)", trigger, res.Revision, len(res.Changes))
+	}
+	if len(res.RestartRequired) > 0 {
+		log.Printf("Config sections changed that apply after a restart: %v", res.RestartRequired)
+	}
+}
+
+// newLedgerSink builds the sink for ledger_sink.kind. For "multi" each member is built from its
+// own ledger_sink.<kind> section; members always report errors so the multi sink can count them.
+// kernel_http keeps undelivered records in the runtime store outbox.
+func newLedgerSink(cfg *config.Config, runtimeStore runtime.RuntimeStore, kind, runtimeKind string, required bool) (sink.LedgerSink, error) {
+	switch kind {
+	case "bundle":
+		if cfg.LedgerSink.Bundle.OutputDir == "" {
+			if home, _ := os.UserHomeDir(); home != "" {
+				cfg.LedgerSink.Bundle.OutputDir = filepath.Join(home, ".ctrldot", "bundles")
+			}
+		}
+		b, err := bundle.NewSink(cfg, runtimeKind, "0.1.0")
+		if err != nil {
+			return nil, fmt.Errorf("bundle sink: %w", err)
+		}
+		return b.WithChain(runtimeStore), nil
+	case "kernel_http":
+		baseURL := cfg.LedgerSink.KernelHTTP.BaseURL
+		if baseURL == "" {
+			baseURL = "http://127.0.0.1:8080"
+		}
+		return kernel_http.NewSink(
+			baseURL,
+			cfg.LedgerSink.KernelHTTP.APIKey,
+			cfg.LedgerSink.KernelHTTP.TimeoutMs,
+			required,
+		).WithOutbox(runtimeStore), nil
+	case "file":
+		f, err := file.NewSink(cfg.LedgerSink.File)
+		if err != nil {
+			return nil, fmt.Errorf("file sink: %w", err)
+		}
+		return f, nil
+	case "syslog":
+		sl, err := syslog.NewSink(cfg.LedgerSink.Syslog)
+		if err != nil {
+			return nil, fmt.Errorf("syslog sink: %w", err)
+		}
+		return sl, nil
+	case "multi":
+		var members []multi.Member
+		for _, m := range cfg.LedgerSink.Multi.Sinks {
+			if m.Kind == "multi" || m.Kind == "" {
+				return nil, fmt.Errorf("multi sink: invalid member kind %q", m.Kind)
+			}
+			inner, err := newLedgerSink(cfg, runtimeStore, m.Kind, runtimeKind, true)
+			if err != nil {
+				return nil, err
+			}
+			name := m.Name
+			if name == "" {
+				name = m.Kind
+			}
+			members = append(members, multi.Member{
+				Name:         name,
+				Sink:         inner,
+				Required:     m.Required,
+				QueueSize:    m.QueueSize,
+				OnFull:       m.OnFull,
+				BlockTimeout: time.Duration(m.BlockTimeoutMs) * time.Millisecond,
+			})
+		}
+		return multi.New(members, time.Duration(cfg.LedgerSink.Multi.DrainTimeoutMs)*time.Millisecond), nil
+	case "", "none":
+		return noop.New(), nil
+	default:
+		log.Printf("Unknown ledger_sink kind %q; records are not emitted", kind)
+		return noop.New(), nil
+	}
 }
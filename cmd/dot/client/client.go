@@ -10,6 +10,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
 )
 
 // Client represents an HTTP client for the kernel API
@@ -107,6 +109,46 @@ func (c *Client) Diff(req DiffRequest) (*DiffResponse, error) {
 	return &resp, nil
 }
 
+// Decisions lists Ctrl Dot decision records received from kernel_http sinks
+func (c *Client) Decisions(req DecisionsRequest) (*DecisionsResponse, error) {
+	params := url.Values{}
+	for k, v := range map[string]string{
+		"agent_id":    req.AgentID,
+		"session_id":  req.SessionID,
+		"decision":    req.Decision,
+		"action_type": req.ActionType,
+		"source":      req.Source,
+	} {
+		if v != "" {
+			params.Add(k, v)
+		}
+	}
+	if req.Since != nil {
+		params.Add("since", req.Since.Format(time.RFC3339))
+	}
+	if req.Until != nil {
+		params.Add("until", req.Until.Format(time.RFC3339))
+	}
+	if req.Limit > 0 {
+		params.Add("limit", strconv.Itoa(req.Limit))
+	}
+
+	var resp DecisionsResponse
+	if err := c.get("/v1/ctrldot/decisions", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Decision gets one Ctrl Dot decision record by ID
+func (c *Client) Decision(id string) (*domain.CtrlDotDecisionRecord, error) {
+	var resp domain.CtrlDotDecisionRecord
+	if err := c.get("/v1/ctrldot/decisions/"+url.PathEscape(id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // Helper methods
 
 func (c *Client) get(path string, params url.Values, result interface{}) error {
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/futurematic/kernel/internal/domain"
 )
@@ -167,3 +168,37 @@ func TestClientExpand(t *testing.T) {
 		t.Errorf("Expected node ID node:test, got %s", result.Nodes[0].ID)
 	}
 }
+
+func TestClientDecisions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/ctrldot/decisions" {
+			t.Errorf("Expected path /v1/ctrldot/decisions, got %s", r.URL.Path)
+		}
+
+		q := r.URL.Query()
+		if q.Get("agent_id") != "agent-1" || q.Get("decision") != "DENY" || q.Get("since") != "2026-01-01T00:00:00Z" || q.Get("session_id") != "" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+
+		response := DecisionsResponse{
+			Decisions: []domain.CtrlDotDecisionRecord{
+				{ID: "dec-1", AgentID: "agent-1", Decision: "DENY", Record: json.RawMessage(`{"id":"dec-1"}`)},
+			},
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	result, err := client.Decisions(DecisionsRequest{AgentID: "agent-1", Decision: "DENY", Since: &since})
+	if err != nil {
+		t.Fatalf("Failed to list decisions: %v", err)
+	}
+
+	if len(result.Decisions) != 1 || result.Decisions[0].ID != "dec-1" {
+		t.Fatalf("Unexpected decisions: %+v", result.Decisions)
+	}
+}
@@ -88,6 +88,23 @@ type DiffResponse struct {
 	Changes []domain.Change `json:"changes"`
 }
 
+// DecisionsRequest filters Ctrl Dot decision records stored by the kernel
+type DecisionsRequest struct {
+	AgentID    string
+	SessionID  string
+	Decision   string
+	ActionType string
+	Source     string
+	Since      *time.Time
+	Until      *time.Time
+	Limit      int
+}
+
+// DecisionsResponse represents a list of Ctrl Dot decision records, newest first
+type DecisionsResponse struct {
+	Decisions []domain.CtrlDotDecisionRecord `json:"decisions"`
+}
+
 // HealthResponse represents a health check response
 type HealthResponse struct {
 	OK bool `json:"ok"`
@@ -17,6 +17,7 @@ func RegisterCommands(rootCmd *cobra.Command) {
 	rootCmd.AddCommand(newHistoryCmd())
 	rootCmd.AddCommand(newDiffCmd())
 	rootCmd.AddCommand(newLsCmd())
+	rootCmd.AddCommand(newDecisionsCmd())
 
 	// Write commands
 	rootCmd.AddCommand(newNewCmd())
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/futurematic/kernel/cmd/dot/client"
+	"github.com/spf13/cobra"
+)
+
+func newDecisionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "decisions [record-id]",
+		Short: "Browse Ctrl Dot decision records",
+		Long: `List decision records delivered by Ctrl Dot daemons (ledger_sink kind kernel_http), newest first,
+or show one record in full.
+
+--since and --until take an RFC 3339 time or a duration back from now (e.g. 24h).`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := getContext(cmd)
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 1 {
+				rec, err := ctx.Client.Decision(args[0])
+				if err != nil {
+					handleError(err)
+					return nil
+				}
+				return ctx.Formatter.PrintDecision(rec)
+			}
+
+			req := client.DecisionsRequest{}
+			req.AgentID, _ = cmd.Flags().GetString("agent")
+			req.SessionID, _ = cmd.Flags().GetString("session")
+			req.Decision, _ = cmd.Flags().GetString("decision")
+			req.ActionType, _ = cmd.Flags().GetString("action-type")
+			req.Source, _ = cmd.Flags().GetString("source")
+			req.Limit, _ = cmd.Flags().GetInt("limit")
+			for flag, dst := range map[string]**time.Time{"since": &req.Since, "until": &req.Until} {
+				v, _ := cmd.Flags().GetString(flag)
+				if v == "" {
+					continue
+				}
+				t, err := parseTimeOrAgo(v)
+				if err != nil {
+					return fmt.Errorf("--%s: %w", flag, err)
+				}
+				*dst = &t
+			}
+
+			resp, err := ctx.Client.Decisions(req)
+			if err != nil {
+				handleError(err)
+				return nil
+			}
+			return ctx.Formatter.PrintDecisions(resp.Decisions)
+		},
+	}
+
+	cmd.Flags().String("agent", "", "Filter by agent ID")
+	cmd.Flags().String("session", "", "Filter by session ID")
+	cmd.Flags().String("decision", "", "Filter by decision (ALLOW, WARN, THROTTLE, DENY, STOP)")
+	cmd.Flags().String("action-type", "", "Filter by action type")
+	cmd.Flags().String("source", "", "Filter by sending daemon")
+	cmd.Flags().String("since", "", "Only records at or after this time")
+	cmd.Flags().String("until", "", "Only records before this time")
+	cmd.Flags().Int("limit", 100, "Limit number of records (max 1000)")
+
+	return cmd
+}
+
+// parseTimeOrAgo parses an RFC 3339 time, or a duration meaning that long before now.
+func parseTimeOrAgo(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC 3339 time or duration, got %q", v)
+	}
+	return time.Now().Add(-d), nil
+}
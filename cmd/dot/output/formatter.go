@@ -18,6 +18,8 @@ type Formatter interface {
 	PrintDiff(result interface{}) error
 	PrintStatus(status interface{}) error
 	PrintConfig(cfg interface{}) error
+	PrintDecisions(records []domain.CtrlDotDecisionRecord) error
+	PrintDecision(record *domain.CtrlDotDecisionRecord) error
 }
 
 // NewFormatter creates a new formatter based on format type
@@ -39,3 +39,11 @@ func (f *JSONFormatter) PrintStatus(status interface{}) error {
 func (f *JSONFormatter) PrintConfig(cfg interface{}) error {
 	return json.NewEncoder(f.w).Encode(cfg)
 }
+
+func (f *JSONFormatter) PrintDecisions(records []domain.CtrlDotDecisionRecord) error {
+	return json.NewEncoder(f.w).Encode(map[string]interface{}{"result": records})
+}
+
+func (f *JSONFormatter) PrintDecision(record *domain.CtrlDotDecisionRecord) error {
+	return json.NewEncoder(f.w).Encode(record)
+}
@@ -1,6 +1,7 @@
 package output
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -189,6 +190,35 @@ func (f *TextFormatter) PrintHistory(ops []domain.Operation) error {
 	return nil
 }
 
+func (f *TextFormatter) PrintDecisions(records []domain.CtrlDotDecisionRecord) error {
+	for _, d := range records {
+		fmt.Fprintf(f.w, "%s %-8s agent=%s action=%s id=%s",
+			d.Timestamp.Format(time.RFC3339), d.Decision, d.AgentID, d.ActionType, d.ID)
+		if d.SessionID != "" {
+			fmt.Fprintf(f.w, " session=%s", d.SessionID)
+		}
+		if d.Source != "" {
+			fmt.Fprintf(f.w, " source=%s", d.Source)
+		}
+		fmt.Fprintln(f.w)
+	}
+	return nil
+}
+
+func (f *TextFormatter) PrintDecision(record *domain.CtrlDotDecisionRecord) error {
+	fmt.Fprintf(f.w, "id=%s decision=%s agent=%s session=%s action=%s\n",
+		record.ID, record.Decision, record.AgentID, record.SessionID, record.ActionType)
+	fmt.Fprintf(f.w, "timestamp=%s received_at=%s source=%s\n",
+		record.Timestamp.Format(time.RFC3339), record.ReceivedAt.Format(time.RFC3339), record.Source)
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, record.Record, "", "  "); err != nil {
+		pretty.Reset()
+		pretty.Write(record.Record)
+	}
+	fmt.Fprintf(f.w, "%s\n", pretty.String())
+	return nil
+}
+
 func (f *TextFormatter) PrintDiff(result interface{}) error {
 	var r struct {
 		Changes []domain.Change `json:"changes"`
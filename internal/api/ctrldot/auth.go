@@ -0,0 +1,101 @@
+package ctrldot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/futurematic/kernel/internal/auth"
+)
+
+// principalKey carries the request's *auth.Token in its context.
+type principalKey struct{}
+
+// principal returns the token the request was authenticated with, or nil when auth is off.
+func principal(r *http.Request) *auth.Token {
+	tok, _ := r.Context().Value(principalKey{}).(*auth.Token)
+	return tok
+}
+
+// authMiddleware requires a bearer token with a role that allows the request, when auth is
+// enabled (h.auth set). /v1/health and the UI page stay public. Agent tokens see only their
+// own agent's events; handlers check the agent ID in request bodies (checkAgent).
+func (h *Handlers) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.auth == nil || publicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tok := h.auth.Authenticate(bearerToken(r))
+		if tok == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ctrldot"`)
+			respondError(w, "missing or invalid API token", http.StatusUnauthorized)
+			return
+		}
+		if !allowed(tok, r) {
+			respondError(w, fmt.Sprintf("%s token %q may not %s %s", tok.Role, tok.Name, r.Method, r.URL.Path), http.StatusForbidden)
+			return
+		}
+		if tok.Role == auth.RoleAgent && (r.URL.Path == "/v1/events" || r.URL.Path == "/v1/events/stream") {
+			q := r.URL.Query()
+			if id := q.Get("agent_id"); id != "" && id != tok.AgentID {
+				respondError(w, fmt.Sprintf("token is scoped to agent %s", tok.AgentID), http.StatusForbidden)
+				return
+			}
+			q.Set("agent_id", tok.AgentID)
+			r.URL.RawQuery = q.Encode()
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, tok)))
+	})
+}
+
+func publicPath(path string) bool {
+	return path == "/v1/health" || path == "/ui" || strings.HasPrefix(path, "/ui/")
+}
+
+func bearerToken(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return ""
+}
+
+// allowed applies the role policy: operators may do anything, read-only tokens may only GET,
+// and agent tokens may call the endpoints an agent needs for itself.
+func allowed(tok *auth.Token, r *http.Request) bool {
+	switch tok.Role {
+	case auth.RoleOperator:
+		return true
+	case auth.RoleReadOnly:
+		return r.Method == http.MethodGet || r.Method == http.MethodHead
+	case auth.RoleAgent:
+		path := r.URL.Path
+		switch r.Method {
+		case http.MethodPost:
+			switch path {
+			case "/v1/agents/register", "/v1/sessions/start", "/v1/actions/propose", "/v1/actions/evaluate":
+				return true
+			}
+			return strings.HasPrefix(path, "/v1/sessions/") && strings.HasSuffix(path, "/end")
+		case http.MethodGet:
+			switch path {
+			case "/v1/capabilities", "/v1/events", "/v1/events/stream",
+				"/v1/agents/" + tok.AgentID, "/v1/agents/" + tok.AgentID + "/limits":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkAgent responds 403 and returns false when the request's agent token is scoped to an
+// agent other than agentID.
+func checkAgent(w http.ResponseWriter, r *http.Request, agentID string) bool {
+	tok := principal(r)
+	if tok == nil || tok.Role != auth.RoleAgent || tok.AgentID == agentID {
+		return true
+	}
+	respondError(w, fmt.Sprintf("token is scoped to agent %s", tok.AgentID), http.StatusForbidden)
+	return false
+}
@@ -0,0 +1,241 @@
+package ctrldot_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/futurematic/kernel/internal/auth"
+	"github.com/futurematic/kernel/internal/ctrldot"
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+// authServer serves the API with auth on and agents a and b registered. Its tokens are
+// operator, read-only, agent (scoped to a) and revoked (a revoked operator token).
+type authServer struct {
+	*httptest.Server
+	svc    ctrldot.Service
+	tokens map[string]string
+}
+
+func newAuthServer(t *testing.T) *authServer {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	store, err := auth.LoadTokenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := make(map[string]string)
+	for _, tok := range []struct {
+		name    string
+		role    auth.Role
+		agentID string
+	}{
+		{"operator", auth.RoleOperator, ""},
+		{"read-only", auth.RoleReadOnly, ""},
+		{"agent", auth.RoleAgent, "a"},
+		{"revoked", auth.RoleOperator, ""},
+	} {
+		secret, _, err := store.Issue(tok.name, tok.role, tok.agentID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[tok.name] = secret
+	}
+	if _, err := store.Revoke("revoked"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	authn, err := auth.NewAuthenticator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, svc, _ := newServer(t, authn)
+	register(t, svc, "a", "b")
+	return &authServer{Server: ts, svc: svc, tokens: tokens}
+}
+
+// do sends a request with the named token ("" for none) and returns the status and body.
+func (s *authServer) do(t *testing.T, token, method, path, body string) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest(method, s.URL+path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.tokens[token])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestAuthRequiresToken(t *testing.T) {
+	s := newAuthServer(t)
+	for _, tc := range []struct {
+		name, token, path string
+		want              int
+	}{
+		{"no token", "", "/v1/agents", http.StatusUnauthorized},
+		{"revoked token", "revoked", "/v1/agents", http.StatusUnauthorized},
+		{"no token on events", "", "/v1/events", http.StatusUnauthorized},
+		{"operator", "operator", "/v1/agents", http.StatusOK},
+		{"read-only GET", "read-only", "/v1/agents", http.StatusOK},
+		{"health is public", "", "/v1/health", http.StatusOK},
+		// The UI handler runs without a token; it finds no files from the test's directory
+		{"ui is public", "", "/ui", http.StatusNotFound},
+		{"ui assets are public", "", "/ui/app.js", http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, body := s.do(t, tc.token, "GET", tc.path, ""); got != tc.want {
+				t.Errorf("GET %s: HTTP %d (%s), want %d", tc.path, got, body, tc.want)
+			}
+		})
+	}
+
+	// A missing token is challenged
+	resp, err := http.Get(s.URL + "/v1/agents")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get("WWW-Authenticate") == "" {
+		t.Error("401 without a WWW-Authenticate challenge")
+	}
+}
+
+func TestAuthReadOnlyCannotPost(t *testing.T) {
+	s := newAuthServer(t)
+	sess, err := s.svc.StartSession(context.Background(), "a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{
+		"/v1/agents/register",
+		"/v1/agents/a/halt",
+		"/v1/agents/a/resume",
+		"/v1/sessions/start",
+		"/v1/sessions/" + sess.SessionID + "/end",
+		"/v1/actions/propose",
+		"/v1/actions/evaluate",
+		"/v1/panic/on",
+		"/v1/panic/off",
+		"/v1/autobundle/test",
+		"/v1/webhooks/test",
+		"/v1/webhooks/retry",
+		"/v1/config/reload",
+		"/v1/config/rollback/1",
+	} {
+		t.Run(path, func(t *testing.T) {
+			if got, body := s.do(t, "read-only", "POST", path, `{}`); got != http.StatusForbidden {
+				t.Errorf("POST %s: HTTP %d (%s), want 403", path, got, body)
+			}
+		})
+	}
+}
+
+func TestAuthAgentTokenScope(t *testing.T) {
+	s := newAuthServer(t)
+	ctx := context.Background()
+	own, err := s.svc.StartSession(ctx, "a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := s.svc.StartSession(ctx, "b", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	propose := func(agentID string) string {
+		return `{"agent_id":"` + agentID + `","action":{"type":"tool.call"},"cost":{"currency":"GBP","estimated_gbp":0.01}}`
+	}
+	for _, tc := range []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"register another agent", "POST", "/v1/agents/register", `{"agent_id":"b"}`, http.StatusForbidden},
+		{"propose for another agent", "POST", "/v1/actions/propose", propose("b"), http.StatusForbidden},
+		{"evaluate a batch with another agent", "POST", "/v1/actions/evaluate",
+			`{"proposals":[` + propose("a") + `,` + propose("b") + `]}`, http.StatusForbidden},
+		{"end another agent's session", "POST", "/v1/sessions/" + other.SessionID + "/end", `{}`, http.StatusForbidden},
+		{"read another agent", "GET", "/v1/agents/b", "", http.StatusForbidden},
+		{"halt itself", "POST", "/v1/agents/a/halt", `{}`, http.StatusForbidden},
+		{"panic", "POST", "/v1/panic/on", `{}`, http.StatusForbidden},
+		{"register itself", "POST", "/v1/agents/register", `{"agent_id":"a"}`, http.StatusOK},
+		{"propose for itself", "POST", "/v1/actions/propose", propose("a"), http.StatusOK},
+		{"evaluate a batch for itself", "POST", "/v1/actions/evaluate", `{"proposals":[` + propose("a") + `]}`, http.StatusOK},
+		{"end its own session", "POST", "/v1/sessions/" + own.SessionID + "/end", `{}`, http.StatusOK},
+		{"read itself", "GET", "/v1/agents/a", "", http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, body := s.do(t, "agent", tc.method, tc.path, tc.body); got != tc.want {
+				t.Errorf("%s %s: HTTP %d (%s), want %d", tc.method, tc.path, got, body, tc.want)
+			}
+		})
+	}
+
+	// A rejected session end leaves the session open
+	if sess, err := s.svc.GetSession(ctx, other.SessionID); err != nil || sess.EndedAt != nil {
+		t.Errorf("b's session after a rejected end = %+v, %v", sess, err)
+	}
+}
+
+func TestAuthAgentTokenEventsForced(t *testing.T) {
+	s := newAuthServer(t)
+
+	if got, _ := s.do(t, "agent", "GET", "/v1/events?agent_id=b", ""); got != http.StatusForbidden {
+		t.Errorf("GET /v1/events?agent_id=b: HTTP %d, want 403", got)
+	}
+	got, body := s.do(t, "agent", "GET", "/v1/events", "")
+	if got != http.StatusOK {
+		t.Fatalf("GET /v1/events: HTTP %d (%s)", got, body)
+	}
+	var events []domain.Event
+	if err := json.Unmarshal([]byte(body), &events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) == 0 {
+		t.Error("agent token sees none of its own events")
+	}
+	for _, e := range events {
+		if e.AgentID != "a" {
+			t.Errorf("agent token sees %s event %s of agent %q", e.Type, e.EventID, e.AgentID)
+		}
+	}
+
+	if got, _ := s.do(t, "agent", "GET", "/v1/events/stream?agent_id=b", ""); got != http.StatusForbidden {
+		t.Errorf("GET /v1/events/stream?agent_id=b: HTTP %d, want 403", got)
+	}
+	// The stream is narrowed to the token's agent without asking
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", s.URL+"/v1/events/stream?type=session.started", nil)
+	req.Header.Set("Authorization", "Bearer "+s.tokens["agent"])
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /v1/events/stream: HTTP %d", resp.StatusCode)
+	}
+	defer resp.Body.Close()
+	stream := &sseStream{t: t, reader: bufio.NewReader(resp.Body), cancel: cancel}
+	if _, err := s.svc.StartSession(context.Background(), "b", nil); err != nil {
+		t.Fatal(err)
+	}
+	sess, err := s.svc.StartSession(context.Background(), "a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, ok := stream.next(); !ok || msg.data.AgentID != "a" || msg.data.SessionID != sess.SessionID {
+		t.Errorf("first streamed event = %+v, %v; want a's session.started", msg.data, ok)
+	}
+}
@@ -2,33 +2,52 @@ package ctrldot
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/futurematic/kernel/internal/auth"
 	"github.com/futurematic/kernel/internal/ctrldot"
 	"github.com/futurematic/kernel/internal/domain"
 	"github.com/futurematic/kernel/internal/ledger/autobundle"
+	"github.com/futurematic/kernel/internal/runtime"
+	"github.com/futurematic/kernel/internal/tracing"
+	"github.com/futurematic/kernel/internal/webhooks"
 )
 
 // Handlers contains HTTP handlers for Ctrl Dot API
 type Handlers struct {
 	service       ctrldot.Service
 	autobundleMgr *autobundle.Manager
+	webhooks      *webhooks.Dispatcher
+	bundleDirs    []string            // served by /v1/bundles
+	auth          *auth.Authenticator // nil: API auth disabled
+	shutdown      chan struct{}       // closed on server shutdown to end event streams
+	shutdownOnce  sync.Once
 }
 
-// NewHandlers creates new HTTP handlers. autobundleMgr may be nil.
-func NewHandlers(service ctrldot.Service, autobundleMgr *autobundle.Manager) *Handlers {
+// NewHandlers creates new HTTP handlers. autobundleMgr and webhookDispatcher may be nil.
+func NewHandlers(service ctrldot.Service, autobundleMgr *autobundle.Manager, webhookDispatcher *webhooks.Dispatcher) *Handlers {
 	return &Handlers{
 		service:       service,
 		autobundleMgr: autobundleMgr,
+		webhooks:      webhookDispatcher,
+		shutdown:      make(chan struct{}),
 	}
 }
 
+// closeStreams ends open event streams so http.Server.Shutdown can complete.
+func (h *Handlers) closeStreams() {
+	h.shutdownOnce.Do(func() { close(h.shutdown) })
+}
+
 // Health handles GET /v1/health
 func (h *Handlers) Health(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -60,6 +79,10 @@ func (h *Handlers) RegisterAgent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkAgent(w, r, req.AgentID) {
+		return
+	}
+
 	agent, err := h.service.RegisterAgent(r.Context(), req.AgentID, req.DisplayName, req.DefaultMode)
 	if err != nil {
 		respondError(w, err.Error(), http.StatusInternalServerError)
@@ -182,6 +205,10 @@ func (h *Handlers) StartSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkAgent(w, r, req.AgentID) {
+		return
+	}
+
 	session, err := h.service.StartSession(r.Context(), req.AgentID, req.Metadata)
 	if err != nil {
 		respondError(w, err.Error(), http.StatusInternalServerError)
@@ -203,6 +230,16 @@ func (h *Handlers) SessionByID(w http.ResponseWriter, r *http.Request) {
 	sessionID := parts[0]
 
 	if len(parts) == 2 && parts[1] == "end" {
+		if tok := principal(r); tok != nil && tok.Role == auth.RoleAgent {
+			session, err := h.service.GetSession(r.Context(), sessionID)
+			if err != nil || session == nil {
+				http.NotFound(w, r)
+				return
+			}
+			if !checkAgent(w, r, session.AgentID) {
+				return
+			}
+		}
 		if err := h.service.EndSession(r.Context(), sessionID); err != nil {
 			respondError(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -227,7 +264,12 @@ func (h *Handlers) ProposeAction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	decision, err := h.service.ProposeAction(r.Context(), proposal)
+	if !checkAgent(w, r, proposal.AgentID) {
+		return
+	}
+
+	// A W3C traceparent from the agent makes the propose spans part of its trace.
+	decision, err := h.service.ProposeAction(tracing.Extract(r.Context(), r.Header), proposal)
 	if err != nil {
 		log.Printf("ProposeAction error: %v", err)
 		respondError(w, err.Error(), http.StatusInternalServerError)
@@ -237,13 +279,162 @@ func (h *Handlers) ProposeAction(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, decision, http.StatusOK)
 }
 
+// maxEvaluateBatch caps the proposals in one POST /v1/actions/evaluate request.
+const maxEvaluateBatch = 100
+
+// EvaluateActions handles POST /v1/actions/evaluate: a dry run of ProposeAction. The body is a
+// single ActionProposal or {"proposals": [...]}; the response always lists one decision per proposal.
+func (h *Handlers) EvaluateActions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(body, &probe); err != nil {
+		respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var proposals []domain.ActionProposal
+	if _, batch := probe["proposals"]; batch {
+		var req domain.EvaluateRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		proposals = req.Proposals
+	} else {
+		var proposal domain.ActionProposal
+		if err := json.Unmarshal(body, &proposal); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		proposals = []domain.ActionProposal{proposal}
+	}
+	if len(proposals) == 0 || len(proposals) > maxEvaluateBatch {
+		respondError(w, "proposals must contain 1 to "+strconv.Itoa(maxEvaluateBatch)+" entries", http.StatusBadRequest)
+		return
+	}
+	for _, p := range proposals {
+		if !checkAgent(w, r, p.AgentID) {
+			return
+		}
+	}
+
+	result, err := h.service.EvaluateActions(tracing.Extract(r.Context(), r.Header), proposals)
+	if err != nil {
+		log.Printf("EvaluateActions error: %v", err)
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, result, http.StatusOK)
+}
+
 // GetEvents handles GET /v1/events
+// Filters: agent_id, session_id, type, severity, decision, action_type, action_hash, since_ts, until_ts.
+// type and severity accept comma-separated lists. When a full page is returned, X-Next-Cursor carries
+// the cursor for the next page (pass it back as ?cursor=).
 func (h *Handlers) GetEvents(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	filter, err := eventFilterFromQuery(r.URL.Query())
+	if err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.service.QueryEvents(r.Context(), filter)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []domain.Event{}
+	}
+
+	if len(events) == filter.Limit {
+		w.Header().Set("X-Next-Cursor", runtime.CursorAfter(events[len(events)-1]).Encode())
+	}
+	respondJSON(w, events, http.StatusOK)
+}
+
+// eventFilterFromQuery parses the GET /v1/events filters shared by the list and stream endpoints.
+func eventFilterFromQuery(q url.Values) (runtime.EventFilter, error) {
+	filter := runtime.EventFilter{
+		AgentID:    queryString(q, "agent_id"),
+		SessionID:  queryString(q, "session_id"),
+		Types:      queryList(q, "type"),
+		Severities: queryList(q, "severity"),
+		Decision:   queryString(q, "decision"),
+		ActionType: queryString(q, "action_type"),
+		ActionHash: queryString(q, "action_hash"),
+		Limit:      50,
+	}
+
+	if tsStr := q.Get("since_ts"); tsStr != "" {
+		if ts, err := strconv.ParseInt(tsStr, 10, 64); err == nil {
+			filter.SinceTS = &ts
+		}
+	}
+	if tsStr := q.Get("until_ts"); tsStr != "" {
+		if ts, err := strconv.ParseInt(tsStr, 10, 64); err == nil {
+			filter.UntilTS = &ts
+		}
+	}
+
+	if limitStr := q.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			filter.Limit = l
+		}
+	}
+
+	if c := q.Get("cursor"); c != "" {
+		cursor, err := runtime.ParseEventCursor(c)
+		if err != nil {
+			return filter, err
+		}
+		filter.Cursor = cursor
+	}
+	return filter, nil
+}
+
+// queryString returns the query parameter as a pointer, or nil when empty.
+func queryString(q url.Values, key string) *string {
+	if v := q.Get(key); v != "" {
+		return &v
+	}
+	return nil
+}
+
+// queryList collects a query parameter given repeatedly and/or comma-separated.
+func queryList(q url.Values, key string) []string {
+	var out []string
+	for _, v := range q[key] {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// GetEventRollups handles GET /v1/events/rollups (hourly aggregates of compacted events)
+func (h *Handlers) GetEventRollups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
 	var agentID *string
 	if id := r.URL.Query().Get("agent_id"); id != "" {
 		agentID = &id
@@ -256,20 +447,20 @@ func (h *Handlers) GetEvents(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	limit := 50
+	limit := 500
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
 			limit = l
 		}
 	}
 
-	events, err := h.service.GetEvents(r.Context(), agentID, sinceTS, limit)
+	rollups, err := h.service.GetEventRollups(r.Context(), agentID, sinceTS, limit)
 	if err != nil {
 		respondError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	respondJSON(w, events, http.StatusOK)
+	respondJSON(w, rollups, http.StatusOK)
 }
 
 // GetEvent handles GET /v1/events/{event_id}
@@ -363,6 +554,88 @@ func (h *Handlers) PanicOff(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, map[string]interface{}{"enabled": false}, http.StatusOK)
 }
 
+// ReloadConfig handles POST /v1/config/reload: re-read the config file and apply it.
+// An invalid file is rejected with 422 and the running config is kept. The optional body
+// {"author": "..."} is recorded with the new config revision.
+func (h *Handlers) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	res, err := h.service.ReloadConfig(r.Context(), "api", requestAuthor(r))
+	if errors.Is(err, ctrldot.ErrReloadDisabled) {
+		respondError(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		respondError(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	respondJSON(w, res, http.StatusOK)
+}
+
+// ConfigRevisions handles GET /v1/config/revisions: applied config revisions, newest first
+// (limit, default 50). File contents are not included.
+func (h *Handlers) ConfigRevisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+	revisions, err := h.service.ListConfigRevisions(r.Context(), limit)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if revisions == nil {
+		revisions = []domain.ConfigRevision{}
+	}
+	respondJSON(w, revisions, http.StatusOK)
+}
+
+// RollbackConfig handles POST /v1/config/rollback/{rev}: write revision rev's file back and
+// apply it as a new revision. Body as for ReloadConfig.
+func (h *Handlers) RollbackConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rev, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/v1/config/rollback/"), 10, 64)
+	if err != nil || rev <= 0 {
+		respondError(w, "revision must be a positive number", http.StatusBadRequest)
+		return
+	}
+	res, err := h.service.RollbackConfig(r.Context(), rev, requestAuthor(r))
+	switch {
+	case errors.Is(err, ctrldot.ErrReloadDisabled):
+		respondError(w, err.Error(), http.StatusNotImplemented)
+	case errors.Is(err, ctrldot.ErrRevisionNotFound):
+		respondError(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		respondError(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		respondJSON(w, res, http.StatusOK)
+	}
+}
+
+// requestAuthor is the name of the request's token, or else the optional {"author": "..."}
+// body of a config change request.
+func requestAuthor(r *http.Request) string {
+	if tok := principal(r); tok != nil {
+		return tok.Name
+	}
+	var body struct {
+		Author string `json:"author"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	return body.Author
+}
+
 // AutobundleStatus handles GET /v1/autobundle
 func (h *Handlers) AutobundleStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -0,0 +1,100 @@
+package ctrldot
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/futurematic/kernel/internal/ledger/sink/bundle"
+)
+
+// bundleInfo is one entry of GET /v1/bundles.
+type bundleInfo struct {
+	Name     string                 `json:"name"`
+	Dir      string                 `json:"dir"`
+	Archive  bool                   `json:"archive"`
+	Manifest *bundle.BundleManifest `json:"manifest,omitempty"`
+}
+
+// ListBundles handles GET /v1/bundles (bundle sink and auto-bundle output directories)
+func (h *Handlers) ListBundles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	out := []bundleInfo{}
+	for _, dir := range h.bundleDirs {
+		names, err := bundle.ListBundles(dir)
+		if err != nil {
+			respondError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, name := range names {
+			m, _ := bundle.ReadManifest(filepath.Join(dir, name))
+			out = append(out, bundleInfo{Name: name, Dir: dir, Archive: bundle.IsArchive(name), Manifest: m})
+		}
+	}
+	respondJSON(w, map[string]interface{}{"bundles": out}, http.StatusOK)
+}
+
+// BundleByName handles GET /v1/bundles/{name}/archive: the bundle as a .tar.gz (packed on the
+// fly for bundle directories; see bundle.Pack).
+func (h *Handlers) BundleByName(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/bundles/"), "/")
+	if len(parts) != 2 || parts[1] != "archive" {
+		respondError(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	path := h.findBundle(parts[0])
+	if path == "" {
+		respondError(w, "Bundle not found", http.StatusNotFound)
+		return
+	}
+
+	var data []byte
+	if bundle.IsArchive(path) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			respondError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data = b
+	} else {
+		var buf bytes.Buffer
+		if err := bundle.Pack(path, &buf); err != nil {
+			respondError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data = buf.Bytes()
+	}
+	filename := strings.TrimSuffix(filepath.Base(path), bundle.ArchiveExt) + bundle.ArchiveExt
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// findBundle resolves name (a bundle directory or archive name, with or without .tar.gz) to a
+// path in one of the bundle directories. Only names that ListBundles returns match.
+func (h *Handlers) findBundle(name string) string {
+	for _, dir := range h.bundleDirs {
+		names, err := bundle.ListBundles(dir)
+		if err != nil {
+			continue
+		}
+		for _, n := range names {
+			if n == name || n == name+bundle.ArchiveExt {
+				return filepath.Join(dir, n)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,24 @@
+package ctrldot
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/futurematic/kernel/internal/metrics"
+)
+
+// Metrics handles GET /metrics (Prometheus text format). 404 when metrics.enabled is false.
+func (h *Handlers) Metrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !metrics.Enabled() {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", metrics.ContentType)
+	if err := metrics.Default.WriteText(r.Context(), w); err != nil {
+		log.Printf("metrics: write: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package ctrldot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/runtime"
+)
+
+const (
+	streamHeartbeat   = 15 * time.Second
+	streamReplayLimit = 1000
+
+	// streamTruncatedEvent (SSE event name, no id) ends a stream whose replay hit streamReplayLimit.
+	streamTruncatedEvent = "stream.truncated"
+)
+
+// StreamEvents handles GET /v1/events/stream (Server-Sent Events).
+// Accepts the same filters as GET /v1/events. Each event's SSE id is a cursor; on reconnect
+// (Last-Event-ID header, or ?last_event_id=) the events appended since that id are replayed
+// from the store, oldest first, before live events. A replay stops after 1000 events with a
+// stream.truncated message and the stream ends; the client reconnects with the last id it got
+// and continues. Replay resumes exactly after the id's event, in append order; an id issued
+// before ids carried the chain position replays its whole second again, so clients should
+// still de-duplicate by event_id.
+func (h *Handlers) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := eventFilterFromQuery(r.URL.Query())
+	if err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Cursor = nil
+
+	var resumeFrom *runtime.EventCursor
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	if lastID != "" {
+		if resumeFrom, err = runtime.ParseEventCursor(lastID); err != nil {
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	// Streams outlive the server's WriteTimeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	// Subscribe before replaying so nothing appended in between is missed.
+	sub := h.service.SubscribeEvents(filter)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 2000\n\n")
+
+	sent := make(map[string]bool)
+	if resumeFrom != nil {
+		replay, truncated, err := h.replayEvents(r, filter, resumeFrom)
+		if err != nil {
+			log.Printf("event stream replay: %v", err)
+		}
+		for _, e := range replay {
+			if err := writeSSE(w, e); err != nil {
+				return
+			}
+			sent[e.EventID] = true
+		}
+		if truncated {
+			// Too far behind to catch up in one response: end the stream so the client
+			// reconnects from the last replayed id and continues where this replay stopped.
+			fmt.Fprintf(w, "event: %s\ndata: {\"replayed\":%d}\n\n", streamTruncatedEvent, streamReplayLimit)
+			flusher.Flush()
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.shutdown:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case e, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind (or shutting down); the client reconnects
+				// with Last-Event-ID and catches up from the store.
+				return
+			}
+			if sent[e.EventID] {
+				continue
+			}
+			if err := writeSSE(w, e); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// replayEvents returns the events matching filter appended after from, oldest first, and
+// whether more remain after streamReplayLimit of them.
+func (h *Handlers) replayEvents(r *http.Request, filter runtime.EventFilter, from *runtime.EventCursor) ([]domain.Event, bool, error) {
+	filter.OldestFirst = true
+	filter.Cursor = from
+	filter.Limit = 200
+
+	var out []domain.Event
+	for {
+		page, err := h.service.QueryEvents(r.Context(), filter)
+		if err != nil {
+			return out, false, err
+		}
+		for _, e := range page {
+			if len(out) == streamReplayLimit {
+				return out, true, nil
+			}
+			out = append(out, e)
+		}
+		if len(page) < filter.Limit {
+			return out, false, nil
+		}
+		filter.Cursor = runtime.CursorAfter(page[len(page)-1])
+	}
+}
+
+// writeSSE writes one event as an SSE message whose id is the event's cursor.
+func writeSSE(w http.ResponseWriter, e domain.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", runtime.CursorAfter(e).Encode(), e.Type, data)
+	return err
+}
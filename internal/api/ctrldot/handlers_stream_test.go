@@ -0,0 +1,229 @@
+package ctrldot_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/runtime"
+	"github.com/futurematic/kernel/internal/runtime/memory"
+)
+
+type sseMessage struct {
+	event, id string
+	data      domain.Event
+}
+
+// sseStream is an open GET /v1/events/stream response.
+type sseStream struct {
+	t      *testing.T
+	reader *bufio.Reader
+	cancel context.CancelFunc
+}
+
+func openStream(t *testing.T, url, lastID string) *sseStream {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		cancel()
+		t.Fatalf("GET %s: HTTP %d", url, resp.StatusCode)
+	}
+	s := &sseStream{t: t, reader: bufio.NewReader(resp.Body), cancel: cancel}
+	t.Cleanup(s.close)
+	return s
+}
+
+func (s *sseStream) close() { s.cancel() }
+
+// next returns the next message with data; ok is false when the server ended the stream.
+func (s *sseStream) next() (msg sseMessage, ok bool) {
+	s.t.Helper()
+	var data string
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return msg, false
+		}
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			if data == "" {
+				continue
+			}
+			if msg.id != "" {
+				if err := json.Unmarshal([]byte(data), &msg.data); err != nil {
+					s.t.Fatalf("event data %q: %v", data, err)
+				}
+			}
+			return msg, true
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "event":
+			msg.event = value
+		case "id":
+			msg.id = value
+		case "data":
+			data = value
+		}
+	}
+}
+
+// registered returns the agent.registered events of agentIDs, oldest first.
+func registered(t *testing.T, st *memory.Store, agentIDs ...string) []domain.Event {
+	t.Helper()
+	events, err := st.ListEvents(context.Background(), runtime.EventFilter{Types: []string{domain.EventTypeAgentRegistered}, OldestFirst: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[string]bool)
+	for _, id := range agentIDs {
+		want[id] = true
+	}
+	var out []domain.Event
+	for _, e := range events {
+		if want[e.AgentID] {
+			out = append(out, e)
+		}
+	}
+	if len(out) != len(agentIDs) {
+		t.Fatalf("found %d agent.registered events, want %d", len(out), len(agentIDs))
+	}
+	return out
+}
+
+// after reports whether e sorts after from in the stores' (second, chain_seq, event_id) order.
+func after(e, from domain.Event) bool {
+	if e.TS.Unix() != from.TS.Unix() {
+		return e.TS.Unix() > from.TS.Unix()
+	}
+	if e.ChainSeq != from.ChainSeq {
+		return e.ChainSeq > from.ChainSeq
+	}
+	return e.EventID > from.EventID
+}
+
+func TestStreamResumesFromLastEventID(t *testing.T) {
+	ts, svc, st := newServer(t, nil)
+	register(t, svc, "r0", "r1", "r2", "r3")
+	events := registered(t, st, "r0", "r1", "r2", "r3")
+
+	// Replay resumes exactly after r1, in append order, even within one second
+	s := openStream(t, ts.URL+"/v1/events/stream?type=agent.registered", runtime.CursorAfter(events[1]).Encode())
+	var got []string
+	for len(got) < 2 {
+		msg, ok := s.next()
+		if !ok {
+			t.Fatalf("stream ended after %v", got)
+		}
+		if msg.id != runtime.CursorAfter(msg.data).Encode() {
+			t.Errorf("SSE id %q is not the event's cursor", msg.id)
+		}
+		got = append(got, msg.data.AgentID)
+	}
+	if fmt.Sprint(got) != "[r2 r3]" {
+		t.Errorf("replayed %v, want [r2 r3]", got)
+	}
+
+	// Live events follow the replay
+	register(t, svc, "r4")
+	if msg, ok := s.next(); !ok || msg.data.AgentID != "r4" {
+		t.Errorf("live event = %+v, %v; want r4's registration", msg.data, ok)
+	}
+}
+
+func TestStreamReplayTruncates(t *testing.T) {
+	ts, svc, st := newServer(t, nil)
+	var agents []string
+	for i := 0; i < 1011; i++ {
+		agents = append(agents, fmt.Sprintf("t%04d", i))
+	}
+	register(t, svc, agents...)
+	// Resume from the first registration, so that 1010 follow it
+	from := registered(t, st, agents...)[0]
+
+	// The first connection replays 1000 events after the resume point, oldest first, then
+	// ends with stream.truncated
+	seen := map[string]bool{from.AgentID: true}
+	s := openStream(t, ts.URL+"/v1/events/stream?type=agent.registered", runtime.CursorAfter(from).Encode())
+	var n int
+	var last domain.Event
+	for {
+		msg, ok := s.next()
+		if !ok {
+			t.Fatal("stream ended without stream.truncated")
+		}
+		if msg.event == "stream.truncated" {
+			break
+		}
+		if last.EventID != "" && !after(msg.data, last) {
+			t.Fatalf("replay not oldest first: %s after %s", msg.data.EventID, last.EventID)
+		}
+		if !after(msg.data, from) {
+			t.Fatalf("replayed %s, which is not after the resume point", msg.data.AgentID)
+		}
+		last = msg.data
+		n++
+		seen[msg.data.AgentID] = true
+	}
+	if n != 1000 {
+		t.Errorf("replayed %d events before stream.truncated, want 1000", n)
+	}
+	if _, ok := s.next(); ok {
+		t.Error("stream continued after stream.truncated")
+	}
+
+	// Reconnecting from the last id delivers the rest
+	s = openStream(t, ts.URL+"/v1/events/stream?type=agent.registered", runtime.CursorAfter(last).Encode())
+	for len(seen) < len(agents) {
+		msg, ok := s.next()
+		if !ok || msg.event == "stream.truncated" {
+			t.Fatalf("second replay ended early (%q) with %d of %d agents seen", msg.event, len(seen), len(agents))
+		}
+		seen[msg.data.AgentID] = true
+	}
+}
+
+func TestStreamFilters(t *testing.T) {
+	ts, svc, _ := newServer(t, nil)
+	register(t, svc, "f1")
+
+	s := openStream(t, ts.URL+"/v1/events/stream?agent_id=f2&type=session.started", "")
+	// Neither the wrong type nor the wrong agent is streamed
+	register(t, svc, "f2")
+	if _, err := svc.StartSession(context.Background(), "f1", nil); err != nil {
+		t.Fatal(err)
+	}
+	sess, err := svc.StartSession(context.Background(), "f2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, ok := s.next()
+	if !ok || msg.data.Type != "session.started" || msg.data.AgentID != "f2" || msg.data.SessionID != sess.SessionID {
+		t.Errorf("first streamed event = %+v, %v; want f2's session.started", msg.data, ok)
+	}
+
+	// A bad resume id is rejected before streaming
+	resp, err := http.Get(ts.URL + "/v1/events/stream?last_event_id=nonsense")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid last_event_id: HTTP %d, want 400", resp.StatusCode)
+	}
+}
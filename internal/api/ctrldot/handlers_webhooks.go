@@ -0,0 +1,105 @@
+package ctrldot
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+// WebhooksStatus handles GET /v1/webhooks (endpoints without secrets, queue depth)
+func (h *Handlers) WebhooksStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.webhooks == nil {
+		respondError(w, "webhooks not configured", http.StatusBadRequest)
+		return
+	}
+	status, err := h.webhooks.Status(r.Context())
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, status, http.StatusOK)
+}
+
+// WebhooksTest handles POST /v1/webhooks/test. Body: {"endpoint": "name"} (optional; default all endpoints).
+func (h *Handlers) WebhooksTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.webhooks == nil {
+		respondError(w, "webhooks not configured", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	results, err := h.webhooks.Test(r.Context(), req.Endpoint)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	respondJSON(w, results, http.StatusOK)
+}
+
+// WebhooksDead handles GET /v1/webhooks/dead (dead-letter list)
+func (h *Handlers) WebhooksDead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.webhooks == nil {
+		respondError(w, "webhooks not configured", http.StatusBadRequest)
+		return
+	}
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+	dead, err := h.webhooks.DeadLetters(r.Context(), limit)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if dead == nil {
+		dead = []domain.WebhookDelivery{}
+	}
+	respondJSON(w, dead, http.StatusOK)
+}
+
+// WebhooksRetry handles POST /v1/webhooks/retry. Body: {"delivery_id": "whd:..."}
+func (h *Handlers) WebhooksRetry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.webhooks == nil {
+		respondError(w, "webhooks not configured", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		DeliveryID string `json:"delivery_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DeliveryID == "" {
+		respondError(w, "delivery_id is required", http.StatusBadRequest)
+		return
+	}
+	if err := h.webhooks.Retry(r.Context(), req.DeliveryID); err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	respondJSON(w, map[string]string{"delivery_id": req.DeliveryID, "status": "pending"}, http.StatusOK)
+}
@@ -8,8 +8,10 @@ import (
 	"runtime/debug"
 	"time"
 
+	"github.com/futurematic/kernel/internal/auth"
 	"github.com/futurematic/kernel/internal/ctrldot"
 	"github.com/futurematic/kernel/internal/ledger/autobundle"
+	"github.com/futurematic/kernel/internal/webhooks"
 )
 
 // recoveryMiddleware recovers from handler panics and returns 500 instead of closing the connection.
@@ -30,11 +32,12 @@ type Server struct {
 	httpServer    *http.Server
 	handlers      *Handlers
 	autobundleMgr *autobundle.Manager
+	onShutdown    []func(context.Context) error
 }
 
-// NewServer creates a new Ctrl Dot HTTP server. autobundleMgr may be nil.
-func NewServer(port int, ctrldotService ctrldot.Service, autobundleMgr *autobundle.Manager) *Server {
-	handlers := NewHandlers(ctrldotService, autobundleMgr)
+// NewServer creates a new Ctrl Dot HTTP server. autobundleMgr and webhookDispatcher may be nil.
+func NewServer(port int, ctrldotService ctrldot.Service, autobundleMgr *autobundle.Manager, webhookDispatcher *webhooks.Dispatcher) *Server {
+	handlers := NewHandlers(ctrldotService, autobundleMgr, webhookDispatcher)
 
 	mux := http.NewServeMux()
 
@@ -50,23 +53,37 @@ func NewServer(port int, ctrldotService ctrldot.Service, autobundleMgr *autobund
 	mux.HandleFunc("/v1/sessions/start", handlers.StartSession)
 	mux.HandleFunc("/v1/sessions/", handlers.SessionByID)
 	mux.HandleFunc("/v1/actions/propose", handlers.ProposeAction)
+	mux.HandleFunc("/v1/actions/evaluate", handlers.EvaluateActions)
 	mux.HandleFunc("/v1/events", handlers.GetEvents)
 	mux.HandleFunc("/v1/events/", handlers.GetEvent)
+	mux.HandleFunc("/v1/events/rollups", handlers.GetEventRollups)
+	mux.HandleFunc("/v1/events/stream", handlers.StreamEvents)
 	mux.HandleFunc("/v1/panic/on", handlers.PanicOn)
 	mux.HandleFunc("/v1/panic/off", handlers.PanicOff)
 	mux.HandleFunc("/v1/panic", handlers.PanicStatus)
 	mux.HandleFunc("/v1/autobundle", handlers.AutobundleStatus)
 	mux.HandleFunc("/v1/autobundle/test", handlers.AutobundleTest)
+	mux.HandleFunc("/v1/bundles", handlers.ListBundles)
+	mux.HandleFunc("/v1/bundles/", handlers.BundleByName)
+	mux.HandleFunc("/v1/webhooks", handlers.WebhooksStatus)
+	mux.HandleFunc("/v1/webhooks/test", handlers.WebhooksTest)
+	mux.HandleFunc("/v1/webhooks/dead", handlers.WebhooksDead)
+	mux.HandleFunc("/v1/webhooks/retry", handlers.WebhooksRetry)
 	mux.HandleFunc("/v1/capabilities", handlers.Capabilities)
 	mux.HandleFunc("/v1/limits/config", handlers.LimitsConfig)
+	mux.HandleFunc("/v1/config/reload", handlers.ReloadConfig)
+	mux.HandleFunc("/v1/config/revisions", handlers.ConfigRevisions)
+	mux.HandleFunc("/v1/config/rollback/", handlers.RollbackConfig)
+	mux.HandleFunc("/metrics", handlers.Metrics)
 
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf(":%d", port),
-		Handler:      recoveryMiddleware(mux),
+		Handler:      recoveryMiddleware(handlers.authMiddleware(mux)),
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	httpServer.RegisterOnShutdown(handlers.closeStreams)
 
 	return &Server{
 		httpServer:    httpServer,
@@ -75,13 +92,37 @@ func NewServer(port int, ctrldotService ctrldot.Service, autobundleMgr *autobund
 	}
 }
 
+// Handler returns the server's HTTP handler (all routes and middleware), e.g. for httptest.
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+// WithBundleDirs sets the directories /v1/bundles lists and serves bundles from.
+func (s *Server) WithBundleDirs(dirs ...string) *Server {
+	s.handlers.bundleDirs = dirs
+	return s
+}
+
+// WithAuth requires API tokens checked by a (see authMiddleware).
+func (s *Server) WithAuth(a *auth.Authenticator) *Server {
+	s.handlers.auth = a
+	return s
+}
+
+// OnShutdown registers fn to run during Shutdown, after in-flight requests have finished
+// (e.g. draining an async ledger sink).
+func (s *Server) OnShutdown(fn func(context.Context) error) {
+	s.onShutdown = append(s.onShutdown, fn)
+}
+
 // Start starts the HTTP server
 func (s *Server) Start() error {
 	log.Printf("Starting Ctrl Dot server on %s", s.httpServer.Addr)
 	return s.httpServer.ListenAndServe()
 }
 
-// Shutdown gracefully shuts down the server. Calls MaybeBundleOnShutdown before closing.
+// Shutdown gracefully shuts down the server. Calls MaybeBundleOnShutdown before closing and
+// OnShutdown hooks after in-flight requests complete.
 func (s *Server) Shutdown(ctx context.Context) error {
 	log.Println("Shutting down Ctrl Dot server...")
 	if s.autobundleMgr != nil {
@@ -91,5 +132,11 @@ func (s *Server) Shutdown(ctx context.Context) error {
 			log.Printf("Shutdown bundle written: %s", path)
 		}
 	}
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	for _, fn := range s.onShutdown {
+		if ferr := fn(ctx); ferr != nil {
+			log.Printf("shutdown: %v", ferr)
+		}
+	}
+	return err
 }
@@ -0,0 +1,43 @@
+package ctrldot_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	api "github.com/futurematic/kernel/internal/api/ctrldot"
+	"github.com/futurematic/kernel/internal/auth"
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/ctrldot"
+	"github.com/futurematic/kernel/internal/ledger/sink/noop"
+	"github.com/futurematic/kernel/internal/limits"
+	"github.com/futurematic/kernel/internal/loop"
+	"github.com/futurematic/kernel/internal/resolution"
+	"github.com/futurematic/kernel/internal/rules"
+	"github.com/futurematic/kernel/internal/runtime/memory"
+)
+
+// newServer serves the API over a service with an in-memory store. authn may be nil.
+func newServer(t *testing.T, authn *auth.Authenticator) (*httptest.Server, ctrldot.Service, *memory.Store) {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	st := memory.New(nil)
+	svc := ctrldot.NewService(st, limits.NewEngine(st, cfg), rules.NewEngine(cfg), loop.NewDetector(st, cfg),
+		resolution.NewManager(nil, ""), noop.New(), nil, cfg, nil)
+	srv := api.NewServer(0, svc, nil, nil)
+	if authn != nil {
+		srv.WithAuth(authn)
+	}
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts, svc, st
+}
+
+func register(t *testing.T, svc ctrldot.Service, agentIDs ...string) {
+	t.Helper()
+	for _, id := range agentIDs {
+		if _, err := svc.RegisterAgent(context.Background(), id, id, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
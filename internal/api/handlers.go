@@ -2,14 +2,17 @@ package api
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/futurematic/kernel/internal/domain"
 	"github.com/futurematic/kernel/internal/kernel"
 	"github.com/futurematic/kernel/internal/ledger/sink"
 	"github.com/futurematic/kernel/internal/query"
+	"github.com/futurematic/kernel/internal/store"
 )
 
 // Handlers contains HTTP handlers
@@ -56,6 +59,10 @@ func (h *Handlers) Plan(w http.ResponseWriter, r *http.Request) {
 
 	plan, err := h.kernelService.Plan(r.Context(), kernelReq)
 	if err != nil {
+		if err == domain.ErrInvalidActorID || err == domain.ErrEmptyIntents || err == domain.ErrInvalidIntentKind {
+			respondError(w, NewError(ErrorCodeValidation, err.Error(), nil), http.StatusBadRequest)
+			return
+		}
 		respondError(w, NewError(ErrorCodeInternal, err.Error(), nil), http.StatusInternalServerError)
 		return
 	}
@@ -102,6 +109,10 @@ func (h *Handlers) Apply(w http.ResponseWriter, r *http.Request) {
 			respondError(w, NewError(ErrorCodePolicyDenied, err.Error(), nil), http.StatusForbidden)
 			return
 		}
+		if strings.Contains(err.Error(), "not found") {
+			respondError(w, NewError(ErrorCodeNotFound, err.Error(), nil), http.StatusNotFound)
+			return
+		}
 		respondError(w, NewError(ErrorCodeInternal, err.Error(), nil), http.StatusInternalServerError)
 		return
 	}
@@ -330,6 +341,10 @@ func (h *Handlers) Resolve(w http.ResponseWriter, r *http.Request) {
 		AssumptionsSnap: req.AssumptionsSnap,
 	})
 	if err != nil {
+		if strings.Contains(err.Error(), "already resolved") {
+			respondError(w, NewError(ErrorCodeConflict, err.Error(), nil), http.StatusConflict)
+			return
+		}
 		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "option_id") {
 			respondError(w, NewError(ErrorCodeValidation, err.Error(), nil), http.StatusBadRequest)
 			return
@@ -428,18 +443,149 @@ func (h *Handlers) ProposalSetsByID(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, ps, http.StatusOK)
 }
 
-// CtRLDotDecisions handles POST /v1/ctrldot/decisions (Kernel HTTP sink: append decision record).
+// Ctrl Dot decision records (ledger_sink kind kernel_http)
+
+const (
+	// ctrlDotIdempotencyKeyHeader carries the decision record ID on POST /v1/ctrldot/decisions.
+	ctrlDotIdempotencyKeyHeader = "Idempotency-Key"
+	// ctrlDotSourceHeader identifies the sending Ctrl Dot daemon (e.g. its hostname).
+	ctrlDotSourceHeader = "X-Ctrldot-Source"
+
+	maxCtrlDotDecisionBytes  = 1 << 20
+	defaultCtrlDotDecisions  = 100
+	maxCtrlDotDecisionsLimit = 1000
+)
+
+// CtRLDotDecisions handles POST /v1/ctrldot/decisions (Kernel HTTP sink: append decision record)
+// and GET /v1/ctrldot/decisions?agent_id=&session_id=&decision=&action_type=&source=&since=&until=&limit=.
+// Records are deduplicated by ID: a redelivered record returns 200 with duplicate=true, a new one 201.
 func (h *Handlers) CtRLDotDecisions(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+	switch r.Method {
+	case http.MethodPost:
+		h.storeCtrlDotDecision(w, r)
+	case http.MethodGet:
+		h.listCtrlDotDecisions(w, r)
+	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handlers) storeCtrlDotDecision(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCtrlDotDecisionBytes))
+	if err != nil {
+		respondError(w, NewError(ErrorCodeValidation, "Request body too large", nil), http.StatusRequestEntityTooLarge)
 		return
 	}
 	var d sink.DecisionRecord
-	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := json.Unmarshal(body, &d); err != nil {
+		respondError(w, NewError(ErrorCodeValidation, "Invalid request body", nil), http.StatusBadRequest)
+		return
+	}
+	key := r.Header.Get(ctrlDotIdempotencyKeyHeader)
+	if d.ID == "" {
+		d.ID = key
+	}
+	if d.ID == "" {
+		respondError(w, NewError(ErrorCodeValidation, "decision record id (or Idempotency-Key) is required", nil), http.StatusBadRequest)
+		return
+	}
+	if key != "" && key != d.ID {
+		respondError(w, NewError(ErrorCodeValidation, "Idempotency-Key does not match decision record id", nil), http.StatusBadRequest)
+		return
+	}
+	if d.AgentID == "" || d.Decision == "" {
+		respondError(w, NewError(ErrorCodeValidation, "agent_id and decision are required", nil), http.StatusUnprocessableEntity)
+		return
+	}
+	rec := domain.CtrlDotDecisionRecord{
+		ID:         d.ID,
+		AgentID:    d.AgentID,
+		SessionID:  d.SessionID,
+		Decision:   string(d.Decision),
+		ActionType: d.ActionType,
+		Source:     r.Header.Get(ctrlDotSourceHeader),
+		Timestamp:  d.Timestamp,
+		ReceivedAt: time.Now().UTC(),
+		Record:     json.RawMessage(body),
+	}
+	if rec.Timestamp.IsZero() {
+		rec.Timestamp = rec.ReceivedAt
+	}
+	inserted, err := h.kernelService.StoreCtrlDotDecision(r.Context(), rec)
+	if err != nil {
+		respondError(w, NewError(ErrorCodeInternal, err.Error(), nil), http.StatusInternalServerError)
+		return
+	}
+	status := http.StatusCreated
+	if !inserted {
+		status = http.StatusOK
+	}
+	respondJSON(w, map[string]interface{}{"id": rec.ID, "duplicate": !inserted}, status)
+}
+
+func (h *Handlers) listCtrlDotDecisions(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := store.DecisionRecordQuery{
+		AgentID:    params.Get("agent_id"),
+		SessionID:  params.Get("session_id"),
+		Decision:   strings.ToUpper(params.Get("decision")),
+		ActionType: params.Get("action_type"),
+		Source:     params.Get("source"),
+		Limit:      defaultCtrlDotDecisions,
+	}
+	for name, dst := range map[string]**time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := params.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				respondError(w, NewError(ErrorCodeValidation, "Invalid "+name+" parameter (RFC 3339 expected)", nil), http.StatusBadRequest)
+				return
+			}
+			*dst = &t
+		}
+	}
+	if l := params.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			respondError(w, NewError(ErrorCodeValidation, "Invalid limit parameter", nil), http.StatusBadRequest)
+			return
+		}
+		q.Limit = n
+	}
+	if q.Limit > maxCtrlDotDecisionsLimit {
+		q.Limit = maxCtrlDotDecisionsLimit
+	}
+	list, err := h.queryEngine.ListCtrlDotDecisions(r.Context(), q)
+	if err != nil {
+		respondError(w, NewError(ErrorCodeInternal, err.Error(), nil), http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []domain.CtrlDotDecisionRecord{}
+	}
+	respondJSON(w, map[string]interface{}{"decisions": list}, http.StatusOK)
+}
+
+// CtRLDotDecisionByID handles GET /v1/ctrldot/decisions/:id
+func (h *Handlers) CtRLDotDecisionByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/ctrldot/decisions/"), "/")
+	if id == "" {
+		respondError(w, NewError(ErrorCodeValidation, "decision record id is required", nil), http.StatusBadRequest)
+		return
+	}
+	rec, err := h.queryEngine.GetCtrlDotDecision(r.Context(), id)
+	if err != nil {
+		if err == domain.ErrCtrlDotDecisionNotFound {
+			respondError(w, NewError(ErrorCodeNotFound, err.Error(), nil), http.StatusNotFound)
+			return
+		}
+		respondError(w, NewError(ErrorCodeInternal, err.Error(), nil), http.StatusInternalServerError)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
+	respondJSON(w, rec, http.StatusOK)
 }
 
 // Helper functions
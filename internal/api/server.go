@@ -36,6 +36,7 @@ func NewServer(port int, kernelService kernel.Service, queryEngine query.Engine)
 	mux.HandleFunc("/v1/proposal_sets", handlers.ProposalSetsRoot)
 	mux.HandleFunc("/v1/proposal_sets/", handlers.ProposalSetsByID)
 	mux.HandleFunc("/v1/ctrldot/decisions", handlers.CtRLDotDecisions)
+	mux.HandleFunc("/v1/ctrldot/decisions/", handlers.CtRLDotDecisionByID)
 
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf(":%d", port),
@@ -62,3 +63,8 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	log.Println("Shutting down kernel server...")
 	return s.httpServer.Shutdown(ctx)
 }
+
+// Handler returns the routed handler (e.g. for httptest)
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
@@ -0,0 +1,248 @@
+// Package auth implements the Ctrl Dot API tokens: bearer secrets with a role, kept hashed in a
+// local JSON file (auth.tokens_path) that `ctrldot tokens` edits and the daemon reads.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Role is what a token may do on the API.
+type Role string
+
+const (
+	// RoleAgent may register, start sessions, propose and evaluate actions and read events,
+	// only for the token's own agent ID.
+	RoleAgent Role = "agent"
+	// RoleOperator may do everything, including panic, halt/resume and config changes.
+	RoleOperator Role = "operator"
+	// RoleReadOnly may make GET requests only (dashboards, metrics scrapers).
+	RoleReadOnly Role = "read-only"
+)
+
+// ParseRole accepts agent, operator or read-only.
+func ParseRole(s string) (Role, error) {
+	switch r := Role(s); r {
+	case RoleAgent, RoleOperator, RoleReadOnly:
+		return r, nil
+	}
+	return "", fmt.Errorf("unknown role %q (want agent, operator or read-only)", s)
+}
+
+// Token is an issued API token. Only the SHA-256 of its secret is stored.
+type Token struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Role      Role       `json:"role"`
+	AgentID   string     `json:"agent_id,omitempty"` // RoleAgent only
+	Hash      string     `json:"hash"`               // hex SHA-256 of the secret
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active reports whether the token has not been revoked.
+func (t Token) Active() bool {
+	return t.RevokedAt == nil
+}
+
+// TokenStore is the token file.
+type TokenStore struct {
+	path   string
+	Tokens []Token `json:"tokens"`
+}
+
+// LoadTokenStore reads the token file at path; a missing file is an empty store.
+func LoadTokenStore(path string) (*TokenStore, error) {
+	ts := &TokenStore{path: expandPath(path)}
+	data, err := os.ReadFile(ts.path)
+	if os.IsNotExist(err) {
+		return ts, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read token file: %w", err)
+	}
+	if err := json.Unmarshal(data, ts); err != nil {
+		return nil, fmt.Errorf("parse token file %s: %w", ts.path, err)
+	}
+	return ts, nil
+}
+
+// Path is the file the store was loaded from.
+func (ts *TokenStore) Path() string {
+	return ts.path
+}
+
+// Save writes the token file atomically, readable only by its owner.
+func (ts *TokenStore) Save() error {
+	if err := os.MkdirAll(filepath.Dir(ts.path), 0700); err != nil {
+		return fmt.Errorf("token file dir: %w", err)
+	}
+	data, err := json.MarshalIndent(ts, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := ts.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write token file: %w", err)
+	}
+	return os.Rename(tmp, ts.path)
+}
+
+// Issue adds a token and returns its secret, which is not stored and cannot be shown again.
+// Names must be unique among active tokens; agent tokens need the agent ID they are scoped to.
+func (ts *TokenStore) Issue(name string, role Role, agentID string) (string, *Token, error) {
+	if name == "" {
+		return "", nil, fmt.Errorf("token name is required")
+	}
+	if _, err := ParseRole(string(role)); err != nil {
+		return "", nil, err
+	}
+	if role == RoleAgent && agentID == "" {
+		return "", nil, fmt.Errorf("an agent token needs the agent ID it is scoped to")
+	}
+	if role != RoleAgent && agentID != "" {
+		return "", nil, fmt.Errorf("only agent tokens are scoped to an agent ID")
+	}
+	for _, t := range ts.Tokens {
+		if t.Active() && t.Name == name {
+			return "", nil, fmt.Errorf("an active token named %q already exists", name)
+		}
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	secret := "cdt_" + base64.RawURLEncoding.EncodeToString(b)
+	hash := hashSecret(secret)
+	ts.Tokens = append(ts.Tokens, Token{
+		ID:        "tok_" + hash[:12],
+		Name:      name,
+		Role:      role,
+		AgentID:   agentID,
+		Hash:      hash,
+		CreatedAt: time.Now().UTC(),
+	})
+	return secret, &ts.Tokens[len(ts.Tokens)-1], nil
+}
+
+// Revoke revokes the active token with the given ID or name.
+func (ts *TokenStore) Revoke(ref string) (*Token, error) {
+	for i := range ts.Tokens {
+		t := &ts.Tokens[i]
+		if t.Active() && (t.ID == ref || t.Name == ref) {
+			now := time.Now().UTC()
+			t.RevokedAt = &now
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("no active token %q", ref)
+}
+
+// Lookup returns the active token whose secret this is, or nil.
+func (ts *TokenStore) Lookup(secret string) *Token {
+	hash := []byte(hashSecret(secret))
+	for i := range ts.Tokens {
+		t := &ts.Tokens[i]
+		if t.Active() && subtle.ConstantTimeCompare(hash, []byte(t.Hash)) == 1 {
+			return t
+		}
+	}
+	return nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticator checks secrets against the token file, re-reading it when it changes so that
+// issued and revoked tokens take effect without restarting the daemon.
+type Authenticator struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	store   *TokenStore
+}
+
+// NewAuthenticator loads the token file at path. A missing file means no token is accepted
+// until one is issued.
+func NewAuthenticator(path string) (*Authenticator, error) {
+	a := &Authenticator{path: expandPath(path)}
+	if err := a.refresh(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Authenticate returns the active token for secret, or nil.
+func (a *Authenticator) Authenticate(secret string) *Token {
+	if secret == "" {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.refresh(); err != nil {
+		// Keep the tokens last read rather than locking everyone out over a bad edit
+		log.Printf("auth: %v (keeping previous tokens)", err)
+	}
+	if t := a.store.Lookup(secret); t != nil {
+		tok := *t
+		return &tok
+	}
+	return nil
+}
+
+// ActiveCount is the number of tokens that would be accepted.
+func (a *Authenticator) ActiveCount() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := 0
+	for _, t := range a.store.Tokens {
+		if t.Active() {
+			n++
+		}
+	}
+	return n
+}
+
+// refresh re-reads the token file when its size or modification time changed. Caller holds mu
+// (or has not shared a yet).
+func (a *Authenticator) refresh() error {
+	var modTime time.Time
+	var size int64
+	if fi, err := os.Stat(a.path); err == nil {
+		modTime, size = fi.ModTime(), fi.Size()
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("stat token file: %w", err)
+	}
+	if a.store != nil && modTime.Equal(a.modTime) && size == a.size {
+		return nil
+	}
+	store, err := LoadTokenStore(a.path)
+	if err != nil {
+		return err
+	}
+	a.store, a.modTime, a.size = store, modTime, size
+	return nil
+}
+
+func expandPath(p string) string {
+	if len(p) >= 2 && p[:2] == "~/" {
+		home, _ := os.UserHomeDir()
+		if home != "" {
+			return filepath.Join(home, p[2:])
+		}
+	}
+	return p
+}
@@ -0,0 +1,60 @@
+package auth_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/futurematic/kernel/internal/auth"
+)
+
+func TestIssueAuthenticateRevoke(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	authn, err := auth.NewAuthenticator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts, err := auth.LoadTokenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ts.Issue("bot", auth.RoleAgent, ""); err == nil {
+		t.Error("agent token without an agent ID was issued")
+	}
+	if _, _, err := ts.Issue("ops", auth.RoleOperator, "agent-1"); err == nil {
+		t.Error("operator token scoped to an agent was issued")
+	}
+	secret, tok, err := ts.Issue("bot", auth.RoleAgent, "agent-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ts.Issue("bot", auth.RoleReadOnly, ""); err == nil {
+		t.Error("duplicate active name was issued")
+	}
+	if err := ts.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The authenticator picks up the new file without being recreated
+	got := authn.Authenticate(secret)
+	if got == nil || got.ID != tok.ID || got.Role != auth.RoleAgent || got.AgentID != "agent-1" {
+		t.Fatalf("Authenticate = %+v, want %s scoped to agent-1", got, tok.ID)
+	}
+	if authn.Authenticate(secret+"x") != nil || authn.Authenticate("") != nil {
+		t.Error("wrong secret accepted")
+	}
+
+	ts, _ = auth.LoadTokenStore(path)
+	if _, err := ts.Revoke("bot"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if authn.Authenticate(secret) != nil {
+		t.Error("revoked token accepted")
+	}
+	if _, err := ts.Revoke(tok.ID); err == nil {
+		t.Error("token revoked twice")
+	}
+}
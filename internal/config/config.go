@@ -1,10 +1,13 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -21,9 +24,18 @@ type Config struct {
 	DegradeModes    DegradeModesConfig  `yaml:"degrade_modes"`
 	Panic           PanicConfig         `yaml:"panic"`
 	Autobundle      AutobundleConfig    `yaml:"autobundle"`
+	LoopDetection   LoopDetectionConfig `yaml:"loop_detection"`
+	Webhooks        WebhooksConfig      `yaml:"webhooks"`
+	Metrics         MetricsConfig       `yaml:"metrics"`
+	Tracing         TracingConfig       `yaml:"tracing"`
+	Shadow          ShadowConfig        `yaml:"shadow,omitempty"`
+	Reload          ReloadConfig        `yaml:"reload"`
+	Auth            AuthConfig          `yaml:"auth"`
 	DisplayCurrency string              `yaml:"display_currency"` // "gbp", "usd", "eur" — for UI display only; values stored in GBP
 	// Loop is set by Effective() when panic is on; loop detector uses it for window/repeats.
 	Loop *LoopOverlay `yaml:"-"`
+	// Revision is the config revision the daemon applied this config as (0 = not recorded).
+	Revision int64 `yaml:"-"`
 }
 
 // AutobundleConfig configures automatic bundle creation on DENY/STOP/shutdown.
@@ -43,6 +55,8 @@ type AutobundleTriggers struct {
 	OnLoopStop    bool `yaml:"on_loop_stop"`
 	OnShutdown    bool `yaml:"on_shutdown"`
 	OnPanicToggle bool `yaml:"on_panic_toggle"`
+	// Write a chain_checkpoint bundle every N minutes while events are appended (0 = off)
+	ChainCheckpointMinutes int `yaml:"chain_checkpoint_minutes"`
 }
 
 // AutobundleInclude specifies what to include in auto-bundles.
@@ -58,6 +72,32 @@ type LoopOverlay struct {
 	StopRepeats   int
 }
 
+// LoopDetectionConfig configures the loop detector. Per-action rules are matched in order;
+// the first rule whose action_type (and tool, if set) matches a proposal wins.
+type LoopDetectionConfig struct {
+	WindowSeconds int           `yaml:"window_seconds"` // default window for actions with no rule (default 600)
+	SafetyNet     LoopSafetyNet `yaml:"safety_net"`
+	Rules         []LoopRule    `yaml:"rules"`
+}
+
+// LoopSafetyNet is the short-window burst check applied on top of the default window.
+type LoopSafetyNet struct {
+	Enabled       bool `yaml:"enabled"`
+	WindowSeconds int  `yaml:"window_seconds"` // default 60
+	StopRepeats   int  `yaml:"stop_repeats"`   // default 10
+}
+
+// LoopRule overrides loop detection for matching actions.
+// ActionType matches that action type and the ones below it ("git.push" also matches
+// "git.push.force"), or only the ones below it when it ends in "." (e.g. "http.").
+type LoopRule struct {
+	ActionType    string `yaml:"action_type"`
+	Tool          string `yaml:"tool,omitempty"`
+	WindowSeconds int    `yaml:"window_seconds,omitempty"`
+	StopRepeats   int    `yaml:"stop_repeats,omitempty"`
+	Exempt        bool   `yaml:"exempt,omitempty"` // never stop matching actions for repetition
+}
+
 // PanicConfig configures panic mode (strict overlay when enabled).
 type PanicConfig struct {
 	Enabled           bool                `yaml:"enabled"`
@@ -117,9 +157,46 @@ type RuntimeStoreConfig struct {
 
 // LedgerSinkConfig configures where decision records are emitted.
 type LedgerSinkConfig struct {
-	Kind       string                 `yaml:"kind"`   // "none" | "kernel_http" | "bundle"
+	Kind       string                 `yaml:"kind"`   // "none" | "kernel_http" | "bundle" | "file" | "syslog" | "multi"
 	KernelHTTP LedgerKernelHTTPConfig `yaml:"kernel_http"` // used when kind == "kernel_http"
 	Bundle     LedgerBundleConfig     `yaml:"bundle"`     // used when kind == "bundle"
+	File       LedgerFileConfig       `yaml:"file"`       // used when kind == "file"
+	Syslog     LedgerSyslogConfig     `yaml:"syslog"`     // used when kind == "syslog"
+	Multi      LedgerMultiConfig      `yaml:"multi"`      // used when kind == "multi"
+}
+
+// Kinds returns the sink kinds in use: the members' kinds for multi, otherwise Kind ("none" if empty).
+func (c LedgerSinkConfig) Kinds() []string {
+	if c.Kind == "multi" {
+		kinds := make([]string, 0, len(c.Multi.Sinks))
+		for _, m := range c.Multi.Sinks {
+			kinds = append(kinds, m.Kind)
+		}
+		return kinds
+	}
+	if c.Kind == "" {
+		return []string{"none"}
+	}
+	return []string{c.Kind}
+}
+
+// LedgerMultiConfig fans decision records out to several sinks. Each member has its own bounded
+// queue and worker, so a slow sink does not hold up the others or the decision path; members
+// marked required are emitted synchronously instead. Member settings (base_url, output_dir, ...)
+// come from the matching ledger_sink.<kind> section.
+type LedgerMultiConfig struct {
+	Sinks          []LedgerMultiMember `yaml:"sinks"`
+	DrainTimeoutMs int                 `yaml:"drain_timeout_ms"` // max wait for queues to empty on shutdown; default 5000
+}
+
+// LedgerMultiMember is one sink of a multi sink.
+type LedgerMultiMember struct {
+	Kind           string `yaml:"kind"`                       // "bundle" | "kernel_http" | "file" | "syslog"
+	Name           string `yaml:"name,omitempty"`             // metrics/log label; default kind
+	Required       bool   `yaml:"required,omitempty"`         // emit synchronously in the decision path
+	QueueSize      int    `yaml:"queue_size,omitempty"`       // default 1024
+	OnFull         string `yaml:"on_full,omitempty"`          // "drop" (default) | "block"
+	BlockTimeoutMs int    `yaml:"block_timeout_ms,omitempty"` // on_full: block waits this long, then drops; default 1000
 }
 
 // LedgerKernelHTTPConfig configures the Kernel HTTP sink.
@@ -134,6 +211,13 @@ type LedgerKernelHTTPConfig struct {
 type LedgerBundleConfig struct {
 	OutputDir string              `yaml:"output_dir"` // e.g. ~/.ctrldot/bundles
 	Sign      LedgerBundleSign    `yaml:"sign"`
+	Encrypt   LedgerBundleEncrypt `yaml:"encrypt"`
+}
+
+// LedgerBundleEncrypt configures encryption of bundle payload files.
+type LedgerBundleEncrypt struct {
+	// X25519 public keys ("x25519:<base64>", from `ctrldot keys recipient`); empty: bundles are plaintext
+	Recipients []string `yaml:"recipients"`
 }
 
 // LedgerBundleSign configures Ed25519 signing for bundles.
@@ -141,12 +225,115 @@ type LedgerBundleSign struct {
 	Enabled       bool   `yaml:"enabled"`
 	KeyPath       string `yaml:"key_path"`        // private key, e.g. ~/.ctrldot/keys/ctrldot_ed25519
 	PublicKeyPath string `yaml:"public_key_path"` // optional, e.g. ~/.ctrldot/keys/ctrldot_ed25519.pub
+	// Public keys accepted by `ctrldot bundle verify` (see `ctrldot keys`), e.g. ~/.ctrldot/keys/trusted_keys.json
+	TrustStorePath string `yaml:"trust_store_path"`
+	// Append-only Merkle log of bundle manifests (see `ctrldot bundle audit`), e.g. ~/.ctrldot/translog; "" disables
+	TransparencyLogDir string `yaml:"transparency_log_dir"`
+}
+
+// LedgerFileConfig configures the JSON Lines file sink. Rotated files are renamed
+// <name>-<UTC timestamp><ext> next to Path.
+type LedgerFileConfig struct {
+	Path            string `yaml:"path"`              // e.g. ~/.ctrldot/ledger/ctrldot.jsonl
+	MaxSizeMB       int    `yaml:"max_size_mb"`       // rotate when the file would exceed this; default 100; -1 disables
+	RotateHours     int    `yaml:"rotate_hours"`      // also rotate this many hours after the file was opened; 0 = size only
+	MaxBackups      int    `yaml:"max_backups"`       // rotated files to keep; 0 = keep all
+	Compress        bool   `yaml:"compress"`          // gzip rotated files
+	Fsync           string `yaml:"fsync"`             // "always" | "interval" (default) | "never"
+	FsyncIntervalMs int    `yaml:"fsync_interval_ms"` // fsync: interval; default 1000
+}
+
+// LedgerSyslogConfig configures the RFC 5424 syslog sink.
+type LedgerSyslogConfig struct {
+	Network  string `yaml:"network"`  // "unixgram" (default), "unix", "udp" or "tcp"
+	Address  string `yaml:"address"`  // default: the local socket (/dev/log, /var/run/syslog or /var/run/log)
+	Facility string `yaml:"facility"` // default "local0"
+	AppName  string `yaml:"app_name"` // default "ctrldot"
 }
 
 // EventsConfig configures event retention for the runtime event log.
+// The daemon compacts events past retention_days or beyond max_rows into hourly rollups.
 type EventsConfig struct {
-	RetentionDays int `yaml:"retention_days"`
-	MaxRows       int `yaml:"max_rows"`
+	RetentionDays          int `yaml:"retention_days"`
+	MaxRows                int `yaml:"max_rows"`
+	CompactIntervalSeconds int `yaml:"compact_interval_seconds"` // default 3600
+}
+
+// WebhooksConfig configures signed outbound webhooks. Matching events are queued in the
+// runtime store and delivered with exponential backoff; requests that exhaust max_attempts
+// move to the dead-letter list.
+type WebhooksConfig struct {
+	Endpoints             []WebhookEndpoint `yaml:"endpoints"`
+	MaxAttempts           int               `yaml:"max_attempts"`            // default 8
+	InitialBackoffSeconds int               `yaml:"initial_backoff_seconds"` // default 5; doubles per attempt
+	MaxBackoffSeconds     int               `yaml:"max_backoff_seconds"`     // default 900
+}
+
+// WebhookEndpoint is one webhook receiver. events defaults to decision.issued, agent.halted,
+// panic.enabled and panic.disabled; decisions (decision.issued only) defaults to DENY and STOP;
+// "*" matches anything. severities is unfiltered when empty.
+type WebhookEndpoint struct {
+	Name       string            `yaml:"name"`
+	URL        string            `yaml:"url"`
+	Secret     string            `yaml:"secret,omitempty"`     // HMAC-SHA256 key for X-Ctrldot-Signature
+	SecretEnv  string            `yaml:"secret_env,omitempty"` // read the secret from this env var instead
+	Format     string            `yaml:"format,omitempty"`     // "generic" (default) | "slack"
+	Template   string            `yaml:"template,omitempty"`   // optional Go text/template body; overrides format
+	Events     []string          `yaml:"events,omitempty"`     // event types, e.g. decision.issued, agent.halted
+	Decisions  []string          `yaml:"decisions,omitempty"`  // e.g. DENY, STOP
+	Severities []string          `yaml:"severities,omitempty"` // info | warn | error
+	Headers    map[string]string `yaml:"headers,omitempty"`
+	TimeoutMs  int               `yaml:"timeout_ms,omitempty"` // default 5000
+}
+
+// MetricsConfig configures the Prometheus endpoint (GET /metrics on the daemon port).
+// Per-agent, action type and reason code labels can be turned off to bound series cardinality.
+type MetricsConfig struct {
+	Enabled bool          `yaml:"enabled"` // default true
+	Labels  MetricsLabels `yaml:"labels"`
+}
+
+// MetricsLabels toggles high-cardinality labels; a disabled label is dropped from every series.
+type MetricsLabels struct {
+	Agent      bool `yaml:"agent"`       // default true
+	ActionType bool `yaml:"action_type"` // default true
+	ReasonCode bool `yaml:"reason_code"` // default true
+}
+
+// TracingConfig configures OpenTelemetry tracing of the propose pipeline. Spans are exported
+// as OTLP/HTTP JSON; an incoming W3C traceparent header makes them part of the caller's trace.
+type TracingConfig struct {
+	Enabled     bool              `yaml:"enabled"`            // default false
+	Endpoint    string            `yaml:"endpoint"`           // default http://127.0.0.1:4318/v1/traces
+	ServiceName string            `yaml:"service_name"`       // default "ctrldot"
+	SampleRatio float64           `yaml:"sample_ratio"`       // root spans only; default 1.0. Callers' sampled flag wins.
+	Headers     map[string]string `yaml:"headers,omitempty"`  // e.g. collector auth
+	TimeoutMs   int               `yaml:"timeout_ms"`         // default 5000
+}
+
+// ShadowConfig is a candidate policy evaluated alongside the live one on every proposal. Its
+// decisions are never returned; ones that differ from the live decision are recorded as
+// decision.shadow_diverged events. Each section is overlaid on the live section, so it only
+// needs the keys that change; omitted sections are the live ones.
+type ShadowConfig struct {
+	Enabled       bool                 `yaml:"enabled"`
+	Rules         *RulesConfig         `yaml:"rules,omitempty"`
+	LoopDetection *LoopDetectionConfig `yaml:"loop_detection,omitempty"`
+	Agents        *AgentsConfig        `yaml:"agents,omitempty"`
+}
+
+// ReloadConfig configures how the daemon picks up edits to the config file. SIGHUP and
+// POST /v1/config/reload always reload; the watcher reloads when the file's contents change.
+type ReloadConfig struct {
+	Watch                bool `yaml:"watch"`                  // default true
+	WatchIntervalSeconds int  `yaml:"watch_interval_seconds"` // default 2
+}
+
+// AuthConfig requires API tokens (managed with `ctrldot tokens`) on every API request except
+// /v1/health and the UI page.
+type AuthConfig struct {
+	Enabled    bool   `yaml:"enabled"`     // default false
+	TokensPath string `yaml:"tokens_path"` // default ~/.ctrldot/tokens.json
 }
 
 // ServerConfig contains server settings
@@ -217,14 +404,14 @@ func EnsureDefaultConfigFile(configPath string) error {
 	if err != nil {
 		return fmt.Errorf("marshal default config: %w", err)
 	}
-	header := "# Ctrl Dot config — edit and restart the daemon to apply changes.\n"
-	return os.WriteFile(configPath, append([]byte(header), data...), 0600)
+	return os.WriteFile(configPath, append([]byte(fileHeader), data...), 0600)
 }
 
+// fileHeader starts every config file written by EnsureDefaultConfigFile and Write.
+const fileHeader = "# Ctrl Dot config — the running daemon reloads changes (server, runtime_store, ledger, ledger_sink and tracing need a restart).\n"
+
 // Load loads configuration from file or environment
 func Load(configPath string) (*Config, error) {
-	cfg := DefaultConfig()
-
 	// Expand ~ to home directory
 	if len(configPath) >= 2 && configPath[:2] == "~/" {
 		home, err := os.UserHomeDir()
@@ -242,13 +429,34 @@ func Load(configPath string) (*Config, error) {
 	}
 
 	// Try to load from file if it exists
+	var data []byte
 	if _, err := os.Stat(configPath); err == nil {
-		data, err := os.ReadFile(configPath)
-		if err != nil {
+		if data, err = os.ReadFile(configPath); err != nil {
 			return nil, fmt.Errorf("failed to read config file: %w", err)
 		}
+	}
+	return Parse(data)
+}
 
-		if err := yaml.Unmarshal(data, cfg); err != nil {
+// Parse builds a config from the contents of a config file: defaults, then data, then
+// environment overrides. The result is validated; see Load.
+func Parse(data []byte) (*Config, error) {
+	cfg := DefaultConfig()
+	var root yaml.Node
+	var problems []error
+	if len(data) > 0 {
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+		// Unknown keys are errors: a misspelt key would otherwise be silently ignored
+		problems = unknownFields(&root, reflect.TypeOf(Config{}), "")
+		if root.Kind != 0 {
+			if err := root.Decode(cfg); err != nil {
+				problems = append(problems, typeErrors(err))
+				return nil, fmt.Errorf("invalid config: %w", errors.Join(problems...))
+			}
+		}
+		if err := resolveShadow(data, cfg); err != nil {
 			return nil, fmt.Errorf("failed to parse config file: %w", err)
 		}
 	}
@@ -292,10 +500,77 @@ func Load(configPath string) (*Config, error) {
 	if v := os.Getenv("CTRLDOT_AUTOBUNDLE_DIR"); v != "" {
 		cfg.Autobundle.OutputDir = v
 	}
+	if v := os.Getenv("CTRLDOT_AUTH"); v != "" {
+		cfg.Auth.Enabled = v == "1" || v == "true" || v == "on"
+	}
 
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, withLines(err, &root))
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid config: %w", errors.Join(problems...))
+	}
 	return cfg, nil
 }
 
+// typeErrors splits a yaml.TypeError ("line 3: cannot unmarshal ...") into FieldErrors.
+func typeErrors(err error) error {
+	var te *yaml.TypeError
+	if !errors.As(err, &te) {
+		return err
+	}
+	errs := make([]error, 0, len(te.Errors))
+	for _, msg := range te.Errors {
+		fe := &FieldError{Msg: msg}
+		if rest, ok := strings.CutPrefix(msg, "line "); ok {
+			if n, m, ok := strings.Cut(rest, ": "); ok {
+				if line, err := strconv.Atoi(n); err == nil {
+					fe.Line, fe.Msg = line, m
+				}
+			}
+		}
+		errs = append(errs, fe)
+	}
+	return errors.Join(errs...)
+}
+
+// resolveShadow decodes the shadow sections in data over copies of cfg's live sections.
+func resolveShadow(data []byte, cfg *Config) error {
+	var doc struct {
+		Shadow struct {
+			Rules         yaml.Node `yaml:"rules"`
+			LoopDetection yaml.Node `yaml:"loop_detection"`
+			Agents        yaml.Node `yaml:"agents"`
+		} `yaml:"shadow"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	cfg.Shadow.Rules, cfg.Shadow.LoopDetection, cfg.Shadow.Agents = nil, nil, nil
+	if doc.Shadow.Rules.Kind != 0 {
+		rules := cloneRulesConfig(cfg.Rules)
+		if err := doc.Shadow.Rules.Decode(&rules); err != nil {
+			return fmt.Errorf("shadow.rules: %w", err)
+		}
+		cfg.Shadow.Rules = &rules
+	}
+	if doc.Shadow.LoopDetection.Kind != 0 {
+		loop := cloneLoopDetectionConfig(cfg.LoopDetection)
+		if err := doc.Shadow.LoopDetection.Decode(&loop); err != nil {
+			return fmt.Errorf("shadow.loop_detection: %w", err)
+		}
+		cfg.Shadow.LoopDetection = &loop
+	}
+	if doc.Shadow.Agents.Kind != 0 {
+		agents := cloneAgentsConfig(cfg.Agents)
+		if err := doc.Shadow.Agents.Decode(&agents); err != nil {
+			return fmt.Errorf("shadow.agents: %w", err)
+		}
+		cfg.Shadow.Agents = &agents
+	}
+	return nil
+}
+
 // Write writes configuration to a file. Path is expanded (e.g. ~ to home).
 func Write(configPath string, cfg *Config) error {
 	if len(configPath) >= 2 && configPath[:2] == "~/" {
@@ -309,8 +584,7 @@ func Write(configPath string, cfg *Config) error {
 	if err != nil {
 		return fmt.Errorf("marshal config: %w", err)
 	}
-	header := "# Ctrl Dot config — edit and restart the daemon to apply changes.\n"
-	return os.WriteFile(configPath, append([]byte(header), data...), 0600)
+	return os.WriteFile(configPath, append([]byte(fileHeader), data...), 0600)
 }
 
 // DefaultConfig returns default configuration
@@ -343,15 +617,51 @@ func DefaultConfig() *Config {
 			Bundle: LedgerBundleConfig{
 				OutputDir: filepath.Join(home, ".ctrldot", "bundles"),
 				Sign: LedgerBundleSign{
-					Enabled:       true,
-					KeyPath:       filepath.Join(home, ".ctrldot", "keys", "ctrldot_ed25519"),
-					PublicKeyPath: filepath.Join(home, ".ctrldot", "keys", "ctrldot_ed25519.pub"),
+					Enabled:            true,
+					KeyPath:            filepath.Join(home, ".ctrldot", "keys", "ctrldot_ed25519"),
+					PublicKeyPath:      filepath.Join(home, ".ctrldot", "keys", "ctrldot_ed25519.pub"),
+					TrustStorePath:     filepath.Join(home, ".ctrldot", "keys", "trusted_keys.json"),
+					TransparencyLogDir: filepath.Join(home, ".ctrldot", "translog"),
 				},
 			},
+			File: LedgerFileConfig{
+				Path:            filepath.Join(home, ".ctrldot", "ledger", "ctrldot.jsonl"),
+				MaxSizeMB:       100,
+				Fsync:           "interval",
+				FsyncIntervalMs: 1000,
+			},
+			Syslog: LedgerSyslogConfig{
+				Facility: "local0",
+				AppName:  "ctrldot",
+			},
 		},
 		Events: EventsConfig{
-			RetentionDays: 7,
-			MaxRows:       50000,
+			RetentionDays:          7,
+			MaxRows:                50000,
+			CompactIntervalSeconds: 3600,
+		},
+		Webhooks: WebhooksConfig{
+			MaxAttempts:           8,
+			InitialBackoffSeconds: 5,
+			MaxBackoffSeconds:     900,
+		},
+		Tracing: TracingConfig{
+			Enabled:     false,
+			Endpoint:    "http://127.0.0.1:4318/v1/traces",
+			ServiceName: "ctrldot",
+			SampleRatio: 1.0,
+			TimeoutMs:   5000,
+		},
+		Reload: ReloadConfig{
+			Watch:                true,
+			WatchIntervalSeconds: 2,
+		},
+		Auth: AuthConfig{
+			TokensPath: filepath.Join(home, ".ctrldot", "tokens.json"),
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Labels:  MetricsLabels{Agent: true, ActionType: true, ReasonCode: true},
 		},
 		Agents: AgentsConfig{
 			Default: AgentDefaults{
@@ -397,6 +707,11 @@ func DefaultConfig() *Config {
 			Loop:  PanicLoop{ThrottleRepeats: 3, StopRepeats: 5, WindowSeconds: 60},
 			Exec:  PanicExec{RequireResolution: true, AllowCommands: []string{}},
 		},
+		LoopDetection: LoopDetectionConfig{
+			WindowSeconds: 600,
+			SafetyNet:     LoopSafetyNet{Enabled: true, WindowSeconds: 60, StopRepeats: 10},
+			Rules:         []LoopRule{},
+		},
 		Autobundle: AutobundleConfig{
 			Enabled:         true,
 			OutputDir:       "", // default: same as LedgerSink.Bundle.OutputDir
@@ -408,6 +723,7 @@ func DefaultConfig() *Config {
 				OnLoopStop:    true,
 				OnShutdown:    true,
 				OnPanicToggle: false,
+				ChainCheckpointMinutes: 60,
 			},
 			Include: AutobundleInclude{
 				EventsTail:     500,
@@ -72,6 +72,26 @@ func Effective(base *Config, panicState *domain.PanicState) *Config {
 	return &out
 }
 
+// Shadow returns base with the shadow sections in place of the live ones, or nil when shadow
+// evaluation is disabled. Apply Effective to the result for the panic overlay. Does not modify base.
+func Shadow(base *Config) *Config {
+	if base == nil || !base.Shadow.Enabled {
+		return nil
+	}
+	out := *base
+	if base.Shadow.Rules != nil {
+		out.Rules = cloneRulesConfig(*base.Shadow.Rules)
+	}
+	if base.Shadow.LoopDetection != nil {
+		out.LoopDetection = cloneLoopDetectionConfig(*base.Shadow.LoopDetection)
+	}
+	if base.Shadow.Agents != nil {
+		out.Agents = cloneAgentsConfig(*base.Shadow.Agents)
+	}
+	out.Shadow = ShadowConfig{}
+	return &out
+}
+
 func cloneAgentsConfig(a AgentsConfig) AgentsConfig {
 	out := AgentsConfig{Default: a.Default}
 	if len(a.Default.WarnPct) > 0 {
@@ -99,6 +119,15 @@ func cloneRulesConfig(r RulesConfig) RulesConfig {
 	return out
 }
 
+func cloneLoopDetectionConfig(l LoopDetectionConfig) LoopDetectionConfig {
+	out := l
+	if len(l.Rules) > 0 {
+		out.Rules = make([]LoopRule, len(l.Rules))
+		copy(out.Rules, l.Rules)
+	}
+	return out
+}
+
 // PanicExpired returns true if panic is enabled but past expires_at.
 func PanicExpired(panicState *domain.PanicState) bool {
 	if panicState == nil || !panicState.Enabled || panicState.ExpiresAt == nil {
@@ -0,0 +1,300 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Reloadable is implemented by components that pick up a reloaded config.
+type Reloadable interface {
+	SetConfig(cfg *Config)
+}
+
+// ReloadFunc adapts a function to Reloadable.
+type ReloadFunc func(cfg *Config)
+
+// SetConfig calls f(cfg).
+func (f ReloadFunc) SetConfig(cfg *Config) { f(cfg) }
+
+// ReloadResult describes one reload.
+type ReloadResult struct {
+	Changes         []string `json:"changes"`                    // e.g. "rules.network.deny_all: false -> true"
+	RestartRequired []string `json:"restart_required,omitempty"` // sections edited since startup that only apply at startup
+	Revision        int64    `json:"revision,omitempty"`         // set by the ApplyFunc when the new config was recorded
+}
+
+// ApplyFunc is called with a changed config and the file contents it came from before the
+// config is handed to the targets, e.g. to record it as a revision and set next.Revision.
+// Returning an error cancels the reload.
+type ApplyFunc func(next *Config, content []byte, res *ReloadResult) error
+
+// Reloader re-reads the config file and hands the new config to every registered component.
+// Sections read only at startup (server, ledger, runtime_store, ledger_sink, tracing, reload)
+// keep their running values.
+type Reloader struct {
+	path    string
+	mu      sync.Mutex
+	current *Config
+	content []byte // file contents current was loaded from
+	boot    Config // as loaded at startup, before any defaults were filled in by the daemon
+	targets []Reloadable
+}
+
+// NewReloader returns a reloader for the file at path, which cfg was loaded from. Create it
+// before the daemon fills in defaults on cfg so edits to restart-only sections are detected.
+func NewReloader(path string, cfg *Config) *Reloader {
+	r := &Reloader{path: expandHome(path), current: cfg, boot: *cfg}
+	r.content, _ = os.ReadFile(r.path)
+	return r
+}
+
+// Register adds components to receive each reloaded config, in order.
+func (r *Reloader) Register(targets ...Reloadable) *Reloader {
+	r.mu.Lock()
+	r.targets = append(r.targets, targets...)
+	r.mu.Unlock()
+	return r
+}
+
+// Current returns the config in use.
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Content returns the contents of the file the config in use was loaded from.
+func (r *Reloader) Content() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.content
+}
+
+// Reload loads and validates the file and, when settings changed, calls before (if not nil)
+// and gives the new config to every target. On error the running config is kept.
+func (r *Reloader) Reload(before ApplyFunc) (*ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+	return r.apply(data, false, before)
+}
+
+// Apply is Reload for contents not in the file yet (e.g. a rollback): they are validated, then
+// written to the file and applied. On error the file and the running config are left as they were.
+func (r *Reloader) Apply(content []byte, before ApplyFunc) (*ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.apply(content, true, before)
+}
+
+func (r *Reloader) apply(data []byte, write bool, before ApplyFunc) (*ReloadResult, error) {
+	next, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	res := &ReloadResult{RestartRequired: keepRestartSections(next, r.current, &r.boot)}
+	res.Changes = Diff(r.current, next)
+	if write {
+		if err := writeFile(r.path, data); err != nil {
+			return nil, err
+		}
+	}
+	if len(res.Changes) == 0 {
+		r.content = data
+		return res, nil
+	}
+	if before != nil {
+		if err := before(next, data, res); err != nil {
+			if write {
+				_ = writeFile(r.path, r.content)
+			}
+			return nil, err
+		}
+	}
+	for _, t := range r.targets {
+		t.SetConfig(next)
+	}
+	r.current, r.content = next, data
+	return res, nil
+}
+
+// writeFile replaces the file at path with data through a rename, so the watcher never sees
+// it half written.
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Watch calls reload whenever the file's contents change, checking every interval, until ctx
+// is done.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration, reload func()) {
+	last, _ := fileSum(r.path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		sum, err := fileSum(r.path)
+		if err != nil || sum == last {
+			continue
+		}
+		last = sum
+		reload()
+	}
+}
+
+func fileSum(path string) ([32]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// keepRestartSections copies running's restart-only sections into next and returns the ones
+// whose file values differ from boot.
+func keepRestartSections(next, running, boot *Config) []string {
+	var changed []string
+	for _, s := range []struct {
+		name string
+		next any
+		boot any
+	}{
+		{"server", next.Server, boot.Server},
+		{"ledger", next.Ledger, boot.Ledger},
+		{"runtime_store", next.RuntimeStore, boot.RuntimeStore},
+		{"ledger_sink", next.LedgerSink, boot.LedgerSink},
+		{"tracing", next.Tracing, boot.Tracing},
+		{"reload", next.Reload, boot.Reload},
+		{"auth", next.Auth, boot.Auth},
+	} {
+		if !reflect.DeepEqual(s.next, s.boot) {
+			changed = append(changed, s.name)
+		}
+	}
+	next.Server, next.Ledger, next.RuntimeStore = running.Server, running.Ledger, running.RuntimeStore
+	next.LedgerSink, next.Tracing, next.Reload = running.LedgerSink, running.Tracing, running.Reload
+	next.Auth = running.Auth
+	return changed
+}
+
+// Diff lists the settings that differ between old and new as "path: old -> new", sorted by
+// path. Secrets (secret, api_key, password, token keys and headers) show as "(redacted)".
+func Diff(old, new *Config) []string {
+	a, b := flatten(old), flatten(new)
+	var out []string
+	for path, av := range a {
+		if bv, ok := b[path]; !ok {
+			out = append(out, fmt.Sprintf("%s: %s -> (unset)", path, redact(path, av)))
+		} else if av != bv {
+			out = append(out, fmt.Sprintf("%s: %s -> %s", path, redact(path, av), redact(path, bv)))
+		}
+	}
+	for path, bv := range b {
+		if _, ok := a[path]; !ok {
+			out = append(out, fmt.Sprintf("%s: (unset) -> %s", path, redact(path, bv)))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// flatten maps each leaf setting of cfg, as it would be written to YAML, to its value. Lists
+// of scalars are one value; lists of sections are indexed (loop_detection.rules[0].tool).
+func flatten(cfg *Config) map[string]string {
+	out := map[string]string{}
+	if cfg == nil {
+		return out
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return out
+	}
+	var tree interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return out
+	}
+	var walk func(path string, v interface{})
+	walk = func(path string, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				if path != "" {
+					k = path + "." + k
+				}
+				walk(k, child)
+			}
+		case []interface{}:
+			for _, item := range v {
+				switch item.(type) {
+				case map[string]interface{}, []interface{}:
+					for i, item := range v {
+						walk(fmt.Sprintf("%s[%d]", path, i), item)
+					}
+					return
+				}
+			}
+			b, _ := json.Marshal(v)
+			out[path] = string(b)
+		default:
+			out[path] = fmt.Sprint(v)
+		}
+	}
+	walk("", tree)
+	return out
+}
+
+func redact(path, value string) string {
+	last := path[strings.LastIndex(path, ".")+1:]
+	for _, secret := range []string{"secret", "api_key", "password", "token"} {
+		if last == secret {
+			return "(redacted)"
+		}
+	}
+	if strings.Contains(path, ".headers") {
+		return "(redacted)"
+	}
+	return value
+}
+
+// expandHome expands a leading ~/ to the home directory.
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
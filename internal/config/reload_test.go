@@ -0,0 +1,101 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/futurematic/kernel/internal/config"
+)
+
+func TestReloaderSwapsConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(yaml string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(yaml), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("server:\n  port: 7777\nrules:\n  network:\n    deny_all: false\n")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got *config.Config
+	r := config.NewReloader(path, cfg).Register(config.ReloadFunc(func(c *config.Config) { got = c }))
+
+	write("server:\n  port: 9999\nrules:\n  network:\n    deny_all: true\n")
+	res, err := r.Reload(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(res.Changes, []string{"rules.network.deny_all: false -> true"}) {
+		t.Errorf("changes = %q", res.Changes)
+	}
+	if !slices.Equal(res.RestartRequired, []string{"server"}) {
+		t.Errorf("restart required = %q, want [server]", res.RestartRequired)
+	}
+	if got == nil || !got.Rules.Network.DenyAll || got.Server.Port != 7777 || r.Current() != got {
+		t.Fatalf("target got %+v, want deny_all and the running port 7777", got)
+	}
+
+	// An invalid file is rejected and the running config is kept
+	write("agents:\n  default:\n    throttle_pct: 2\n    hard_stop_pct: 1\n")
+	if _, err := r.Reload(nil); err == nil || !strings.Contains(err.Error(), "throttle_pct") {
+		t.Fatalf("Reload of an invalid config = %v, want a throttle_pct error", err)
+	}
+	if r.Current() != got {
+		t.Error("running config replaced by an invalid one")
+	}
+}
+
+func TestReloaderApplyWritesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	original := "rules:\n  network:\n    deny_all: true\n"
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := config.NewReloader(path, cfg)
+	rollback := []byte("rules:\n  network:\n    deny_all: false\n")
+
+	// A failing ApplyFunc leaves the file and the running config alone
+	if _, err := r.Apply(rollback, func(*config.Config, []byte, *config.ReloadResult) error { return errors.New("store down") }); err == nil {
+		t.Fatal("Apply succeeded although the ApplyFunc failed")
+	}
+	if data, _ := os.ReadFile(path); string(data) != original || r.Current() != cfg {
+		t.Fatalf("file after a failed Apply = %q", data)
+	}
+
+	res, err := r.Apply(rollback, func(next *config.Config, content []byte, res *config.ReloadResult) error {
+		next.Revision, res.Revision = 7, 7
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(rollback) || string(r.Content()) != string(rollback) {
+		t.Errorf("file after Apply = %q", data)
+	}
+	if res.Revision != 7 || r.Current().Revision != 7 || r.Current().Rules.Network.DenyAll {
+		t.Errorf("applied %+v, revision %d", r.Current().Rules.Network, res.Revision)
+	}
+}
+
+func TestDiffRedactsSecrets(t *testing.T) {
+	a := config.DefaultConfig()
+	b := config.DefaultConfig()
+	b.Webhooks.Endpoints = []config.WebhookEndpoint{{Name: "ops", URL: "https://example.com/hook", Secret: "s3cret"}}
+	for _, c := range config.Diff(a, b) {
+		if strings.Contains(c, "s3cret") {
+			t.Errorf("diff shows a secret: %s", c)
+		}
+	}
+}
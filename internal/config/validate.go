@@ -0,0 +1,360 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldError is a config problem at a key path such as agents.default.throttle_pct. Line is
+// the line of that key in the config file, or 0 when it is not in the file (e.g. a default or
+// an environment override).
+type FieldError struct {
+	Path string `json:"path,omitempty"`
+	Line int    `json:"line,omitempty"`
+	Msg  string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	var b strings.Builder
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", e.Line)
+	}
+	if e.Path != "" {
+		b.WriteString(e.Path + ": ")
+	}
+	b.WriteString(e.Msg)
+	return b.String()
+}
+
+// Problems flattens an error from Load or Validate into its field errors. Other errors become a
+// FieldError with only a message.
+func Problems(err error) []*FieldError {
+	var out []*FieldError
+	var walk func(error)
+	walk = func(err error) {
+		var fe *FieldError
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				walk(e)
+			}
+		} else if fe, ok = err.(*FieldError); ok {
+			out = append(out, fe)
+		} else if inner := errors.Unwrap(err); inner != nil && errors.As(inner, &fe) {
+			// A wrapper such as "invalid config: ..." around the problems
+			walk(inner)
+		} else if err != nil {
+			out = append(out, &FieldError{Msg: err.Error()})
+		}
+	}
+	walk(err)
+	return out
+}
+
+// Validate checks settings that parse but cannot work, e.g. thresholds out of order or an
+// unknown sink kind. All problems are returned together as FieldErrors without line numbers;
+// Load adds them.
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(path, format string, args ...interface{}) {
+		errs = append(errs, &FieldError{Path: path, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	if p := c.Server.Port; p < 0 || p > 65535 {
+		add("server.port", "%d is not a TCP port", p)
+	}
+	if k := c.RuntimeStore.Kind; k != "" && k != "sqlite" && k != "postgres" {
+		add("runtime_store.kind", "unknown kind %q (sqlite, postgres)", k)
+	}
+	sinkKinds := []string{"none", "kernel_http", "bundle", "file", "syslog", "multi"}
+	if k := c.LedgerSink.Kind; k != "" && !slices.Contains(sinkKinds, k) {
+		add("ledger_sink.kind", "unknown kind %q (%s)", k, strings.Join(sinkKinds, ", "))
+	}
+	if c.LedgerSink.Kind == "multi" && len(c.LedgerSink.Multi.Sinks) == 0 {
+		add("ledger_sink.multi.sinks", "kind multi needs at least one sink")
+	}
+	for i, m := range c.LedgerSink.Multi.Sinks {
+		path := fmt.Sprintf("ledger_sink.multi.sinks[%d]", i)
+		if m.Kind == "" || m.Kind == "none" || m.Kind == "multi" || !slices.Contains(sinkKinds, m.Kind) {
+			add(path+".kind", "unknown member kind %q (bundle, kernel_http, file, syslog)", m.Kind)
+		}
+		if m.OnFull != "" && m.OnFull != "drop" && m.OnFull != "block" {
+			add(path+".on_full", "unknown value %q (drop, block)", m.OnFull)
+		}
+	}
+	if f := c.LedgerSink.File.Fsync; f != "" && f != "always" && f != "interval" && f != "never" {
+		add("ledger_sink.file.fsync", "unknown value %q (always, interval, never)", f)
+	}
+	if n := c.LedgerSink.Syslog.Network; n != "" && !slices.Contains([]string{"unixgram", "unix", "udp", "tcp"}, n) {
+		add("ledger_sink.syslog.network", "unknown network %q (unixgram, unix, udp, tcp)", n)
+	}
+	if cur := c.DisplayCurrency; cur != "" && cur != "gbp" && cur != "usd" && cur != "eur" {
+		add("display_currency", "unknown currency %q (gbp, usd, eur)", cur)
+	}
+	if c.Events.RetentionDays < 0 {
+		add("events.retention_days", "must not be negative")
+	}
+	if c.Events.MaxRows < 0 {
+		add("events.max_rows", "must not be negative")
+	}
+
+	errs = append(errs, validateAgents("agents", c.Agents)...)
+	errs = append(errs, validateLoopDetection("loop_detection", c.LoopDetection)...)
+	errs = append(errs, validatePanic(c.Panic)...)
+	if c.Shadow.Agents != nil {
+		errs = append(errs, validateAgents("shadow.agents", *c.Shadow.Agents)...)
+	}
+	if c.Shadow.LoopDetection != nil {
+		errs = append(errs, validateLoopDetection("shadow.loop_detection", *c.Shadow.LoopDetection)...)
+	}
+
+	for i, ep := range c.Webhooks.Endpoints {
+		path := fmt.Sprintf("webhooks.endpoints[%d]", i)
+		if ep.URL == "" {
+			add(path+".url", "required")
+		}
+		if f := ep.Format; f != "" && f != "generic" && f != "slack" {
+			add(path+".format", "unknown format %q (generic, slack)", f)
+		}
+		for _, name := range slices.Sorted(maps.Keys(ep.Headers)) {
+			if reservedWebhookHeader(name) {
+				add(path+".headers."+name, "is set by ctrldot and cannot be overridden")
+			}
+		}
+	}
+	if r := c.Tracing.SampleRatio; r < 0 || r > 1 {
+		add("tracing.sample_ratio", "%v is outside 0..1", r)
+	}
+	if c.Reload.WatchIntervalSeconds < 0 {
+		add("reload.watch_interval_seconds", "must not be negative")
+	}
+	if c.Auth.Enabled && c.Auth.TokensPath == "" {
+		add("auth.tokens_path", "required when auth is enabled")
+	}
+	return errors.Join(errs...)
+}
+
+// reservedWebhookHeader reports whether a webhook request header is one the dispatcher sets:
+// Content-Type, User-Agent and the X-Ctrldot-* event, delivery, timestamp and signature headers.
+func reservedWebhookHeader(name string) bool {
+	return strings.EqualFold(name, "Content-Type") || strings.EqualFold(name, "User-Agent") ||
+		(len(name) >= 10 && strings.EqualFold(name[:10], "X-Ctrldot-"))
+}
+
+func validateAgents(path string, a AgentsConfig) []error {
+	var errs []error
+	add := func(key, format string, args ...interface{}) {
+		errs = append(errs, &FieldError{Path: path + ".default." + key, Msg: fmt.Sprintf(format, args...)})
+	}
+	d := a.Default
+	if d.DailyBudgetGBP < 0 {
+		add("daily_budget_gbp", "must not be negative")
+	}
+	for _, pct := range d.WarnPct {
+		if pct <= 0 {
+			add("warn_pct", "%v must be above 0", pct)
+		} else if d.ThrottlePct > 0 && pct > d.ThrottlePct {
+			add("warn_pct", "%v is above throttle_pct %v", pct, d.ThrottlePct)
+		}
+	}
+	if d.ThrottlePct < 0 {
+		add("throttle_pct", "must not be negative")
+	}
+	if d.HardStopPct < 0 {
+		add("hard_stop_pct", "must not be negative")
+	}
+	if d.HardStopPct > 0 && d.ThrottlePct > d.HardStopPct {
+		add("throttle_pct", "%v is above hard_stop_pct %v", d.ThrottlePct, d.HardStopPct)
+	}
+	if d.MaxIterationsPerAction < 0 {
+		add("max_iterations_per_action", "must not be negative")
+	}
+	return errs
+}
+
+func validateLoopDetection(path string, l LoopDetectionConfig) []error {
+	var errs []error
+	add := func(key, format string, args ...interface{}) {
+		errs = append(errs, &FieldError{Path: path + "." + key, Msg: fmt.Sprintf(format, args...)})
+	}
+	if l.WindowSeconds < 0 {
+		add("window_seconds", "must not be negative")
+	}
+	if l.SafetyNet.WindowSeconds < 0 {
+		add("safety_net.window_seconds", "must not be negative")
+	}
+	if l.SafetyNet.StopRepeats < 0 {
+		add("safety_net.stop_repeats", "must not be negative")
+	}
+	for i, r := range l.Rules {
+		rule := fmt.Sprintf("rules[%d]", i)
+		if r.ActionType == "" && r.Tool == "" {
+			add(rule, "needs action_type or tool")
+		}
+		if r.WindowSeconds < 0 {
+			add(rule+".window_seconds", "must not be negative")
+		}
+		if r.StopRepeats < 0 {
+			add(rule+".stop_repeats", "must not be negative")
+		}
+	}
+	return errs
+}
+
+func validatePanic(p PanicConfig) []error {
+	var errs []error
+	add := func(path, format string, args ...interface{}) {
+		errs = append(errs, &FieldError{Path: "panic." + path, Msg: fmt.Sprintf(format, args...)})
+	}
+	if p.TTLSeconds < 0 {
+		add("ttl_seconds", "must not be negative")
+	}
+	if p.MaxDailyBudgetUSD < 0 {
+		add("max_daily_budget_usd", "must not be negative")
+	}
+	t := p.Thresholds
+	if t.WarnPct > 0 && t.ThrottlePct > 0 && t.WarnPct > t.ThrottlePct {
+		add("thresholds.warn_pct", "%v is above throttle_pct %v", t.WarnPct, t.ThrottlePct)
+	}
+	if t.ThrottlePct > 0 && t.StopPct > 0 && t.ThrottlePct > t.StopPct {
+		add("thresholds.throttle_pct", "%v is above stop_pct %v", t.ThrottlePct, t.StopPct)
+	}
+	if m := p.Filesystem.Mode; m != "" && m != "workspace_only" && m != "read_only" {
+		add("filesystem.mode", "unknown mode %q (workspace_only, read_only)", m)
+	}
+	if p.Loop.WindowSeconds < 0 || p.Loop.StopRepeats < 0 || p.Loop.ThrottleRepeats < 0 {
+		add("loop", "window_seconds, throttle_repeats and stop_repeats must not be negative")
+	}
+	return errs
+}
+
+// unknownFields reports keys in node that t (a struct, map, slice or pointer to one) has no
+// field for, with their path and line.
+func unknownFields(node *yaml.Node, t reflect.Type, path string) []error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return unknownFields(node.Content[0], t, path)
+	}
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	var errs []error
+	switch {
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				errs = append(errs, &FieldError{Path: join(key.Value), Line: key.Line, Msg: "unknown field"})
+				continue
+			}
+			errs = append(errs, unknownFields(value, field, join(key.Value))...)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, unknownFields(node.Content[i+1], t.Elem(), join(node.Content[i].Value))...)
+		}
+	case node.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice:
+		for i, item := range node.Content {
+			errs = append(errs, unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return errs
+}
+
+// yamlFields maps a struct's YAML keys to their field types.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+var pathIndex = regexp.MustCompile(`^(.*)\[(\d+)\]$`)
+
+// withLines sets the line of every FieldError in err whose path is found in the document root
+// to the line of the deepest key on that path.
+func withLines(err error, root *yaml.Node) error {
+	for _, fe := range Problems(err) {
+		if fe.Line == 0 && fe.Path != "" {
+			fe.Line = lineOf(root, fe.Path)
+		}
+	}
+	return err
+}
+
+// lineOf returns the line of the deepest node in root along path (e.g. loop_detection.rules[1].stop_repeats),
+// or 0 when not even the first key is present.
+func lineOf(root *yaml.Node, path string) int {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	line := 0
+	for _, part := range strings.Split(path, ".") {
+		var indexes []int
+		for m := pathIndex.FindStringSubmatch(part); m != nil; m = pathIndex.FindStringSubmatch(part) {
+			n, _ := strconv.Atoi(m[2])
+			indexes = append([]int{n}, indexes...)
+			part = m[1]
+		}
+		next := mappingValue(node, part)
+		if next == nil {
+			return line
+		}
+		line, node = next.Line, next
+		for _, n := range indexes {
+			if node.Kind != yaml.SequenceNode || n >= len(node.Content) {
+				return line
+			}
+			node = node.Content[n]
+			line = node.Line
+		}
+	}
+	return line
+}
+
+// mappingValue returns the value for key in a mapping node, or nil. For scalars the key's line
+// is the one to report, and the value is on the same line.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			if node.Content[i+1].Kind == yaml.ScalarNode {
+				return node.Content[i+1]
+			}
+			// Report nested sections at their key, not their first child
+			v := *node.Content[i+1]
+			v.Line = node.Content[i].Line
+			return &v
+		}
+	}
+	return nil
+}
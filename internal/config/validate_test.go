@@ -0,0 +1,68 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/futurematic/kernel/internal/config"
+)
+
+func TestLoadReportsProblemsWithLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	load := func(yaml string) []*config.FieldError {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(yaml), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := config.Load(path)
+		return config.Problems(err)
+	}
+
+	got := load("rules:\n  require_resolutoin: [git.push]\nshadow:\n  agents:\n    default:\n      budget: 1\n")
+	want := []config.FieldError{
+		{Path: "rules.require_resolutoin", Line: 2, Msg: "unknown field"},
+		{Path: "shadow.agents.default.budget", Line: 6, Msg: "unknown field"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unknown fields = %v, want %v", got, want)
+	}
+	for i := range want {
+		if *got[i] != want[i] {
+			t.Errorf("problem %d = %+v, want %+v", i, *got[i], want[i])
+		}
+	}
+
+	got = load("server:\n  port: http\n")
+	if len(got) != 1 || got[0].Line != 2 {
+		t.Errorf("type error = %v, want one on line 2", got)
+	}
+
+	got = load("agents:\n  default:\n    daily_budget_gbp: -1\n    throttle_pct: 1.2\n    hard_stop_pct: 1.0\nloop_detection:\n  rules:\n    - action_type: tool.call\n    - stop_repeats: 3\n")
+	lines := map[string]int{}
+	for _, p := range got {
+		lines[p.Path] = p.Line
+	}
+	wantLines := map[string]int{
+		"agents.default.daily_budget_gbp": 3,
+		"agents.default.throttle_pct":     4,
+		"loop_detection.rules[1]":         9,
+	}
+	if len(lines) != len(wantLines) {
+		t.Fatalf("problems = %v, want paths %v", got, wantLines)
+	}
+	for p, line := range wantLines {
+		if l, ok := lines[p]; !ok || l != line {
+			t.Errorf("%s: line %d (reported %v), want %d", p, l, ok, line)
+		}
+	}
+
+	got = load("webhooks:\n  endpoints:\n    - name: ops\n      url: http://127.0.0.1:9/hook\n      headers:\n        Authorization: Bearer x\n        x-ctrldot-signature: forged\n")
+	if len(got) != 1 || got[0].Path != "webhooks.endpoints[0].headers.x-ctrldot-signature" {
+		t.Errorf("reserved webhook header = %v, want one problem for x-ctrldot-signature", got)
+	}
+
+	if got := load("agents:\n  default:\n    daily_budget_gbp: 5\n"); len(got) != 0 {
+		t.Errorf("valid config: %v", got)
+	}
+}
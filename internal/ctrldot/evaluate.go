@@ -0,0 +1,76 @@
+package ctrldot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/tracing"
+)
+
+// EvaluateActions decides each proposal as ProposeAction would, without appending events,
+// updating limits state or issuing tokens. Proposals that would be allowed add their estimated
+// cost to their agent's budget for the rest of the batch, so a plan is judged as a whole. Loop
+// detection sees only recorded history, not earlier proposals in the batch.
+func (s *service) EvaluateActions(ctx context.Context, proposals []domain.ActionProposal) (*domain.EvaluateResponse, error) {
+	cfg := s.config.Load()
+	ctx, span := tracing.StartKind(ctx, "ctrldot.evaluate", tracing.KindServer, tracing.Int("ctrldot.proposals", len(proposals)))
+	defer span.End()
+
+	panicState, err := s.runtimeStore.GetPanicState(ctx)
+	if err != nil {
+		span.RecordError(err)
+		panicState = nil
+	}
+	if config.PanicExpired(panicState) {
+		// ProposeAction persists the auto-disable; a dry run only applies it
+		disabled := *panicState
+		disabled.Enabled = false
+		panicState = &disabled
+	}
+	effectiveConfig := config.Effective(cfg, panicState)
+
+	out := &domain.EvaluateResponse{Decisions: make([]domain.DecisionResponse, 0, len(proposals))}
+	pending := map[string]float64{} // per agent: cost of earlier allowed proposals in the batch
+	for i, proposal := range proposals {
+		agent, halted, err := s.lookupAgent(ctx, proposal.AgentID)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("proposal %d: %w", i, err)
+		}
+		if agent == nil {
+			out.Decisions = append(out.Decisions, domain.DecisionResponse{Decision: domain.DecisionDeny, Reason: "Agent not registered"})
+			continue
+		}
+		if halted {
+			out.Decisions = append(out.Decisions, domain.DecisionResponse{Decision: domain.DecisionStop, Reason: "Agent is halted"})
+			continue
+		}
+
+		ruleDecision, ruleReason := s.rulesEngine.EvaluateWithConfig(ctx, proposal, effectiveConfig)
+		loopStop := s.loopDetector.DetectWithConfig(ctx, proposal, effectiveConfig)
+		charged := proposal
+		charged.Cost.EstimatedGBP += pending[proposal.AgentID]
+		limitDecision, warnings, throttle := s.limitsEngine.EvaluateWithConfig(ctx, charged, agent, effectiveConfig)
+		o := evaluate(effectiveConfig, proposal, ruleDecision, ruleReason, loopStop, limitDecision)
+
+		response := domain.DecisionResponse{
+			Decision: o.Decision,
+			Warnings: warnings,
+			Throttle: throttle,
+			Reason:   o.Reason,
+		}
+		for _, code := range o.Codes {
+			response.Reasons = append(response.Reasons, domain.Reason{Code: code, Message: o.Reason})
+		}
+		response.Recommendation = recommend(ctx, proposal, o, panicState)
+		out.Decisions = append(out.Decisions, response)
+
+		if o.Decision == domain.DecisionAllow || o.Decision == domain.DecisionWarn || o.Decision == domain.DecisionThrottle {
+			pending[proposal.AgentID] += proposal.Cost.EstimatedGBP
+			out.CumulativeCostGBP += proposal.Cost.EstimatedGBP
+		}
+	}
+	return out, nil
+}
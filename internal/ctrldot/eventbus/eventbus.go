@@ -0,0 +1,103 @@
+package eventbus
+
+import (
+	"sync"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+// DefaultBuffer is the per-subscriber queue length used when Subscribe is given 0.
+const DefaultBuffer = 256
+
+// Broadcaster fans appended events out to in-process subscribers (SSE streams).
+// Publish never blocks: a subscriber whose queue is full is dropped and its channel
+// closed, so a slow reader cannot hold up ProposeAction. Dropped subscribers are
+// expected to resume from the store (see Subscription.Lagged).
+type Broadcaster struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+// New creates an empty broadcaster.
+func New() *Broadcaster {
+	return &Broadcaster{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives events published after Subscribe. C is closed when the
+// subscription is closed, falls behind, or the broadcaster is closed.
+type Subscription struct {
+	C <-chan domain.Event
+
+	b      *Broadcaster
+	ch     chan domain.Event
+	match  func(domain.Event) bool
+	lagged bool
+}
+
+// Subscribe registers a subscriber. match may be nil to receive every event.
+func (b *Broadcaster) Subscribe(buffer int, match func(domain.Event) bool) *Subscription {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	ch := make(chan domain.Event, buffer)
+	sub := &Subscription{C: ch, b: b, ch: ch, match: match}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Publish delivers e to every matching subscriber without blocking.
+func (b *Broadcaster) Publish(e domain.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		if sub.match != nil && !sub.match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			sub.lagged = true
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribers returns the number of active subscriptions.
+func (b *Broadcaster) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Close closes every subscription.
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+// remove must be called with b.mu held.
+func (b *Broadcaster) remove(sub *Subscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Close unsubscribes. Safe to call more than once.
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	s.b.remove(s)
+}
+
+// Lagged reports whether the subscription was dropped because its queue filled up.
+func (s *Subscription) Lagged() bool {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	return s.lagged
+}
@@ -0,0 +1,41 @@
+package eventbus_test
+
+import (
+	"testing"
+
+	"github.com/futurematic/kernel/internal/ctrldot/eventbus"
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+func TestPublishDropsLaggingSubscriber(t *testing.T) {
+	b := eventbus.New()
+	slow := b.Subscribe(2, nil)
+	other := b.Subscribe(2, func(e domain.Event) bool { return e.AgentID == "b" })
+	defer other.Close()
+
+	for _, id := range []string{"e1", "e2", "e3"} {
+		b.Publish(domain.Event{EventID: id, AgentID: "a"})
+	}
+	// The slow subscriber keeps what it had queued, then sees its channel closed
+	var got []string
+	for e := range slow.C {
+		got = append(got, e.EventID)
+	}
+	if len(got) != 2 || got[0] != "e1" || got[1] != "e2" || !slow.Lagged() {
+		t.Errorf("slow subscriber got %v, lagged %v; want [e1 e2], true", got, slow.Lagged())
+	}
+	// Events a subscriber does not match never fill its queue
+	if other.Lagged() || b.Subscribers() != 1 {
+		t.Errorf("non-matching subscriber lagged %v, subscribers %d", other.Lagged(), b.Subscribers())
+	}
+	b.Publish(domain.Event{EventID: "e4", AgentID: "b"})
+	if e := <-other.C; e.EventID != "e4" {
+		t.Errorf("matching subscriber got %s, want e4", e.EventID)
+	}
+
+	b.Close()
+	if _, ok := <-other.C; ok || other.Lagged() {
+		t.Error("Close did not close the subscription, or marked it lagged")
+	}
+	other.Close() // safe after the broadcaster closed it
+}
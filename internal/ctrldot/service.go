@@ -2,22 +2,31 @@ package ctrldot
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/ctrldot/eventbus"
 	"github.com/futurematic/kernel/internal/ctrldot/recommendations"
 	"github.com/futurematic/kernel/internal/domain"
 	"github.com/futurematic/kernel/internal/ledger/autobundle"
 	"github.com/futurematic/kernel/internal/ledger/sink"
 	"github.com/futurematic/kernel/internal/loop"
 	"github.com/futurematic/kernel/internal/limits"
+	"github.com/futurematic/kernel/internal/metrics"
 	"github.com/futurematic/kernel/internal/resolution"
 	"github.com/futurematic/kernel/internal/rules"
 	"github.com/futurematic/kernel/internal/runtime"
+	"github.com/futurematic/kernel/internal/tracing"
 	"github.com/google/uuid"
 )
 
@@ -29,15 +38,30 @@ type Service interface {
 	// ProposeAction evaluates an action proposal and returns a decision
 	ProposeAction(ctx context.Context, proposal domain.ActionProposal) (*domain.DecisionResponse, error)
 
+	// EvaluateActions decides proposals without recording anything (dry run); costs accumulate across the batch
+	EvaluateActions(ctx context.Context, proposals []domain.ActionProposal) (*domain.EvaluateResponse, error)
+
 	// StartSession starts a new session for an agent
 	StartSession(ctx context.Context, agentID string, metadata map[string]interface{}) (*domain.Session, error)
 
+	// GetSession retrieves a session
+	GetSession(ctx context.Context, sessionID string) (*domain.Session, error)
+
 	// EndSession ends a session
 	EndSession(ctx context.Context, sessionID string) error
 
 	// GetEvents retrieves events
 	GetEvents(ctx context.Context, agentID *string, sinceTS *int64, limit int) ([]domain.Event, error)
 
+	// QueryEvents retrieves events matching filter, newest first
+	QueryEvents(ctx context.Context, filter runtime.EventFilter) ([]domain.Event, error)
+
+	// SubscribeEvents streams events matching filter as they are appended. Callers must Close the subscription.
+	SubscribeEvents(filter runtime.EventFilter) *eventbus.Subscription
+
+	// GetEventRollups retrieves hourly per-agent aggregates of compacted events
+	GetEventRollups(ctx context.Context, agentID *string, sinceTS *int64, limit int) ([]domain.EventRollup, error)
+
 	// ListAgents lists all agents
 	ListAgents(ctx context.Context) ([]domain.Agent, error)
 
@@ -66,8 +90,26 @@ type Service interface {
 
 	// GetLimitsConfig returns default limits from config (read-only view).
 	GetLimitsConfig(ctx context.Context) (*domain.LimitsConfigResponse, error)
+
+	// ReloadConfig re-reads the config file and swaps it in under every engine, recording a new
+	// config revision when settings changed. trigger ("sighup", "watch", "api") and author
+	// (may be empty) are recorded with it.
+	ReloadConfig(ctx context.Context, trigger, author string) (*config.ReloadResult, error)
+
+	// ListConfigRevisions lists applied config revisions, newest first.
+	ListConfigRevisions(ctx context.Context, limit int) ([]domain.ConfigRevision, error)
+
+	// RollbackConfig writes the contents of config revision rev back to the config file and
+	// applies them as a new revision.
+	RollbackConfig(ctx context.Context, rev int64, author string) (*config.ReloadResult, error)
 }
 
+// ErrReloadDisabled is returned by ReloadConfig and RollbackConfig when the service has no reloader.
+var ErrReloadDisabled = errors.New("config reload is not enabled")
+
+// ErrRevisionNotFound is returned by RollbackConfig for an unknown revision.
+var ErrRevisionNotFound = errors.New("config revision not found")
+
 // service implements Service
 type service struct {
 	runtimeStore   runtime.RuntimeStore
@@ -77,11 +119,14 @@ type service struct {
 	resolutionMgr  *resolution.Manager
 	ledgerSink     sink.LedgerSink
 	autobundleMgr  *autobundle.Manager
-	config         *config.Config
+	config         atomic.Pointer[config.Config]
+	reloader       *config.Reloader
+	events         *eventbus.Broadcaster
 }
 
 // NewService creates a new Ctrl Dot service using RuntimeStore for all runtime state.
-// autobundleMgr may be nil to disable auto-bundles.
+// autobundleMgr may be nil to disable auto-bundles. reloader may be nil to disable config
+// reload; otherwise the service registers itself for reloaded configs.
 func NewService(
 	runtimeStore runtime.RuntimeStore,
 	limitsEngine *limits.Engine,
@@ -91,8 +136,9 @@ func NewService(
 	ledgerSink sink.LedgerSink,
 	autobundleMgr *autobundle.Manager,
 	cfg *config.Config,
+	reloader *config.Reloader,
 ) Service {
-	return &service{
+	s := &service{
 		runtimeStore:  runtimeStore,
 		limitsEngine: limitsEngine,
 		rulesEngine:  rulesEngine,
@@ -100,7 +146,157 @@ func NewService(
 		resolutionMgr: resolutionMgr,
 		ledgerSink:   ledgerSink,
 		autobundleMgr: autobundleMgr,
-		config:       cfg,
+		reloader:     reloader,
+		events:       eventbus.New(),
+	}
+	s.config.Store(cfg)
+	if reloader != nil {
+		reloader.Register(s)
+		s.recordStartupRevision(context.Background(), cfg)
+	}
+	return s
+}
+
+// SetConfig swaps in a reloaded config for proposals evaluated from now on.
+func (s *service) SetConfig(cfg *config.Config) {
+	s.config.Store(cfg)
+}
+
+// ReloadConfig reloads the config file and records a config revision and a config.reloaded
+// event when settings changed.
+func (s *service) ReloadConfig(ctx context.Context, trigger, author string) (*config.ReloadResult, error) {
+	if s.reloader == nil {
+		return nil, ErrReloadDisabled
+	}
+	res, err := s.reloader.Reload(s.recordRevision(ctx, trigger, author, 0))
+	if err != nil {
+		return nil, err
+	}
+	s.configReloaded(ctx, trigger, res)
+	return res, nil
+}
+
+// ListConfigRevisions lists applied config revisions, newest first.
+func (s *service) ListConfigRevisions(ctx context.Context, limit int) ([]domain.ConfigRevision, error) {
+	return s.runtimeStore.ListConfigRevisions(ctx, limit)
+}
+
+// RollbackConfig restores the file contents of revision rev and applies them.
+func (s *service) RollbackConfig(ctx context.Context, rev int64, author string) (*config.ReloadResult, error) {
+	if s.reloader == nil {
+		return nil, ErrReloadDisabled
+	}
+	target, err := s.runtimeStore.GetConfigRevision(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, ErrRevisionNotFound
+	}
+	res, err := s.reloader.Apply([]byte(target.Content), s.recordRevision(ctx, "rollback", author, rev))
+	if err != nil {
+		return nil, err
+	}
+	s.configReloaded(ctx, "rollback", res)
+	return res, nil
+}
+
+// recordRevision returns an ApplyFunc that records the new config as a revision and stamps it.
+func (s *service) recordRevision(ctx context.Context, trigger, author string, rollbackOf int64) config.ApplyFunc {
+	return func(next *config.Config, content []byte, res *config.ReloadResult) error {
+		rev := domain.ConfigRevision{
+			ContentHash: contentHash(content),
+			Author:      author,
+			Trigger:     trigger,
+			RollbackOf:  rollbackOf,
+			Changes:     res.Changes,
+			Content:     string(content),
+			CreatedAt:   time.Now(),
+		}
+		if err := s.runtimeStore.AppendConfigRevision(ctx, &rev); err != nil {
+			return fmt.Errorf("record config revision: %w", err)
+		}
+		next.Revision, res.Revision = rev.Rev, rev.Rev
+		return nil
+	}
+}
+
+// recordStartupRevision stamps cfg with the latest revision when the file is unchanged since,
+// and otherwise records it as a new revision. Failures are logged; cfg then has no revision.
+func (s *service) recordStartupRevision(ctx context.Context, cfg *config.Config) {
+	content := s.reloader.Content()
+	hash := contentHash(content)
+	latest, err := s.runtimeStore.ListConfigRevisions(ctx, 1)
+	if err != nil {
+		log.Printf("config revisions: %v", err)
+		return
+	}
+	if len(latest) == 1 && latest[0].ContentHash == hash {
+		cfg.Revision = latest[0].Rev
+		return
+	}
+	// Changes since the previous revision, comparing the files alone (not the daemon's defaults)
+	changes := []string{}
+	if len(latest) == 1 {
+		prev, perr := config.Parse([]byte(latest[0].Content))
+		cur, cerr := config.Parse(content)
+		if perr == nil && cerr == nil {
+			changes = config.Diff(prev, cur)
+		}
+	}
+	rev := domain.ConfigRevision{ContentHash: hash, Trigger: "startup", Changes: changes, Content: string(content), CreatedAt: time.Now()}
+	if err := s.runtimeStore.AppendConfigRevision(ctx, &rev); err != nil {
+		log.Printf("config revisions: %v", err)
+		return
+	}
+	cfg.Revision = rev.Rev
+}
+
+// configReloaded records a config.reloaded event for a reload that changed settings.
+func (s *service) configReloaded(ctx context.Context, trigger string, res *config.ReloadResult) {
+	if len(res.Changes) == 0 {
+		return
+	}
+	payload := map[string]interface{}{"trigger": trigger, "changes": res.Changes}
+	if res.Revision > 0 {
+		payload["config_rev"] = res.Revision
+	}
+	if len(res.RestartRequired) > 0 {
+		payload["restart_required"] = res.RestartRequired
+	}
+	s.lifecycleEvent(ctx, domain.EventTypeConfigReloaded, "", "", payload)
+}
+
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// appendEvent persists e and then publishes it to live subscribers.
+func (s *service) appendEvent(ctx context.Context, e *domain.Event) error {
+	if err := s.runtimeStore.AppendEvent(ctx, e); err != nil {
+		return err
+	}
+	s.events.Publish(*e)
+	return nil
+}
+
+// lifecycleEvent records an agent/session lifecycle change. Failures are logged, not returned.
+func (s *service) lifecycleEvent(ctx context.Context, eventType, agentID, sessionID string, payload map[string]interface{}) {
+	event := domain.Event{
+		EventID:     "evt:" + uuid.New().String(),
+		TS:          time.Now(),
+		Type:        eventType,
+		AgentID:     agentID,
+		SessionID:   sessionID,
+		Severity:    domain.EventSeverityInfo,
+		PayloadJSON: payload,
+	}
+	if eventType == domain.EventTypeAgentHalted || eventType == domain.EventTypePanicEnabled {
+		event.Severity = domain.EventSeverityWarn
+	}
+	if err := s.appendEvent(ctx, &event); err != nil {
+		log.Printf("append %s event: %v", eventType, err)
 	}
 }
 
@@ -134,18 +330,66 @@ func (s *service) RegisterAgent(ctx context.Context, agentID string, displayName
 			"default_mode": defaultMode,
 		},
 	}
-	if err := s.runtimeStore.AppendEvent(ctx, &event); err != nil {
+	if err := s.appendEvent(ctx, &event); err != nil {
 		return nil, fmt.Errorf("failed to append event: %w", err)
 	}
 
 	return &agent, nil
 }
 
-// ProposeAction evaluates an action proposal and returns a decision
+// ProposeAction evaluates an action proposal and returns a decision.
+// It records the ctrldot.propose span (a child of the caller's traceparent, if any) and metrics.
 func (s *service) ProposeAction(ctx context.Context, proposal domain.ActionProposal) (*domain.DecisionResponse, error) {
-	agent, err := s.runtimeStore.GetAgent(ctx, proposal.AgentID)
+	start := time.Now()
+	ctx, span := tracing.StartKind(ctx, "ctrldot.propose", tracing.KindServer,
+		tracing.String("ctrldot.agent_id", proposal.AgentID),
+		tracing.String("ctrldot.session_id", proposal.SessionID),
+		tracing.String("ctrldot.action_type", proposal.Action.Type),
+	)
+	defer span.End()
+
+	response, err := s.proposeAction(ctx, proposal)
+	metrics.ProposeDuration.Observe(metrics.Since(start))
+	span.RecordError(err)
+	if response != nil {
+		var codes []string
+		for _, r := range response.Reasons {
+			codes = append(codes, r.Code)
+		}
+		metrics.ObserveDecision(string(response.Decision), codes, proposal.AgentID, proposal.Action.Type)
+		span.SetAttributes(
+			tracing.String("ctrldot.decision", string(response.Decision)),
+			tracing.Strings("ctrldot.reason_codes", codes),
+			tracing.String("ctrldot.reason", response.Reason),
+		)
+	}
+	return response, err
+}
+
+// lookupAgent returns the agent (nil if unregistered) and whether it is halted.
+func (s *service) lookupAgent(ctx context.Context, agentID string) (*domain.Agent, bool, error) {
+	agent, err := s.runtimeStore.GetAgent(ctx, agentID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get agent: %w", err)
+	}
+	if agent == nil {
+		return nil, false, nil
+	}
+	halted, err := s.runtimeStore.IsAgentHalted(ctx, agentID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check halted status: %w", err)
+	}
+	return agent, halted, nil
+}
+
+func (s *service) proposeAction(ctx context.Context, proposal domain.ActionProposal) (*domain.DecisionResponse, error) {
+	spanCtx, span := tracing.Start(ctx, "ctrldot.agent_lookup")
+	agent, halted, err := s.lookupAgent(spanCtx, proposal.AgentID)
+	span.RecordError(err)
+	span.SetAttributes(tracing.Bool("ctrldot.agent_registered", agent != nil), tracing.Bool("ctrldot.agent_halted", halted))
+	span.End()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get agent: %w", err)
+		return nil, err
 	}
 	if agent == nil {
 		return &domain.DecisionResponse{
@@ -153,11 +397,6 @@ func (s *service) ProposeAction(ctx context.Context, proposal domain.ActionPropo
 			Reason:   "Agent not registered",
 		}, nil
 	}
-
-	halted, err := s.runtimeStore.IsAgentHalted(ctx, proposal.AgentID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check halted status: %w", err)
-	}
 	if halted {
 		return &domain.DecisionResponse{
 			Decision: domain.DecisionStop,
@@ -166,40 +405,48 @@ func (s *service) ProposeAction(ctx context.Context, proposal domain.ActionPropo
 	}
 
 	// Load panic state and apply TTL auto-disable
-	panicState, err := s.runtimeStore.GetPanicState(ctx)
+	spanCtx, span = tracing.Start(ctx, "ctrldot.panic_state")
+	panicState, err := s.runtimeStore.GetPanicState(spanCtx)
 	if err != nil {
+		span.RecordError(err)
 		panicState = nil
 	}
 	if config.PanicExpired(panicState) {
 		disabled := *panicState
 		disabled.Enabled = false
-		_ = s.runtimeStore.SetPanicState(ctx, disabled)
+		_ = s.runtimeStore.SetPanicState(spanCtx, disabled)
 		panicState = &disabled
 	}
-	effectiveConfig := config.Effective(s.config, panicState)
-
-	ruleDecision, ruleReason := s.rulesEngine.EvaluateWithConfig(ctx, proposal, effectiveConfig)
-	loopStop := s.loopDetector.DetectWithConfig(ctx, proposal, effectiveConfig)
-	limitDecision, warnings, throttle := s.limitsEngine.EvaluateWithConfig(ctx, proposal, agent, effectiveConfig)
-
-	finalDecision := ruleDecision
-	responseReason := ruleReason
-	if ruleDecision == domain.DecisionDeny {
-		finalDecision = domain.DecisionDeny
-		responseReason = ruleReason
-	} else if loopStop {
-		finalDecision = domain.DecisionStop
-		responseReason = "Loop detected: repeated action"
-	} else if limitDecision == domain.DecisionStop || limitDecision == domain.DecisionDeny {
-		finalDecision = limitDecision
-		if limitDecision == domain.DecisionStop {
-			responseReason = "Budget limit reached"
-		}
-	} else if limitDecision == domain.DecisionThrottle && finalDecision == domain.DecisionAllow {
-		finalDecision = domain.DecisionThrottle
-	} else if limitDecision == domain.DecisionWarn && finalDecision == domain.DecisionAllow {
-		finalDecision = domain.DecisionWarn
-	}
+	span.SetAttributes(tracing.Bool("ctrldot.panic_enabled", panicState != nil && panicState.Enabled))
+	span.End()
+	// One config for the whole proposal, even if a reload swaps it meanwhile
+	cfg := s.config.Load()
+	effectiveConfig := config.Effective(cfg, panicState)
+
+	spanCtx, span = tracing.Start(ctx, "ctrldot.rules")
+	evalStart := time.Now()
+	ruleDecision, ruleReason := s.rulesEngine.EvaluateWithConfig(spanCtx, proposal, effectiveConfig)
+	metrics.EvaluatorDuration.Observe(metrics.Since(evalStart), metrics.EvaluatorRules)
+	span.SetAttributes(tracing.String("ctrldot.rules.decision", string(ruleDecision)))
+	span.End()
+
+	spanCtx, span = tracing.Start(ctx, "ctrldot.loop")
+	evalStart = time.Now()
+	loopStop := s.loopDetector.DetectWithConfig(spanCtx, proposal, effectiveConfig)
+	metrics.EvaluatorDuration.Observe(metrics.Since(evalStart), metrics.EvaluatorLoop)
+	span.SetAttributes(tracing.Bool("ctrldot.loop.stop", loopStop))
+	span.End()
+
+	spanCtx, span = tracing.Start(ctx, "ctrldot.limits")
+	evalStart = time.Now()
+	limitDecision, warnings, throttle := s.limitsEngine.EvaluateWithConfig(spanCtx, proposal, agent, effectiveConfig)
+	metrics.EvaluatorDuration.Observe(metrics.Since(evalStart), metrics.EvaluatorLimits)
+	span.SetAttributes(tracing.String("ctrldot.limits.decision", string(limitDecision)))
+	span.End()
+
+	live := evaluate(effectiveConfig, proposal, ruleDecision, ruleReason, loopStop, limitDecision)
+	finalDecision, responseReason := live.Decision, live.Reason
+	shadow := s.evaluateShadow(ctx, cfg, proposal, agent, panicState)
 
 	eventID := "evt:" + uuid.New().String()
 	decisionEvent := domain.Event{
@@ -211,6 +458,7 @@ func (s *service) ProposeAction(ctx context.Context, proposal domain.ActionPropo
 		Severity:    domain.EventSeverityInfo,
 		PayloadJSON: map[string]interface{}{
 			"decision":    string(finalDecision),
+			"reason":      responseReason,
 			"action_type": proposal.Action.Type,
 			"action_hash": proposal.Context.Hash,
 		},
@@ -218,34 +466,23 @@ func (s *service) ProposeAction(ctx context.Context, proposal domain.ActionPropo
 		CostGBP:    &proposal.Cost.EstimatedGBP,
 		CostTokens: &proposal.Cost.EstimatedTokens,
 	}
-	if err := s.runtimeStore.AppendEvent(ctx, &decisionEvent); err != nil {
+	if cfg != nil && cfg.Revision > 0 {
+		decisionEvent.PayloadJSON["config_rev"] = cfg.Revision
+	}
+	spanCtx, span = tracing.Start(ctx, "ctrldot.event_append")
+	if err := s.appendEvent(spanCtx, &decisionEvent); err != nil {
 		// Log but don't fail the response
-		_ = err
+		span.RecordError(err)
 	}
+	span.End()
+	s.recordShadowDivergence(ctx, proposal, eventID, live, shadow)
 
 	// Persist updated limits state when we allow execution
 	if finalDecision == domain.DecisionAllow || finalDecision == domain.DecisionWarn || finalDecision == domain.DecisionThrottle {
-		now := time.Now()
-		windowStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		windowStartTS := windowStart.Unix() * 1000
-		state, _ := s.runtimeStore.GetLimitsState(ctx, proposal.AgentID, windowStartTS, "daily")
-		if state == nil {
-			state = &domain.LimitsState{
-				AgentID:            proposal.AgentID,
-				WindowStart:        windowStart,
-				WindowType:         "daily",
-				BudgetSpentGBP:     0,
-				BudgetSpentTokens:  0,
-				ActionCount:        0,
-			}
-		}
-		state.BudgetSpentGBP += proposal.Cost.EstimatedGBP
-		state.BudgetSpentTokens += proposal.Cost.EstimatedTokens
-		state.ActionCount++
-		_ = s.runtimeStore.UpdateLimitsState(ctx, *state)
+		_ = s.limitsEngine.Record(ctx, proposal)
 	}
 
-	reasonCodes := reasonCodesFromOutcome(finalDecision, responseReason)
+	reasonCodes := live.Codes
 	response := &domain.DecisionResponse{
 		Decision:      finalDecision,
 		Warnings:      warnings,
@@ -256,20 +493,7 @@ func (s *service) ProposeAction(ctx context.Context, proposal domain.ActionPropo
 	for _, code := range reasonCodes {
 		response.Reasons = append(response.Reasons, domain.Reason{Code: code, Message: responseReason})
 	}
-	if finalDecision == domain.DecisionDeny || finalDecision == domain.DecisionStop || finalDecision == domain.DecisionThrottle {
-		if rec := recommendations.Recommend(ctx, recommendations.RecommendOptions{
-			Decision:         finalDecision,
-			ReasonText:       responseReason,
-			ReasonCodes:      reasonCodes,
-			ActionType:       proposal.Action.Type,
-			PanicEnabled:     panicState != nil && panicState.Enabled,
-			ResolutionAbsent: strings.Contains(responseReason, "resolution") || strings.Contains(responseReason, "Resolution"),
-			AgentID:          proposal.AgentID,
-			SessionID:        proposal.SessionID,
-		}); rec != nil {
-			response.Recommendation = rec
-		}
-	}
+	response.Recommendation = recommend(ctx, proposal, live, panicState)
 
 	if finalDecision == domain.DecisionAllow || finalDecision == domain.DecisionWarn || finalDecision == domain.DecisionThrottle {
 		token, err := s.resolutionMgr.GenerateToken(ctx, proposal.AgentID, proposal.Action.Type, 10*time.Minute)
@@ -287,8 +511,19 @@ func (s *service) ProposeAction(ctx context.Context, proposal domain.ActionPropo
 		budgetLimit = 10.0
 	}
 	record := buildDecisionRecord(proposal, response, &decisionEvent, budgetLimit)
-	_ = s.ledgerSink.EmitDecision(ctx, record)
-	_ = s.ledgerSink.EmitEvent(ctx, &decisionEvent)
+	if cfg != nil {
+		record.ConfigRev = cfg.Revision
+	}
+	spanCtx, span = tracing.Start(ctx, "ctrldot.ledger_sink", tracing.String("ctrldot.sink", s.ledgerSinkKind()))
+	if err := s.ledgerSink.EmitDecision(spanCtx, record); err != nil {
+		metrics.LedgerSinkErrors.Inc(s.ledgerSinkKind(), "decision")
+		span.RecordError(err)
+	}
+	if err := s.ledgerSink.EmitEvent(spanCtx, &decisionEvent); err != nil {
+		metrics.LedgerSinkErrors.Inc(s.ledgerSinkKind(), "event")
+		span.RecordError(err)
+	}
+	span.End()
 
 	// Auto-bundle on DENY/STOP (debounced per session+trigger)
 	if s.autobundleMgr != nil && (finalDecision == domain.DecisionDeny || finalDecision == domain.DecisionStop) {
@@ -310,17 +545,73 @@ func (s *service) ProposeAction(ctx context.Context, proposal domain.ActionPropo
 		for _, r := range response.Reasons {
 			codes = append(codes, r.Code)
 		}
-		if path, err := s.autobundleMgr.MaybeBundleOnDecision(ctx, record, trigger, effectivePanic, nextSteps, codes); err == nil && path != "" {
+		spanCtx, span = tracing.Start(ctx, "ctrldot.autobundle", tracing.String("ctrldot.autobundle.trigger", trigger))
+		path, err := s.autobundleMgr.MaybeBundleOnDecision(spanCtx, record, trigger, effectivePanic, nextSteps, codes)
+		span.RecordError(err)
+		if err == nil && path != "" {
 			response.AutobundlePath = path
 			response.AutobundleTrigger = trigger
+			span.SetAttributes(tracing.String("ctrldot.autobundle.path", path))
 		}
+		span.End()
 	}
 
 	return response, nil
 }
 
-// reasonCodesFromOutcome returns stable reason codes for the given decision and reason text.
-func reasonCodesFromOutcome(decision domain.Decision, reason string) []string {
+// recommend returns next steps for a DENY, STOP or THROTTLE outcome, or nil.
+func recommend(ctx context.Context, proposal domain.ActionProposal, o outcome, panicState *domain.PanicState) *domain.Recommendation {
+	if o.Decision != domain.DecisionDeny && o.Decision != domain.DecisionStop && o.Decision != domain.DecisionThrottle {
+		return nil
+	}
+	return recommendations.Recommend(ctx, recommendations.RecommendOptions{
+		Decision:         o.Decision,
+		ReasonText:       o.Reason,
+		ReasonCodes:      o.Codes,
+		ActionType:       proposal.Action.Type,
+		PanicEnabled:     panicState != nil && panicState.Enabled,
+		ResolutionAbsent: strings.Contains(o.Reason, "resolution") || strings.Contains(o.Reason, "Resolution"),
+		AgentID:          proposal.AgentID,
+		SessionID:        proposal.SessionID,
+	})
+}
+
+// ledgerSinkKind is the configured ledger_sink.kind, for metric labels.
+func (s *service) ledgerSinkKind() string {
+	cfg := s.config.Load()
+	if cfg == nil || cfg.LedgerSink.Kind == "" {
+		return "none"
+	}
+	return cfg.LedgerSink.Kind
+}
+
+// CombineDecision merges the evaluator outcomes into the final decision and reason: a rules
+// DENY wins, then a loop STOP, then the limits decision (STOP or DENY; THROTTLE and WARN only
+// downgrade an ALLOW).
+func CombineDecision(ruleDecision domain.Decision, ruleReason string, loopStop bool, limitDecision domain.Decision) (domain.Decision, string) {
+	finalDecision := ruleDecision
+	responseReason := ruleReason
+	if ruleDecision == domain.DecisionDeny {
+		finalDecision = domain.DecisionDeny
+		responseReason = ruleReason
+	} else if loopStop {
+		finalDecision = domain.DecisionStop
+		responseReason = "Loop detected: repeated action"
+	} else if limitDecision == domain.DecisionStop || limitDecision == domain.DecisionDeny {
+		finalDecision = limitDecision
+		if limitDecision == domain.DecisionStop {
+			responseReason = "Budget limit reached"
+		}
+	} else if limitDecision == domain.DecisionThrottle && finalDecision == domain.DecisionAllow {
+		finalDecision = domain.DecisionThrottle
+	} else if limitDecision == domain.DecisionWarn && finalDecision == domain.DecisionAllow {
+		finalDecision = domain.DecisionWarn
+	}
+	return finalDecision, responseReason
+}
+
+// ReasonCodes returns stable reason codes for the given decision and reason text.
+func ReasonCodes(decision domain.Decision, reason string) []string {
 	var codes []string
 	if decision == domain.DecisionStop {
 		if strings.Contains(reason, "Loop") {
@@ -357,13 +648,26 @@ func (s *service) StartSession(ctx context.Context, agentID string, metadata map
 	if err := s.runtimeStore.CreateSession(ctx, session); err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
+	s.lifecycleEvent(ctx, domain.EventTypeSessionStarted, agentID, sessionID, map[string]interface{}{
+		"session_id": sessionID,
+		"metadata":   metadata,
+	})
 
 	return &session, nil
 }
 
+// GetSession retrieves a session
+func (s *service) GetSession(ctx context.Context, sessionID string) (*domain.Session, error) {
+	return s.runtimeStore.GetSession(ctx, sessionID)
+}
+
 // EndSession ends a session
 func (s *service) EndSession(ctx context.Context, sessionID string) error {
-	return s.runtimeStore.EndSession(ctx, sessionID)
+	if err := s.runtimeStore.EndSession(ctx, sessionID); err != nil {
+		return err
+	}
+	s.lifecycleEvent(ctx, domain.EventTypeSessionEnded, "", sessionID, map[string]interface{}{"session_id": sessionID})
+	return nil
 }
 
 // GetEvents retrieves events
@@ -371,6 +675,21 @@ func (s *service) GetEvents(ctx context.Context, agentID *string, sinceTS *int64
 	return s.runtimeStore.ListEvents(ctx, runtime.EventFilter{AgentID: agentID, SinceTS: sinceTS, Limit: limit})
 }
 
+// QueryEvents retrieves events matching filter, newest first
+func (s *service) QueryEvents(ctx context.Context, filter runtime.EventFilter) ([]domain.Event, error) {
+	return s.runtimeStore.ListEvents(ctx, filter)
+}
+
+// SubscribeEvents streams events matching filter as they are appended
+func (s *service) SubscribeEvents(filter runtime.EventFilter) *eventbus.Subscription {
+	return s.events.Subscribe(0, filter.Matches)
+}
+
+// GetEventRollups retrieves hourly per-agent aggregates of compacted events
+func (s *service) GetEventRollups(ctx context.Context, agentID *string, sinceTS *int64, limit int) ([]domain.EventRollup, error) {
+	return s.runtimeStore.ListEventRollups(ctx, runtime.EventFilter{AgentID: agentID, SinceTS: sinceTS, Limit: limit})
+}
+
 // ListAgents lists all agents
 func (s *service) ListAgents(ctx context.Context) ([]domain.Agent, error) {
 	return s.runtimeStore.ListAgents(ctx)
@@ -383,12 +702,20 @@ func (s *service) GetAgent(ctx context.Context, agentID string) (*domain.Agent,
 
 // HaltAgent halts an agent
 func (s *service) HaltAgent(ctx context.Context, agentID string, reason string) error {
-	return s.runtimeStore.HaltAgent(ctx, agentID, reason)
+	if err := s.runtimeStore.HaltAgent(ctx, agentID, reason); err != nil {
+		return err
+	}
+	s.lifecycleEvent(ctx, domain.EventTypeAgentHalted, agentID, "", map[string]interface{}{"reason": reason})
+	return nil
 }
 
 // ResumeAgent resumes a halted agent
 func (s *service) ResumeAgent(ctx context.Context, agentID string) error {
-	return s.runtimeStore.ResumeAgent(ctx, agentID)
+	if err := s.runtimeStore.ResumeAgent(ctx, agentID); err != nil {
+		return err
+	}
+	s.lifecycleEvent(ctx, domain.EventTypeAgentResumed, agentID, "", nil)
+	return nil
 }
 
 // GetPanicState returns the current panic mode state from the runtime store.
@@ -409,19 +736,32 @@ func (s *service) GetPanicState(ctx context.Context) (*domain.PanicState, error)
 
 // SetPanicState updates panic mode state in the runtime store.
 func (s *service) SetPanicState(ctx context.Context, state domain.PanicState) error {
-	return s.runtimeStore.SetPanicState(ctx, state)
+	if err := s.runtimeStore.SetPanicState(ctx, state); err != nil {
+		return err
+	}
+	if state.Enabled {
+		s.lifecycleEvent(ctx, domain.EventTypePanicEnabled, "", "", map[string]interface{}{
+			"reason":      state.Reason,
+			"ttl_seconds": state.TTLSeconds,
+		})
+	} else {
+		s.lifecycleEvent(ctx, domain.EventTypePanicDisabled, "", "", nil)
+	}
+	return nil
 }
 
 // GetAutobundleStatus returns current autobundle config from service config.
 func (s *service) GetAutobundleStatus(ctx context.Context) (*config.AutobundleConfig, error) {
-	if s.config == nil {
+	cfg := s.config.Load()
+	if cfg == nil {
 		return &config.AutobundleConfig{}, nil
 	}
-	return &s.config.Autobundle, nil
+	return &cfg.Autobundle, nil
 }
 
 // GetCapabilities returns capabilities for agent discovery (GET /v1/capabilities).
 func (s *service) GetCapabilities(ctx context.Context) (*domain.CapabilitiesResponse, error) {
+	cfg := s.config.Load()
 	out := &domain.CapabilitiesResponse{
 		CtrlDot: domain.CtrlDotCapabilities{
 			Version: "0.1.0",
@@ -439,10 +779,9 @@ func (s *service) GetCapabilities(ctx context.Context) (*domain.CapabilitiesResp
 			},
 		},
 	}
-	if s.config == nil {
+	if cfg == nil {
 		return out, nil
 	}
-	cfg := s.config
 
 	// Base URL from config
 	host := cfg.Server.Host
@@ -454,6 +793,7 @@ func (s *service) GetCapabilities(ctx context.Context) (*domain.CapabilitiesResp
 		port = 7777
 	}
 	out.CtrlDot.API.BaseURL = fmt.Sprintf("http://%s:%d", host, port)
+	out.CtrlDot.API.AuthRequired = cfg.Auth.Enabled
 	out.CtrlDot.RuntimeStore.Kind = cfg.RuntimeStore.Kind
 	if out.CtrlDot.RuntimeStore.Kind == "" {
 		out.CtrlDot.RuntimeStore.Kind = "sqlite"
@@ -468,9 +808,21 @@ func (s *service) GetCapabilities(ctx context.Context) (*domain.CapabilitiesResp
 	if cfg.LedgerSink.Bundle.OutputDir != "" {
 		out.CtrlDot.LedgerSink.BundleDir = expandPathForCapabilities(cfg.LedgerSink.Bundle.OutputDir)
 	}
+	if m, ok := s.ledgerSink.(interface {
+		Members() []domain.LedgerSinkMemberInfo
+	}); ok {
+		out.CtrlDot.LedgerSink.Members = m.Members()
+	}
+	if counts, err := s.runtimeStore.CountOutbox(ctx); err == nil {
+		pending, dead := counts[domain.OutboxStatusPending], counts[domain.OutboxStatusDead]
+		if slices.Contains(cfg.LedgerSink.Kinds(), "kernel_http") || pending > 0 || dead > 0 {
+			out.CtrlDot.LedgerSink.Outbox = &domain.LedgerOutboxInfo{Pending: pending, Dead: dead}
+		}
+	}
 
 	// Panic state from store
 	panicState, _ := s.runtimeStore.GetPanicState(ctx)
+	out.CtrlDot.Loop = s.loopDetector.EffectiveSettings(config.Effective(cfg, panicState))
 	if panicState != nil && panicState.Enabled {
 		out.CtrlDot.Panic.Enabled = true
 		out.CtrlDot.Panic.ExpiresAt = panicState.ExpiresAt
@@ -506,6 +858,7 @@ func expandPathForCapabilities(p string) string {
 
 // GetAgentLimits returns current budget/limits state for an agent (daily window).
 func (s *service) GetAgentLimits(ctx context.Context, agentID string) (*domain.AgentLimitsResponse, error) {
+	cfg := s.config.Load()
 	now := time.Now()
 	windowStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	windowStartTS := windowStart.Unix() * 1000
@@ -528,8 +881,8 @@ func (s *service) GetAgentLimits(ctx context.Context, agentID string) (*domain.A
 		HardStopPct:         1.00,
 		MaxIterationsPerAction: 25,
 	}
-	if s.config != nil {
-		defaults = s.config.Agents.Default
+	if cfg != nil {
+		defaults = cfg.Agents.Default
 	}
 	limit := defaults.DailyBudgetGBP
 	if limit <= 0 {
@@ -556,6 +909,7 @@ func (s *service) GetAgentLimits(ctx context.Context, agentID string) (*domain.A
 
 // GetLimitsConfig returns default limits from config (read-only).
 func (s *service) GetLimitsConfig(ctx context.Context) (*domain.LimitsConfigResponse, error) {
+	cfg := s.config.Load()
 	defaults := config.AgentDefaults{
 		DailyBudgetGBP:      10.0,
 		WarnPct:             []float64{0.70, 0.90},
@@ -563,8 +917,8 @@ func (s *service) GetLimitsConfig(ctx context.Context) (*domain.LimitsConfigResp
 		HardStopPct:         1.00,
 		MaxIterationsPerAction: 25,
 	}
-	if s.config != nil {
-		defaults = s.config.Agents.Default
+	if cfg != nil {
+		defaults = cfg.Agents.Default
 	}
 	return &domain.LimitsConfigResponse{
 		DailyBudgetGBP: defaults.DailyBudgetGBP,
@@ -585,6 +939,7 @@ func buildDecisionRecord(proposal domain.ActionProposal, response *domain.Decisi
 		SessionID:              proposal.SessionID,
 		Timestamp:              ev.TS,
 		ActionType:             proposal.Action.Type,
+		ActionTool:             proposal.Context.Tool,
 		ActionTarget:           sink.RedactMap(proposal.Action.Target),
 		ActionInputs:           sink.RedactMap(proposal.Action.Inputs),
 		Decision:               response.Decision,
@@ -595,5 +950,6 @@ func buildDecisionRecord(proposal domain.ActionProposal, response *domain.Decisi
 		BudgetLimit:            budgetLimit,
 		ActionHash:             proposal.Context.Hash,
 		ExecutionTokenPresent:  response.ExecutionToken != "",
+		ResolutionTokenPresent: proposal.ResolutionToken != "",
 	}
 }
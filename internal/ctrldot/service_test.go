@@ -0,0 +1,165 @@
+package ctrldot_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/ctrldot"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/ledger/sink/noop"
+	"github.com/futurematic/kernel/internal/limits"
+	"github.com/futurematic/kernel/internal/loop"
+	"github.com/futurematic/kernel/internal/resolution"
+	"github.com/futurematic/kernel/internal/rules"
+	"github.com/futurematic/kernel/internal/runtime"
+	"github.com/futurematic/kernel/internal/runtime/memory"
+)
+
+// newService returns a service over an in-memory store with agent-1 registered.
+func newService(t *testing.T, cfg *config.Config) (ctrldot.Service, *memory.Store) {
+	t.Helper()
+	st := memory.New(nil)
+	svc := ctrldot.NewService(st, limits.NewEngine(st, cfg), rules.NewEngine(cfg), loop.NewDetector(st, cfg),
+		resolution.NewManager(nil, ""), noop.New(), nil, cfg, nil)
+	if _, err := svc.RegisterAgent(context.Background(), "agent-1", "Agent", ""); err != nil {
+		t.Fatal(err)
+	}
+	return svc, st
+}
+
+func TestEvaluateActionsBatchIsDryRun(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Default.DailyBudgetGBP = 1
+	svc, st := newService(t, cfg)
+	ctx := context.Background()
+
+	step := domain.ActionProposal{AgentID: "agent-1", Action: domain.Action{Type: "tool.call"},
+		Cost: domain.CostEstimate{Currency: "GBP", EstimatedGBP: 0.4}}
+	for run := 0; run < 2; run++ {
+		res, err := svc.EvaluateActions(ctx, []domain.ActionProposal{step, step, step})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []domain.Decision
+		for _, d := range res.Decisions {
+			got = append(got, d.Decision)
+			if d.ExecutionToken != "" || d.LedgerEventID != "" {
+				t.Errorf("dry run issued a token or event: %+v", d)
+			}
+		}
+		// The third step takes the batch to 120% of the budget
+		if len(got) != 3 || got[0] != domain.DecisionAllow || got[1] != domain.DecisionAllow || got[2] != domain.DecisionStop {
+			t.Fatalf("run %d: decisions = %v, want ALLOW, ALLOW, STOP", run, got)
+		}
+		if res.CumulativeCostGBP < 0.79 || res.CumulativeCostGBP > 0.81 {
+			t.Errorf("cumulative cost = %v, want 0.8", res.CumulativeCostGBP)
+		}
+	}
+
+	events, err := st.ListEvents(ctx, runtime.EventFilter{Types: []string{domain.EventTypeDecisionIssued}})
+	if err != nil || len(events) != 0 {
+		t.Errorf("decision events after dry run = %d, %v; want none", len(events), err)
+	}
+	if limits, err := svc.GetAgentLimits(ctx, "agent-1"); err != nil || limits.SpentGBP != 0 || limits.ActionCount != 0 {
+		t.Errorf("limits after dry run = %+v, %v; want nothing spent", limits, err)
+	}
+}
+
+func TestConfigRevisionsAndRollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	original := []byte("agents:\n  default:\n    daily_budget_gbp: 5\n")
+	if err := os.WriteFile(path, original, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := memory.New(nil)
+	ctx := context.Background()
+	newSvc := func(cfg *config.Config) ctrldot.Service {
+		rulesEngine := rules.NewEngine(cfg)
+		reloader := config.NewReloader(path, cfg).Register(rulesEngine)
+		return ctrldot.NewService(st, limits.NewEngine(st, cfg), rulesEngine, loop.NewDetector(st, cfg),
+			resolution.NewManager(nil, ""), noop.New(), nil, cfg, reloader)
+	}
+	svc := newSvc(cfg)
+	if _, err := svc.RegisterAgent(ctx, "agent-1", "Agent", ""); err != nil {
+		t.Fatal(err)
+	}
+	lastDecisionRev := func() string {
+		t.Helper()
+		resp, err := svc.ProposeAction(ctx, domain.ActionProposal{AgentID: "agent-1", Action: domain.Action{Type: "tool.call"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		events, err := st.ListEvents(ctx, runtime.EventFilter{Types: []string{domain.EventTypeDecisionIssued}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range events {
+			if e.EventID == resp.LedgerEventID {
+				return fmt.Sprint(e.PayloadJSON["config_rev"])
+			}
+		}
+		t.Fatalf("decision event %s not found", resp.LedgerEventID)
+		return ""
+	}
+	if rev := lastDecisionRev(); rev != "1" {
+		t.Errorf("decision config_rev at startup = %s, want 1", rev)
+	}
+
+	if err := os.WriteFile(path, []byte("agents:\n  default:\n    daily_budget_gbp: 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	res, err := svc.ReloadConfig(ctx, "api", "alice")
+	if err != nil || res.Revision != 2 || len(res.Changes) == 0 {
+		t.Fatalf("reload = %+v, %v; want revision 2 with changes", res, err)
+	}
+	if rev := lastDecisionRev(); rev != "2" {
+		t.Errorf("decision config_rev after reload = %s, want 2", rev)
+	}
+
+	res, err = svc.RollbackConfig(ctx, 1, "bob")
+	if err != nil || res.Revision != 3 {
+		t.Fatalf("rollback = %+v, %v; want revision 3", res, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(original) {
+		t.Errorf("config file after rollback = %q, want %q", data, original)
+	}
+	if rev := lastDecisionRev(); rev != "3" {
+		t.Errorf("decision config_rev after rollback = %s, want 3", rev)
+	}
+	if _, err := svc.RollbackConfig(ctx, 99, ""); !errors.Is(err, ctrldot.ErrRevisionNotFound) {
+		t.Errorf("rollback to unknown revision: %v, want ErrRevisionNotFound", err)
+	}
+
+	revisions, err := svc.ListConfigRevisions(ctx, 10)
+	if err != nil || len(revisions) != 3 {
+		t.Fatalf("revisions = %d, %v; want 3", len(revisions), err)
+	}
+	if r := revisions[0]; r.Rev != 3 || r.Trigger != "rollback" || r.RollbackOf != 1 || r.Author != "bob" || r.ContentHash != revisions[2].ContentHash {
+		t.Errorf("latest revision = %+v, want rollback of 1 by bob with revision 1's hash", r)
+	}
+	if r := revisions[1]; r.Rev != 2 || r.Trigger != "api" || r.Author != "alice" {
+		t.Errorf("revision 2 = %+v, want api reload by alice", r)
+	}
+
+	// A restart over the same file reuses the latest revision
+	cfg, err = config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newSvc(cfg)
+	if cfg.Revision != 3 {
+		t.Errorf("revision after restart = %d, want 3", cfg.Revision)
+	}
+	if revisions, _ := svc.ListConfigRevisions(ctx, 10); len(revisions) != 3 {
+		t.Errorf("revisions after restart = %d, want 3", len(revisions))
+	}
+}
@@ -0,0 +1,137 @@
+package ctrldot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/loop"
+	"github.com/futurematic/kernel/internal/metrics"
+	"github.com/futurematic/kernel/internal/tracing"
+	"github.com/google/uuid"
+)
+
+// outcome is a combined decision and the config rule that produced it.
+type outcome struct {
+	Decision domain.Decision
+	Reason   string
+	Codes    []string
+	Rule     string
+}
+
+// evaluate combines the evaluator results into an outcome under cfg (the effective config).
+func evaluate(cfg *config.Config, proposal domain.ActionProposal, ruleDecision domain.Decision, ruleReason string, loopStop bool, limitDecision domain.Decision) outcome {
+	decision, reason := CombineDecision(ruleDecision, ruleReason, loopStop, limitDecision)
+	o := outcome{Decision: decision, Reason: reason, Codes: ReasonCodes(decision, reason)}
+	switch {
+	case ruleDecision == domain.DecisionDeny:
+		switch {
+		case strings.HasPrefix(ruleReason, "Requires resolution"):
+			o.Rule = "rules.require_resolution"
+		case strings.HasPrefix(ruleReason, "Filesystem"):
+			o.Rule = "rules.filesystem"
+		case strings.HasPrefix(ruleReason, "Network"):
+			o.Rule = "rules.network"
+		default:
+			o.Rule = "rules"
+		}
+	case loopStop:
+		o.Rule = "loop_detection"
+		if rule := loop.MatchRule(cfg, proposal); rule != nil {
+			for i := range cfg.LoopDetection.Rules {
+				if &cfg.LoopDetection.Rules[i] == rule {
+					o.Rule = fmt.Sprintf("loop_detection.rules[%d]", i)
+				}
+			}
+		}
+		if cfg != nil && cfg.Loop != nil {
+			o.Rule = "panic.loop"
+		}
+	case decision == domain.DecisionStop:
+		o.Rule = "agents.default.hard_stop_pct"
+	case decision == domain.DecisionThrottle:
+		o.Rule = "agents.default.throttle_pct"
+	case decision == domain.DecisionWarn:
+		o.Rule = "agents.default.warn_pct"
+	}
+	return o
+}
+
+// diverges reports whether the shadow outcome differs from the live one.
+func (o outcome) diverges(live outcome) bool {
+	return o.Decision != live.Decision || !slices.Equal(o.Codes, live.Codes)
+}
+
+// evaluateShadow runs the rules, loop and limits evaluators under cfg's shadow config (with the
+// panic overlay) and returns its outcome, or nil when shadow evaluation is disabled. It must run
+// before the live decision is recorded so both see the same history. It never fails: errors and
+// panics are logged and reported as no outcome.
+func (s *service) evaluateShadow(ctx context.Context, cfg *config.Config, proposal domain.ActionProposal, agent *domain.Agent, panicState *domain.PanicState) (o *outcome) {
+	shadowConfig := config.Shadow(cfg)
+	if shadowConfig == nil {
+		return nil
+	}
+	ctx, span := tracing.Start(ctx, "ctrldot.shadow")
+	defer span.End()
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("shadow evaluation panicked: %v", r)
+			log.Print(err)
+			span.RecordError(err)
+			o = nil
+		}
+	}()
+
+	start := time.Now()
+	effective := config.Effective(shadowConfig, panicState)
+	ruleDecision, ruleReason := s.rulesEngine.EvaluateWithConfig(ctx, proposal, effective)
+	loopStop := s.loopDetector.DetectWithConfig(ctx, proposal, effective)
+	limitDecision, _, _ := s.limitsEngine.EvaluateWithConfig(ctx, proposal, agent, effective)
+	shadow := evaluate(effective, proposal, ruleDecision, ruleReason, loopStop, limitDecision)
+	metrics.EvaluatorDuration.Observe(metrics.Since(start), metrics.EvaluatorShadow)
+	metrics.ShadowEvaluations.Inc()
+	span.SetAttributes(tracing.String("ctrldot.shadow.decision", string(shadow.Decision)), tracing.String("ctrldot.shadow.rule", shadow.Rule))
+	return &shadow
+}
+
+// recordShadowDivergence appends a decision.shadow_diverged event for the live decision event
+// decisionEventID when shadow differs from live. Failures are logged, not returned.
+func (s *service) recordShadowDivergence(ctx context.Context, proposal domain.ActionProposal, decisionEventID string, live outcome, shadow *outcome) {
+	if shadow == nil || !shadow.diverges(live) {
+		return
+	}
+	ruleLabel := shadow.Rule
+	if ruleLabel == "" {
+		ruleLabel = "none"
+	}
+	metrics.ShadowDivergences.Inc(string(live.Decision), string(shadow.Decision), ruleLabel)
+	event := domain.Event{
+		EventID:   "evt:" + uuid.New().String(),
+		TS:        time.Now(),
+		Type:      domain.EventTypeDecisionShadowDiverged,
+		AgentID:   proposal.AgentID,
+		SessionID: proposal.SessionID,
+		Severity:  domain.EventSeverityInfo,
+		PayloadJSON: map[string]interface{}{
+			"decision_event_id":   decisionEventID,
+			"action_type":         proposal.Action.Type,
+			"live_decision":       string(live.Decision),
+			"live_reason_codes":   live.Codes,
+			"live_rule":           live.Rule,
+			"shadow_decision":     string(shadow.Decision),
+			"shadow_reason":       shadow.Reason,
+			"shadow_reason_codes": shadow.Codes,
+			"shadow_rule":         shadow.Rule,
+		},
+		// No ActionHash: the loop detector counts events by action hash, so carrying it here
+		// would count each diverging proposal twice.
+	}
+	if err := s.appendEvent(ctx, &event); err != nil {
+		log.Printf("append %s event: %v", event.Type, err)
+	}
+}
@@ -0,0 +1,115 @@
+package ctrldot_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/runtime"
+)
+
+const shadowYAML = `
+rules:
+  filesystem:
+    allow_roots: []
+  network:
+    deny_all: false
+shadow:
+  enabled: true
+  rules:
+    filesystem:
+      allow_roots: ["/work"]
+`
+
+func TestShadowDivergence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(shadowYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The shadow section only overrides filesystem roots; the rest comes from the live rules
+	if cfg.Shadow.Rules == nil || cfg.Shadow.Rules.Network.DenyAll || len(cfg.Shadow.Rules.RequireResolution) != len(cfg.Rules.RequireResolution) {
+		t.Fatalf("shadow rules = %+v, want the live rules with allow_roots [/work]", cfg.Shadow.Rules)
+	}
+
+	ctx := context.Background()
+	svc, st := newService(t, cfg)
+	propose := func(path string) *domain.DecisionResponse {
+		resp, err := svc.ProposeAction(ctx, domain.ActionProposal{AgentID: "agent-1",
+			Action: domain.Action{Type: "filesystem.write", Target: map[string]interface{}{"path": path}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	resp := propose("/etc/hosts")
+	if resp.Decision != domain.DecisionAllow {
+		t.Fatalf("live decision = %s, want ALLOW (shadow must not affect it)", resp.Decision)
+	}
+	propose("/work/notes.txt")
+
+	events, err := st.ListEvents(ctx, runtime.EventFilter{Types: []string{domain.EventTypeDecisionShadowDiverged}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d shadow_diverged events, want 1 (only /etc/hosts diverges)", len(events))
+	}
+	p := events[0].PayloadJSON
+	if p["live_decision"] != "ALLOW" || p["shadow_decision"] != "DENY" || p["shadow_rule"] != "rules.filesystem" {
+		t.Errorf("payload = %v", p)
+	}
+	if p["decision_event_id"] != resp.LedgerEventID {
+		t.Errorf("divergence linked to the wrong decision event")
+	}
+}
+
+func TestShadowLeavesLoopCountsAlone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(shadowYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	decisions := func(shadow bool) []domain.Decision {
+		cfg, err := config.Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.Agents.Default.MaxIterationsPerAction = 4
+		cfg.Shadow.Enabled = shadow
+		svc, st := newService(t, cfg)
+		hash := "write-hosts"
+		var out []domain.Decision
+		// Every repeat diverges, so with shadow on each also records a decision.shadow_diverged
+		// event; the fifth repeat is the first to reach the loop limit either way
+		for i := 0; i < 6; i++ {
+			resp, err := svc.ProposeAction(ctx, domain.ActionProposal{AgentID: "agent-1",
+				Action:  domain.Action{Type: "filesystem.write", Target: map[string]interface{}{"path": "/etc/hosts"}},
+				Context: domain.ActionContext{Hash: hash}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, resp.Decision)
+		}
+		// Only decision.issued events carry the action hash loops are counted by
+		hashed, err := st.ListEvents(ctx, runtime.EventFilter{ActionHash: &hash})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hashed) != 6 {
+			t.Errorf("shadow %v: %d events carry the action hash, want the 6 decisions", shadow, len(hashed))
+		}
+		return out
+	}
+	without, with := decisions(false), decisions(true)
+	if fmt.Sprint(with) != fmt.Sprint(without) || without[3] != domain.DecisionAllow || without[4] != domain.DecisionStop {
+		t.Errorf("decisions with shadow = %v, without = %v; want them identical", with, without)
+	}
+}
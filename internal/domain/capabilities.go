@@ -16,6 +16,7 @@ type CtrlDotCapabilities struct {
 	RuntimeStore  RuntimeStoreInfo       `json:"runtime_store"`
 	LedgerSink    LedgerSinkInfo         `json:"ledger_sink"`
 	Panic         PanicCapabilities      `json:"panic"`
+	Loop          LoopSettingsInfo       `json:"loop"`
 	Features      FeaturesInfo           `json:"features"`
 }
 
@@ -25,10 +26,11 @@ type BuildInfo struct {
 	BuiltAt string `json:"built_at"`
 }
 
-// APIInfo describes the API base URL and version.
+// APIInfo describes the API base URL and version, and whether requests need a bearer token.
 type APIInfo struct {
-	BaseURL string `json:"base_url"`
-	Version string `json:"version"`
+	BaseURL      string `json:"base_url"`
+	Version      string `json:"version"`
+	AuthRequired bool   `json:"auth_required"`
 }
 
 // RuntimeStoreInfo describes where runtime state is stored.
@@ -39,8 +41,26 @@ type RuntimeStoreInfo struct {
 
 // LedgerSinkInfo describes where decision records are emitted.
 type LedgerSinkInfo struct {
-	Kind      string `json:"kind"`
-	BundleDir string `json:"bundle_dir,omitempty"`
+	Kind      string                 `json:"kind"`
+	BundleDir string                 `json:"bundle_dir,omitempty"`
+	Members   []LedgerSinkMemberInfo `json:"members,omitempty"` // kind == "multi"
+	Outbox    *LedgerOutboxInfo      `json:"outbox,omitempty"`  // kernel_http
+}
+
+// LedgerOutboxInfo counts decision records in the runtime store outbox.
+type LedgerOutboxInfo struct {
+	Pending int64 `json:"pending"`
+	Dead    int64 `json:"dead"`
+}
+
+// LedgerSinkMemberInfo is a point-in-time view of one multi sink member.
+type LedgerSinkMemberInfo struct {
+	Name       string `json:"name"`
+	Required   bool   `json:"required"`
+	QueueSize  int    `json:"queue_size,omitempty"`
+	QueueDepth int    `json:"queue_depth"`
+	OnFull     string `json:"on_full,omitempty"`
+	Dropped    int64  `json:"dropped"`
 }
 
 // PanicCapabilities describes current panic state and effective overlay when enabled.
@@ -64,6 +84,22 @@ type LoopInfo struct {
 	StopRepeats   int `json:"stop_repeats"`
 }
 
+// LoopSettingsInfo describes the effective loop detection settings (panic overlay applied).
+type LoopSettingsInfo struct {
+	Default   LoopInfo       `json:"default"`
+	SafetyNet *LoopInfo      `json:"safety_net,omitempty"`
+	Rules     []LoopRuleInfo `json:"rules,omitempty"`
+}
+
+// LoopRuleInfo describes a per-action-type loop rule.
+type LoopRuleInfo struct {
+	ActionType    string `json:"action_type,omitempty"`
+	Tool          string `json:"tool,omitempty"`
+	WindowSeconds int    `json:"window_seconds,omitempty"`
+	StopRepeats   int    `json:"stop_repeats,omitempty"`
+	Exempt        bool   `json:"exempt,omitempty"`
+}
+
 // FeaturesInfo describes which features are enabled.
 type FeaturesInfo struct {
 	ResolutionTokens bool `json:"resolution_tokens"`
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// ConfigRevision is a config file the daemon applied. Revisions are numbered from 1 in the
+// order they were applied; decision events record the revision they were decided under.
+type ConfigRevision struct {
+	Rev         int64     `json:"rev"`
+	ContentHash string    `json:"content_hash"` // sha256:<hex> of the file contents
+	Author      string    `json:"author,omitempty"`
+	Trigger     string    `json:"trigger"`               // startup | watch | sighup | api | rollback
+	RollbackOf  int64     `json:"rollback_of,omitempty"` // the revision restored, for trigger rollback
+	Changes     []string  `json:"changes"`               // settings changed from the previous revision, secrets redacted
+	Content     string    `json:"-"`                     // the file contents, including secrets
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CtrlDotDecisionRecord is a decision record a Ctrl Dot daemon delivered to Kernel
+// (POST /v1/ctrldot/decisions, ledger_sink kind kernel_http). The indexed fields are
+// copied out of Record, which is kept exactly as received.
+type CtrlDotDecisionRecord struct {
+	ID         string          `json:"id"`
+	AgentID    string          `json:"agent_id"`
+	SessionID  string          `json:"session_id,omitempty"`
+	Decision   string          `json:"decision"`
+	ActionType string          `json:"action_type"`
+	Source     string          `json:"source,omitempty"` // sending daemon (X-Ctrldot-Source), if given
+	Timestamp  time.Time       `json:"timestamp"`
+	ReceivedAt time.Time       `json:"received_at"`
+	Record     json.RawMessage `json:"record"`
+}
@@ -25,6 +25,18 @@ type DecisionResponse struct {
 	AutobundleTrigger string          `json:"autobundle_trigger,omitempty"`
 }
 
+// EvaluateRequest is the body of POST /v1/actions/evaluate for a batch of proposals.
+type EvaluateRequest struct {
+	Proposals []ActionProposal `json:"proposals"`
+}
+
+// EvaluateResponse is the result of a dry-run evaluation, one decision per proposal in order.
+// Decisions carry no execution token or ledger event ID: nothing was recorded.
+type EvaluateResponse struct {
+	Decisions         []DecisionResponse `json:"decisions"`
+	CumulativeCostGBP float64            `json:"cumulative_cost_gbp"` // estimated cost of the proposals that would be allowed
+}
+
 // Warning represents a warning message
 type Warning struct {
 	Code    string `json:"code"`
@@ -32,4 +32,5 @@ var (
 	ErrResolutionRequired        = errors.New("resolution required before apply")
 	ErrResolutionNotFound        = errors.New("resolution not found")
 	ErrProposalSetNotFound       = errors.New("proposal set not found")
+	ErrCtrlDotDecisionNotFound   = errors.New("decision record not found")
 )
@@ -14,20 +14,31 @@ type Event struct {
 	ActionHash  string                 `json:"action_hash,omitempty"`
 	CostGBP     *float64               `json:"cost_gbp,omitempty"`
 	CostTokens  *int64                 `json:"cost_tokens,omitempty"`
+	// Hash chain position (runtime log only; zero for Kernel operation events and events written before chaining)
+	ChainSeq    int64                  `json:"chain_seq,omitempty"`
+	PrevHash    string                 `json:"prev_hash,omitempty"`
+	Hash        string                 `json:"hash,omitempty"`
 }
 
 // Event types
 const (
 	EventTypeAgentRegistered    = "agent.registered"
 	EventTypeSessionStarted     = "session.started"
+	EventTypeSessionEnded       = "session.ended"
 	EventTypeActionProposed     = "action.proposed"
 	EventTypeDecisionIssued     = "decision.issued"
+	EventTypeDecisionShadowDiverged = "decision.shadow_diverged"
 	EventTypeLimitWarning       = "limit.warning"
 	EventTypeLimitThrottleApplied = "limit.throttle_applied"
 	EventTypeLimitExceeded      = "limit.exceeded"
 	EventTypeAgentHalted        = "agent.halted"
+	EventTypeAgentResumed       = "agent.resumed"
+	EventTypePanicEnabled       = "panic.enabled"
+	EventTypePanicDisabled      = "panic.disabled"
 	EventTypeRuleBlocked        = "rule.blocked"
 	EventTypeLoopDetected       = "loop.detected"
+	EventTypeEventsCompacted    = "events.compacted"
+	EventTypeConfigReloaded     = "config.reloaded"
 )
 
 // Event severity levels
@@ -0,0 +1,13 @@
+package domain
+
+// EventChainHead is the state of the runtime event hash chain. Each chained event stores the
+// hash of its predecessor; compaction prunes a prefix of the chain and records the last pruned
+// event as the anchor that verification starts from.
+type EventChainHead struct {
+	HeadSeq    int64  `json:"head_seq"`
+	HeadHash   string `json:"head_hash,omitempty"`
+	AnchorSeq  int64  `json:"anchor_seq,omitempty"`
+	AnchorHash string `json:"anchor_hash,omitempty"`
+	// Unchained counts events without a chain position (written before chaining or by Kernel operations)
+	Unchained int64 `json:"unchained"`
+}
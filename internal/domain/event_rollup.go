@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+// EventRollup is an hourly per-agent aggregate of runtime events, kept after the raw events are compacted.
+type EventRollup struct {
+	AgentID    string    `json:"agent_id"`
+	HourStart  time.Time `json:"hour_start"`
+	EventType  string    `json:"event_type"`
+	Decision   string    `json:"decision,omitempty"` // set for decision.issued events
+	EventCount int64     `json:"event_count"`
+	CostGBP    float64   `json:"cost_gbp"`
+	CostTokens int64     `json:"cost_tokens"`
+}
+
+// EventCompaction is the result of one compaction run over the runtime event log.
+type EventCompaction struct {
+	Cutoff          *time.Time `json:"cutoff,omitempty"` // events older than this were pruned (retention_days)
+	MaxRows         int        `json:"max_rows,omitempty"`
+	Deleted         int64      `json:"deleted"`
+	RollupsUpserted int64      `json:"rollups_upserted"`
+	DurationMs      int64      `json:"duration_ms"`
+	// Chain anchor after the run: seq and hash of the newest pruned chained event (0 = nothing pruned yet)
+	AnchorSeq  int64  `json:"chain_anchor_seq,omitempty"`
+	AnchorHash string `json:"chain_anchor_hash,omitempty"`
+}
+
+// Record adds the run's results to the payload of its events.compacted event e. The chain
+// anchor is included once set, so the chained event vouches for where verification starts.
+func (c *EventCompaction) Record(e *Event) {
+	if e.PayloadJSON == nil {
+		e.PayloadJSON = map[string]interface{}{}
+	}
+	e.PayloadJSON["deleted"] = c.Deleted
+	e.PayloadJSON["rollups_upserted"] = c.RollupsUpserted
+	e.PayloadJSON["max_rows"] = c.MaxRows
+	e.PayloadJSON["duration_ms"] = c.DurationMs
+	if c.Cutoff != nil {
+		e.PayloadJSON["cutoff"] = c.Cutoff.UTC().Format(time.RFC3339)
+	}
+	if c.AnchorSeq > 0 {
+		e.PayloadJSON["chain_anchor_seq"] = c.AnchorSeq
+		e.PayloadJSON["chain_anchor_hash"] = c.AnchorHash
+	}
+}
+
+// EventStats summarises the runtime event log (for ctrldot doctor).
+type EventStats struct {
+	EventCount     int64      `json:"event_count"`
+	OldestEventAt  *time.Time `json:"oldest_event_at,omitempty"`
+	RollupCount    int64      `json:"rollup_count"`
+	LastCompaction *Event     `json:"last_compaction,omitempty"`
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// OutboxRecord is a ledger record that a sink could not deliver yet. Records are delivered
+// in Seq order per sink; RecordID doubles as the idempotency key so the receiver can dedupe.
+type OutboxRecord struct {
+	Seq           int64     `json:"seq"`
+	Sink          string    `json:"sink"`      // e.g. kernel_http
+	RecordID      string    `json:"record_id"` // DecisionRecord.ID
+	Payload       string    `json:"payload"`   // JSON body, fixed at enqueue time
+	Status        string    `json:"status"`    // pending | dead
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastError     string    `json:"last_error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Outbox statuses. Delivered records are removed; dead records were rejected as malformed.
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusDead    = "dead"
+)
@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+// WebhookDelivery is one queued outbound webhook request (one event for one endpoint).
+// Body is rendered when the event is enqueued, so retries send identical payloads.
+type WebhookDelivery struct {
+	DeliveryID     string    `json:"delivery_id"`
+	Endpoint       string    `json:"endpoint"` // webhooks.endpoints[].name
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Body           string    `json:"body"`
+	Status         string    `json:"status"` // pending | dead
+	Attempts       int       `json:"attempts"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	LastError      string    `json:"last_error,omitempty"`
+	LastStatusCode int       `json:"last_status_code,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Webhook delivery statuses. Delivered requests are removed from the queue.
+const (
+	WebhookStatusPending = "pending"
+	WebhookStatusDead    = "dead"
+)
+
+// WebhookTestResult is the outcome of sending a test payload to one endpoint.
+type WebhookTestResult struct {
+	Endpoint   string `json:"endpoint"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// WebhooksStatus summarises configured endpoints and the delivery queue.
+type WebhooksStatus struct {
+	Endpoints []WebhookEndpointInfo `json:"endpoints"`
+	Pending   int64                 `json:"pending"`
+	Dead      int64                 `json:"dead"`
+}
+
+// WebhookEndpointInfo describes a configured endpoint (no secrets).
+type WebhookEndpointInfo struct {
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	Format     string   `json:"format"`
+	Events     []string `json:"events,omitempty"`
+	Decisions  []string `json:"decisions,omitempty"`
+	Severities []string `json:"severities,omitempty"`
+	Signed     bool     `json:"signed"`
+}
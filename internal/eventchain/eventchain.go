@@ -0,0 +1,247 @@
+// Package eventchain hashes runtime events into a per-daemon chain and verifies it.
+//
+// Each chained event stores prev_hash (the hash of the event before it, "" for the first)
+// and hash, the SHA-256 of its canonical JSON, which covers chain_seq and prev_hash. Editing,
+// deleting or reordering rows breaks the chain at the first affected event. Compaction prunes
+// a prefix of the chain; the store keeps the last pruned event as the anchor verification
+// starts from.
+package eventchain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+// canonicalEvent fixes the field order and representation that is hashed.
+type canonicalEvent struct {
+	ChainSeq   int64       `json:"chain_seq"`
+	PrevHash   string      `json:"prev_hash"`
+	EventID    string      `json:"event_id"`
+	TS         string      `json:"ts"`
+	Type       string      `json:"type"`
+	AgentID    string      `json:"agent_id"`
+	SessionID  string      `json:"session_id"`
+	Severity   string      `json:"severity"`
+	Payload    interface{} `json:"payload"`
+	ActionHash string      `json:"action_hash"`
+	CostGBP    *float64    `json:"cost_gbp"`
+	CostTokens *int64      `json:"cost_tokens"`
+}
+
+// Canonical returns the bytes hashed for e. The timestamp is UTC with second precision and the
+// payload is normalised through a JSON round trip, so the result is the same before the event
+// is stored and after it is read back from SQLite or Postgres.
+func Canonical(e domain.Event) ([]byte, error) {
+	var payload interface{} = map[string]interface{}{}
+	if len(e.PayloadJSON) > 0 {
+		raw, err := json.Marshal(e.PayloadJSON)
+		if err != nil {
+			return nil, fmt.Errorf("marshal payload: %w", err)
+		}
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return nil, fmt.Errorf("normalise payload: %w", err)
+		}
+	}
+	return json.Marshal(canonicalEvent{
+		ChainSeq:   e.ChainSeq,
+		PrevHash:   e.PrevHash,
+		EventID:    e.EventID,
+		TS:         e.TS.UTC().Truncate(time.Second).Format(time.RFC3339),
+		Type:       e.Type,
+		AgentID:    e.AgentID,
+		SessionID:  e.SessionID,
+		Severity:   e.Severity,
+		Payload:    payload,
+		ActionHash: e.ActionHash,
+		CostGBP:    e.CostGBP,
+		CostTokens: e.CostTokens,
+	})
+}
+
+// Hash returns the hex SHA-256 of e's canonical form.
+func Hash(e domain.Event) (string, error) {
+	b, err := Canonical(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Link sets e's chain position after (prevSeq, prevHash) and computes its hash.
+func Link(e *domain.Event, prevSeq int64, prevHash string) error {
+	e.ChainSeq = prevSeq + 1
+	e.PrevHash = prevHash
+	h, err := Hash(*e)
+	if err != nil {
+		return err
+	}
+	e.Hash = h
+	return nil
+}
+
+// Source is the part of the runtime store that verification reads.
+type Source interface {
+	EventChainHead(ctx context.Context) (*domain.EventChainHead, error)
+	// ListChainedEvents returns chained events with chain_seq > afterSeq, in chain order.
+	ListChainedEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.Event, error)
+	EventStats(ctx context.Context) (*domain.EventStats, error)
+}
+
+// Break describes the first broken link found by Verify.
+type Break struct {
+	Seq     int64  `json:"seq"`
+	EventID string `json:"event_id,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// Result is the outcome of Verify.
+type Result struct {
+	OK         bool                  `json:"ok"`
+	Head       domain.EventChainHead `json:"head"`
+	Checked    int64                 `json:"checked"`
+	Break      *Break                `json:"break,omitempty"`
+	VerifiedAt time.Time             `json:"verified_at"`
+}
+
+const pageSize = 1000
+
+// Verify walks the chain from the anchor to the head and reports the first broken link.
+// Errors are returned only when the store cannot be read.
+func Verify(ctx context.Context, src Source) (*Result, error) {
+	head, err := src.EventChainHead(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read chain head: %w", err)
+	}
+	res := &Result{Head: *head, VerifiedAt: time.Now().UTC()}
+	fail := func(seq int64, eventID, format string, args ...interface{}) (*Result, error) {
+		res.Break = &Break{Seq: seq, EventID: eventID, Reason: fmt.Sprintf(format, args...)}
+		return res, nil
+	}
+
+	if head.AnchorSeq > 0 {
+		if brk, err := checkAnchor(ctx, src, head); err != nil {
+			return nil, err
+		} else if brk != nil {
+			res.Break = brk
+			return res, nil
+		}
+	}
+
+	seq, hash := head.AnchorSeq, head.AnchorHash
+	for {
+		events, err := src.ListChainedEvents(ctx, seq, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("list chained events: %w", err)
+		}
+		for _, e := range events {
+			if e.ChainSeq != seq+1 {
+				return fail(seq+1, "", "events %d..%d are missing", seq+1, e.ChainSeq-1)
+			}
+			if e.PrevHash != hash {
+				return fail(e.ChainSeq, e.EventID, "prev_hash does not match the hash of event %d", seq)
+			}
+			got, err := Hash(e)
+			if err != nil {
+				return fail(e.ChainSeq, e.EventID, "cannot hash event: %v", err)
+			}
+			if got != e.Hash {
+				return fail(e.ChainSeq, e.EventID, "hash mismatch: event was modified after it was written")
+			}
+			seq, hash = e.ChainSeq, e.Hash
+			res.Checked++
+		}
+		if len(events) < pageSize {
+			break
+		}
+	}
+
+	switch {
+	case seq < head.HeadSeq:
+		return fail(seq+1, "", "events %d..%d are missing (chain head is %d)", seq+1, head.HeadSeq, head.HeadSeq)
+	case seq > head.HeadSeq:
+		return fail(head.HeadSeq+1, "", "events beyond the recorded chain head %d", head.HeadSeq)
+	case hash != head.HeadHash:
+		return fail(seq, "", "last event hash does not match the recorded chain head")
+	}
+	res.OK = true
+	return res, nil
+}
+
+// checkAnchor cross-checks the stored anchor against the latest events.compacted event, which
+// is itself chained, so the anchor cannot be moved to hide a deleted prefix.
+func checkAnchor(ctx context.Context, src Source, head *domain.EventChainHead) (*Break, error) {
+	stats, err := src.EventStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("event stats: %w", err)
+	}
+	if stats.LastCompaction == nil {
+		return &Break{Seq: head.AnchorSeq, Reason: "chain anchor is set but no events.compacted event records it"}, nil
+	}
+	c := stats.LastCompaction
+	anchorSeq, _ := c.PayloadJSON["chain_anchor_seq"].(float64)
+	anchorHash, _ := c.PayloadJSON["chain_anchor_hash"].(string)
+	if int64(anchorSeq) != head.AnchorSeq || anchorHash != head.AnchorHash {
+		return &Break{Seq: head.AnchorSeq, EventID: c.EventID,
+			Reason: fmt.Sprintf("chain anchor %d does not match the last compaction (anchor %d)", head.AnchorSeq, int64(anchorSeq))}, nil
+	}
+	return nil, nil
+}
+
+// Checkpoint is a chain head recorded in a signed bundle manifest, for offline verification.
+type Checkpoint struct {
+	Seq  int64     `json:"seq"`
+	Hash string    `json:"hash"`
+	At   time.Time `json:"at"`
+}
+
+// CheckpointFrom returns the current chain head of src as a checkpoint (nil when the chain is empty).
+func CheckpointFrom(ctx context.Context, src interface {
+	EventChainHead(ctx context.Context) (*domain.EventChainHead, error)
+}) (*Checkpoint, error) {
+	head, err := src.EventChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if head.HeadSeq == 0 {
+		return nil, nil
+	}
+	return &Checkpoint{Seq: head.HeadSeq, Hash: head.HeadHash, At: time.Now().UTC()}, nil
+}
+
+// VerifyCheckpoint checks that the event at cp.Seq still has the checkpointed hash. A checkpoint
+// older than the anchor can only be checked when it is the anchor itself.
+func VerifyCheckpoint(ctx context.Context, src Source, cp Checkpoint) (*Break, error) {
+	head, err := src.EventChainHead(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read chain head: %w", err)
+	}
+	switch {
+	case cp.Seq > head.HeadSeq:
+		return &Break{Seq: cp.Seq, Reason: fmt.Sprintf("checkpoint is beyond the chain head %d (events were removed)", head.HeadSeq)}, nil
+	case cp.Seq == head.AnchorSeq:
+		if cp.Hash != head.AnchorHash {
+			return &Break{Seq: cp.Seq, Reason: "checkpoint hash does not match the chain anchor"}, nil
+		}
+		return nil, nil
+	case cp.Seq < head.AnchorSeq:
+		return nil, nil // compacted away; the anchor check in Verify covers the pruned prefix
+	}
+	events, err := src.ListChainedEvents(ctx, cp.Seq-1, 1)
+	if err != nil {
+		return nil, fmt.Errorf("list chained events: %w", err)
+	}
+	if len(events) == 0 || events[0].ChainSeq != cp.Seq {
+		return &Break{Seq: cp.Seq, Reason: "checkpointed event is missing"}, nil
+	}
+	if events[0].Hash != cp.Hash {
+		return &Break{Seq: cp.Seq, EventID: events[0].EventID, Reason: "event hash differs from the signed checkpoint"}, nil
+	}
+	return nil, nil
+}
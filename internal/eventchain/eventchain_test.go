@@ -0,0 +1,169 @@
+package eventchain_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/eventchain"
+	"github.com/futurematic/kernel/internal/runtime/compactor"
+	"github.com/futurematic/kernel/internal/runtime/sqlite"
+	"github.com/google/uuid"
+)
+
+func TestHashIsStableAcrossStorage(t *testing.T) {
+	cost := 0.25
+	e := domain.Event{EventID: "evt:1", TS: time.Date(2026, 1, 2, 3, 4, 5, 600, time.FixedZone("x", 3600)), Type: "decision.issued",
+		AgentID: "a", Severity: "info", PayloadJSON: map[string]interface{}{"n": 3, "decision": "ALLOW"}, CostGBP: &cost}
+	if err := eventchain.Link(&e, 4, "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if e.ChainSeq != 5 || e.PrevHash != "abc" || len(e.Hash) != 64 {
+		t.Fatalf("Link = %d %q %q", e.ChainSeq, e.PrevHash, e.Hash)
+	}
+	// As read back: UTC, second precision, numbers decoded as float64
+	stored := e
+	stored.TS = time.Date(2026, 1, 2, 2, 4, 5, 0, time.UTC)
+	stored.PayloadJSON = map[string]interface{}{"decision": "ALLOW", "n": float64(3)}
+	if h, _ := eventchain.Hash(stored); h != e.Hash {
+		t.Errorf("hash after storage = %s, want %s", h, e.Hash)
+	}
+	stored.Severity = "warn"
+	if h, _ := eventchain.Hash(stored); h == e.Hash {
+		t.Error("hash did not change when the event changed")
+	}
+}
+
+func openStore(t *testing.T) (*sqlite.Store, *sql.DB) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ctrldot.sqlite")
+	st, err := sqlite.Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+	raw, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { raw.Close() })
+	return st, raw
+}
+
+func appendEvents(t *testing.T, st *sqlite.Store, n int, ts time.Time) []domain.Event {
+	t.Helper()
+	var out []domain.Event
+	for i := 0; i < n; i++ {
+		e := domain.Event{EventID: "evt:" + uuid.New().String(), TS: ts, Type: domain.EventTypeActionProposed, AgentID: "agent-1",
+			Severity: domain.EventSeverityInfo, PayloadJSON: map[string]interface{}{"i": i}}
+		if err := st.AppendEvent(context.Background(), &e); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func verify(t *testing.T, st *sqlite.Store) *eventchain.Result {
+	t.Helper()
+	res, err := eventchain.Verify(context.Background(), st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestVerify(t *testing.T) {
+	st, _ := openStore(t)
+	if res := verify(t, st); !res.OK || res.Checked != 0 {
+		t.Fatalf("empty chain = %+v", res)
+	}
+	events := appendEvents(t, st, 5, time.Now())
+	res := verify(t, st)
+	if !res.OK || res.Checked != 5 || res.Head.HeadSeq != 5 || res.Head.HeadHash != events[4].Hash {
+		t.Fatalf("Verify = %+v", res)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		sql     string
+		seq     int64
+		pattern string
+	}{
+		{"edit", `UPDATE ctrldot_events SET severity = 'warn' WHERE chain_seq = 3`, 3, "hash mismatch"},
+		{"delete", `DELETE FROM ctrldot_events WHERE chain_seq = 2`, 2, "missing"},
+		{"truncate", `DELETE FROM ctrldot_events WHERE chain_seq >= 4`, 4, "missing"},
+		{"rehash", `UPDATE ctrldot_events SET hash = 'x' WHERE chain_seq = 3`, 3, "hash mismatch"},
+		{"head", `UPDATE ctrldot_event_chain SET head_seq = 4`, 5, "beyond"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st, raw := openStore(t)
+			appendEvents(t, st, 5, time.Now())
+			if _, err := raw.Exec(tc.sql); err != nil {
+				t.Fatal(err)
+			}
+			res := verify(t, st)
+			if res.OK || res.Break == nil || res.Break.Seq != tc.seq || !strings.Contains(res.Break.Reason, tc.pattern) {
+				t.Errorf("Verify = %+v, break %+v; want break at %d (%s)", res, res.Break, tc.seq, tc.pattern)
+			}
+		})
+	}
+}
+
+func TestVerifyAfterCompaction(t *testing.T) {
+	st, raw := openStore(t)
+	old := appendEvents(t, st, 3, time.Now().AddDate(0, 0, -10))
+	appendEvents(t, st, 2, time.Now())
+
+	cfg := config.DefaultConfig()
+	cfg.Events.RetentionDays = 7
+	result, err := compactor.New(st, cfg).RunOnce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Deleted != 3 || result.AnchorSeq != 3 || result.AnchorHash != old[2].Hash {
+		t.Fatalf("compaction = %+v", result)
+	}
+	if res := verify(t, st); !res.OK || res.Checked != 3 {
+		t.Fatalf("Verify after compaction = %+v, break %+v", res, res.Break)
+	}
+
+	// Moving the anchor to hide deleted events is caught by the chained compaction record
+	if _, err := raw.Exec(`DELETE FROM ctrldot_events WHERE chain_seq = 4`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.Exec(`UPDATE ctrldot_event_chain SET anchor_seq = 4, anchor_hash = (SELECT prev_hash FROM ctrldot_events WHERE chain_seq = 5)`); err != nil {
+		t.Fatal(err)
+	}
+	if res := verify(t, st); res.OK || !strings.Contains(res.Break.Reason, "anchor") {
+		t.Errorf("Verify with moved anchor = %+v, break %+v", res, res.Break)
+	}
+}
+
+func TestVerifyCheckpoint(t *testing.T) {
+	st, raw := openStore(t)
+	appendEvents(t, st, 3, time.Now())
+	cp, err := eventchain.CheckpointFrom(context.Background(), st)
+	if err != nil || cp == nil || cp.Seq != 3 {
+		t.Fatalf("CheckpointFrom = %+v, %v", cp, err)
+	}
+	appendEvents(t, st, 2, time.Now())
+	if brk, err := eventchain.VerifyCheckpoint(context.Background(), st, *cp); err != nil || brk != nil {
+		t.Fatalf("VerifyCheckpoint = %+v, %v", brk, err)
+	}
+
+	// Rewriting the whole chain consistently still contradicts the signed checkpoint
+	if _, err := raw.Exec(`UPDATE ctrldot_events SET hash = 'rewritten' WHERE chain_seq = 3`); err != nil {
+		t.Fatal(err)
+	}
+	if brk, err := eventchain.VerifyCheckpoint(context.Background(), st, *cp); err != nil || brk == nil || brk.Seq != 3 {
+		t.Errorf("VerifyCheckpoint after rewrite = %+v, %v", brk, err)
+	}
+}
@@ -0,0 +1,194 @@
+package kernel_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/store"
+)
+
+// memStore is an in-memory store.Store covering what the kernel service, policy engine
+// and planner use. Methods it does not implement panic via the nil embedded interface.
+type memStore struct {
+	store.Store
+
+	mu           sync.Mutex
+	ops          []domain.Operation
+	plans        map[string]domain.Plan
+	applied      map[string]int64
+	nodes        map[string]domain.Node
+	links        map[string]domain.Link
+	policySet    *domain.PolicySet
+	proposalSets map[string]domain.ProposalSet
+	resolutions  map[string]domain.Resolution
+	decisions    map[string]domain.CtrlDotDecisionRecord
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		plans:        map[string]domain.Plan{},
+		applied:      map[string]int64{},
+		nodes:        map[string]domain.Node{},
+		links:        map[string]domain.Link{},
+		proposalSets: map[string]domain.ProposalSet{},
+		resolutions:  map[string]domain.Resolution{},
+		decisions:    map[string]domain.CtrlDotDecisionRecord{},
+	}
+}
+
+func (s *memStore) OpenTx(ctx context.Context) (store.Tx, error) {
+	return &memTx{s: s}, nil
+}
+
+func (s *memStore) GetNextSeq(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.ops)) + 1, nil
+}
+
+func (s *memStore) ResolveAsOf(ctx context.Context, asof domain.AsOf) (int64, error) {
+	if asof.Seq != nil {
+		return *asof.Seq, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.ops)), nil
+}
+
+func (s *memStore) GetActivePolicySet(ctx context.Context, namespaceID string) (*domain.PolicySet, error) {
+	return s.policySet, nil
+}
+
+func (s *memStore) GetProposalSet(ctx context.Context, id string) (*domain.ProposalSet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, ok := s.proposalSets[id]
+	if !ok {
+		return nil, domain.ErrProposalSetNotFound
+	}
+	return &ps, nil
+}
+
+func (s *memStore) GetResolution(ctx context.Context, id string) (*domain.Resolution, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.resolutions[id]
+	if !ok {
+		return nil, domain.ErrResolutionNotFound
+	}
+	return &r, nil
+}
+
+func (s *memStore) GetResolutionForProposalSet(ctx context.Context, proposalSetID string) (*domain.Resolution, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.resolutions {
+		if r.ProposalSetID == proposalSetID {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memStore) StoreCtrlDotDecision(ctx context.Context, r domain.CtrlDotDecisionRecord) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.decisions[r.ID]; ok {
+		return false, nil
+	}
+	s.decisions[r.ID] = r
+	return true, nil
+}
+
+// memTx reads committed state and buffers writes until Commit.
+type memTx struct {
+	store.Tx
+
+	s       *memStore
+	pending []func()
+}
+
+func (t *memTx) write(f func()) error {
+	t.pending = append(t.pending, f)
+	return nil
+}
+
+func (t *memTx) Commit() error {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	for _, f := range t.pending {
+		f()
+	}
+	t.pending = nil
+	return nil
+}
+
+func (t *memTx) Rollback() error {
+	t.pending = nil
+	return nil
+}
+
+func (t *memTx) AppendOperation(ctx context.Context, op domain.Operation) error {
+	return t.write(func() { t.s.ops = append(t.s.ops, op) })
+}
+
+func (t *memTx) CreateNode(ctx context.Context, node domain.Node, seq int64) error {
+	return t.write(func() { t.s.nodes[node.ID] = node })
+}
+
+func (t *memTx) CreateLink(ctx context.Context, link domain.Link, seq int64) error {
+	return t.write(func() { t.s.links[link.ID] = link })
+}
+
+func (t *memTx) GetLinksTo(ctx context.Context, toNodeID string, namespaceID *string, linkType *string, asofSeq int64) ([]domain.Link, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	var out []domain.Link
+	for _, l := range t.s.links {
+		if l.ToNodeID == toNodeID && (linkType == nil || l.Type == *linkType) {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func (t *memTx) StorePlan(ctx context.Context, plan domain.Plan, policyHash string) error {
+	return t.write(func() { t.s.plans[plan.ID] = plan })
+}
+
+func (t *memTx) GetPlan(ctx context.Context, planID string) (*domain.Plan, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	p, ok := t.s.plans[planID]
+	if !ok {
+		return nil, errors.New("plan not found")
+	}
+	return &p, nil
+}
+
+func (t *memTx) IsPlanApplied(ctx context.Context, planID string) (bool, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	_, ok := t.s.applied[planID]
+	return ok, nil
+}
+
+func (t *memTx) MarkPlanApplied(ctx context.Context, planID string, opID string, seq int64) error {
+	if applied, _ := t.IsPlanApplied(ctx, planID); applied {
+		return fmt.Errorf("plan %s already applied", planID)
+	}
+	return t.write(func() { t.s.applied[planID] = seq })
+}
+
+func (t *memTx) StoreProposalSet(ctx context.Context, ps *domain.ProposalSet) error {
+	cp := *ps
+	return t.write(func() { t.s.proposalSets[cp.ProposalSetID] = cp })
+}
+
+func (t *memTx) StoreResolution(ctx context.Context, r *domain.Resolution) error {
+	cp := *r
+	return t.write(func() { t.s.resolutions[cp.ResolutionID] = cp })
+}
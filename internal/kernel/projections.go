@@ -0,0 +1,180 @@
+package kernel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/store"
+	"github.com/google/uuid"
+)
+
+// applyChange writes the projection for one expanded change at op.Seq. Change payloads
+// use the JSON field names of the domain types, so they are decoded by round-tripping.
+func applyChange(ctx context.Context, tx store.Tx, change domain.Change, op domain.Operation) error {
+	switch change.Kind {
+	case domain.ChangeCreateNode:
+		var node domain.Node
+		if err := decodePayload(change.Payload, &node); err != nil {
+			return err
+		}
+		if err := node.Validate(); err != nil {
+			return err
+		}
+		return tx.CreateNode(ctx, node, op.Seq)
+	case domain.ChangeCreateLink:
+		var link domain.Link
+		if err := decodePayload(change.Payload, &link); err != nil {
+			return err
+		}
+		if link.NamespaceID == nil {
+			link.NamespaceID = change.NamespaceID
+		}
+		if err := link.Validate(); err != nil {
+			return err
+		}
+		return tx.CreateLink(ctx, link, op.Seq)
+	case domain.ChangeCreateMaterial:
+		var material domain.Material
+		if err := decodePayload(change.Payload, &material); err != nil {
+			return err
+		}
+		if err := material.Validate(); err != nil {
+			return err
+		}
+		return tx.CreateMaterial(ctx, material, op.Seq)
+	case domain.ChangeCreateRoleAssignment:
+		var role domain.RoleAssignment
+		if err := decodePayload(change.Payload, &role); err != nil {
+			return err
+		}
+		if role.NamespaceID == "" && change.NamespaceID != nil {
+			role.NamespaceID = *change.NamespaceID
+		}
+		if err := role.Validate(); err != nil {
+			return err
+		}
+		return tx.CreateRoleAssignment(ctx, role, op.Seq)
+	case domain.ChangeRetireNode:
+		id, err := payloadString(change.Payload, "node_id")
+		if err != nil {
+			return err
+		}
+		return tx.RetireNode(ctx, id, op.Seq)
+	case domain.ChangeRetireLink:
+		id, err := payloadString(change.Payload, "link_id")
+		if err != nil {
+			return err
+		}
+		return tx.RetireLink(ctx, id, op.Seq)
+	case domain.ChangeRetireMaterial:
+		id, err := payloadString(change.Payload, "material_id")
+		if err != nil {
+			return err
+		}
+		return tx.RetireMaterial(ctx, id, op.Seq)
+	case domain.ChangeRetireRoleAssignment:
+		id, err := payloadString(change.Payload, "role_assignment_id")
+		if err != nil {
+			return err
+		}
+		return tx.RetireRoleAssignment(ctx, id, op.Seq)
+
+	// Ctrl Dot domain
+	case domain.ChangeCreateAgent:
+		var agent domain.Agent
+		if err := decodePayload(change.Payload, &agent); err != nil {
+			return err
+		}
+		if agent.AgentID == "" {
+			return fmt.Errorf("agent_id is required")
+		}
+		if agent.CreatedAt.IsZero() {
+			agent.CreatedAt = op.OccurredAt
+		}
+		if agent.DefaultMode == "" {
+			agent.DefaultMode = domain.AgentModeNormal
+		}
+		return tx.CreateAgentTx(ctx, agent)
+	case domain.ChangeCreateSession:
+		var session domain.Session
+		if err := decodePayload(change.Payload, &session); err != nil {
+			return err
+		}
+		if session.SessionID == "" || session.AgentID == "" {
+			return fmt.Errorf("session_id and agent_id are required")
+		}
+		if session.StartedAt.IsZero() {
+			session.StartedAt = op.OccurredAt
+		}
+		return tx.CreateSessionTx(ctx, session)
+	case domain.ChangeEndSession:
+		id, err := payloadString(change.Payload, "session_id")
+		if err != nil {
+			return err
+		}
+		return tx.EndSessionTx(ctx, id)
+	case domain.ChangeAppendEvent:
+		var event domain.Event
+		if err := decodePayload(change.Payload, &event); err != nil {
+			return err
+		}
+		if event.Type == "" {
+			return fmt.Errorf("type is required")
+		}
+		if event.EventID == "" {
+			event.EventID = uuid.New().String()
+		}
+		if event.TS.IsZero() {
+			event.TS = op.OccurredAt
+		}
+		if event.Severity == "" {
+			event.Severity = domain.EventSeverityInfo
+		}
+		return tx.AppendEventTx(ctx, event, op.Seq)
+	case domain.ChangeUpdateLimitsState:
+		var state domain.LimitsState
+		if err := decodePayload(change.Payload, &state); err != nil {
+			return err
+		}
+		if state.AgentID == "" {
+			return fmt.Errorf("agent_id is required")
+		}
+		return tx.UpdateLimitsStateTx(ctx, state)
+	case domain.ChangeHaltAgent:
+		id, err := payloadString(change.Payload, "agent_id")
+		if err != nil {
+			return err
+		}
+		reason, _ := change.Payload["reason"].(string)
+		return tx.HaltAgentTx(ctx, id, reason)
+	case domain.ChangeResumeAgent:
+		id, err := payloadString(change.Payload, "agent_id")
+		if err != nil {
+			return err
+		}
+		return tx.ResumeAgentTx(ctx, id)
+	default:
+		return domain.ErrInvalidChangeKind
+	}
+}
+
+func decodePayload(payload map[string]interface{}, v interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+	return nil
+}
+
+func payloadString(payload map[string]interface{}, key string) (string, error) {
+	v, _ := payload[key].(string)
+	if v == "" {
+		return "", fmt.Errorf("%s is required", key)
+	}
+	return v, nil
+}
@@ -0,0 +1,331 @@
+package kernel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/planner"
+	"github.com/futurematic/kernel/internal/policy"
+	"github.com/futurematic/kernel/internal/store"
+	"github.com/google/uuid"
+)
+
+// Service is the Kernel write path: plans are computed and stored with their policy
+// report, then applied once as an Operation together with its projections.
+type Service interface {
+	// Plan expands intents, evaluates the namespace policy set and stores the plan
+	Plan(ctx context.Context, req PlanRequest) (*domain.Plan, error)
+
+	// Apply applies a stored plan exactly once, atomically
+	Apply(ctx context.Context, req ApplyRequest) (*domain.Operation, error)
+
+	// Resolve records the chosen option of a proposal set
+	Resolve(ctx context.Context, req ResolveRequest) (*domain.Resolution, error)
+
+	// StoreProposalSet validates and stores a proposal set
+	StoreProposalSet(ctx context.Context, ps *domain.ProposalSet) error
+
+	// StoreCtrlDotDecision stores a decision record from a Ctrl Dot kernel_http sink;
+	// inserted is false when a record with the same ID already exists
+	StoreCtrlDotDecision(ctx context.Context, r domain.CtrlDotDecisionRecord) (inserted bool, err error)
+}
+
+// PlanRequest contains the inputs for POST /v1/plan
+type PlanRequest struct {
+	ActorID      string
+	Capabilities []string
+	NamespaceID  *string
+	AsOf         domain.AsOf
+	Intents      []domain.Intent
+}
+
+// ApplyRequest contains the inputs for POST /v1/apply
+type ApplyRequest struct {
+	ActorID      string
+	Capabilities []string
+	PlanID       string
+	PlanHash     string
+	// ResolutionID is accepted from the API but not checked or recorded: plans are not
+	// linked to resolutions, and an Operation has no field for one.
+	ResolutionID string
+}
+
+// ResolveRequest contains the inputs for POST /v1/resolve
+type ResolveRequest struct {
+	ProposalSetID   string
+	OptionID        string
+	Resolver        string
+	Rationale       string
+	Constraints     string
+	AssumptionsSnap string
+}
+
+// NewService creates a new kernel service
+func NewService(store store.Store, planner planner.Planner, policyEngine policy.Engine) Service {
+	return &service{
+		store:        store,
+		planner:      planner,
+		policyEngine: policyEngine,
+	}
+}
+
+type service struct {
+	store        store.Store
+	planner      planner.Planner
+	policyEngine policy.Engine
+
+	// applyMu serializes Apply so the next seq read outside the transaction is not
+	// handed out twice; the operations primary key still guards other processes.
+	applyMu sync.Mutex
+}
+
+// Plan implements the Service interface
+func (s *service) Plan(ctx context.Context, req PlanRequest) (*domain.Plan, error) {
+	if req.ActorID == "" {
+		return nil, domain.ErrInvalidActorID
+	}
+	if len(req.Intents) == 0 {
+		return nil, domain.ErrEmptyIntents
+	}
+	for _, intent := range req.Intents {
+		if err := intent.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	plan, err := s.planner.Plan(ctx, planner.PlanRequest{
+		ActorID:     req.ActorID,
+		NamespaceID: req.NamespaceID,
+		AsOf:        req.AsOf,
+		Intents:     req.Intents,
+	})
+	if err != nil {
+		return nil, err
+	}
+	plan.ID = "plan:" + uuid.New().String()
+	plan.CreatedAt = domain.Now()
+
+	var policySet *domain.PolicySet
+	var policyHash string
+	if req.NamespaceID != nil {
+		policySet, err = s.store.GetActivePolicySet(ctx, *req.NamespaceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get policy set: %w", err)
+		}
+		if policySet != nil {
+			policyHash = policySet.PolicyHash
+		}
+	}
+	var asofSeq int64
+	if plan.AsOfSeq != nil {
+		asofSeq = *plan.AsOfSeq
+	}
+	report, err := s.policyEngine.Evaluate(ctx, policySet, plan.Expanded, req.NamespaceID, asofSeq, s.store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate policy: %w", err)
+	}
+	plan.PolicyReport = *report
+
+	tx, err := s.store.OpenTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := tx.StorePlan(ctx, *plan, policyHash); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit plan: %w", err)
+	}
+	return plan, nil
+}
+
+// Apply implements the Service interface. The plan hash must match the stored plan, the
+// plan must not have been applied and its policy report must have no denies. The
+// Operation, its projections and the applied marker are written in one transaction; the
+// marker only succeeds on an unapplied plan, which keeps apply single-use across processes.
+func (s *service) Apply(ctx context.Context, req ApplyRequest) (*domain.Operation, error) {
+	if req.PlanID == "" {
+		return nil, domain.ErrInvalidPlanID
+	}
+	if req.PlanHash == "" {
+		return nil, domain.ErrInvalidPlanHash
+	}
+
+	s.applyMu.Lock()
+	defer s.applyMu.Unlock()
+
+	tx, err := s.store.OpenTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	plan, err := tx.GetPlan(ctx, req.PlanID)
+	if err != nil {
+		return nil, fmt.Errorf("plan %s: %w", req.PlanID, err)
+	}
+	if plan.Hash != req.PlanHash {
+		return nil, fmt.Errorf("plan hash mismatch: plan %s has hash %s", plan.ID, plan.Hash)
+	}
+	applied, err := tx.IsPlanApplied(ctx, plan.ID)
+	if err != nil {
+		return nil, err
+	}
+	if applied {
+		return nil, fmt.Errorf("plan %s already applied", plan.ID)
+	}
+	if plan.PolicyReport.HasDenies() {
+		msgs := make([]string, 0, len(plan.PolicyReport.Denies))
+		for _, d := range plan.PolicyReport.Denies {
+			msgs = append(msgs, d.RuleID+": "+d.Message)
+		}
+		return nil, fmt.Errorf("policy denied: %s", strings.Join(msgs, "; "))
+	}
+
+	seq, err := s.store.GetNextSeq(ctx)
+	if err != nil {
+		return nil, err
+	}
+	actorID := req.ActorID
+	if actorID == "" {
+		actorID = plan.ActorID
+	}
+	capabilities := req.Capabilities
+	if capabilities == nil {
+		capabilities = []string{}
+	}
+	op := domain.Operation{
+		ID:           "op:" + uuid.New().String(),
+		Seq:          seq,
+		OccurredAt:   domain.Now(),
+		ActorID:      actorID,
+		Capabilities: capabilities,
+		PlanID:       plan.ID,
+		PlanHash:     plan.Hash,
+		Class:        plan.Class,
+		Changes:      plan.Expanded,
+	}
+	if err := op.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.AppendOperation(ctx, op); err != nil {
+		return nil, err
+	}
+	for i, change := range op.Changes {
+		if err := applyChange(ctx, tx, change, op); err != nil {
+			return nil, fmt.Errorf("change %d (%s): %w", i, change.Kind, err)
+		}
+	}
+	if err := tx.MarkPlanApplied(ctx, plan.ID, op.ID, op.Seq); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit operation: %w", err)
+	}
+	return &op, nil
+}
+
+// Resolve implements the Service interface
+func (s *service) Resolve(ctx context.Context, req ResolveRequest) (*domain.Resolution, error) {
+	if req.ProposalSetID == "" || req.OptionID == "" || req.Resolver == "" {
+		return nil, fmt.Errorf("proposal_set_id, option_id and resolver are required")
+	}
+	ps, err := s.store.GetProposalSet(ctx, req.ProposalSetID)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, opt := range ps.Options {
+		if opt.ID == req.OptionID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("option_id %q is not an option of proposal set %s", req.OptionID, ps.ProposalSetID)
+	}
+	existing, err := s.store.GetResolutionForProposalSet(ctx, ps.ProposalSetID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("proposal set %s already resolved (resolution %s)", ps.ProposalSetID, existing.ResolutionID)
+	}
+
+	r := &domain.Resolution{
+		ResolutionID:    "resolution:" + uuid.New().String(),
+		ProposalSetID:   ps.ProposalSetID,
+		OptionID:        req.OptionID,
+		Resolver:        req.Resolver,
+		Rationale:       req.Rationale,
+		Constraints:     req.Constraints,
+		AssumptionsSnap: req.AssumptionsSnap,
+		CreatedAt:       domain.Now(),
+	}
+	tx, err := s.store.OpenTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := tx.StoreResolution(ctx, r); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit resolution: %w", err)
+	}
+	return r, nil
+}
+
+// StoreProposalSet implements the Service interface. Validation errors are returned
+// unwrapped (domain.ErrProposalSetTooFewOptions, domain.ErrProposalSetInvalid).
+func (s *service) StoreProposalSet(ctx context.Context, ps *domain.ProposalSet) error {
+	if err := ps.Validate(domain.MinOptionsCount); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(ps.Options))
+	for i := range ps.Options {
+		if ps.Options[i].ID == "" {
+			ps.Options[i].ID = fmt.Sprintf("option-%d", i+1)
+		}
+		if seen[ps.Options[i].ID] {
+			return domain.ErrProposalSetInvalid
+		}
+		seen[ps.Options[i].ID] = true
+	}
+	if ps.RecommendedOptionID != nil && !seen[*ps.RecommendedOptionID] {
+		return domain.ErrProposalSetInvalid
+	}
+	if ps.ProposalSetID == "" {
+		ps.ProposalSetID = "proposal_set:" + uuid.New().String()
+	}
+	if ps.CreatedAt.IsZero() {
+		ps.CreatedAt = domain.Now()
+	}
+
+	tx, err := s.store.OpenTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := tx.StoreProposalSet(ctx, ps); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit proposal set: %w", err)
+	}
+	return nil
+}
+
+// StoreCtrlDotDecision implements the Service interface
+func (s *service) StoreCtrlDotDecision(ctx context.Context, r domain.CtrlDotDecisionRecord) (bool, error) {
+	if r.ID == "" {
+		return false, errors.New("decision record id is required")
+	}
+	return s.store.StoreCtrlDotDecision(ctx, r)
+}
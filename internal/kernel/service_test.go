@@ -0,0 +1,258 @@
+package kernel_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/futurematic/kernel/internal/api"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/kernel"
+	"github.com/futurematic/kernel/internal/planner"
+	"github.com/futurematic/kernel/internal/policy"
+	"github.com/futurematic/kernel/internal/query"
+	"github.com/futurematic/kernel/internal/store"
+	"github.com/futurematic/kernel/internal/store/sqlite"
+	"github.com/google/uuid"
+)
+
+const acyclicPolicy = `policy_set: test
+version: "1"
+rules:
+  - id: parent-acyclic
+    when:
+      op: [CreateLink]
+      link_type: PARENT_OF
+    require:
+      - predicate: acyclic
+        args:
+          link_type: PARENT_OF
+    effect:
+      deny: PARENT_OF links must not form cycles
+`
+
+// newTestServer serves the Kernel API over st, as cmd/kerneld does.
+func newTestServer(t *testing.T, st store.Store) *httptest.Server {
+	t.Helper()
+	svc := kernel.NewService(st, planner.NewPlanner(st, planner.NewExpander(), planner.NewHasher()), policy.NewEngine())
+	srv := httptest.NewServer(api.NewServer(0, svc, query.NewEngine(st)).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, srv *httptest.Server, path string, body interface{}, out interface{}) int {
+	t.Helper()
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(srv.URL+path, "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s response: %v", path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func planBody(ns string, intents ...domain.Intent) map[string]interface{} {
+	return map[string]interface{}{"actor_id": "user:test", "namespace_id": ns, "intents": intents}
+}
+
+func createNode(id string) domain.Intent {
+	return domain.Intent{Kind: domain.IntentCreateNode, Payload: map[string]interface{}{"node_id": id, "title": id}}
+}
+
+func createLink(from, to string) domain.Intent {
+	return domain.Intent{Kind: domain.IntentCreateLink, Payload: map[string]interface{}{"from_node_id": from, "to_node_id": to, "type": "PARENT_OF"}}
+}
+
+func applyBody(p domain.Plan) map[string]string {
+	return map[string]string{"actor_id": "user:test", "plan_id": p.ID, "plan_hash": p.Hash}
+}
+
+func TestPlanApplyIsSingleUse(t *testing.T) {
+	st := newMemStore()
+	srv := newTestServer(t, st)
+
+	var plan domain.Plan
+	if code := post(t, srv, "/v1/plan", planBody("Test:/a", createNode("node:a"), createNode("node:b"), createLink("node:a", "node:b")), &plan); code != http.StatusOK {
+		t.Fatalf("plan: status %d", code)
+	}
+	if plan.ID == "" || plan.Hash == "" || len(plan.Expanded) != 3 {
+		t.Fatalf("plan = %+v", plan)
+	}
+	if _, ok := st.plans[plan.ID]; !ok {
+		t.Fatal("plan was not stored")
+	}
+
+	if code := post(t, srv, "/v1/apply", map[string]string{"actor_id": "user:test", "plan_id": plan.ID, "plan_hash": "sha256:wrong"}, nil); code != http.StatusConflict {
+		t.Fatalf("apply with wrong hash: status %d, want 409", code)
+	}
+
+	var op domain.Operation
+	if code := post(t, srv, "/v1/apply", applyBody(plan), &op); code != http.StatusOK {
+		t.Fatalf("apply: status %d", code)
+	}
+	if op.Seq != 1 || op.PlanID != plan.ID || len(op.Changes) != 3 {
+		t.Fatalf("operation = %+v", op)
+	}
+	if len(st.nodes) != 2 || len(st.links) != 1 {
+		t.Fatalf("projections: %d nodes, %d links", len(st.nodes), len(st.links))
+	}
+
+	if code := post(t, srv, "/v1/apply", applyBody(plan), nil); code != http.StatusConflict {
+		t.Fatalf("second apply: status %d, want 409", code)
+	}
+	if len(st.ops) != 1 {
+		t.Fatalf("%d operations, want 1", len(st.ops))
+	}
+}
+
+func TestApplyIsAtomic(t *testing.T) {
+	st := newMemStore()
+	srv := newTestServer(t, st)
+
+	// The self link passes planning (no policy set) but fails validation on apply.
+	var plan domain.Plan
+	if code := post(t, srv, "/v1/plan", planBody("Test:/a", createNode("node:a"), createLink("node:a", "node:a")), &plan); code != http.StatusOK {
+		t.Fatalf("plan: status %d", code)
+	}
+	if code := post(t, srv, "/v1/apply", applyBody(plan), nil); code != http.StatusInternalServerError {
+		t.Fatalf("apply: status %d, want 500", code)
+	}
+	if len(st.ops) != 0 || len(st.nodes) != 0 || len(st.applied) != 0 {
+		t.Fatalf("partial apply: %d ops, %d nodes, %d applied", len(st.ops), len(st.nodes), len(st.applied))
+	}
+}
+
+func TestApplyPolicyDenied(t *testing.T) {
+	st := newMemStore()
+	st.policySet = &domain.PolicySet{ID: "ps:test", NamespaceID: "Test", PolicyYAML: acyclicPolicy, PolicyHash: "sha256:test"}
+	srv := newTestServer(t, st)
+
+	var plan domain.Plan
+	if code := post(t, srv, "/v1/plan", planBody("Test:/a", createLink("node:a", "node:a")), &plan); code != http.StatusOK {
+		t.Fatalf("plan: status %d", code)
+	}
+	if !plan.PolicyReport.HasDenies() || plan.PolicyReport.Denies[0].RuleID != "parent-acyclic" {
+		t.Fatalf("policy report = %+v", plan.PolicyReport)
+	}
+	if code := post(t, srv, "/v1/apply", applyBody(plan), nil); code != http.StatusForbidden {
+		t.Fatalf("apply: status %d, want 403", code)
+	}
+}
+
+func TestProposalSetResolve(t *testing.T) {
+	st := newMemStore()
+	srv := newTestServer(t, st)
+
+	ps := domain.ProposalSet{
+		Question:      "Which parent?",
+		FocusedNodeID: "node:a",
+		NamespaceID:   "Test:/a",
+		Options:       []domain.ProposalOption{{ID: "a", Summary: "A"}, {ID: "b", Summary: "B"}},
+	}
+	if code := post(t, srv, "/v1/proposal_sets", ps, nil); code != http.StatusBadRequest {
+		t.Fatalf("two options: status %d, want 400", code)
+	}
+	ps.Options = append(ps.Options, domain.ProposalOption{ID: "c", Summary: "C"})
+	var created struct {
+		ProposalSetID string `json:"proposal_set_id"`
+	}
+	if code := post(t, srv, "/v1/proposal_sets", ps, &created); code != http.StatusOK || created.ProposalSetID == "" {
+		t.Fatalf("store proposal set: status %d, id %q", code, created.ProposalSetID)
+	}
+
+	resolve := map[string]string{"proposal_set_id": created.ProposalSetID, "option_id": "z", "resolver": "user:test"}
+	if code := post(t, srv, "/v1/resolve", resolve, nil); code != http.StatusBadRequest {
+		t.Fatalf("unknown option: status %d, want 400", code)
+	}
+	resolve["option_id"] = "b"
+	var res domain.Resolution
+	if code := post(t, srv, "/v1/resolve", resolve, &res); code != http.StatusOK || res.OptionID != "b" {
+		t.Fatalf("resolve: status %d, resolution %+v", code, res)
+	}
+	if code := post(t, srv, "/v1/resolve", resolve, nil); code != http.StatusConflict {
+		t.Fatalf("second resolve: status %d, want 409", code)
+	}
+
+	// resolution_id is accepted on apply but not tied to the plan
+	var plan domain.Plan
+	post(t, srv, "/v1/plan", planBody("Test:/a", createNode("node:a")), &plan)
+	body := applyBody(plan)
+	body["resolution_id"] = res.ResolutionID
+	if code := post(t, srv, "/v1/apply", body, nil); code != http.StatusOK {
+		t.Fatalf("apply with resolution: status %d", code)
+	}
+}
+
+// TestPostgres runs plan/apply against a migrated database (make migrate) when DB_URL is set.
+func TestPostgres(t *testing.T) {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		t.Skip("DB_URL not set")
+	}
+	st, err := store.NewPostgresStore(dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	testDatabase(t, st)
+}
+
+func TestSQLite(t *testing.T) {
+	st, err := sqlite.Open(context.Background(), filepath.Join(t.TempDir(), "kernel.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	testDatabase(t, st)
+}
+
+// testDatabase runs plan/apply and decision storage through the API against a real store.
+func testDatabase(t *testing.T, st store.Store) {
+	srv := newTestServer(t, st)
+
+	ns := "KernelTest:/" + uuid.New().String()
+	if err := st.CreateNamespace(context.Background(), domain.Namespace{ID: ns, Name: ns}); err != nil {
+		t.Fatal(err)
+	}
+	a, b := "node:"+uuid.New().String(), "node:"+uuid.New().String()
+	var plan domain.Plan
+	if code := post(t, srv, "/v1/plan", planBody(ns, createNode(a), createNode(b), createLink(a, b)), &plan); code != http.StatusOK {
+		t.Fatalf("plan: status %d", code)
+	}
+	var op domain.Operation
+	if code := post(t, srv, "/v1/apply", applyBody(plan), &op); code != http.StatusOK {
+		t.Fatalf("apply: status %d", code)
+	}
+	if op.Seq <= 0 || len(op.Changes) != 3 {
+		t.Fatalf("operation = %+v", op)
+	}
+	if code := post(t, srv, "/v1/apply", applyBody(plan), nil); code != http.StatusConflict {
+		t.Fatalf("second apply: status %d, want 409", code)
+	}
+
+	rec := domain.CtrlDotDecisionRecord{ID: uuid.New().String(), AgentID: "agent:test", Decision: "ALLOW"}
+	body, _ := json.Marshal(map[string]interface{}{"id": rec.ID, "agent_id": rec.AgentID, "decision": rec.Decision})
+	for i, want := range []int{http.StatusCreated, http.StatusOK} {
+		resp, err := http.Post(srv.URL+"/v1/ctrldot/decisions", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("decision post %d: status %d, want %d", i, resp.StatusCode, want)
+		}
+	}
+}
@@ -2,14 +2,18 @@ package autobundle
 
 import (
 	"context"
+	"log"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/futurematic/kernel/internal/config"
 	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/eventchain"
 	"github.com/futurematic/kernel/internal/ledger/sink"
 	"github.com/futurematic/kernel/internal/ledger/sink/bundle"
+	"github.com/futurematic/kernel/internal/metrics"
 	"github.com/futurematic/kernel/internal/runtime"
 )
 
@@ -23,15 +27,21 @@ const (
 	TriggerPanicOn       = "panic_on"
 	TriggerPanicOff      = "panic_off"
 	TriggerManualTest    = "manual_test"
+	TriggerChainCheckpoint = "chain_checkpoint"
 )
 
 // Manager creates signed bundles on DENY/STOP/shutdown/panic toggle, with debounce.
+// Every bundle checkpoints the runtime event chain head; Start also writes periodic
+// chain_checkpoint bundles.
 type Manager struct {
-	cfg     *config.Config
+	cfg     atomic.Pointer[config.Config]
 	store   runtime.RuntimeStore
 	mu      sync.Mutex
 	lastAt  map[string]time.Time // key: sessionID or sessionID+"."+trigger for debounce
 	daemonVersion string
+	lastCheckpointSeq int64
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
 }
 
 // NewManager creates an autobundle manager. cfg must not be nil; store may be nil (no event tail).
@@ -39,19 +49,118 @@ func NewManager(cfg *config.Config, store runtime.RuntimeStore, daemonVersion st
 	if daemonVersion == "" {
 		daemonVersion = "0.1.0"
 	}
-	return &Manager{
-		cfg:           cfg,
+	m := &Manager{
 		store:         store,
 		lastAt:        make(map[string]time.Time),
 		daemonVersion: daemonVersion,
+		stopCh:        make(chan struct{}),
 	}
+	m.cfg.Store(cfg)
+	return m
+}
+
+// SetConfig swaps in a reloaded config for bundles written from now on. The chain checkpoint
+// interval is fixed when Start is called.
+func (m *Manager) SetConfig(cfg *config.Config) {
+	m.cfg.Store(cfg)
+}
+
+// Start writes a chain_checkpoint bundle every autobundle.triggers.chain_checkpoint_minutes
+// (when the chain has grown since the last one), until Stop. No-op when disabled.
+func (m *Manager) Start() {
+	cfg := m.cfg.Load()
+	if cfg == nil || !cfg.Autobundle.Enabled || cfg.Autobundle.Triggers.ChainCheckpointMinutes <= 0 || m.store == nil {
+		return
+	}
+	interval := time.Duration(cfg.Autobundle.Triggers.ChainCheckpointMinutes) * time.Minute
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stopCh:
+				return
+			case <-ticker.C:
+				if _, err := m.MaybeCheckpointChain(context.Background()); err != nil {
+					log.Printf("autobundle chain checkpoint: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the checkpoint loop and waits for an in-flight checkpoint to finish.
+func (m *Manager) Stop() {
+	close(m.stopCh)
+	m.wg.Wait()
+}
+
+// MaybeCheckpointChain writes a chain_checkpoint bundle (manifest only: no decisions or events)
+// unless the event chain head has not moved since the last checkpoint. No debounce.
+func (m *Manager) MaybeCheckpointChain(ctx context.Context) (path string, err error) {
+	cfg := m.cfg.Load()
+	if cfg == nil || !cfg.Autobundle.Enabled || m.store == nil {
+		return "", nil
+	}
+	outputDir := m.outputDir()
+	if outputDir == "" {
+		return "", nil
+	}
+	cp, err := eventchain.CheckpointFrom(ctx, m.store)
+	if err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	unchanged := cp == nil || cp.Seq == m.lastCheckpointSeq
+	m.mu.Unlock()
+	if unchanged {
+		return "", nil
+	}
+
+	path, err = bundle.WriteOne(bundle.WriteOneOptions{
+		OutputDir:        outputDir,
+		SignEnabled:      cfg.LedgerSink.Bundle.Sign.Enabled,
+		PrivateKeyPath:   cfg.LedgerSink.Bundle.Sign.KeyPath,
+		PublicKeyPath:    cfg.LedgerSink.Bundle.Sign.PublicKeyPath,
+		TransparencyLogDir: cfg.LedgerSink.Bundle.Sign.TransparencyLogDir,
+		EncryptRecipients:  cfg.LedgerSink.Bundle.Encrypt.Recipients,
+		ConfigRev:          cfg.Revision,
+		RuntimeStoreKind: m.runtimeKind(),
+		DaemonVersion:    m.daemonVersion,
+		Trigger:          TriggerChainCheckpoint,
+		ChainCheckpoint:  cp,
+	})
+	if err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	m.lastCheckpointSeq = cp.Seq
+	m.mu.Unlock()
+	metrics.AutobundleWrites.Inc(TriggerChainCheckpoint)
+	return path, nil
+}
+
+// chainCheckpoint returns the current event chain head for a manifest (nil without a store or on error).
+func (m *Manager) chainCheckpoint(ctx context.Context) *eventchain.Checkpoint {
+	if m.store == nil {
+		return nil
+	}
+	cp, err := eventchain.CheckpointFrom(ctx, m.store)
+	if err != nil {
+		log.Printf("autobundle: read event chain head: %v", err)
+		return nil
+	}
+	return cp
 }
 
 // MaybeBundleOnDecision writes a bundle when trigger is enabled and not debounced.
 // trigger should be one of TriggerDecisionDeny, TriggerDecisionStop, TriggerLoopStop, TriggerBudgetStop.
 // nextSteps and reasonCodes are optional (for README.md).
 func (m *Manager) MaybeBundleOnDecision(ctx context.Context, record *sink.DecisionRecord, trigger string, effectivePanic bool, nextSteps []string, reasonCodes []string) (path string, err error) {
-	if m.cfg == nil || !m.cfg.Autobundle.Enabled {
+	cfg := m.cfg.Load()
+	if cfg == nil || !cfg.Autobundle.Enabled {
 		return "", nil
 	}
 	if !m.triggerEnabled(trigger) {
@@ -73,24 +182,27 @@ func (m *Manager) MaybeBundleOnDecision(ctx context.Context, record *sink.Decisi
 
 	decisions := []*sink.DecisionRecord{record}
 	var events []*domain.Event
-	if m.store != nil && m.cfg.Autobundle.Include.EventsTail > 0 {
+	if m.store != nil && cfg.Autobundle.Include.EventsTail > 0 {
 		since := time.Now().Add(-1 * time.Hour).Unix() * 1000
 		agentID := record.AgentID
-		list, _ := m.store.ListEvents(ctx, runtime.EventFilter{AgentID: &agentID, SinceTS: &since, Limit: m.cfg.Autobundle.Include.EventsTail})
+		list, _ := m.store.ListEvents(ctx, runtime.EventFilter{AgentID: &agentID, SinceTS: &since, Limit: cfg.Autobundle.Include.EventsTail})
 		for i := range list {
 			events = append(events, &list[i])
 		}
 	}
 	var cfgSnapshot *config.Config
-	if m.cfg.Autobundle.Include.ConfigSnapshot {
-		cfgSnapshot = m.cfg
+	if cfg.Autobundle.Include.ConfigSnapshot {
+		cfgSnapshot = cfg
 	}
 
 	path, err = bundle.WriteOne(bundle.WriteOneOptions{
 		OutputDir:             outputDir,
-		SignEnabled:           m.cfg.LedgerSink.Bundle.Sign.Enabled,
-		PrivateKeyPath:       m.cfg.LedgerSink.Bundle.Sign.KeyPath,
-		PublicKeyPath:        m.cfg.LedgerSink.Bundle.Sign.PublicKeyPath,
+		SignEnabled:           cfg.LedgerSink.Bundle.Sign.Enabled,
+		PrivateKeyPath:       cfg.LedgerSink.Bundle.Sign.KeyPath,
+		PublicKeyPath:        cfg.LedgerSink.Bundle.Sign.PublicKeyPath,
+		TransparencyLogDir: cfg.LedgerSink.Bundle.Sign.TransparencyLogDir,
+		EncryptRecipients:  cfg.LedgerSink.Bundle.Encrypt.Recipients,
+		ConfigRev:          cfg.Revision,
 		RuntimeStoreKind:     m.runtimeKind(),
 		DaemonVersion:        m.daemonVersion,
 		SessionID:            record.SessionID,
@@ -103,6 +215,7 @@ func (m *Manager) MaybeBundleOnDecision(ctx context.Context, record *sink.Decisi
 		EffectivePanicEnabled: effectivePanic,
 		ReasonCodes:          reasonCodes,
 		NextSteps:            nextSteps,
+		ChainCheckpoint:      m.chainCheckpoint(ctx),
 	})
 	if err != nil {
 		return "", err
@@ -110,13 +223,15 @@ func (m *Manager) MaybeBundleOnDecision(ctx context.Context, record *sink.Decisi
 	m.mu.Lock()
 	m.lastAt[debounceKey] = time.Now()
 	m.mu.Unlock()
+	metrics.AutobundleWrites.Inc(trigger)
 	return path, nil
 }
 
 // MaybeBundleOnShutdown writes a shutdown bundle if triggers.on_shutdown is enabled.
 // Debounce does not apply to shutdown.
 func (m *Manager) MaybeBundleOnShutdown(ctx context.Context) (path string, err error) {
-	if m.cfg == nil || !m.cfg.Autobundle.Enabled || !m.cfg.Autobundle.Triggers.OnShutdown {
+	cfg := m.cfg.Load()
+	if cfg == nil || !cfg.Autobundle.Enabled || !cfg.Autobundle.Triggers.OnShutdown {
 		return "", nil
 	}
 	outputDir := m.outputDir()
@@ -125,23 +240,26 @@ func (m *Manager) MaybeBundleOnShutdown(ctx context.Context) (path string, err e
 	}
 
 	var events []*domain.Event
-	if m.store != nil && m.cfg.Autobundle.Include.EventsTail > 0 {
+	if m.store != nil && cfg.Autobundle.Include.EventsTail > 0 {
 		since := time.Now().Add(-1 * time.Hour).Unix() * 1000
-		list, _ := m.store.ListEvents(ctx, runtime.EventFilter{SinceTS: &since, Limit: m.cfg.Autobundle.Include.EventsTail})
+		list, _ := m.store.ListEvents(ctx, runtime.EventFilter{SinceTS: &since, Limit: cfg.Autobundle.Include.EventsTail})
 		for i := range list {
 			events = append(events, &list[i])
 		}
 	}
 	var cfgSnapshot *config.Config
-	if m.cfg.Autobundle.Include.ConfigSnapshot {
-		cfgSnapshot = m.cfg
+	if cfg.Autobundle.Include.ConfigSnapshot {
+		cfgSnapshot = cfg
 	}
 
 	path, err = bundle.WriteOne(bundle.WriteOneOptions{
 		OutputDir:             outputDir,
-		SignEnabled:           m.cfg.LedgerSink.Bundle.Sign.Enabled,
-		PrivateKeyPath:       m.cfg.LedgerSink.Bundle.Sign.KeyPath,
-		PublicKeyPath:        m.cfg.LedgerSink.Bundle.Sign.PublicKeyPath,
+		SignEnabled:           cfg.LedgerSink.Bundle.Sign.Enabled,
+		PrivateKeyPath:       cfg.LedgerSink.Bundle.Sign.KeyPath,
+		PublicKeyPath:        cfg.LedgerSink.Bundle.Sign.PublicKeyPath,
+		TransparencyLogDir: cfg.LedgerSink.Bundle.Sign.TransparencyLogDir,
+		EncryptRecipients:  cfg.LedgerSink.Bundle.Encrypt.Recipients,
+		ConfigRev:          cfg.Revision,
 		RuntimeStoreKind:     m.runtimeKind(),
 		DaemonVersion:        m.daemonVersion,
 		SessionID:            "",
@@ -151,16 +269,19 @@ func (m *Manager) MaybeBundleOnShutdown(ctx context.Context) (path string, err e
 		ConfigSnapshot:       cfgSnapshot,
 		Trigger:              TriggerShutdown,
 		EffectivePanicEnabled: false,
+		ChainCheckpoint:      m.chainCheckpoint(ctx),
 	})
 	if err != nil {
 		return "", err
 	}
+	metrics.AutobundleWrites.Inc(TriggerShutdown)
 	return path, nil
 }
 
 // MaybeBundleOnPanicToggle writes a bundle when panic is toggled if triggers.on_panic_toggle is enabled.
 func (m *Manager) MaybeBundleOnPanicToggle(ctx context.Context, panicOn bool) (path string, err error) {
-	if m.cfg == nil || !m.cfg.Autobundle.Enabled || !m.cfg.Autobundle.Triggers.OnPanicToggle {
+	cfg := m.cfg.Load()
+	if cfg == nil || !cfg.Autobundle.Enabled || !cfg.Autobundle.Triggers.OnPanicToggle {
 		return "", nil
 	}
 	outputDir := m.outputDir()
@@ -174,9 +295,12 @@ func (m *Manager) MaybeBundleOnPanicToggle(ctx context.Context, panicOn bool) (p
 	}
 	path, err = bundle.WriteOne(bundle.WriteOneOptions{
 		OutputDir:             outputDir,
-		SignEnabled:           m.cfg.LedgerSink.Bundle.Sign.Enabled,
-		PrivateKeyPath:       m.cfg.LedgerSink.Bundle.Sign.KeyPath,
-		PublicKeyPath:        m.cfg.LedgerSink.Bundle.Sign.PublicKeyPath,
+		SignEnabled:           cfg.LedgerSink.Bundle.Sign.Enabled,
+		PrivateKeyPath:       cfg.LedgerSink.Bundle.Sign.KeyPath,
+		PublicKeyPath:        cfg.LedgerSink.Bundle.Sign.PublicKeyPath,
+		TransparencyLogDir: cfg.LedgerSink.Bundle.Sign.TransparencyLogDir,
+		EncryptRecipients:  cfg.LedgerSink.Bundle.Encrypt.Recipients,
+		ConfigRev:          cfg.Revision,
 		RuntimeStoreKind:     m.runtimeKind(),
 		DaemonVersion:        m.daemonVersion,
 		SessionID:            "",
@@ -186,16 +310,19 @@ func (m *Manager) MaybeBundleOnPanicToggle(ctx context.Context, panicOn bool) (p
 		ConfigSnapshot:       nil,
 		Trigger:              trigger,
 		EffectivePanicEnabled: panicOn,
+		ChainCheckpoint:      m.chainCheckpoint(ctx),
 	})
 	if err != nil {
 		return "", err
 	}
+	metrics.AutobundleWrites.Inc(trigger)
 	return path, nil
 }
 
 // MaybeBundleTest forces a bundle with trigger manual_test (for CLI test). No debounce.
 func (m *Manager) MaybeBundleTest(ctx context.Context) (path string, err error) {
-	if m.cfg == nil || !m.cfg.Autobundle.Enabled {
+	cfg := m.cfg.Load()
+	if cfg == nil || !cfg.Autobundle.Enabled {
 		return "", nil
 	}
 	outputDir := m.outputDir()
@@ -204,23 +331,26 @@ func (m *Manager) MaybeBundleTest(ctx context.Context) (path string, err error)
 	}
 
 	var events []*domain.Event
-	if m.store != nil && m.cfg.Autobundle.Include.EventsTail > 0 {
+	if m.store != nil && cfg.Autobundle.Include.EventsTail > 0 {
 		since := time.Now().Add(-1 * time.Hour).Unix() * 1000
-		list, _ := m.store.ListEvents(ctx, runtime.EventFilter{SinceTS: &since, Limit: m.cfg.Autobundle.Include.EventsTail})
+		list, _ := m.store.ListEvents(ctx, runtime.EventFilter{SinceTS: &since, Limit: cfg.Autobundle.Include.EventsTail})
 		for i := range list {
 			events = append(events, &list[i])
 		}
 	}
 	var cfgSnapshot *config.Config
-	if m.cfg.Autobundle.Include.ConfigSnapshot {
-		cfgSnapshot = m.cfg
+	if cfg.Autobundle.Include.ConfigSnapshot {
+		cfgSnapshot = cfg
 	}
 
-	return bundle.WriteOne(bundle.WriteOneOptions{
+	path, err = bundle.WriteOne(bundle.WriteOneOptions{
 		OutputDir:      outputDir,
-		SignEnabled:    m.cfg.LedgerSink.Bundle.Sign.Enabled,
-		PrivateKeyPath: m.cfg.LedgerSink.Bundle.Sign.KeyPath,
-		PublicKeyPath:  m.cfg.LedgerSink.Bundle.Sign.PublicKeyPath,
+		SignEnabled:    cfg.LedgerSink.Bundle.Sign.Enabled,
+		PrivateKeyPath: cfg.LedgerSink.Bundle.Sign.KeyPath,
+		PublicKeyPath:  cfg.LedgerSink.Bundle.Sign.PublicKeyPath,
+		TransparencyLogDir: cfg.LedgerSink.Bundle.Sign.TransparencyLogDir,
+		EncryptRecipients:  cfg.LedgerSink.Bundle.Encrypt.Recipients,
+		ConfigRev:          cfg.Revision,
 		RuntimeStoreKind: m.runtimeKind(),
 		DaemonVersion:  m.daemonVersion,
 		SessionID:      "",
@@ -229,32 +359,40 @@ func (m *Manager) MaybeBundleTest(ctx context.Context) (path string, err error)
 		Events:         events,
 		ConfigSnapshot: cfgSnapshot,
 		Trigger:        TriggerManualTest,
+		ChainCheckpoint: m.chainCheckpoint(ctx),
 	})
+	if err != nil {
+		return "", err
+	}
+	metrics.AutobundleWrites.Inc(TriggerManualTest)
+	return path, nil
 }
 
 func (m *Manager) triggerEnabled(trigger string) bool {
+	cfg := m.cfg.Load()
 	switch trigger {
 	case TriggerDecisionDeny:
-		return m.cfg.Autobundle.Triggers.OnDeny
+		return cfg.Autobundle.Triggers.OnDeny
 	case TriggerDecisionStop:
-		return m.cfg.Autobundle.Triggers.OnStop
+		return cfg.Autobundle.Triggers.OnStop
 	case TriggerLoopStop:
-		return m.cfg.Autobundle.Triggers.OnLoopStop
+		return cfg.Autobundle.Triggers.OnLoopStop
 	case TriggerBudgetStop:
-		return m.cfg.Autobundle.Triggers.OnBudgetStop
+		return cfg.Autobundle.Triggers.OnBudgetStop
 	default:
 		return false
 	}
 }
 
 func (m *Manager) debounced(key string) bool {
+	cfg := m.cfg.Load()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	last, ok := m.lastAt[key]
 	if !ok {
 		return false
 	}
-	debounceSec := m.cfg.Autobundle.DebounceSeconds
+	debounceSec := cfg.Autobundle.DebounceSeconds
 	if debounceSec <= 0 {
 		debounceSec = 10
 	}
@@ -262,9 +400,10 @@ func (m *Manager) debounced(key string) bool {
 }
 
 func (m *Manager) outputDir() string {
-	dir := m.cfg.Autobundle.OutputDir
+	cfg := m.cfg.Load()
+	dir := cfg.Autobundle.OutputDir
 	if dir == "" {
-		dir = m.cfg.LedgerSink.Bundle.OutputDir
+		dir = cfg.LedgerSink.Bundle.OutputDir
 	}
 	if dir == "" {
 		home, _ := filepath.Abs(".")
@@ -274,7 +413,8 @@ func (m *Manager) outputDir() string {
 }
 
 func (m *Manager) runtimeKind() string {
-	k := m.cfg.RuntimeStore.Kind
+	cfg := m.cfg.Load()
+	k := cfg.RuntimeStore.Kind
 	if k == "" {
 		return "sqlite"
 	}
@@ -0,0 +1,238 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArchiveExt is the file extension of packed bundles.
+const ArchiveExt = ".tar.gz"
+
+// maxArchiveSize bounds how much of an archive is read into memory.
+const maxArchiveSize = 1 << 30
+
+// IsArchive reports whether path names a packed bundle.
+func IsArchive(path string) bool {
+	return strings.HasSuffix(path, ArchiveExt)
+}
+
+// bundleReader reads a bundle file by name from a directory or an archive.
+type bundleReader func(name string) ([]byte, error)
+
+// openBundle returns a reader for the bundle directory or archive at bundlePath.
+func openBundle(bundlePath string) (bundleReader, error) {
+	info, err := os.Stat(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return func(name string) ([]byte, error) {
+			return os.ReadFile(filepath.Join(bundlePath, name))
+		}, nil
+	}
+	if !IsArchive(bundlePath) {
+		return nil, fmt.Errorf("%s: not a bundle directory or %s archive", bundlePath, ArchiveExt)
+	}
+	_, files, err := readArchive(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	return func(name string) ([]byte, error) {
+		data, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+		}
+		return data, nil
+	}, nil
+}
+
+// ReadFile reads the payload file name (e.g. "decision_records.jsonl") from the bundle directory
+// or archive at bundlePath. Encrypted payloads are not decrypted: run `ctrldot bundle decrypt`
+// and read the decrypted copy.
+func ReadFile(bundlePath, name string) ([]byte, error) {
+	read, err := openBundle(expandPath(bundlePath))
+	if err != nil {
+		return nil, err
+	}
+	data, err := read(name)
+	if errors.Is(err, os.ErrNotExist) {
+		if _, encErr := read(name + encryptedExt); encErr == nil {
+			return nil, fmt.Errorf("%s is encrypted (decrypt the bundle first: ctrldot bundle decrypt)", name)
+		}
+	}
+	return data, err
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Pack writes the bundle directory dir to w as a gzipped tar with a single top-level directory
+// named after the bundle. The output depends only on the bundle's contents: entries are sorted,
+// owners are cleared and every timestamp is the manifest's created_at, so packing the same
+// bundle twice gives byte-identical archives.
+func Pack(dir string, w io.Writer) error {
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	mtime := m.CreatedAt.UTC().Truncate(time.Second)
+	root := filepath.Base(filepath.Clean(dir))
+	gz, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: root + "/", Mode: 0755, ModTime: mtime, Format: tar.FormatPAX}); err != nil {
+		return err
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{Typeflag: tar.TypeReg, Name: root + "/" + name, Mode: 0644, Size: int64(len(data)), ModTime: mtime, Format: tar.FormatPAX}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// PackFile packs dir into outPath (default: <dir>.tar.gz) and returns the archive path.
+func PackFile(dir, outPath string) (string, error) {
+	dir = filepath.Clean(expandPath(dir))
+	if outPath == "" {
+		outPath = dir + ArchiveExt
+	} else if info, err := os.Stat(outPath); err == nil && info.IsDir() {
+		outPath = filepath.Join(outPath, filepath.Base(dir)+ArchiveExt)
+	}
+	var buf bytes.Buffer
+	if err := Pack(dir, &buf); err != nil {
+		return "", err
+	}
+	tmp := outPath + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	return outPath, os.Rename(tmp, outPath)
+}
+
+// readArchive reads a packed bundle into memory, returning its directory name and files.
+// Archives with more than one top-level directory, nested paths or non-regular files are rejected.
+func readArchive(archivePath string) (string, map[string][]byte, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", archivePath, err)
+	}
+	tr := tar.NewReader(gz)
+	var root string
+	files := make(map[string][]byte)
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", archivePath, err)
+		}
+		name := path.Clean(hdr.Name)
+		dir, file := path.Split(name)
+		if hdr.Typeflag == tar.TypeDir {
+			dir, file = name+"/", ""
+		}
+		top := strings.TrimSuffix(dir, "/")
+		if top == "" || strings.Contains(top, "/") || !strings.HasPrefix(top, "bundle_") {
+			return "", nil, fmt.Errorf("%s: unexpected entry %q (want bundle_*/<file>)", archivePath, hdr.Name)
+		}
+		if root == "" {
+			root = top
+		} else if top != root {
+			return "", nil, fmt.Errorf("%s: more than one bundle directory (%s, %s)", archivePath, root, top)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+		default:
+			return "", nil, fmt.Errorf("%s: %q is not a regular file", archivePath, hdr.Name)
+		}
+		total += hdr.Size
+		if total > maxArchiveSize {
+			return "", nil, fmt.Errorf("%s: archive too large", archivePath)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", archivePath, err)
+		}
+		files[file] = data
+	}
+	if root == "" {
+		return "", nil, fmt.Errorf("%s: empty archive", archivePath)
+	}
+	return root, files, nil
+}
+
+// Unpack extracts the archive into destDir and returns the bundle directory. An existing
+// bundle directory is not overwritten.
+func Unpack(archivePath, destDir string) (string, error) {
+	root, files, err := readArchive(expandPath(archivePath))
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(expandPath(destDir), root)
+	if _, err := os.Stat(dir); err == nil {
+		return "", fmt.Errorf("%s already exists", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), files[name], 0644); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
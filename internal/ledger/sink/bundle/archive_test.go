@@ -0,0 +1,135 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPackIsDeterministic(t *testing.T) {
+	k := newTestKeys(t)
+	dir := writeSignedBundle(t, k, "s1")
+
+	var a, b bytes.Buffer
+	if err := Pack(dir, &a); err != nil {
+		t.Fatal(err)
+	}
+	// File times and permissions on disk do not leak into the archive
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "events.jsonl"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(dir, "events.jsonl"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Pack(dir, &b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Fatal("packing the same bundle twice gave different archives")
+	}
+}
+
+func TestArchiveVerifyListAndUnpack(t *testing.T) {
+	k := newTestKeys(t)
+	out, logDir := t.TempDir(), t.TempDir()
+	dir := writeLoggedBundles(t, k, out, logDir, "s1")[0]
+	archive, err := PackFile(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archive != dir+ArchiveExt {
+		t.Errorf("PackFile = %s", archive)
+	}
+
+	opts := VerifyOptions{Trust: loadTrust(t, k.trust)}
+	if _, err := VerifyBundleWith(archive, opts); err != nil {
+		t.Errorf("VerifyBundleWith(archive): %v", err)
+	}
+	if _, err := VerifyTransparency(archive, OpenTransparencyLog(logDir), opts); err != nil {
+		t.Errorf("VerifyTransparency(archive): %v", err)
+	}
+	names, err := ListBundles(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[1] != filepath.Base(archive) {
+		t.Errorf("ListBundles = %v", names)
+	}
+
+	// The archive stands in for the deleted directory in an audit
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if rep, err := OpenTransparencyLog(logDir).Audit([]string{out}, opts); err != nil || !rep.OK {
+		t.Errorf("Audit with archive only = %+v, %v", rep, err)
+	}
+
+	unpacked, err := Unpack(archive, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(unpacked) != filepath.Base(dir) {
+		t.Errorf("Unpack = %s", unpacked)
+	}
+	if _, err := VerifyBundleWith(unpacked, opts); err != nil {
+		t.Errorf("verify unpacked: %v", err)
+	}
+	if _, err := Unpack(archive, filepath.Dir(unpacked)); err == nil {
+		t.Error("Unpack over an existing bundle: no error")
+	}
+
+	// A tampered archive fails verification
+	tampered := filepath.Join(t.TempDir(), "bundle_x"+ArchiveExt)
+	writeArchive(t, tampered, map[string]string{"bundle_x/manifest.json": "{}", "bundle_x/events.jsonl": "forged"})
+	if _, err := VerifyBundleWith(tampered, opts); err == nil {
+		t.Error("tampered archive verified")
+	}
+}
+
+func TestUnpackRejectsUnsafeArchives(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"traversal":   {"bundle_x/../../evil": "x"},
+		"absolute":    {"/bundle_x/manifest.json": "x"},
+		"nested":      {"bundle_x/sub/manifest.json": "x"},
+		"two bundles": {"bundle_x/manifest.json": "x", "bundle_y/manifest.json": "y"},
+		"not bundle":  {"other/manifest.json": "x"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "a"+ArchiveExt)
+			writeArchive(t, path, files)
+			dest := t.TempDir()
+			if dir, err := Unpack(path, dest); err == nil {
+				t.Errorf("Unpack = %s, want error", dir)
+			}
+		})
+	}
+}
+
+func writeArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(body))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
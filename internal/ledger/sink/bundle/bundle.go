@@ -1,20 +1,22 @@
 package bundle
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdh"
 	"crypto/ed25519"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/futurematic/kernel/internal/config"
 	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/eventchain"
 	"github.com/futurematic/kernel/internal/ledger/sink"
 	"gopkg.in/yaml.v3"
 )
@@ -27,9 +29,13 @@ type Sink struct {
 	signEnabled     bool
 	privateKeyPath  string
 	publicKeyPath   string
+	trustStorePath  string
+	translogDir     string // transparency log; "" disables
+	recipients      []*ecdh.PublicKey // encrypt payload files to these keys; none: plaintext
 	runtimeStoreKind string
 	daemonVersion   string
-	configSnapshot  *config.Config // redacted when writing
+	configSnapshot  atomic.Pointer[config.Config] // redacted when writing; kept current by SetConfig
+	chain           ChainHeadSource // optional; checkpointed into each manifest
 	mu              sync.Mutex
 	sessions        map[string]*sessionBundle
 }
@@ -53,12 +59,38 @@ type BundleManifest struct {
 	SessionID              string           `json:"session_id"`
 	AgentID                string           `json:"agent_id"`
 	Hashes                 map[string]string `json:"hashes"`
+	KeyID                  string           `json:"key_id,omitempty"` // signing key (see KeyID); empty when unsigned
 	Redactions             []string         `json:"redactions,omitempty"`
 	// Auto-bundle metadata (optional)
 	Trigger                string    `json:"trigger,omitempty"`                  // e.g. decision_deny, decision_stop, shutdown, panic_on
 	TriggeredAt            time.Time `json:"triggered_at,omitempty"`             // when trigger fired
 	DecisionID              string    `json:"decision_id,omitempty"`               // ID of decision that triggered (if any)
 	EffectivePanicEnabled   bool      `json:"effective_panic_enabled,omitempty"`   // panic was on when bundle created
+	// Runtime event chain head when the bundle was written; signed with the manifest so the
+	// event log can be checked offline (ctrldot events verify --checkpoint)
+	ChainCheckpoint *eventchain.Checkpoint `json:"chain_checkpoint,omitempty"`
+	// Set when payload files are encrypted (see encrypt.go); Hashes cover the .enc files
+	Encryption *BundleEncryption `json:"encryption,omitempty"`
+	// Config revision in force when the bundle was written (GET /v1/config/revisions); each
+	// decision record carries the revision it was made under
+	ConfigRev int64 `json:"config_rev,omitempty"`
+}
+
+// ChainHeadSource reads the runtime event chain head (runtime.RuntimeStore implements it).
+type ChainHeadSource interface {
+	EventChainHead(ctx context.Context) (*domain.EventChainHead, error)
+}
+
+// SetConfig updates the config snapshot (and revision) written into bundles. Output, signing
+// and encryption settings are fixed when the sink is created.
+func (s *Sink) SetConfig(cfg *config.Config) {
+	s.configSnapshot.Store(cfg)
+}
+
+// WithChain makes the sink checkpoint the event chain head of src into each bundle manifest.
+func (s *Sink) WithChain(src ChainHeadSource) *Sink {
+	s.chain = src
+	return s
 }
 
 // NewSink creates a bundle sink. Output dir and key paths are expanded (~).
@@ -72,11 +104,18 @@ func NewSink(cfg *config.Config, runtimeStoreKind, daemonVersion string) (*Sink,
 		signEnabled:      cfg.LedgerSink.Bundle.Sign.Enabled,
 		privateKeyPath:   expandPath(cfg.LedgerSink.Bundle.Sign.KeyPath),
 		publicKeyPath:    expandPath(cfg.LedgerSink.Bundle.Sign.PublicKeyPath),
+		trustStorePath:   TrustStorePath(cfg.LedgerSink.Bundle.Sign),
+		translogDir:      TransparencyLogDir(cfg.LedgerSink.Bundle.Sign),
 		runtimeStoreKind: runtimeStoreKind,
 		daemonVersion:    daemonVersion,
-		configSnapshot:   cfg,
 		sessions:         make(map[string]*sessionBundle),
 	}
+	s.configSnapshot.Store(cfg)
+	recipients, err := ParseRecipients(cfg.LedgerSink.Bundle.Encrypt.Recipients)
+	if err != nil {
+		return nil, fmt.Errorf("ledger_sink.bundle.encrypt: %w", err)
+	}
+	s.recipients = recipients
 	if s.signEnabled {
 		if err := s.ensureKeypair(); err != nil {
 			return nil, err
@@ -95,27 +134,94 @@ func expandPath(p string) string {
 	return p
 }
 
+// ensureKeypair generates (and trusts) a signing key on first use.
 func (s *Sink) ensureKeypair() error {
-	dir := filepath.Dir(s.privateKeyPath)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("keys dir: %w", err)
-	}
 	if _, err := os.Stat(s.privateKeyPath); err == nil {
 		return nil
 	}
-	pub, priv, err := ed25519.GenerateKey(nil)
+	_, err := GenerateSigningKey(s.privateKeyPath, s.publicKeyPath, s.trustStorePath)
+	return err
+}
+
+// signManifest sets the manifest key ID, then marshals and signs it. priv is nil when signing is off.
+func signManifest(m *BundleManifest, priv ed25519.PrivateKey) (manifestBytes, sig []byte, err error) {
+	if priv != nil {
+		m.KeyID = KeyID(priv.Public().(ed25519.PublicKey))
+	}
+	manifestBytes, err = json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if priv != nil {
+		sig = ed25519.Sign(priv, manifestBytes)
+	}
+	return manifestBytes, sig, nil
+}
+
+// writeSigned writes manifest.json and, when signed, signature.ed25519 and public_key.ed25519.
+func writeSigned(dir string, manifestBytes, sig []byte, priv ed25519.PrivateKey) error {
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), manifestBytes, 0644); err != nil {
+		return err
+	}
+	if sig == nil {
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(dir, "signature.ed25519"), sig, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "public_key.ed25519"), priv.Public().(ed25519.PublicKey), 0644)
+}
+
+// payloadWriter writes a bundle's payload files, encrypted when the bundle has recipients, and
+// records the hash of each stored file for the manifest.
+type payloadWriter struct {
+	dir    string
+	enc    *encrypter // nil: plaintext
+	hashes map[string]string
+}
+
+func newPayloadWriter(dir string, recipients []*ecdh.PublicKey) (*payloadWriter, error) {
+	enc, err := newEncrypter(recipients)
 	if err != nil {
-		return fmt.Errorf("generate key: %w", err)
+		return nil, fmt.Errorf("bundle encryption: %w", err)
 	}
-	if err := os.WriteFile(s.privateKeyPath, priv, 0600); err != nil {
-		return fmt.Errorf("write private key: %w", err)
+	return &payloadWriter{dir: dir, enc: enc, hashes: make(map[string]string)}, nil
+}
+
+func (p *payloadWriter) write(name string, data []byte) error {
+	if p.enc != nil {
+		var err error
+		if name, data, err = p.enc.seal(name, data); err != nil {
+			return err
+		}
 	}
-	if err := os.WriteFile(s.publicKeyPath, pub, 0644); err != nil {
-		return fmt.Errorf("write public key: %w", err)
+	if err := os.WriteFile(filepath.Join(p.dir, name), data, 0644); err != nil {
+		return err
 	}
+	p.hashes[name] = sha256Hex(data)
 	return nil
 }
 
+// writeJSONL writes one JSON value per line.
+func (p *payloadWriter) writeJSONL(name string, n int, item func(i int) interface{}) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := 0; i < n; i++ {
+		if err := enc.Encode(item(i)); err != nil {
+			return err
+		}
+	}
+	return p.write(name, buf.Bytes())
+}
+
+// encryption returns the manifest record (nil for plaintext bundles).
+func (p *payloadWriter) encryption() *BundleEncryption {
+	if p.enc == nil {
+		return nil
+	}
+	return p.enc.info
+}
+
 func (s *Sink) getOrCreateSession(sessionID, agentID string) *sessionBundle {
 	if b, ok := s.sessions[sessionID]; ok {
 		return b
@@ -195,65 +301,26 @@ func (s *Sink) writeBundle(b *sessionBundle) error {
 		return fmt.Errorf("bundle dir: %w", err)
 	}
 
-	// decision_records.jsonl
-	decPath := filepath.Join(dir, "decision_records.jsonl")
-	decFile, err := os.Create(decPath)
+	payload, err := newPayloadWriter(dir, s.recipients)
 	if err != nil {
 		return err
 	}
-	enc := json.NewEncoder(decFile)
-	for _, d := range b.Decisions {
-		if err := enc.Encode(d); err != nil {
-			decFile.Close()
-			return err
-		}
-	}
-	if err := decFile.Close(); err != nil {
+	if err := payload.writeJSONL("decision_records.jsonl", len(b.Decisions), func(i int) interface{} { return b.Decisions[i] }); err != nil {
 		return err
 	}
-
-	// events.jsonl
-	evPath := filepath.Join(dir, "events.jsonl")
-	evFile, err := os.Create(evPath)
-	if err != nil {
+	if err := payload.writeJSONL("events.jsonl", len(b.Events), func(i int) interface{} { return b.Events[i] }); err != nil {
 		return err
 	}
-	evEnc := json.NewEncoder(evFile)
-	for _, e := range b.Events {
-		if err := evEnc.Encode(e); err != nil {
-			evFile.Close()
-			return err
-		}
-	}
-	if err := evFile.Close(); err != nil {
-		return err
-	}
-
 	// config_snapshot.yaml (redacted)
-	cfgPath := filepath.Join(dir, "config_snapshot.yaml")
-	cfgRedacted := redactConfig(s.configSnapshot)
-	cfgYaml, err := yaml.Marshal(cfgRedacted)
+	snapshot := s.configSnapshot.Load()
+	cfgYaml, err := yaml.Marshal(redactConfig(snapshot))
 	if err != nil {
 		return err
 	}
-	if err := os.WriteFile(cfgPath, cfgYaml, 0644); err != nil {
+	if err := payload.write("config_snapshot.yaml", cfgYaml); err != nil {
 		return err
 	}
 
-	// Hashes of the three files (manifest will be written after we sign it)
-	hashes := make(map[string]string)
-	for name, path := range map[string]string{
-		"decision_records.jsonl": decPath,
-		"events.jsonl":           evPath,
-		"config_snapshot.yaml":   cfgPath,
-	} {
-		h, err := sha256File(path)
-		if err != nil {
-			return err
-		}
-		hashes[name] = h
-	}
-
 	manifest := BundleManifest{
 		BundleVersion:    bundleVersion,
 		CreatedAt:        b.Created,
@@ -262,46 +329,39 @@ func (s *Sink) writeBundle(b *sessionBundle) error {
 		LedgerSinkKind:   "bundle",
 		SessionID:        b.SessionID,
 		AgentID:          b.AgentID,
-		Hashes:           hashes,
+		Hashes:           payload.hashes,
 		Redactions:       RedactKeys,
+		Encryption:       payload.encryption(),
+		ConfigRev:        snapshot.Revision,
 	}
-	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
-	if err != nil {
-		return err
-	}
-	manifestPath := filepath.Join(dir, "manifest.json")
-	if err := os.WriteFile(manifestPath, manifestBytes, 0644); err != nil {
-		return err
+	if s.chain != nil {
+		manifest.ChainCheckpoint, _ = eventchain.CheckpointFrom(context.Background(), s.chain)
 	}
-
-	var sig []byte
+	var priv ed25519.PrivateKey
 	if s.signEnabled {
-		priv, err := os.ReadFile(s.privateKeyPath)
-		if err != nil {
-			return fmt.Errorf("read private key: %w", err)
-		}
-		if len(priv) != ed25519.PrivateKeySize {
-			return fmt.Errorf("invalid private key size")
-		}
-		sig = ed25519.Sign(priv, manifestBytes)
-		if err := os.WriteFile(filepath.Join(dir, "signature.ed25519"), sig, 0644); err != nil {
+		if priv, err = ReadSigningKey(s.privateKeyPath); err != nil {
 			return err
 		}
-		pub, _ := os.ReadFile(s.publicKeyPath)
-		if len(pub) == ed25519.PublicKeySize {
-			_ = os.WriteFile(filepath.Join(dir, "public_key.ed25519"), pub, 0644)
-		}
 	}
-	return nil
+	manifestBytes, sig, err := signManifest(&manifest, priv)
+	if err != nil {
+		return err
+	}
+	if err := writeSigned(dir, manifestBytes, sig, priv); err != nil {
+		return err
+	}
+	return logBundle(s.translogDir, dir, priv)
 }
 
-func sha256File(path string) (string, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return "", err
+// logBundle appends the bundle in dir to the transparency log in logDir ("" skips).
+func logBundle(logDir, dir string, priv ed25519.PrivateKey) error {
+	if logDir == "" {
+		return nil
+	}
+	if _, err := OpenTransparencyLog(logDir).Append(dir, priv); err != nil {
+		return fmt.Errorf("transparency log: %w", err)
 	}
-	h := sha256.Sum256(data)
-	return hex.EncodeToString(h[:]), nil
+	return nil
 }
 
 // redactConfig returns a copy of config with sensitive values redacted (for config_snapshot.yaml).
@@ -329,47 +389,151 @@ func redactConfig(cfg *config.Config) interface{} {
 	}
 }
 
-// VerifyBundle verifies signature and manifest hashes for a bundle directory.
+// ReadManifest reads manifest.json from a bundle directory or archive (without verifying it).
+func ReadManifest(dir string) (*BundleManifest, error) {
+	read, err := openBundle(dir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := read("manifest.json")
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var m BundleManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// VerifyBundle verifies manifest hashes and the signature of a bundle directory or archive
+// against the public key shipped in the bundle. That proves integrity only: anyone can re-sign with their own key. Use VerifyBundleWith
+// and a TrustStore to check who signed it.
 func VerifyBundle(dir string) error {
-	manifestPath := filepath.Join(dir, "manifest.json")
-	manifestData, err := os.ReadFile(manifestPath)
+	_, err := VerifyBundleWith(dir, VerifyOptions{})
+	return err
+}
+
+// VerifyOptions selects the keys a bundle signature is checked against.
+type VerifyOptions struct {
+	Trust    *TrustStore      // nil: use the bundle's embedded public_key.ed25519
+	PinKeyID string           // if set, only this key is accepted
+	Log      *TransparencyLog // if set, retired keys only verify bundles this log recorded before retirement
+}
+
+// VerifyBundleWith verifies manifest hashes and the signature, and returns the signing key ID.
+// With a trust store the manifest key_id must name a trusted key (bundles without key_id are
+// tried against every trusted key) that is not revoked. A retired key only verifies a bundle
+// signed before retirement: with opts.Log, one covered by a signed tree head of that log from
+// before retirement; without it, one whose manifest created_at is before retirement, which the
+// signer chooses, so a leaked retired key can backdate bundles (revoke it instead).
+func VerifyBundleWith(dir string, opts VerifyOptions) (string, error) {
+	read, err := openBundle(dir)
+	if err != nil {
+		return "", err
+	}
+	manifestData, err := read("manifest.json")
 	if err != nil {
-		return fmt.Errorf("read manifest: %w", err)
+		return "", fmt.Errorf("read manifest: %w", err)
 	}
 	var m BundleManifest
 	if err := json.Unmarshal(manifestData, &m); err != nil {
-		return fmt.Errorf("parse manifest: %w", err)
+		return "", fmt.Errorf("parse manifest: %w", err)
 	}
 	for name, wantHash := range m.Hashes {
-		path := filepath.Join(dir, name)
-		got, err := sha256File(path)
+		data, err := read(name)
 		if err != nil {
-			return fmt.Errorf("%s: %w", name, err)
+			return "", fmt.Errorf("%s: %w", name, err)
 		}
-		if got != wantHash {
-			return fmt.Errorf("%s: hash mismatch (got %s, want %s)", name, got, wantHash)
+		if got := sha256Hex(data); got != wantHash {
+			return "", fmt.Errorf("%s: hash mismatch (got %s, want %s)", name, got, wantHash)
 		}
 	}
-	sigPath := filepath.Join(dir, "signature.ed25519")
-	pubPath := filepath.Join(dir, "public_key.ed25519")
-	pub, err := os.ReadFile(pubPath)
+	sig, err := read("signature.ed25519")
 	if err != nil {
-		return fmt.Errorf("read public key: %w", err)
+		return "", fmt.Errorf("read signature: %w", err)
 	}
-	if len(pub) != ed25519.PublicKeySize {
-		return fmt.Errorf("invalid public key size")
+	if opts.PinKeyID != "" && m.KeyID != "" && m.KeyID != opts.PinKeyID {
+		return "", fmt.Errorf("bundle signed by %s, not pinned key %s", m.KeyID, opts.PinKeyID)
 	}
-	sig, err := os.ReadFile(sigPath)
-	if err != nil {
-		return fmt.Errorf("read signature: %w", err)
+
+	if opts.Trust == nil {
+		pub, err := read("public_key.ed25519")
+		if err != nil {
+			return "", fmt.Errorf("read public key: %w", err)
+		}
+		if len(pub) != ed25519.PublicKeySize {
+			return "", fmt.Errorf("invalid public key size")
+		}
+		id := KeyID(pub)
+		if opts.PinKeyID != "" && id != opts.PinKeyID {
+			return "", fmt.Errorf("bundle signed by %s, not pinned key %s", id, opts.PinKeyID)
+		}
+		if !ed25519.Verify(pub, manifestData, sig) {
+			return "", fmt.Errorf("signature verification failed")
+		}
+		return id, nil
 	}
-	if !ed25519.Verify(pub, manifestData, sig) {
-		return fmt.Errorf("signature verification failed")
+
+	signedAt := &m.CreatedAt
+	if opts.Log != nil {
+		if signedAt, err = opts.Log.loggedAt(sha256Hex(manifestData), opts); err != nil {
+			return "", err
+		}
 	}
-	return nil
+	return verifyTrusted(opts, m.KeyID, signedAt, manifestData, sig)
+}
+
+// verifyTrusted checks sig over data against the trusted keys in opts (see VerifyBundleWith) for
+// something signed at `at` by keyID ("" tries every trusted key), and returns the signing key ID.
+// A nil `at` (signing time unknown) is accepted from active keys only.
+func verifyTrusted(opts VerifyOptions, keyID string, at *time.Time, data, sig []byte) (string, error) {
+	var candidates []TrustedKey
+	for _, k := range opts.Trust.Keys {
+		if (keyID == "" || k.KeyID == keyID) && (opts.PinKeyID == "" || k.KeyID == opts.PinKeyID) {
+			candidates = append(candidates, k)
+		}
+	}
+	if len(candidates) == 0 {
+		if keyID != "" {
+			return "", fmt.Errorf("signed by untrusted key %s (trust it with: ctrldot keys trust)", keyID)
+		}
+		return "", fmt.Errorf("no trusted key to verify against (add one with: ctrldot keys trust)")
+	}
+	for _, k := range candidates {
+		pub, err := k.Public()
+		if err != nil {
+			return "", err
+		}
+		if !ed25519.Verify(pub, data, sig) {
+			continue
+		}
+		if k.RevokedAt != nil {
+			return "", fmt.Errorf("signed by %s, which was revoked (%s)", k.KeyID, k.RevokedAt.Format(time.RFC3339))
+		}
+		if k.RetiredAt != nil && at == nil {
+			return "", fmt.Errorf("signed by %s, which was retired (%s), and not logged before then", k.KeyID, k.RetiredAt.Format(time.RFC3339))
+		}
+		if k.RetiredAt != nil && at.After(*k.RetiredAt) {
+			return "", fmt.Errorf("signed by %s after the key was retired (%s)", k.KeyID, k.RetiredAt.Format(time.RFC3339))
+		}
+		return k.KeyID, nil
+	}
+	return "", fmt.Errorf("signature verification failed: not signed by a trusted key")
 }
 
-// ListBundles returns bundle directory names in outputDir, sorted by name (newest last).
+// BundleDirs returns the directories bundles are written to: ledger_sink.bundle.output_dir and,
+// when set to somewhere else, autobundle.output_dir (~ expanded).
+func BundleDirs(cfg *config.Config) []string {
+	dirs := []string{expandPath(cfg.LedgerSink.Bundle.OutputDir)}
+	if d := expandPath(cfg.Autobundle.OutputDir); d != "" && d != dirs[0] {
+		dirs = append(dirs, d)
+	}
+	return dirs
+}
+
+// ListBundles returns the bundle directory and archive (.tar.gz) names in outputDir, sorted by
+// name (newest last).
 func ListBundles(outputDir string) ([]string, error) {
 	outputDir = expandPath(outputDir)
 	entries, err := os.ReadDir(outputDir)
@@ -381,7 +545,7 @@ func ListBundles(outputDir string) ([]string, error) {
 	}
 	var names []string
 	for _, e := range entries {
-		if e.IsDir() && len(e.Name()) > 7 && e.Name()[:7] == "bundle_" {
+		if len(e.Name()) > 7 && e.Name()[:7] == "bundle_" && (e.IsDir() || (e.Type().IsRegular() && IsArchive(e.Name()))) {
 			names = append(names, e.Name())
 		}
 	}
@@ -407,6 +571,10 @@ type WriteOneOptions struct {
 	EffectivePanicEnabled bool
 	ReasonCodes           []string // for README.md
 	NextSteps             []string // for README.md (runnable commands)
+	ChainCheckpoint       *eventchain.Checkpoint
+	TransparencyLogDir    string   // append the bundle to this transparency log ("" skips)
+	EncryptRecipients     []string // encrypt payload files to these X25519 keys (see ParseRecipient)
+	ConfigRev             int64    // config revision in force (0 = not recorded)
 }
 
 // WriteOne writes a single bundle directory with the given decisions, events, and optional trigger metadata.
@@ -431,57 +599,28 @@ func WriteOne(opts WriteOneOptions) (string, error) {
 		return "", fmt.Errorf("bundle dir: %w", err)
 	}
 
-	decPath := filepath.Join(dir, "decision_records.jsonl")
-	decFile, err := os.Create(decPath)
+	recipients, err := ParseRecipients(opts.EncryptRecipients)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("bundle encryption: %w", err)
 	}
-	enc := json.NewEncoder(decFile)
-	for _, d := range opts.Decisions {
-		if err := enc.Encode(d); err != nil {
-			decFile.Close()
-			return "", err
-		}
-	}
-	if err := decFile.Close(); err != nil {
-		return "", err
-	}
-
-	evPath := filepath.Join(dir, "events.jsonl")
-	evFile, err := os.Create(evPath)
+	payload, err := newPayloadWriter(dir, recipients)
 	if err != nil {
 		return "", err
 	}
-	evEnc := json.NewEncoder(evFile)
-	for _, e := range opts.Events {
-		_ = evEnc.Encode(e)
-	}
-	if err := evFile.Close(); err != nil {
+	if err := payload.writeJSONL("decision_records.jsonl", len(opts.Decisions), func(i int) interface{} { return opts.Decisions[i] }); err != nil {
 		return "", err
 	}
-
-	hashes := make(map[string]string)
-	for name, path := range map[string]string{"decision_records.jsonl": decPath, "events.jsonl": evPath} {
-		h, err := sha256File(path)
-		if err != nil {
-			return "", err
-		}
-		hashes[name] = h
+	if err := payload.writeJSONL("events.jsonl", len(opts.Events), func(i int) interface{} { return opts.Events[i] }); err != nil {
+		return "", err
 	}
 	if opts.ConfigSnapshot != nil {
-		cfgPath := filepath.Join(dir, "config_snapshot.yaml")
 		cfgYaml, err := yaml.Marshal(redactConfig(opts.ConfigSnapshot))
 		if err != nil {
 			return "", err
 		}
-		if err := os.WriteFile(cfgPath, cfgYaml, 0644); err != nil {
-			return "", err
-		}
-		h, err := sha256File(cfgPath)
-		if err != nil {
+		if err := payload.write("config_snapshot.yaml", cfgYaml); err != nil {
 			return "", err
 		}
-		hashes["config_snapshot.yaml"] = h
 	}
 
 	manifest := BundleManifest{
@@ -492,37 +631,31 @@ func WriteOne(opts WriteOneOptions) (string, error) {
 		LedgerSinkKind:         "bundle",
 		SessionID:              opts.SessionID,
 		AgentID:                opts.AgentID,
-		Hashes:                 hashes,
+		Hashes:                 payload.hashes,
 		Redactions:             RedactKeys,
 		Trigger:                opts.Trigger,
 		TriggeredAt:            now,
 		DecisionID:             opts.DecisionID,
 		EffectivePanicEnabled:  opts.EffectivePanicEnabled,
+		ChainCheckpoint:        opts.ChainCheckpoint,
+		Encryption:             payload.encryption(),
+		ConfigRev:              opts.ConfigRev,
+	}
+	var priv ed25519.PrivateKey
+	if opts.SignEnabled {
+		if priv, err = ReadSigningKey(opts.PrivateKeyPath); err != nil {
+			return "", err
+		}
 	}
-	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	manifestBytes, sig, err := signManifest(&manifest, priv)
 	if err != nil {
 		return "", err
 	}
-	manifestPath := filepath.Join(dir, "manifest.json")
-	if err := os.WriteFile(manifestPath, manifestBytes, 0644); err != nil {
+	if err := writeSigned(dir, manifestBytes, sig, priv); err != nil {
 		return "", err
 	}
-	if opts.SignEnabled {
-		priv, err := os.ReadFile(expandPath(opts.PrivateKeyPath))
-		if err != nil {
-			return "", fmt.Errorf("read private key: %w", err)
-		}
-		if len(priv) != ed25519.PrivateKeySize {
-			return "", fmt.Errorf("invalid private key size")
-		}
-		sig := ed25519.Sign(priv, manifestBytes)
-		if err := os.WriteFile(filepath.Join(dir, "signature.ed25519"), sig, 0644); err != nil {
-			return "", err
-		}
-		pub, _ := os.ReadFile(expandPath(opts.PublicKeyPath))
-		if len(pub) == ed25519.PublicKeySize {
-			_ = os.WriteFile(filepath.Join(dir, "public_key.ed25519"), pub, 0644)
-		}
+	if err := logBundle(opts.TransparencyLogDir, dir, priv); err != nil {
+		return "", err
 	}
 
 	// README.md (not in manifest hashes; for humans and agents)
@@ -534,6 +667,11 @@ func WriteOne(opts WriteOneOptions) (string, error) {
 	if len(opts.Decisions) > 0 {
 		reasonSummary = opts.Decisions[0].Reason
 	}
+	nextSteps := opts.NextSteps
+	if payload.enc != nil {
+		// Reasons and suggested commands can name targets; keep them in the encrypted payload
+		reasonSummary, nextSteps = "", nil
+	}
 	_ = WriteREADME(dir, ReadMEOptions{
 		Trigger:       opts.Trigger,
 		Timestamp:     now,
@@ -543,8 +681,9 @@ func WriteOne(opts WriteOneOptions) (string, error) {
 		ReasonCodes:   opts.ReasonCodes,
 		ReasonSummary: reasonSummary,
 		PanicEnabled:  opts.EffectivePanicEnabled,
-		NextSteps:     opts.NextSteps,
+		NextSteps:     nextSteps,
 		BundleDirName: dirName,
+		Encrypted:     payload.enc != nil,
 	})
 
 	return dir, nil
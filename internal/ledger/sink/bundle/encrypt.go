@@ -0,0 +1,319 @@
+package bundle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Encrypted bundles store each payload file as <name>.enc, sealed with AES-256-GCM under a random
+// per-bundle data key (file name as additional data). The data key is wrapped for each recipient
+// with a key derived (HKDF-SHA256) from an ephemeral X25519 exchange with the recipient's public
+// key. The manifest lists the recipients and hashes the ciphertext, so the signature can be
+// verified without decrypting.
+
+// EncryptionScheme identifies the construction above in manifests.
+const EncryptionScheme = "x25519-hkdf-sha256-aes256gcm/v1"
+
+const (
+	encryptedExt    = ".enc"
+	recipientPrefix = "x25519:"
+	wrapInfo        = "ctrldot-bundle-key/v1"
+)
+
+// BundleEncryption is the manifest record of an encrypted bundle.
+type BundleEncryption struct {
+	Scheme     string               `json:"scheme"`
+	Files      []string             `json:"files"` // payload names; stored as <name>.enc
+	Recipients []EncryptedRecipient `json:"recipients"`
+}
+
+// EncryptedRecipient is the data key wrapped for one recipient.
+type EncryptedRecipient struct {
+	KeyID        string `json:"key_id"`        // RecipientKeyID of the recipient public key
+	EphemeralKey string `json:"ephemeral_key"` // base64 X25519 public key
+	WrappedKey   string `json:"wrapped_key"`   // base64 AES-256-GCM ciphertext of the data key
+}
+
+// RecipientKeyID identifies an X25519 recipient key: "x25519:" + the first 16 hex chars of its SHA-256.
+func RecipientKeyID(pub *ecdh.PublicKey) string {
+	sum := sha256.Sum256(pub.Bytes())
+	return recipientPrefix + hex.EncodeToString(sum[:8])
+}
+
+// FormatRecipient returns the config form of a recipient public key ("x25519:<base64>").
+func FormatRecipient(pub *ecdh.PublicKey) string {
+	return recipientPrefix + base64.StdEncoding.EncodeToString(pub.Bytes())
+}
+
+// ParseRecipient parses a recipient public key from ledger_sink.bundle.encrypt.recipients
+// ("x25519:<base64>", or bare base64).
+func ParseRecipient(s string) (*ecdh.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), recipientPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("recipient %q: not base64", s)
+	}
+	pub, err := ecdh.X25519().NewPublicKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("recipient %q: %w", s, err)
+	}
+	return pub, nil
+}
+
+// ParseRecipients parses all configured recipients.
+func ParseRecipients(recipients []string) ([]*ecdh.PublicKey, error) {
+	out := make([]*ecdh.PublicKey, 0, len(recipients))
+	for _, r := range recipients {
+		pub, err := ParseRecipient(r)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, pub)
+	}
+	return out, nil
+}
+
+// GenerateRecipientKey writes a new X25519 private key (base64, mode 0600) to path and returns
+// its public key.
+func GenerateRecipientKey(path string) (*ecdh.PublicKey, error) {
+	path = expandPath(path)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("%s already exists", path)
+	}
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(priv.Bytes())+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return priv.PublicKey(), nil
+}
+
+// ReadRecipientKey reads an X25519 private key written by GenerateRecipientKey (raw 32 bytes
+// are accepted too).
+func ReadRecipientKey(path string) (*ecdh.PrivateKey, error) {
+	data, err := os.ReadFile(expandPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("read recipient key: %w", err)
+	}
+	if len(data) != 32 {
+		if data, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(data))); err != nil {
+			return nil, fmt.Errorf("recipient key %s: not base64", path)
+		}
+	}
+	priv, err := ecdh.X25519().NewPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("recipient key %s: %w", path, err)
+	}
+	return priv, nil
+}
+
+// encrypter seals payload files for one bundle.
+type encrypter struct {
+	aead cipher.AEAD
+	info *BundleEncryption
+}
+
+// newEncrypter creates a data key and wraps it for each recipient. No recipients: nil (plaintext).
+func newEncrypter(recipients []*ecdh.PublicKey) (*encrypter, error) {
+	if len(recipients) == 0 {
+		return nil, nil
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	e := &encrypter{aead: aead, info: &BundleEncryption{Scheme: EncryptionScheme}}
+	for _, pub := range recipients {
+		eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		shared, err := eph.ECDH(pub)
+		if err != nil {
+			return nil, err
+		}
+		kek, err := wrapKey(shared, eph.PublicKey(), pub)
+		if err != nil {
+			return nil, err
+		}
+		// The key-encryption key is used once, so a fixed nonce is safe
+		wrapped := kek.Seal(nil, make([]byte, kek.NonceSize()), dataKey, nil)
+		e.info.Recipients = append(e.info.Recipients, EncryptedRecipient{
+			KeyID:        RecipientKeyID(pub),
+			EphemeralKey: base64.StdEncoding.EncodeToString(eph.PublicKey().Bytes()),
+			WrappedKey:   base64.StdEncoding.EncodeToString(wrapped),
+		})
+	}
+	return e, nil
+}
+
+// seal encrypts a payload file and returns its stored name and contents (nonce || ciphertext).
+func (e *encrypter) seal(name string, plaintext []byte) (string, []byte, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	e.info.Files = append(e.info.Files, name)
+	return name + encryptedExt, e.aead.Seal(nonce, nonce, plaintext, []byte(name)), nil
+}
+
+// wrapKey derives the AEAD that wraps the data key from the X25519 shared secret; the ephemeral
+// and recipient public keys are bound in as the HKDF salt.
+func wrapKey(shared []byte, ephPub, recipientPub *ecdh.PublicKey) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, ephPub.Bytes()...), recipientPub.Bytes()...)
+	key, err := hkdf.Key(sha256.New, shared, salt, wrapInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	return newGCM(key)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// openDataKey unwraps the bundle data key with the recipient private key.
+func openDataKey(enc *BundleEncryption, priv *ecdh.PrivateKey) (cipher.AEAD, error) {
+	if enc.Scheme != EncryptionScheme {
+		return nil, fmt.Errorf("unsupported encryption scheme %q", enc.Scheme)
+	}
+	id := RecipientKeyID(priv.PublicKey())
+	for _, r := range enc.Recipients {
+		if r.KeyID != id {
+			continue
+		}
+		ephBytes, err := base64.StdEncoding.DecodeString(r.EphemeralKey)
+		if err != nil {
+			return nil, fmt.Errorf("recipient %s: bad ephemeral key", id)
+		}
+		eph, err := ecdh.X25519().NewPublicKey(ephBytes)
+		if err != nil {
+			return nil, fmt.Errorf("recipient %s: %w", id, err)
+		}
+		wrapped, err := base64.StdEncoding.DecodeString(r.WrappedKey)
+		if err != nil {
+			return nil, fmt.Errorf("recipient %s: bad wrapped key", id)
+		}
+		shared, err := priv.ECDH(eph)
+		if err != nil {
+			return nil, fmt.Errorf("recipient %s: %w", id, err)
+		}
+		kek, err := wrapKey(shared, eph, priv.PublicKey())
+		if err != nil {
+			return nil, err
+		}
+		dataKey, err := kek.Open(nil, make([]byte, kek.NonceSize()), wrapped, nil)
+		if err != nil {
+			return nil, fmt.Errorf("recipient %s: cannot unwrap the data key", id)
+		}
+		return newGCM(dataKey)
+	}
+	return nil, fmt.Errorf("bundle is not encrypted to %s", id)
+}
+
+// Decrypt decrypts the encrypted bundle at path (directory or archive) with the recipient
+// private key into outDir: a copy of the bundle (so `ctrldot bundle verify` still works on it)
+// plus the plaintext payload files. The bundle should be verified first.
+func Decrypt(path string, priv *ecdh.PrivateKey, outDir string) error {
+	m, err := ReadManifest(path)
+	if err != nil {
+		return err
+	}
+	if m.Encryption == nil {
+		return fmt.Errorf("bundle is not encrypted")
+	}
+	aead, err := openDataKey(m.Encryption, priv)
+	if err != nil {
+		return err
+	}
+	read, err := openBundle(path)
+	if err != nil {
+		return err
+	}
+	plain := make(map[string][]byte, len(m.Encryption.Files))
+	for _, name := range m.Encryption.Files {
+		sealed, err := read(name + encryptedExt)
+		if err != nil {
+			return err
+		}
+		if len(sealed) < aead.NonceSize() {
+			return fmt.Errorf("%s%s: truncated", name, encryptedExt)
+		}
+		data, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name))
+		if err != nil {
+			return fmt.Errorf("%s%s: decryption failed", name, encryptedExt)
+		}
+		plain[name] = data
+	}
+
+	if _, err := os.Stat(outDir); err == nil {
+		return fmt.Errorf("%s already exists", outDir)
+	}
+	if err := os.MkdirAll(outDir, 0700); err != nil {
+		return err
+	}
+	if err := copyBundleFiles(path, outDir); err != nil {
+		return err
+	}
+	for name, data := range plain {
+		if err := os.WriteFile(filepath.Join(outDir, name), data, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyBundleFiles copies every file of the bundle directory or archive at path into dir.
+func copyBundleFiles(path, dir string) error {
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		_, files, err := readArchive(path)
+		if err != nil {
+			return err
+		}
+		for name, data := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(path, e.Name()))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, e.Name()), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
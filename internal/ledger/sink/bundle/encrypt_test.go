@@ -0,0 +1,131 @@
+package bundle
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/ledger/sink"
+)
+
+func TestEncryptedBundle(t *testing.T) {
+	k := newTestKeys(t)
+	keyDir := t.TempDir()
+	alice, err := GenerateRecipientKey(filepath.Join(keyDir, "alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := GenerateRecipientKey(filepath.Join(keyDir, "bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const secret = "/srv/customers/acme/ledger.csv"
+	dir, err := WriteOne(WriteOneOptions{
+		OutputDir: t.TempDir(), SignEnabled: true, PrivateKeyPath: k.priv, SessionID: "s1",
+		Decisions: []*sink.DecisionRecord{{ID: "d1", Decision: domain.DecisionDeny, Reason: "deny write to " + secret,
+			ActionTarget: map[string]interface{}{"path": secret}}},
+		NextSteps:         []string{"ctrldot resolve allow-once --target " + secret},
+		EncryptRecipients: []string{FormatRecipient(alice), FormatRecipient(bob)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing on disk reveals the target; hashes cover the ciphertext
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		data, _ := os.ReadFile(filepath.Join(dir, e.Name()))
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("%s contains the plaintext target", e.Name())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "decision_records.jsonl")); !os.IsNotExist(err) {
+		t.Error("plaintext decision_records.jsonl written")
+	}
+	m, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Encryption == nil || len(m.Encryption.Recipients) != 2 || m.Hashes["decision_records.jsonl.enc"] == "" {
+		t.Fatalf("manifest = %+v", m)
+	}
+	opts := VerifyOptions{Trust: loadTrust(t, k.trust)}
+	if _, err := VerifyBundleWith(dir, opts); err != nil {
+		t.Fatalf("verify without decrypting: %v", err)
+	}
+
+	for _, name := range []string{"alice", "bob"} {
+		priv, err := ReadRecipientKey(filepath.Join(keyDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := filepath.Join(t.TempDir(), "plain")
+		if err := Decrypt(dir, priv, out); err != nil {
+			t.Fatalf("%s: Decrypt: %v", name, err)
+		}
+		data, err := os.ReadFile(filepath.Join(out, "decision_records.jsonl"))
+		if err != nil || !strings.Contains(string(data), secret) {
+			t.Errorf("%s: decrypted decisions = %q, %v", name, data, err)
+		}
+		if _, err := VerifyBundleWith(out, opts); err != nil {
+			t.Errorf("%s: verify decrypted copy: %v", name, err)
+		}
+	}
+
+	// Decrypting from the packed archive works too
+	archive, err := PackFile(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, _ := ReadRecipientKey(filepath.Join(keyDir, "alice"))
+	if err := Decrypt(archive, priv, filepath.Join(t.TempDir(), "plain")); err != nil {
+		t.Errorf("Decrypt(archive): %v", err)
+	}
+
+	if _, err := GenerateRecipientKey(filepath.Join(keyDir, "eve")); err != nil {
+		t.Fatal(err)
+	}
+	eve, _ := ReadRecipientKey(filepath.Join(keyDir, "eve"))
+	if err := Decrypt(dir, eve, filepath.Join(t.TempDir(), "plain")); err == nil || !strings.Contains(err.Error(), "not encrypted to") {
+		t.Errorf("Decrypt with a non-recipient key: %v", err)
+	}
+}
+
+func TestTamperedCiphertext(t *testing.T) {
+	k := newTestKeys(t)
+	keyPath := filepath.Join(t.TempDir(), "r")
+	pub, err := GenerateRecipientKey(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := WriteOne(WriteOneOptions{OutputDir: t.TempDir(), SignEnabled: true, PrivateKeyPath: k.priv, SessionID: "s1",
+		Events: []*domain.Event{{EventID: "e1", Type: "x"}}, EncryptRecipients: []string{FormatRecipient(pub)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "events.jsonl.enc")
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] ^= 1
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyBundle(dir); err == nil {
+		t.Error("tampered ciphertext verified")
+	}
+	priv, _ := ReadRecipientKey(keyPath)
+	if err := Decrypt(dir, priv, filepath.Join(t.TempDir(), "plain")); err == nil {
+		t.Error("tampered ciphertext decrypted")
+	}
+}
+
+func TestParseRecipient(t *testing.T) {
+	if _, err := ParseRecipient("x25519:not-base64!"); err == nil {
+		t.Error("bad base64 parsed")
+	}
+	if _, err := ParseRecipient("x25519:AAAA"); err == nil {
+		t.Error("short key parsed")
+	}
+}
@@ -0,0 +1,236 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+)
+
+// KeyID identifies an Ed25519 public key: "ed25519:" + the first 16 hex chars of its SHA-256.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return "ed25519:" + hex.EncodeToString(sum[:8])
+}
+
+// TrustedKey is a public key allowed to sign bundles. Retired keys (after rotation) still verify
+// bundles signed before RetiredAt: logged before it, when VerifyOptions.Log is set, else created
+// before it by the manifest's own created_at, which a leaked key can backdate. Revoked keys
+// verify nothing.
+type TrustedKey struct {
+	KeyID     string     `json:"key_id"`
+	PublicKey string     `json:"public_key"` // base64
+	Label     string     `json:"label,omitempty"`
+	AddedAt   time.Time  `json:"added_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Public decodes the key.
+func (k TrustedKey) Public() (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(k.PublicKey)
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("trusted key %s: invalid public key", k.KeyID)
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// TrustStore is the local set of trusted bundle signing keys, kept as a JSON file
+// (ledger_sink.bundle.sign.trust_store_path).
+type TrustStore struct {
+	path string
+	Keys []TrustedKey `json:"keys"`
+}
+
+// TrustStorePath is sign.trust_store_path, defaulting to trusted_keys.json next to the signing key.
+func TrustStorePath(sign config.LedgerBundleSign) string {
+	if sign.TrustStorePath != "" {
+		return expandPath(sign.TrustStorePath)
+	}
+	return filepath.Join(filepath.Dir(expandPath(sign.KeyPath)), "trusted_keys.json")
+}
+
+// LoadTrustStore reads the trust store at path; a missing file is an empty store.
+func LoadTrustStore(path string) (*TrustStore, error) {
+	ts := &TrustStore{path: expandPath(path)}
+	data, err := os.ReadFile(ts.path)
+	if os.IsNotExist(err) {
+		return ts, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read trust store: %w", err)
+	}
+	if err := json.Unmarshal(data, ts); err != nil {
+		return nil, fmt.Errorf("parse trust store %s: %w", ts.path, err)
+	}
+	return ts, nil
+}
+
+// Save writes the trust store atomically.
+func (ts *TrustStore) Save() error {
+	if err := os.MkdirAll(filepath.Dir(ts.path), 0700); err != nil {
+		return fmt.Errorf("trust store dir: %w", err)
+	}
+	sort.Slice(ts.Keys, func(i, j int) bool { return ts.Keys[i].AddedAt.Before(ts.Keys[j].AddedAt) })
+	data, err := json.MarshalIndent(ts, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := ts.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write trust store: %w", err)
+	}
+	return os.Rename(tmp, ts.path)
+}
+
+// Get returns the trusted key with id, or nil.
+func (ts *TrustStore) Get(id string) *TrustedKey {
+	for i := range ts.Keys {
+		if ts.Keys[i].KeyID == id {
+			return &ts.Keys[i]
+		}
+	}
+	return nil
+}
+
+// Trust adds pub to the store (no-op when already trusted) and returns its key ID.
+func (ts *TrustStore) Trust(pub ed25519.PublicKey, label string) string {
+	id := KeyID(pub)
+	if ts.Get(id) == nil {
+		ts.Keys = append(ts.Keys, TrustedKey{
+			KeyID:     id,
+			PublicKey: base64.StdEncoding.EncodeToString(pub),
+			Label:     label,
+			AddedAt:   time.Now().UTC(),
+		})
+	}
+	return id
+}
+
+// Revoke marks the trusted key id as revoked, so nothing it signed verifies any more.
+func (ts *TrustStore) Revoke(id string) error {
+	k := ts.Get(id)
+	if k == nil {
+		return fmt.Errorf("no trusted key %s", id)
+	}
+	if k.RevokedAt == nil {
+		now := time.Now().UTC()
+		k.RevokedAt = &now
+	}
+	return nil
+}
+
+// ExportedKey is the `ctrldot keys export` format, accepted by `ctrldot keys trust`.
+type ExportedKey struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"` // base64
+}
+
+// ParsePublicKey accepts a raw 32-byte key (public_key.ed25519), an ExportedKey JSON document,
+// or a base64 or hex encoded key.
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	if len(data) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(data), nil
+	}
+	text := strings.TrimSpace(string(data))
+	var exported ExportedKey
+	if json.Unmarshal([]byte(text), &exported) == nil && exported.PublicKey != "" {
+		text = exported.PublicKey
+	}
+	for _, decode := range []func(string) ([]byte, error){base64.StdEncoding.DecodeString, hex.DecodeString} {
+		if b, err := decode(text); err == nil && len(b) == ed25519.PublicKeySize {
+			if exported.KeyID != "" && exported.KeyID != KeyID(b) {
+				return nil, fmt.Errorf("key_id %s does not match the public key", exported.KeyID)
+			}
+			return ed25519.PublicKey(b), nil
+		}
+	}
+	return nil, fmt.Errorf("not an Ed25519 public key (want raw 32 bytes, base64, hex, or ctrldot keys export JSON)")
+}
+
+// ReadSigningKey reads the private signing key at privateKeyPath.
+func ReadSigningKey(privateKeyPath string) (ed25519.PrivateKey, error) {
+	priv, err := os.ReadFile(expandPath(privateKeyPath))
+	if err != nil {
+		return nil, fmt.Errorf("read private key: %w", err)
+	}
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key size")
+	}
+	return ed25519.PrivateKey(priv), nil
+}
+
+// GenerateSigningKey writes a new keypair to privateKeyPath/publicKeyPath and trusts it.
+func GenerateSigningKey(privateKeyPath, publicKeyPath, trustStorePath string) (string, error) {
+	privateKeyPath, publicKeyPath = expandPath(privateKeyPath), expandPath(publicKeyPath)
+	if err := os.MkdirAll(filepath.Dir(privateKeyPath), 0700); err != nil {
+		return "", fmt.Errorf("keys dir: %w", err)
+	}
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return "", fmt.Errorf("generate key: %w", err)
+	}
+	if err := os.WriteFile(privateKeyPath, priv, 0600); err != nil {
+		return "", fmt.Errorf("write private key: %w", err)
+	}
+	if publicKeyPath != "" {
+		if err := os.WriteFile(publicKeyPath, pub, 0644); err != nil {
+			return "", fmt.Errorf("write public key: %w", err)
+		}
+	}
+	if trustStorePath == "" {
+		return KeyID(pub), nil
+	}
+	ts, err := LoadTrustStore(trustStorePath)
+	if err != nil {
+		return "", err
+	}
+	id := ts.Trust(pub, "generated "+time.Now().UTC().Format(time.RFC3339))
+	return id, ts.Save()
+}
+
+// RotateSigningKey retires the current signing key (its private key is moved to
+// <keys dir>/retired/<key id>, and it stays trusted for bundles created before now) and
+// generates and trusts a new one. Returns the old and new key IDs.
+func RotateSigningKey(privateKeyPath, publicKeyPath, trustStorePath string) (oldID, newID string, err error) {
+	old, err := ReadSigningKey(privateKeyPath)
+	if err != nil {
+		return "", "", err
+	}
+	oldPub := old.Public().(ed25519.PublicKey)
+	oldID = KeyID(oldPub)
+	ts, err := LoadTrustStore(trustStorePath)
+	if err != nil {
+		return "", "", err
+	}
+	ts.Trust(oldPub, "")
+	now := time.Now().UTC()
+	ts.Get(oldID).RetiredAt = &now
+	if err := ts.Save(); err != nil {
+		return "", "", err
+	}
+
+	retiredDir := filepath.Join(filepath.Dir(expandPath(privateKeyPath)), "retired")
+	if err := os.MkdirAll(retiredDir, 0700); err != nil {
+		return "", "", fmt.Errorf("retired keys dir: %w", err)
+	}
+	retiredPath := filepath.Join(retiredDir, strings.ReplaceAll(oldID, ":", "_"))
+	if err := os.Rename(expandPath(privateKeyPath), retiredPath); err != nil {
+		return "", "", fmt.Errorf("retire private key: %w", err)
+	}
+	newID, err = GenerateSigningKey(privateKeyPath, publicKeyPath, trustStorePath)
+	if err != nil {
+		return "", "", err
+	}
+	return oldID, newID, nil
+}
@@ -0,0 +1,234 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testKeys struct {
+	priv, pub, trust string
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	dir := t.TempDir()
+	k := testKeys{filepath.Join(dir, "k.ed25519"), filepath.Join(dir, "k.pub"), filepath.Join(dir, "trusted_keys.json")}
+	if _, err := GenerateSigningKey(k.priv, k.pub, k.trust); err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func writeSignedBundle(t *testing.T, k testKeys, session string) string {
+	t.Helper()
+	dir, err := WriteOne(WriteOneOptions{OutputDir: t.TempDir(), SignEnabled: true, PrivateKeyPath: k.priv, PublicKeyPath: k.pub, SessionID: session})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func loadTrust(t *testing.T, path string) *TrustStore {
+	t.Helper()
+	ts, err := LoadTrustStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func TestVerifyAgainstTrustedKeys(t *testing.T) {
+	k := newTestKeys(t)
+	dir := writeSignedBundle(t, k, "s1")
+	m, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := loadTrust(t, k.trust)
+	if id, err := VerifyBundleWith(dir, VerifyOptions{Trust: ts}); err != nil || id != m.KeyID {
+		t.Fatalf("VerifyBundleWith = %q, %v; want %s", id, err, m.KeyID)
+	}
+	if _, err := VerifyBundleWith(dir, VerifyOptions{Trust: ts, PinKeyID: m.KeyID}); err != nil {
+		t.Errorf("pinned to signer: %v", err)
+	}
+	if _, err := VerifyBundleWith(dir, VerifyOptions{Trust: ts, PinKeyID: "ed25519:0000000000000000"}); err == nil {
+		t.Error("pinned to another key: verified")
+	}
+	if _, err := VerifyBundleWith(dir, VerifyOptions{Trust: loadTrust(t, filepath.Join(t.TempDir(), "none.json"))}); err == nil {
+		t.Error("empty trust store: verified")
+	}
+}
+
+func TestForgedBundleIsRejected(t *testing.T) {
+	k := newTestKeys(t)
+	dir := writeSignedBundle(t, k, "s1")
+
+	// Re-sign a tampered manifest with the forger's key and ship that key in the bundle
+	m, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.AgentID = "someone-else"
+	_, forger, _ := ed25519.GenerateKey(nil)
+	manifestBytes, sig, err := signManifest(m, forger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSigned(dir, manifestBytes, sig, forger); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyBundle(dir); err != nil {
+		t.Fatalf("embedded-key verify should still pass (integrity only): %v", err)
+	}
+	_, err = VerifyBundleWith(dir, VerifyOptions{Trust: loadTrust(t, k.trust)})
+	if err == nil || !strings.Contains(err.Error(), "untrusted key") {
+		t.Errorf("forged bundle: err = %v, want untrusted key", err)
+	}
+
+	// Claiming the trusted key_id does not help without its private key
+	m.KeyID = loadTrust(t, k.trust).Keys[0].KeyID
+	manifestBytes, _ = json.MarshalIndent(m, "", "  ")
+	sig = ed25519.Sign(forger, manifestBytes)
+	if err := writeSigned(dir, manifestBytes, sig, forger); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyBundleWith(dir, VerifyOptions{Trust: loadTrust(t, k.trust)}); err == nil {
+		t.Error("forged bundle claiming the trusted key_id: verified")
+	}
+}
+
+func TestRotateKeepsOldBundlesVerifiable(t *testing.T) {
+	k := newTestKeys(t)
+	before := writeSignedBundle(t, k, "before")
+	oldPriv, err := ReadSigningKey(k.priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldID, newID, err := RotateSigningKey(k.priv, k.pub, k.trust)
+	if err != nil || oldID == newID {
+		t.Fatalf("RotateSigningKey = %s, %s, %v", oldID, newID, err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(k.priv), "retired", strings.ReplaceAll(oldID, ":", "_"))); err != nil {
+		t.Errorf("retired private key: %v", err)
+	}
+	after := writeSignedBundle(t, k, "after")
+
+	ts := loadTrust(t, k.trust)
+	if ts.Get(oldID) == nil || ts.Get(oldID).RetiredAt == nil || ts.Get(newID) == nil {
+		t.Fatalf("trust store after rotation = %+v", ts.Keys)
+	}
+	if id, err := VerifyBundleWith(before, VerifyOptions{Trust: ts}); err != nil || id != oldID {
+		t.Errorf("bundle from before rotation = %q, %v", id, err)
+	}
+	if id, err := VerifyBundleWith(after, VerifyOptions{Trust: ts}); err != nil || id != newID {
+		t.Errorf("bundle from after rotation = %q, %v", id, err)
+	}
+
+	// A leaked retired key cannot sign new bundles
+	retiredKey := filepath.Join(t.TempDir(), "old.ed25519")
+	if err := os.WriteFile(retiredKey, oldPriv, 0600); err != nil {
+		t.Fatal(err)
+	}
+	late := writeSignedBundle(t, testKeys{priv: retiredKey}, "late")
+	if _, err := VerifyBundleWith(late, VerifyOptions{Trust: ts}); err == nil || !strings.Contains(err.Error(), "retired") {
+		t.Errorf("bundle signed with retired key after rotation: err = %v", err)
+	}
+}
+
+func TestRetiredKeyIsDatedByTheLog(t *testing.T) {
+	k := newTestKeys(t)
+	out, logDir := t.TempDir(), t.TempDir()
+	logged := writeLoggedBundles(t, k, out, logDir, "logged")[0]
+	oldPriv, err := ReadSigningKey(k.priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldID, _, err := RotateSigningKey(k.priv, k.pub, k.trust)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The leaked retired key signs a new bundle claiming to be from the day before
+	retiredKey := filepath.Join(t.TempDir(), "old.ed25519")
+	if err := os.WriteFile(retiredKey, oldPriv, 0600); err != nil {
+		t.Fatal(err)
+	}
+	backdated := writeSignedBundle(t, testKeys{priv: retiredKey}, "backdated")
+	m, err := ReadManifest(backdated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.CreatedAt = m.CreatedAt.Add(-24 * time.Hour)
+	manifestBytes, sig, err := signManifest(m, oldPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSigned(backdated, manifestBytes, sig, oldPriv); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := loadTrust(t, k.trust)
+	log := OpenTransparencyLog(logDir)
+	if id, err := VerifyBundleWith(logged, VerifyOptions{Trust: ts, Log: log}); err != nil || id != oldID {
+		t.Errorf("bundle logged before rotation = %q, %v", id, err)
+	}
+	// Dated by its own created_at the backdated bundle passes; the log has no record of it
+	if _, err := VerifyBundleWith(backdated, VerifyOptions{Trust: ts}); err != nil {
+		t.Errorf("backdated bundle without a log: %v", err)
+	}
+	if _, err := VerifyBundleWith(backdated, VerifyOptions{Trust: ts, Log: log}); err == nil || !strings.Contains(err.Error(), "not logged") {
+		t.Errorf("backdated bundle against the log: err = %v", err)
+	}
+	// Logging it now dates it now
+	if _, err := log.Append(backdated, oldPriv); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyBundleWith(backdated, VerifyOptions{Trust: ts, Log: log}); err == nil || !strings.Contains(err.Error(), "retired") {
+		t.Errorf("backdated bundle logged after rotation: err = %v", err)
+	}
+
+	// Revoked, the key verifies nothing, however it is dated
+	if err := ts.Revoke(oldID); err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []VerifyOptions{{Trust: ts}, {Trust: ts, Log: log}} {
+		if _, err := VerifyBundleWith(logged, opts); err == nil || !strings.Contains(err.Error(), "revoked") {
+			t.Errorf("bundle signed by a revoked key (log %v): err = %v", opts.Log != nil, err)
+		}
+	}
+	if err := ts.Revoke("ed25519:0000000000000000"); err == nil {
+		t.Error("Revoke of an unknown key succeeded")
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	exported, _ := json.Marshal(ExportedKey{KeyID: KeyID(pub), Algorithm: "ed25519", PublicKey: base64.StdEncoding.EncodeToString(pub)})
+	for name, data := range map[string][]byte{
+		"raw":    pub,
+		"base64": []byte(base64.StdEncoding.EncodeToString(pub) + "\n"),
+		"hex":    []byte(hex.EncodeToString(pub)),
+		"export": exported,
+	} {
+		got, err := ParsePublicKey(data)
+		if err != nil || !got.Equal(pub) {
+			t.Errorf("%s: ParsePublicKey = %x, %v", name, got, err)
+		}
+	}
+	wrongID, _ := json.Marshal(ExportedKey{KeyID: "ed25519:0000000000000000", PublicKey: base64.StdEncoding.EncodeToString(pub)})
+	if _, err := ParsePublicKey(wrongID); err == nil {
+		t.Error("export with mismatched key_id: parsed")
+	}
+	if _, err := ParsePublicKey([]byte("not a key")); err == nil {
+		t.Error("garbage: parsed")
+	}
+}
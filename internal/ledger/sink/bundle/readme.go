@@ -21,6 +21,7 @@ type ReadMEOptions struct {
 	PanicExpiresAt *time.Time
 	NextSteps     []string
 	BundleDirName string
+	Encrypted     bool // payload files are encrypted (README only points at ctrldot bundle decrypt)
 }
 
 // WriteREADME writes README.md into dir (and optionally README.txt). No secrets.
@@ -83,6 +84,11 @@ func WriteREADME(dir string, opts ReadMEOptions) error {
 		verifyCmd = fmt.Sprintf("ctrldot bundle verify %s", opts.BundleDirName)
 	}
 
+	notes := "This bundle is signed. Share the entire directory."
+	if opts.Encrypted {
+		notes += "\nPayload files (*.enc) are encrypted; recipients can read them with `ctrldot bundle decrypt <bundle> --key <recipient key>`."
+	}
+
 	md := fmt.Sprintf(`# Ctrl Dot Bundle Summary
 
 ## What happened
@@ -107,7 +113,7 @@ func WriteREADME(dir string, opts ReadMEOptions) error {
 `+"```"+`
 
 ## Notes
-This bundle is signed. Share the entire directory.
+%s
 `,
 		opts.Trigger,
 		when,
@@ -118,6 +124,7 @@ This bundle is signed. Share the entire directory.
 		panicLine,
 		nextSteps,
 		verifyCmd,
+		notes,
 	)
 
 	readmePath := filepath.Join(dir, "README.md")
@@ -0,0 +1,598 @@
+package bundle
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/merkle"
+)
+
+// The transparency log is an append-only Merkle tree (RFC 6962) of bundle manifest hashes, kept
+// in ledger_sink.bundle.sign.transparency_log_dir:
+//
+//	entries.jsonl     one LogEntry per bundle, in log order
+//	tree_heads.jsonl  the signed tree head after each append
+//	frontier.json     the tree's subtree roots, so an append does not rehash every entry
+//	lock              held while appending (the daemon and the CLI both append)
+//
+// Each bundle also gets transparency.json: its log index, the signed tree head when it was
+// logged and the inclusion proof for that head. Deleting a bundle leaves its entry behind
+// (`ctrldot bundle audit`), and rewriting entries contradicts the signed tree heads.
+
+const (
+	logEntriesFile   = "entries.jsonl"
+	logTreeHeadsFile = "tree_heads.jsonl"
+	logFrontierFile  = "frontier.json"
+	logLockFile      = "lock"
+	inclusionFile    = "transparency.json"
+)
+
+// ErrNotLogged is returned by VerifyTransparency for a bundle without transparency.json.
+var ErrNotLogged = errors.New("bundle is not in a transparency log (written before logging was enabled, or logging is off)")
+
+// TransparencyLogDir is sign.transparency_log_dir with ~ expanded; "" disables the log.
+func TransparencyLogDir(sign config.LedgerBundleSign) string {
+	return expandPath(sign.TransparencyLogDir)
+}
+
+// LogEntry is one logged bundle manifest.
+type LogEntry struct {
+	Index          int64     `json:"index"`
+	ManifestSHA256 string    `json:"manifest_sha256"`
+	Bundle         string    `json:"bundle"` // bundle directory when it was logged
+	KeyID          string    `json:"key_id,omitempty"`
+	LoggedAt       time.Time `json:"logged_at"`
+}
+
+// TreeHead is a signed commitment to the first TreeSize entries of the log.
+type TreeHead struct {
+	TreeSize  int64     `json:"tree_size"`
+	RootHash  string    `json:"root_hash"` // hex
+	Timestamp time.Time `json:"timestamp"`
+	KeyID     string    `json:"key_id,omitempty"`
+	Signature string    `json:"signature,omitempty"` // base64 Ed25519 over signedData()
+}
+
+func (h TreeHead) signedData() []byte {
+	return []byte(fmt.Sprintf("ctrldot-tree-head/v1\n%d\n%s\n%s\n", h.TreeSize, h.RootHash, h.Timestamp.UTC().Format(time.RFC3339Nano)))
+}
+
+// Inclusion is written to a bundle as transparency.json.
+type Inclusion struct {
+	LogIndex       int64    `json:"log_index"`
+	ManifestSHA256 string   `json:"manifest_sha256"`
+	TreeHead       TreeHead `json:"tree_head"`
+	Proof          []string `json:"inclusion_proof"` // hex, leaf to root
+}
+
+// TransparencyLog is the log stored in one directory.
+type TransparencyLog struct {
+	dir string
+}
+
+// OpenTransparencyLog returns the log in dir (created on first append).
+func OpenTransparencyLog(dir string) *TransparencyLog {
+	return &TransparencyLog{dir: expandPath(dir)}
+}
+
+// Dir returns the log directory.
+func (l *TransparencyLog) Dir() string { return l.dir }
+
+// Entries returns the logged entries in file order.
+func (l *TransparencyLog) Entries() ([]LogEntry, error) {
+	var out []LogEntry
+	err := readJSONL(filepath.Join(l.dir, logEntriesFile), func(line []byte) error {
+		var e LogEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		out = append(out, e)
+		return nil
+	})
+	return out, err
+}
+
+// TreeHeads returns the signed tree heads, oldest first.
+func (l *TransparencyLog) TreeHeads() ([]TreeHead, error) {
+	var out []TreeHead
+	err := readJSONL(filepath.Join(l.dir, logTreeHeadsFile), func(line []byte) error {
+		var h TreeHead
+		if err := json.Unmarshal(line, &h); err != nil {
+			return err
+		}
+		out = append(out, h)
+		return nil
+	})
+	return out, err
+}
+
+// Append logs the manifest of the bundle in bundleDir, signs the new tree head with priv (nil
+// leaves it unsigned) and writes the bundle's transparency.json.
+func (l *TransparencyLog) Append(bundleDir string, priv ed25519.PrivateKey) (*Inclusion, error) {
+	manifest, err := os.ReadFile(filepath.Join(bundleDir, "manifest.json"))
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	digest := sha256.Sum256(manifest)
+
+	if err := os.MkdirAll(l.dir, 0700); err != nil {
+		return nil, fmt.Errorf("transparency log dir: %w", err)
+	}
+	unlock, err := lockLog(filepath.Join(l.dir, logLockFile))
+	if err != nil {
+		return nil, fmt.Errorf("lock transparency log: %w", err)
+	}
+	defer unlock()
+
+	frontier, err := l.frontier()
+	if err != nil {
+		return nil, err
+	}
+	entry := LogEntry{
+		Index:          frontier.Size,
+		ManifestSHA256: hex.EncodeToString(digest[:]),
+		Bundle:         bundleDir,
+		LoggedAt:       time.Now().UTC(),
+	}
+	if priv != nil {
+		entry.KeyID = KeyID(priv.Public().(ed25519.PublicKey))
+	}
+	proof := frontier.Append(merkle.LeafHash(digest[:]))
+	head := TreeHead{TreeSize: frontier.Size, RootHash: hex.EncodeToString(frontier.Root()), Timestamp: entry.LoggedAt}
+	if priv != nil {
+		head.KeyID = entry.KeyID
+		head.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, head.signedData()))
+	}
+
+	if err := appendJSONL(filepath.Join(l.dir, logEntriesFile), entry); err != nil {
+		return nil, err
+	}
+	if err := appendJSONL(filepath.Join(l.dir, logTreeHeadsFile), head); err != nil {
+		return nil, err
+	}
+	if err := l.saveFrontier(frontier); err != nil {
+		return nil, err
+	}
+	inc := &Inclusion{LogIndex: entry.Index, ManifestSHA256: entry.ManifestSHA256, TreeHead: head, Proof: hexList(proof)}
+	data, err := json.MarshalIndent(inc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(bundleDir, inclusionFile), data, 0644); err != nil {
+		return nil, err
+	}
+	return inc, nil
+}
+
+// logFrontier is frontier.json: the merkle.Frontier of the log and the size of entries.jsonl it
+// was computed from, which tells a stale file (entries written without it) from a current one.
+type logFrontier struct {
+	TreeSize     int64    `json:"tree_size"`
+	Nodes        []string `json:"nodes"` // hex, largest subtree first
+	EntriesBytes int64    `json:"entries_bytes"`
+}
+
+// frontier returns the log's tree frontier from frontier.json, rebuilding it from the entries
+// when the file is missing, unreadable or stale. Call with the log locked.
+func (l *TransparencyLog) frontier() (*merkle.Frontier, error) {
+	size, err := l.entriesBytes()
+	if err != nil {
+		return nil, err
+	}
+	var saved logFrontier
+	if data, err := os.ReadFile(filepath.Join(l.dir, logFrontierFile)); err == nil &&
+		json.Unmarshal(data, &saved) == nil && saved.EntriesBytes == size {
+		if _, nodes, err := decodeHexes("", saved.Nodes); err == nil && len(nodes) == bits.OnesCount64(uint64(saved.TreeSize)) {
+			return &merkle.Frontier{Size: saved.TreeSize, Nodes: nodes}, nil
+		}
+	}
+	leaves, err := l.leaves()
+	if err != nil {
+		return nil, err
+	}
+	f := &merkle.Frontier{}
+	for _, leaf := range leaves {
+		f.Append(leaf)
+	}
+	return f, nil
+}
+
+// saveFrontier writes f to frontier.json, recording the current size of entries.jsonl.
+func (l *TransparencyLog) saveFrontier(f *merkle.Frontier) error {
+	size, err := l.entriesBytes()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(logFrontier{TreeSize: f.Size, Nodes: hexList(f.Nodes), EntriesBytes: size})
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(l.dir, logFrontierFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", logFrontierFile, err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func (l *TransparencyLog) entriesBytes() (int64, error) {
+	info, err := os.Stat(filepath.Join(l.dir, logEntriesFile))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// VerifyTreeHead checks the tree head signature against the trusted keys in opts. Without a
+// trust store (integrity-only verification) the signature is not checked.
+func VerifyTreeHead(h TreeHead, opts VerifyOptions) error {
+	if opts.Trust == nil {
+		return nil
+	}
+	if h.Signature == "" {
+		return fmt.Errorf("tree head %d is not signed", h.TreeSize)
+	}
+	sig, err := base64.StdEncoding.DecodeString(h.Signature)
+	if err != nil {
+		return fmt.Errorf("tree head %d: bad signature encoding", h.TreeSize)
+	}
+	if _, err := verifyTrusted(VerifyOptions{Trust: opts.Trust}, h.KeyID, &h.Timestamp, h.signedData(), sig); err != nil {
+		return fmt.Errorf("tree head %d: %w", h.TreeSize, err)
+	}
+	return nil
+}
+
+// TransparencyResult is the outcome of VerifyTransparency.
+type TransparencyResult struct {
+	LogIndex int64     `json:"log_index"`
+	TreeHead TreeHead  `json:"tree_head"`        // head recorded in the bundle
+	Latest   *TreeHead `json:"latest,omitempty"` // latest head of the local log, when checked
+}
+
+// VerifyTransparency proves that the bundle in dir was logged: its inclusion proof must lead to
+// the signed tree head recorded in transparency.json. With a local log it also proves inclusion
+// in the log's latest signed tree head and consistency between the two heads, so the log has
+// only been appended to since the bundle was written.
+func VerifyTransparency(dir string, log *TransparencyLog, opts VerifyOptions) (*TransparencyResult, error) {
+	read, err := openBundle(dir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := read(inclusionFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotLogged
+	}
+	if err != nil {
+		return nil, err
+	}
+	var inc Inclusion
+	if err := json.Unmarshal(data, &inc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", inclusionFile, err)
+	}
+	manifest, err := read("manifest.json")
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	digest := sha256.Sum256(manifest)
+	if hex.EncodeToString(digest[:]) != inc.ManifestSHA256 {
+		return nil, fmt.Errorf("manifest does not match the logged manifest hash")
+	}
+	leaf := merkle.LeafHash(digest[:])
+	if err := VerifyTreeHead(inc.TreeHead, opts); err != nil {
+		return nil, err
+	}
+	root, proof, err := decodeHexes(inc.TreeHead.RootHash, inc.Proof)
+	if err != nil {
+		return nil, err
+	}
+	if err := merkle.VerifyInclusion(leaf, inc.LogIndex, inc.TreeHead.TreeSize, proof, root); err != nil {
+		return nil, fmt.Errorf("inclusion in tree head %d: %w", inc.TreeHead.TreeSize, err)
+	}
+	res := &TransparencyResult{LogIndex: inc.LogIndex, TreeHead: inc.TreeHead}
+	if log == nil {
+		return res, nil
+	}
+
+	heads, err := log.TreeHeads()
+	if err != nil {
+		return nil, err
+	}
+	if len(heads) == 0 {
+		return nil, fmt.Errorf("transparency log %s is empty; it cannot be the log this bundle was written to", log.dir)
+	}
+	latest := heads[len(heads)-1]
+	if err := VerifyTreeHead(latest, opts); err != nil {
+		return nil, err
+	}
+	leaves, err := log.leaves()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(leaves)) < latest.TreeSize || latest.TreeSize < inc.TreeHead.TreeSize {
+		return nil, fmt.Errorf("transparency log %s has fewer entries than its signed tree heads: entries were removed", log.dir)
+	}
+	leaves = leaves[:latest.TreeSize]
+	latestRoot, err := hex.DecodeString(latest.RootHash)
+	if err != nil {
+		return nil, fmt.Errorf("latest tree head: bad root hash")
+	}
+	cons, err := merkle.ConsistencyProof(leaves, int(inc.TreeHead.TreeSize))
+	if err != nil {
+		return nil, err
+	}
+	if err := merkle.VerifyConsistency(inc.TreeHead.TreeSize, latest.TreeSize, root, latestRoot, cons); err != nil {
+		return nil, fmt.Errorf("log %s is not consistent with the tree head in the bundle (size %d → %d): %w",
+			log.dir, inc.TreeHead.TreeSize, latest.TreeSize, err)
+	}
+	incl, err := merkle.InclusionProof(leaves, int(inc.LogIndex))
+	if err != nil {
+		return nil, err
+	}
+	if err := merkle.VerifyInclusion(leaf, inc.LogIndex, latest.TreeSize, incl, latestRoot); err != nil {
+		return nil, fmt.Errorf("inclusion in latest tree head %d: %w", latest.TreeSize, err)
+	}
+	res.Latest = &latest
+	return res, nil
+}
+
+// AuditProblem is one finding of Audit. Index is -1 for bundles that are not in the log.
+type AuditProblem struct {
+	Index   int64  `json:"index"`
+	Bundle  string `json:"bundle,omitempty"`
+	Problem string `json:"problem"`
+}
+
+// AuditReport is the outcome of Audit.
+type AuditReport struct {
+	LogDir    string         `json:"log_dir"`
+	Entries   int            `json:"entries"`
+	TreeHeads int            `json:"tree_heads"`
+	Latest    *TreeHead      `json:"latest,omitempty"`
+	Bundles   int            `json:"bundles_scanned"`
+	Problems  []AuditProblem `json:"problems"`
+	OK        bool           `json:"ok"`
+}
+
+// Audit checks the log against its signed tree heads and against the bundles in bundleDirs:
+// every logged bundle must still exist unchanged (in bundleDirs or at its logged path, as a
+// directory or a packed archive), and every bundle found must be in the log.
+func (l *TransparencyLog) Audit(bundleDirs []string, opts VerifyOptions) (*AuditReport, error) {
+	entries, err := l.Entries()
+	if err != nil {
+		return nil, err
+	}
+	heads, err := l.TreeHeads()
+	if err != nil {
+		return nil, err
+	}
+	rep := &AuditReport{LogDir: l.dir, Entries: len(entries), TreeHeads: len(heads), Problems: []AuditProblem{}}
+	add := func(index int64, bundle, format string, args ...interface{}) {
+		rep.Problems = append(rep.Problems, AuditProblem{Index: index, Bundle: bundle, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	leaves := make([][]byte, 0, len(entries))
+	for i, e := range entries {
+		if e.Index != int64(i) {
+			add(int64(i), "", "entry %d holds index %d: entries were removed or reordered", i, e.Index)
+		}
+		leaf, err := entryLeaf(e)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+
+	var prevSize int64
+	for _, h := range heads {
+		if err := VerifyTreeHead(h, opts); err != nil {
+			add(h.TreeSize-1, "", "%v", err)
+		}
+		switch {
+		case h.TreeSize < prevSize:
+			add(h.TreeSize-1, "", "tree head %d is smaller than the one before it (%d)", h.TreeSize, prevSize)
+		case h.TreeSize > int64(len(leaves)):
+			add(int64(len(leaves)), "", "entries %d..%d are missing (signed tree head %d)", len(leaves), h.TreeSize-1, h.TreeSize)
+		case hex.EncodeToString(merkle.Root(leaves[:h.TreeSize])) != h.RootHash:
+			add(h.TreeSize-1, "", "signed tree head %d does not match the log: entries were rewritten", h.TreeSize)
+		}
+		prevSize = h.TreeSize
+	}
+	if len(heads) > 0 {
+		rep.Latest = &heads[len(heads)-1]
+	}
+	if int64(len(entries)) > prevSize {
+		add(prevSize, "", "entries %d..%d are not covered by a signed tree head", prevSize, len(entries)-1)
+	}
+
+	// Bundles on disk, by manifest hash
+	found := make(map[string]string)
+	for _, root := range bundleDirs {
+		names, err := ListBundles(root)
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			dir := filepath.Join(root, name)
+			if h, err := manifestHash(dir); err == nil {
+				found[h] = dir
+				rep.Bundles++
+			}
+		}
+	}
+	logged := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		logged[e.ManifestSHA256] = true
+		if _, ok := found[e.ManifestSHA256]; ok {
+			continue
+		}
+		h, err := manifestHash(e.Bundle)
+		switch {
+		case err != nil:
+			add(e.Index, e.Bundle, "bundle is missing (deleted, or moved out of the audited directories)")
+		case h != e.ManifestSHA256:
+			add(e.Index, e.Bundle, "manifest changed after the bundle was logged")
+		}
+	}
+	for h, dir := range found {
+		if !logged[h] {
+			add(-1, dir, "bundle is not in the transparency log")
+		}
+	}
+	rep.OK = len(rep.Problems) == 0
+	return rep, nil
+}
+
+// loggedAt returns the timestamp of the first signed tree head of the log that covers the entry
+// for manifestSHA256, or nil when the log holds no such entry. Unlike the manifest's created_at,
+// the signer of a bundle cannot choose it after the fact. The head must match the entries and
+// verify against opts, and no head may be older than the one before it.
+func (l *TransparencyLog) loggedAt(manifestSHA256 string, opts VerifyOptions) (*time.Time, error) {
+	entries, err := l.Entries()
+	if err != nil {
+		return nil, err
+	}
+	index := -1
+	for i, e := range entries {
+		if e.ManifestSHA256 == manifestSHA256 {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, nil
+	}
+	heads, err := l.TreeHeads()
+	if err != nil {
+		return nil, err
+	}
+	for i, h := range heads {
+		if i > 0 && h.Timestamp.Before(heads[i-1].Timestamp) {
+			return nil, fmt.Errorf("transparency log %s: tree head %d is older than the one before it", l.dir, h.TreeSize)
+		}
+		if h.TreeSize <= int64(index) {
+			continue
+		}
+		leaves, err := l.leaves()
+		if err != nil {
+			return nil, err
+		}
+		if h.TreeSize > int64(len(leaves)) || hex.EncodeToString(merkle.Root(leaves[:h.TreeSize])) != h.RootHash {
+			return nil, fmt.Errorf("transparency log %s: signed tree head %d does not match the log", l.dir, h.TreeSize)
+		}
+		if err := VerifyTreeHead(h, opts); err != nil {
+			return nil, err
+		}
+		return &h.Timestamp, nil
+	}
+	return nil, nil
+}
+
+func (l *TransparencyLog) leaves() ([][]byte, error) {
+	entries, err := l.Entries()
+	if err != nil {
+		return nil, err
+	}
+	leaves := make([][]byte, 0, len(entries))
+	for _, e := range entries {
+		leaf, err := entryLeaf(e)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leaf)
+	}
+	return leaves, nil
+}
+
+// manifestHash returns the hex SHA-256 of manifest.json in a bundle directory or archive.
+func manifestHash(dir string) (string, error) {
+	read, err := openBundle(dir)
+	if err != nil {
+		return "", err
+	}
+	manifest, err := read("manifest.json")
+	if err != nil {
+		return "", err
+	}
+	return sha256Hex(manifest), nil
+}
+
+func entryLeaf(e LogEntry) ([]byte, error) {
+	digest, err := hex.DecodeString(e.ManifestSHA256)
+	if err != nil || len(digest) != sha256.Size {
+		return nil, fmt.Errorf("transparency log entry %d: bad manifest hash", e.Index)
+	}
+	return merkle.LeafHash(digest), nil
+}
+
+func decodeHexes(root string, proof []string) ([]byte, [][]byte, error) {
+	r, err := hex.DecodeString(root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("bad root hash")
+	}
+	out := make([][]byte, len(proof))
+	for i, p := range proof {
+		if out[i], err = hex.DecodeString(p); err != nil {
+			return nil, nil, fmt.Errorf("bad inclusion proof")
+		}
+	}
+	return r, out, nil
+}
+
+func hexList(hashes [][]byte) []string {
+	out := make([]string, len(hashes))
+	for i, h := range hashes {
+		out[i] = hex.EncodeToString(h)
+	}
+	return out
+}
+
+func readJSONL(path string, fn func([]byte) error) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		if err := fn(sc.Bytes()); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	return sc.Err()
+}
+
+func appendJSONL(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package bundle
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockLog takes an exclusive flock on path, which serialises appends across processes as well
+// as within one, and returns its release.
+func lockLog(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
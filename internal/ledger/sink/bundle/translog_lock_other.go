@@ -0,0 +1,14 @@
+//go:build !unix
+
+package bundle
+
+import "sync"
+
+// logMu stands in for the file lock where flock is not available: it serialises appends within
+// one process only.
+var logMu sync.Mutex
+
+func lockLog(path string) (func(), error) {
+	logMu.Lock()
+	return logMu.Unlock, nil
+}
@@ -0,0 +1,204 @@
+package bundle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func writeLoggedBundles(t *testing.T, k testKeys, outDir, logDir string, sessions ...string) []string {
+	t.Helper()
+	var dirs []string
+	for _, s := range sessions {
+		dir, err := WriteOne(WriteOneOptions{OutputDir: outDir, SignEnabled: true, PrivateKeyPath: k.priv, SessionID: s, TransparencyLogDir: logDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+func TestTransparencyInclusionAndConsistency(t *testing.T) {
+	k := newTestKeys(t)
+	out, logDir := t.TempDir(), t.TempDir()
+	dirs := writeLoggedBundles(t, k, out, logDir, "s0", "s1", "s2")
+	log := OpenTransparencyLog(logDir)
+	opts := VerifyOptions{Trust: loadTrust(t, k.trust)}
+
+	for i, dir := range dirs {
+		res, err := VerifyTransparency(dir, log, opts)
+		if err != nil {
+			t.Fatalf("bundle %d: %v", i, err)
+		}
+		if res.LogIndex != int64(i) || res.TreeHead.TreeSize != int64(i+1) || res.Latest == nil || res.Latest.TreeSize != 3 {
+			t.Errorf("bundle %d: %+v", i, res)
+		}
+	}
+	rep, err := log.Audit([]string{out}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK || rep.Entries != 3 || rep.Bundles != 3 {
+		t.Errorf("Audit = %+v", rep)
+	}
+
+	// Without a trust store the tree head signature is not checked, but the proofs still are
+	if _, err := VerifyTransparency(dirs[0], nil, VerifyOptions{}); err != nil {
+		t.Errorf("offline verify: %v", err)
+	}
+	if _, err := VerifyTransparency(dirs[0], log, VerifyOptions{Trust: loadTrust(t, filepath.Join(t.TempDir(), "none.json"))}); err == nil {
+		t.Error("tree head signed by an untrusted key: verified")
+	}
+}
+
+func TestAuditReportsDeletedBundle(t *testing.T) {
+	k := newTestKeys(t)
+	out, logDir := t.TempDir(), t.TempDir()
+	dirs := writeLoggedBundles(t, k, out, logDir, "s0", "deny", "s2")
+	if err := os.RemoveAll(dirs[1]); err != nil {
+		t.Fatal(err)
+	}
+	rep, err := OpenTransparencyLog(logDir).Audit([]string{out}, VerifyOptions{Trust: loadTrust(t, k.trust)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.OK || len(rep.Problems) != 1 || rep.Problems[0].Index != 1 || !strings.Contains(rep.Problems[0].Problem, "missing") {
+		t.Errorf("Audit = %+v", rep.Problems)
+	}
+}
+
+func TestRewrittenLogIsDetected(t *testing.T) {
+	k := newTestKeys(t)
+	out, logDir := t.TempDir(), t.TempDir()
+	dirs := writeLoggedBundles(t, k, out, logDir, "s0", "deny", "s2")
+	log := OpenTransparencyLog(logDir)
+
+	// Drop the DENY bundle and its entry, renumbering the rest
+	entries, err := log.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries = []LogEntry{entries[0], entries[2]}
+	entries[1].Index = 1
+	path := filepath.Join(logDir, logEntriesFile)
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if err := appendJSONL(path, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.RemoveAll(dirs[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := VerifyOptions{Trust: loadTrust(t, k.trust)}
+	rep, err := log.Audit([]string{out}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.OK {
+		t.Error("Audit of rewritten log = OK")
+	}
+	if _, err := VerifyTransparency(dirs[2], log, opts); err == nil {
+		t.Error("VerifyTransparency against rewritten log: verified")
+	}
+}
+
+func TestUnloggedBundle(t *testing.T) {
+	k := newTestKeys(t)
+	out, logDir := t.TempDir(), t.TempDir()
+	writeLoggedBundles(t, k, out, logDir, "s0")
+	unlogged := writeLoggedBundles(t, k, out, "", "s1")[0]
+
+	if _, err := VerifyTransparency(unlogged, OpenTransparencyLog(logDir), VerifyOptions{}); !errors.Is(err, ErrNotLogged) {
+		t.Errorf("VerifyTransparency = %v, want ErrNotLogged", err)
+	}
+	rep, err := OpenTransparencyLog(logDir).Audit([]string{out}, VerifyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.OK || len(rep.Problems) != 1 || rep.Problems[0].Index != -1 || rep.Problems[0].Bundle != unlogged {
+		t.Errorf("Audit = %+v", rep.Problems)
+	}
+}
+
+func TestConcurrentAppendsShareTheLog(t *testing.T) {
+	k := newTestKeys(t)
+	out, logDir := t.TempDir(), t.TempDir()
+	dirs := writeLoggedBundles(t, k, out, "", "s0", "s1", "s2", "s3", "s4", "s5", "s6", "s7")
+	priv, err := ReadSigningKey(k.priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each append opens the log separately, as the daemon and the CLI do
+	var wg sync.WaitGroup
+	for _, dir := range dirs {
+		wg.Add(1)
+		go func(dir string) {
+			defer wg.Done()
+			if _, err := OpenTransparencyLog(logDir).Append(dir, priv); err != nil {
+				t.Error(err)
+			}
+		}(dir)
+	}
+	wg.Wait()
+
+	opts := VerifyOptions{Trust: loadTrust(t, k.trust)}
+	rep, err := OpenTransparencyLog(logDir).Audit([]string{out}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK || rep.Entries != len(dirs) || rep.TreeHeads != len(dirs) {
+		t.Errorf("Audit = %+v", rep)
+	}
+	for _, dir := range dirs {
+		if _, err := VerifyTransparency(dir, OpenTransparencyLog(logDir), opts); err != nil {
+			t.Errorf("%s: %v", filepath.Base(dir), err)
+		}
+	}
+}
+
+func TestAppendRebuildsAMissingOrStaleFrontier(t *testing.T) {
+	k := newTestKeys(t)
+	out, logDir := t.TempDir(), t.TempDir()
+	dirs := writeLoggedBundles(t, k, out, logDir, "s0", "s1", "s2")
+	log := OpenTransparencyLog(logDir)
+	if _, err := os.Stat(filepath.Join(logDir, logFrontierFile)); err != nil {
+		t.Fatalf("frontier not saved: %v", err)
+	}
+
+	// Missing: rebuilt from the entries
+	if err := os.Remove(filepath.Join(logDir, logFrontierFile)); err != nil {
+		t.Fatal(err)
+	}
+	dirs = append(dirs, writeLoggedBundles(t, k, out, logDir, "s3")...)
+
+	// Stale: an entry was written after it (as by an append that stopped before saving it)
+	frontier, err := os.ReadFile(filepath.Join(logDir, logFrontierFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirs = append(dirs, writeLoggedBundles(t, k, out, logDir, "s4")...)
+	if err := os.WriteFile(filepath.Join(logDir, logFrontierFile), frontier, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dirs = append(dirs, writeLoggedBundles(t, k, out, logDir, "s5")...)
+
+	opts := VerifyOptions{Trust: loadTrust(t, k.trust)}
+	for i, dir := range dirs {
+		res, err := VerifyTransparency(dir, log, opts)
+		if err != nil || res.LogIndex != int64(i) {
+			t.Errorf("bundle %d: %+v, %v", i, res, err)
+		}
+	}
+	if rep, err := log.Audit([]string{out}, opts); err != nil || !rep.OK {
+		t.Errorf("Audit = %+v, %v", rep, err)
+	}
+}
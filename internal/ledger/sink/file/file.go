@@ -0,0 +1,375 @@
+package file
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/ledger/sink"
+)
+
+// Fsync policies.
+const (
+	FsyncAlways   = "always"
+	FsyncInterval = "interval"
+	FsyncNever    = "never"
+)
+
+const (
+	defaultMaxSizeMB     = 100
+	defaultFsyncInterval = time.Second
+	rotatedTimeFormat    = "20060102T150405.000Z"
+)
+
+// Line is one JSON Lines record: a decision or an event.
+type Line struct {
+	Kind     string               `json:"kind"` // "decision" | "event"
+	Decision *sink.DecisionRecord `json:"decision,omitempty"`
+	Event    *domain.Event        `json:"event,omitempty"`
+}
+
+// Sink appends decision records and events to a JSON Lines file, rotating it by size and
+// age. Rotated files are optionally gzipped and pruned to MaxBackups.
+type Sink struct {
+	path          string
+	maxSize       int64
+	rotateEvery   time.Duration
+	maxBackups    int
+	compress      bool
+	fsync         string
+	fsyncInterval time.Duration
+	now           func() time.Time
+
+	mu       sync.Mutex
+	f        *os.File
+	size     int64
+	openedAt time.Time
+	dirty    bool
+	closed   bool
+
+	bg       sync.WaitGroup // compression and pruning of rotated files
+	bgMu     sync.Mutex     // one rotated file is processed at a time
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSink opens (or creates) cfg.Path for appending.
+func NewSink(cfg config.LedgerFileConfig) (*Sink, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("ledger_sink.file.path is required")
+	}
+	s := &Sink{
+		path:          expandPath(cfg.Path),
+		maxBackups:    cfg.MaxBackups,
+		compress:      cfg.Compress,
+		fsync:         cfg.Fsync,
+		fsyncInterval: time.Duration(cfg.FsyncIntervalMs) * time.Millisecond,
+		rotateEvery:   time.Duration(cfg.RotateHours) * time.Hour,
+		now:           time.Now,
+	}
+	switch {
+	case cfg.MaxSizeMB == 0:
+		s.maxSize = defaultMaxSizeMB << 20
+	case cfg.MaxSizeMB > 0:
+		s.maxSize = int64(cfg.MaxSizeMB) << 20
+	}
+	switch s.fsync {
+	case "":
+		s.fsync = FsyncInterval
+	case FsyncAlways, FsyncInterval, FsyncNever:
+	default:
+		return nil, fmt.Errorf("ledger_sink.file.fsync: unknown policy %q", cfg.Fsync)
+	}
+	if s.fsyncInterval <= 0 {
+		s.fsyncInterval = defaultFsyncInterval
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return nil, fmt.Errorf("create ledger dir: %w", err)
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	if s.fsync == FsyncInterval {
+		s.stop = make(chan struct{})
+		s.done = make(chan struct{})
+		go s.syncLoop()
+	}
+	return s, nil
+}
+
+func expandPath(p string) string {
+	if strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[2:])
+		}
+	}
+	return p
+}
+
+// EmitDecision appends d. Decision records are already redacted by the service.
+func (s *Sink) EmitDecision(ctx context.Context, d *sink.DecisionRecord) error {
+	return s.write(Line{Kind: "decision", Decision: d})
+}
+
+// EmitEvent appends e with its payload redacted.
+func (s *Sink) EmitEvent(ctx context.Context, e *domain.Event) error {
+	ev := *e
+	ev.PayloadJSON = sink.RedactMap(e.PayloadJSON)
+	return s.write(Line{Kind: "event", Event: &ev})
+}
+
+func (s *Sink) write(l Line) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", l.Kind, err)
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return fmt.Errorf("file sink closed")
+	}
+	if s.shouldRotate(int64(len(b))) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.f.Write(b)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", s.path, err)
+	}
+	if s.fsync == FsyncAlways {
+		return s.f.Sync()
+	}
+	s.dirty = true
+	return nil
+}
+
+// shouldRotate reports whether the current file is non-empty and writing n more bytes
+// would exceed the size limit, or the file is older than the rotation interval.
+func (s *Sink) shouldRotate(n int64) bool {
+	if s.size == 0 {
+		return false
+	}
+	if s.maxSize > 0 && s.size+n > s.maxSize {
+		return true
+	}
+	return s.rotateEvery > 0 && s.now().Sub(s.openedAt) >= s.rotateEvery
+}
+
+func (s *Sink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", s.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.f, s.size, s.openedAt = f, info.Size(), s.now()
+	if s.size > 0 {
+		// Reopening an existing file (daemon restart): keep aging it from when it was started,
+		// or a frequently restarted daemon would never rotate by age
+		s.openedAt = startedAt(s.path, info)
+	}
+	return nil
+}
+
+// startedAt returns the time of the first record in the file at path, or its modification
+// time when that cannot be read.
+func startedAt(path string, info os.FileInfo) time.Time {
+	f, err := os.Open(path)
+	if err != nil {
+		return info.ModTime()
+	}
+	defer f.Close()
+	first, err := bufio.NewReader(f).ReadBytes('\n')
+	var l Line
+	if err != nil || json.Unmarshal(first, &l) != nil {
+		return info.ModTime()
+	}
+	switch {
+	case l.Decision != nil && !l.Decision.Timestamp.IsZero():
+		return l.Decision.Timestamp
+	case l.Event != nil && !l.Event.TS.IsZero():
+		return l.Event.TS
+	}
+	return info.ModTime()
+}
+
+// rotate renames the current file aside, opens a fresh one and compresses/prunes in the background.
+func (s *Sink) rotate() error {
+	if err := s.f.Sync(); err != nil {
+		log.Printf("ledger sink file: sync before rotate: %v", err)
+	}
+	if err := s.f.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", s.path, err)
+	}
+	rotated := s.rotatedName(s.now())
+	if err := os.Rename(s.path, rotated); err != nil {
+		return fmt.Errorf("rotate %s: %w", s.path, err)
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	s.dirty = false
+	s.bg.Add(1)
+	go func() {
+		defer s.bg.Done()
+		s.bgMu.Lock()
+		defer s.bgMu.Unlock()
+		if s.compress {
+			if err := gzipFile(rotated); err != nil {
+				log.Printf("ledger sink file: compress %s: %v", rotated, err)
+			}
+		}
+		if err := s.prune(); err != nil {
+			log.Printf("ledger sink file: prune: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Sink) rotatedName(t time.Time) string {
+	ext := filepath.Ext(s.path)
+	base := strings.TrimSuffix(s.path, ext)
+	name := base + "-" + t.UTC().Format(rotatedTimeFormat) + ext
+	// Two rotations within the same millisecond (tiny max_size_mb): keep both.
+	for i := 1; ; i++ {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			if _, err := os.Stat(name + ".gz"); os.IsNotExist(err) {
+				return name
+			}
+		}
+		name = fmt.Sprintf("%s-%s_%d%s", base, t.UTC().Format(rotatedTimeFormat), i, ext)
+	}
+}
+
+// Rotated returns the rotated files (plain or .gz), oldest first.
+func (s *Sink) Rotated() ([]string, error) {
+	ext := filepath.Ext(s.path)
+	base := strings.TrimSuffix(s.path, ext)
+	matches, err := filepath.Glob(base + "-*" + ext + "*")
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, m := range matches {
+		if strings.HasSuffix(m, ext) || strings.HasSuffix(m, ext+".gz") {
+			out = append(out, m)
+		}
+	}
+	// The UTC timestamp suffix sorts chronologically.
+	sort.Strings(out)
+	return out, nil
+}
+
+func (s *Sink) prune() error {
+	if s.maxBackups <= 0 {
+		return nil
+	}
+	files, err := s.Rotated()
+	if err != nil {
+		return err
+	}
+	for len(files) > s.maxBackups {
+		if err := os.Remove(files[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	zw.Name = filepath.Base(path)
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (s *Sink) syncLoop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.fsyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.dirty && !s.closed {
+				if err := s.f.Sync(); err != nil {
+					log.Printf("ledger sink file: fsync: %v", err)
+				}
+				s.dirty = false
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Close syncs and closes the file and waits for background compression.
+func (s *Sink) Close() error {
+	if s.stop != nil {
+		s.stopOnce.Do(func() { close(s.stop) })
+		<-s.done
+	}
+	s.mu.Lock()
+	var err error
+	if !s.closed {
+		s.closed = true
+		if s.fsync != FsyncNever {
+			err = s.f.Sync()
+		}
+		if cerr := s.f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	s.mu.Unlock()
+	s.bg.Wait()
+	return err
+}
+
+var _ sink.LedgerSink = (*Sink)(nil)
@@ -0,0 +1,145 @@
+package file
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/ledger/sink"
+)
+
+func readLines(t *testing.T, path string) []Line {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var r interface{ Read([]byte) (int, error) } = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	}
+	var out []Line
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		var l Line
+		if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+			t.Fatalf("%s: invalid JSON line %q: %v", path, sc.Text(), err)
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+func TestWritesRedactedJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ctrldot.jsonl")
+	s, err := NewSink(config.LedgerFileConfig{Path: path, Fsync: FsyncAlways})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	s.EmitDecision(ctx, &sink.DecisionRecord{ID: "d1", Decision: domain.DecisionDeny})
+	s.EmitEvent(ctx, &domain.Event{EventID: "evt:1", Type: "agent.halted", PayloadJSON: map[string]interface{}{"api_key": "sk-123", "reason": "loop"}})
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := readLines(t, path)
+	if len(lines) != 2 || lines[0].Kind != "decision" || lines[0].Decision.ID != "d1" || lines[1].Kind != "event" {
+		t.Fatalf("unexpected lines: %+v", lines)
+	}
+	if p := lines[1].Event.PayloadJSON; p["api_key"] != "[redacted]" || p["reason"] != "loop" {
+		t.Errorf("event payload not redacted: %v", p)
+	}
+}
+
+func TestRotationCompressAndPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ctrldot.jsonl")
+	s, err := NewSink(config.LedgerFileConfig{Path: path, MaxSizeMB: -1, RotateHours: 1, MaxBackups: 2, Compress: true, Fsync: FsyncNever})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	s.openedAt = now
+
+	ctx := context.Background()
+	for i, id := range []string{"d1", "d2", "d3", "d4"} {
+		now = now.Add(time.Duration(i) * time.Hour)
+		if err := s.EmitDecision(ctx, &sink.DecisionRecord{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// d1, d2, d3 were each rotated out; only the newest two rotated files are kept.
+	rotated, err := s.Rotated()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 2 {
+		t.Fatalf("expected 2 rotated files, got %v", rotated)
+	}
+	for i, want := range []string{"d2", "d3"} {
+		if !strings.HasSuffix(rotated[i], ".jsonl.gz") {
+			t.Fatalf("rotated file not compressed: %s", rotated[i])
+		}
+		if lines := readLines(t, rotated[i]); len(lines) != 1 || lines[0].Decision.ID != want {
+			t.Errorf("%s: expected %s, got %+v", rotated[i], want, lines)
+		}
+	}
+	if lines := readLines(t, path); len(lines) != 1 || lines[0].Decision.ID != "d4" {
+		t.Errorf("current file: expected d4, got %+v", lines)
+	}
+}
+
+func TestRotationAgeSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ctrldot.jsonl")
+	cfg := config.LedgerFileConfig{Path: path, MaxSizeMB: -1, RotateHours: 1, Fsync: FsyncNever}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	// Each restart reopens the file 40 minutes later; the second write after the first
+	// restart is already an hour after the file was started
+	for i, id := range []string{"d1", "d2", "d3"} {
+		now := start.Add(time.Duration(i) * 40 * time.Minute)
+		s, err := NewSink(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.now = func() time.Time { return now }
+		if err := s.EmitDecision(ctx, &sink.DecisionRecord{ID: id, Timestamp: now}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, _ := NewSink(cfg)
+	defer s.Close()
+	rotated, err := s.Rotated()
+	if err != nil || len(rotated) != 1 {
+		t.Fatalf("rotated files = %v, %v; want one", rotated, err)
+	}
+	if lines := readLines(t, rotated[0]); len(lines) != 2 || lines[0].Decision.ID != "d1" || lines[1].Decision.ID != "d2" {
+		t.Errorf("rotated file: expected d1, d2, got %+v", lines)
+	}
+	if lines := readLines(t, path); len(lines) != 1 || lines[0].Decision.ID != "d3" {
+		t.Errorf("current file: expected d3, got %+v", lines)
+	}
+}
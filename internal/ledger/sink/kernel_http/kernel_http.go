@@ -7,10 +7,13 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/futurematic/kernel/internal/domain"
 	"github.com/futurematic/kernel/internal/ledger/sink"
+	"github.com/futurematic/kernel/internal/runtime"
 )
 
 // Sink POSTs decision records to the Kernel HTTP API.
@@ -19,7 +22,17 @@ type Sink struct {
 	apiKey    string
 	timeout   time.Duration
 	required  bool
+	source    string // sent as X-Ctrldot-Source so Kernel can tell daemons apart
 	client    *http.Client
+
+	// Outbox delivery (nil unless WithOutbox is called)
+	outbox   runtime.RuntimeStore
+	kick     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	flushMu  sync.Mutex
+	now      func() time.Time
 }
 
 // NewSink creates a Kernel HTTP sink. baseURL is the Kernel root (e.g. http://127.0.0.1:8080).
@@ -27,11 +40,13 @@ func NewSink(baseURL, apiKey string, timeoutMs int, required bool) *Sink {
 	if timeoutMs <= 0 {
 		timeoutMs = 2000
 	}
+	source, _ := os.Hostname()
 	return &Sink{
 		baseURL:  baseURL,
 		apiKey:   apiKey,
 		timeout:  time.Duration(timeoutMs) * time.Millisecond,
 		required: required,
+		source:   source,
 		client: &http.Client{
 			Timeout: time.Duration(timeoutMs) * time.Millisecond,
 		},
@@ -39,6 +54,7 @@ func NewSink(baseURL, apiKey string, timeoutMs int, required bool) *Sink {
 }
 
 // EmitDecision POSTs the decision record to Kernel. Best-effort unless Required; on failure logs and returns error only if Required.
+// With an outbox (WithOutbox), undelivered records are persisted and delivered later in order instead of being dropped.
 func (s *Sink) EmitDecision(ctx context.Context, d *sink.DecisionRecord) error {
 	body, err := json.Marshal(d)
 	if err != nil {
@@ -48,46 +64,50 @@ func (s *Sink) EmitDecision(ctx context.Context, d *sink.DecisionRecord) error {
 		log.Printf("kernel_http: marshal decision: %v", err)
 		return nil
 	}
-	url := s.baseURL + "/v1/ctrldot/decisions"
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if s.outbox != nil {
+		return s.emitWithOutbox(ctx, d.ID, body)
+	}
+	_, err = s.post(ctx, d.ID, body)
+	if err != nil {
+		// One retry with fresh body
+		_, err = s.post(ctx, d.ID, body)
+	}
 	if err != nil {
 		if s.required {
 			return err
 		}
-		log.Printf("kernel_http: new request: %v", err)
+		log.Printf("kernel_http: %v", err)
 		return nil
 	}
+	return nil
+}
+
+// post sends one attempt. Non-2xx responses are errors; the status code is returned when there was a response.
+func (s *Sink) post(ctx context.Context, recordID string, body []byte) (int, error) {
+	url := s.baseURL + "/v1/ctrldot/decisions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
 	req.Header.Set("Content-Type", "application/json")
+	if recordID != "" {
+		req.Header.Set(IdempotencyKeyHeader, recordID)
+	}
+	if s.source != "" {
+		req.Header.Set(SourceHeader, s.source)
+	}
 	if s.apiKey != "" {
 		req.Header.Set("Authorization", "Bearer "+s.apiKey)
 	}
 	resp, err := s.client.Do(req)
 	if err != nil {
-		// One retry with fresh body
-		req2, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-		req2.Header.Set("Content-Type", "application/json")
-		if s.apiKey != "" {
-			req2.Header.Set("Authorization", "Bearer "+s.apiKey)
-		}
-		resp, err = s.client.Do(req2)
-	}
-	if err != nil {
-		if s.required {
-			return fmt.Errorf("POST %s: %w", url, err)
-		}
-		log.Printf("kernel_http: POST %s: %v", url, err)
-		return nil
+		return 0, fmt.Errorf("POST %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		err := fmt.Errorf("POST %s: %d", url, resp.StatusCode)
-		if s.required {
-			return err
-		}
-		log.Printf("kernel_http: %v", err)
-		return nil
+		return resp.StatusCode, fmt.Errorf("POST %s: %d", url, resp.StatusCode)
 	}
-	return nil
+	return resp.StatusCode, nil
 }
 
 // EmitEvent is a no-op for Kernel HTTP (decisions only).
@@ -95,8 +115,9 @@ func (s *Sink) EmitEvent(ctx context.Context, e *domain.Event) error {
 	return nil
 }
 
-// Close is a no-op.
+// Close stops outbox delivery (if any). Undelivered records stay in the outbox for the next start.
 func (s *Sink) Close() error {
+	s.stopOutbox()
 	return nil
 }
 
@@ -0,0 +1,142 @@
+package kernel_http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/ledger/sink"
+	"github.com/futurematic/kernel/internal/runtime"
+	"github.com/futurematic/kernel/internal/runtime/sqlite"
+)
+
+// fakeKernel accepts decisions unless down; status overrides the response per record ID.
+type fakeKernel struct {
+	mu       sync.Mutex
+	down     bool
+	status   map[string]int
+	received []string
+}
+
+func (k *fakeKernel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var d sink.DecisionRecord
+	json.NewDecoder(r.Body).Decode(&d)
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if r.Header.Get(IdempotencyKeyHeader) != d.ID {
+		http.Error(w, "missing idempotency key", http.StatusBadRequest)
+		return
+	}
+	if k.down {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if code := k.status[d.ID]; code != 0 {
+		w.WriteHeader(code)
+		return
+	}
+	k.received = append(k.received, d.ID)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (k *fakeKernel) setDown(down bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.down = down
+}
+
+func (k *fakeKernel) got() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return append([]string(nil), k.received...)
+}
+
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newOutboxSink(url string, st runtime.RuntimeStore, clock *testClock) *Sink {
+	s := NewSink(url, "", 1000, false)
+	s.now = clock.Now
+	return s.WithOutbox(st)
+}
+
+func TestOutboxDeliversInOrderAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "ctrldot.sqlite"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+	kernel := &fakeKernel{status: map[string]int{"dup": http.StatusConflict, "bad": http.StatusUnprocessableEntity}}
+	srv := httptest.NewServer(kernel)
+	defer srv.Close()
+	clock := &testClock{now: time.Unix(1700000000, 0)}
+
+	// Kernel down: records are queued, not dropped.
+	kernel.setDown(true)
+	s := newOutboxSink(srv.URL, st, clock)
+	for _, id := range []string{"d1", "d2", "d3"} {
+		if err := s.EmitDecision(ctx, &sink.DecisionRecord{ID: id}); err != nil {
+			t.Fatalf("emit %s: %v", id, err)
+		}
+	}
+	counts, err := st.CountOutbox(ctx)
+	if err != nil || counts[domain.OutboxStatusPending] != 3 {
+		t.Fatalf("expected 3 pending records, got %v (%v)", counts, err)
+	}
+	s.Close()
+
+	// Restart with Kernel back: the queue is delivered in order, then new records go direct.
+	kernel.setDown(false)
+	clock.Advance(2 * time.Second)
+	s = newOutboxSink(srv.URL, st, clock)
+	defer s.Close()
+	if _, err := s.Flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	for _, id := range []string{"dup", "bad", "d4"} {
+		if err := s.EmitDecision(ctx, &sink.DecisionRecord{ID: id}); err != nil {
+			t.Fatalf("emit %s: %v", id, err)
+		}
+	}
+	if got := kernel.got(); len(got) != 4 || got[0] != "d1" || got[1] != "d2" || got[2] != "d3" || got[3] != "d4" {
+		t.Fatalf("unexpected delivery order: %v", got)
+	}
+
+	// 409 means Kernel already has the record; 422 is not retried but kept as dead.
+	if _, err := s.Flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	counts, _ = st.CountOutbox(ctx)
+	if counts[domain.OutboxStatusPending] != 0 || counts[domain.OutboxStatusDead] != 1 {
+		t.Errorf("unexpected outbox counts: %v", counts)
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 20: 5 * time.Minute} {
+		if got := outboxBackoff(attempts); got != want {
+			t.Errorf("outboxBackoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
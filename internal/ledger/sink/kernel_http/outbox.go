@@ -0,0 +1,215 @@
+package kernel_http
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/runtime"
+)
+
+// OutboxSink is the sink name of kernel_http records in the runtime store outbox.
+const OutboxSink = "kernel_http"
+
+// IdempotencyKeyHeader carries the decision record ID so Kernel can dedupe redeliveries.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// SourceHeader identifies the sending daemon (its hostname) to Kernel.
+const SourceHeader = "X-Ctrldot-Source"
+
+const (
+	outboxBatch       = 100
+	outboxPoll        = time.Second
+	outboxBackoffBase = time.Second
+	outboxBackoffMax  = 5 * time.Minute
+)
+
+// WithOutbox persists records Kernel did not accept to store and delivers them in order,
+// with backoff, from a background loop. Records left over from a previous run are
+// delivered first. Call Close to stop the loop.
+func (s *Sink) WithOutbox(store runtime.RuntimeStore) *Sink {
+	s.outbox = store
+	if s.now == nil {
+		s.now = time.Now
+	}
+	s.kick = make(chan struct{}, 1)
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.runOutbox()
+	return s
+}
+
+// emitWithOutbox posts directly when nothing is queued ahead of the record; otherwise (or
+// if the post fails) it appends the record to the outbox so delivery order is preserved.
+func (s *Sink) emitWithOutbox(ctx context.Context, recordID string, body []byte) error {
+	var sendErr error
+	var rejected bool
+	if s.flushMu.TryLock() {
+		pending, err := s.outbox.ListOutbox(ctx, runtime.OutboxFilter{Sink: OutboxSink, Status: domain.OutboxStatusPending, Limit: 1})
+		if err == nil && len(pending) == 0 {
+			status, err := s.post(ctx, recordID, body)
+			if err == nil || status == http.StatusConflict {
+				s.flushMu.Unlock()
+				return nil
+			}
+			sendErr, rejected = err, rejectedStatus(status)
+		}
+		s.flushMu.Unlock()
+	}
+
+	now := s.now().UTC()
+	rec := domain.OutboxRecord{
+		Sink:          OutboxSink,
+		RecordID:      recordID,
+		Payload:       string(body),
+		Status:        domain.OutboxStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if sendErr != nil {
+		rec.Attempts = 1
+		rec.LastError = sendErr.Error()
+		rec.NextAttemptAt = now.Add(outboxBackoff(1))
+		if rejected {
+			// Kept for inspection but never retried, so it does not hold up later records.
+			rec.Status = domain.OutboxStatusDead
+			log.Printf("kernel_http: record %s rejected, marked dead: %v", recordID, sendErr)
+		}
+	}
+	if err := s.outbox.EnqueueOutbox(context.WithoutCancel(ctx), rec); err != nil {
+		err = fmt.Errorf("enqueue outbox record %s: %w", recordID, err)
+		if s.required {
+			return err
+		}
+		log.Printf("kernel_http: %v", err)
+		return nil
+	}
+	s.kickOutbox()
+	if sendErr != nil && s.required {
+		return fmt.Errorf("%w (queued for redelivery)", sendErr)
+	}
+	return nil
+}
+
+// Flush delivers due outbox records in order and returns how many were delivered. It stops
+// at the first record that is not yet due or fails with a retryable error. Records Kernel
+// rejects as invalid (400, 413, 422) are marked dead so they do not block the queue.
+func (s *Sink) Flush(ctx context.Context) (int, error) {
+	if s.outbox == nil {
+		return 0, nil
+	}
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	delivered := 0
+	for {
+		recs, err := s.outbox.ListOutbox(ctx, runtime.OutboxFilter{Sink: OutboxSink, Status: domain.OutboxStatusPending, Limit: outboxBatch})
+		if err != nil {
+			return delivered, err
+		}
+		if len(recs) == 0 {
+			return delivered, nil
+		}
+		for _, r := range recs {
+			now := s.now().UTC()
+			if r.NextAttemptAt.After(now) {
+				return delivered, nil
+			}
+			status, err := s.post(ctx, r.RecordID, []byte(r.Payload))
+			switch {
+			case err == nil || status == http.StatusConflict:
+				if err := s.outbox.DeleteOutbox(ctx, r.Seq); err != nil {
+					return delivered, err
+				}
+				delivered++
+				continue
+			case rejectedStatus(status):
+				r.Status = domain.OutboxStatusDead
+				log.Printf("kernel_http: outbox record %s rejected, marked dead: %v", r.RecordID, err)
+			default:
+				r.NextAttemptAt = now.Add(outboxBackoff(r.Attempts + 1))
+			}
+			r.Attempts++
+			r.LastError = err.Error()
+			r.UpdatedAt = now
+			if uerr := s.outbox.UpdateOutbox(ctx, r); uerr != nil {
+				return delivered, uerr
+			}
+			if r.Status != domain.OutboxStatusDead {
+				return delivered, err
+			}
+		}
+	}
+}
+
+// rejectedStatus reports whether Kernel rejected the record itself, so retrying cannot help.
+func rejectedStatus(status int) bool {
+	return status == http.StatusBadRequest || status == http.StatusRequestEntityTooLarge || status == http.StatusUnprocessableEntity
+}
+
+// outboxBackoff is 1s doubling per attempt, capped at 5 minutes.
+func outboxBackoff(attempts int) time.Duration {
+	d := outboxBackoffBase
+	for i := 1; i < attempts && d < outboxBackoffMax; i++ {
+		d *= 2
+	}
+	if d > outboxBackoffMax {
+		d = outboxBackoffMax
+	}
+	return d
+}
+
+func (s *Sink) kickOutbox() {
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Sink) runOutbox() {
+	defer close(s.done)
+	ticker := time.NewTicker(outboxPoll)
+	defer ticker.Stop()
+	var lastErr string
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-s.stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		n, err := s.Flush(ctx)
+		cancel()
+		if n > 0 {
+			log.Printf("kernel_http: delivered %d outbox records", n)
+		}
+		// Log each distinct failure once rather than on every poll.
+		if err != nil && err.Error() != lastErr {
+			log.Printf("kernel_http: outbox delivery: %v", err)
+		}
+		if err != nil {
+			lastErr = err.Error()
+		} else {
+			lastErr = ""
+		}
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		case <-s.kick:
+		}
+	}
+}
+
+func (s *Sink) stopOutbox() {
+	if s.outbox == nil {
+		return
+	}
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
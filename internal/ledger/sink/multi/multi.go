@@ -0,0 +1,254 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/ledger/sink"
+	"github.com/futurematic/kernel/internal/metrics"
+)
+
+// Queue-full policies for async members.
+const (
+	OnFullDrop  = "drop"
+	OnFullBlock = "block"
+)
+
+const (
+	defaultQueueSize    = 1024
+	defaultBlockTimeout = time.Second
+	defaultDrainTimeout = 5 * time.Second
+)
+
+// Member is one sink of a multi sink.
+type Member struct {
+	Name         string
+	Sink         sink.LedgerSink
+	Required     bool          // emit synchronously; errors are returned to the caller
+	QueueSize    int           // async queue capacity; default 1024
+	OnFull       string        // OnFullDrop (default) or OnFullBlock
+	BlockTimeout time.Duration // OnFullBlock: max wait before dropping; default 1s
+}
+
+type record struct {
+	decision *sink.DecisionRecord
+	event    *domain.Event
+}
+
+type member struct {
+	Member
+	queue chan record
+	done  chan struct{}
+
+	mu      sync.Mutex
+	dropped int64
+}
+
+// Sink fans records out to its members. Required members are emitted inline; the others
+// each get a bounded queue and a worker, so emitting never waits on their I/O.
+type Sink struct {
+	members      []*member
+	drainTimeout time.Duration
+
+	mu        sync.RWMutex // guards closed against in-flight enqueues
+	closed    bool
+	drainOnce sync.Once
+	closeOnce sync.Once
+}
+
+// New starts workers for the async members. drainTimeout bounds Close; 0 means 5s.
+func New(members []Member, drainTimeout time.Duration) *Sink {
+	if drainTimeout <= 0 {
+		drainTimeout = defaultDrainTimeout
+	}
+	s := &Sink{drainTimeout: drainTimeout}
+	for _, m := range members {
+		mm := &member{Member: m}
+		if !mm.Required {
+			if mm.OnFull == "" {
+				mm.OnFull = OnFullDrop
+			}
+			if mm.BlockTimeout <= 0 {
+				mm.BlockTimeout = defaultBlockTimeout
+			}
+			if mm.QueueSize <= 0 {
+				mm.QueueSize = defaultQueueSize
+			}
+			mm.queue = make(chan record, mm.QueueSize)
+			mm.done = make(chan struct{})
+			go mm.run()
+		}
+		s.members = append(s.members, mm)
+	}
+	return s
+}
+
+// EmitDecision emits d to required members inline and queues it for the rest.
+// Only required members' errors are returned.
+func (s *Sink) EmitDecision(ctx context.Context, d *sink.DecisionRecord) error {
+	return s.emit(ctx, record{decision: d})
+}
+
+// EmitEvent emits e to required members inline and queues it for the rest.
+func (s *Sink) EmitEvent(ctx context.Context, e *domain.Event) error {
+	return s.emit(ctx, record{event: e})
+}
+
+func (s *Sink) emit(ctx context.Context, r record) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var errs []error
+	for _, m := range s.members {
+		if m.Required {
+			if err := m.emit(ctx, r); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", m.Name, err))
+			}
+			continue
+		}
+		if s.closed {
+			m.drop("sink closed")
+			continue
+		}
+		m.enqueue(r)
+	}
+	return errors.Join(errs...)
+}
+
+// Drain stops accepting async records and waits for queued ones to be emitted, or for ctx.
+func (s *Sink) Drain(ctx context.Context) error {
+	s.drainOnce.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		for _, m := range s.members {
+			if m.queue != nil {
+				close(m.queue)
+			}
+		}
+		s.mu.Unlock()
+	})
+	for _, m := range s.members {
+		if m.done == nil {
+			continue
+		}
+		select {
+		case <-m.done:
+		case <-ctx.Done():
+			return fmt.Errorf("drain %s: %d records not emitted: %w", m.Name, len(m.queue), ctx.Err())
+		}
+	}
+	return nil
+}
+
+// Close drains (bounded by the drain timeout) and then closes every member, e.g. so the
+// bundle sink writes its bundles.
+func (s *Sink) Close() error {
+	var errs []error
+	s.closeOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), s.drainTimeout)
+		defer cancel()
+		if err := s.Drain(ctx); err != nil {
+			log.Printf("ledger sink multi: %v", err)
+		}
+		for _, m := range s.members {
+			if err := m.Sink.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", m.Name, err))
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// SetConfig passes a reloaded config to the members that take one (e.g. the bundle sink's
+// config snapshot).
+func (s *Sink) SetConfig(cfg *config.Config) {
+	for _, m := range s.members {
+		if r, ok := m.Sink.(config.Reloadable); ok {
+			r.SetConfig(cfg)
+		}
+	}
+}
+
+// Members reports each member's queue depth and drop count (GET /v1/capabilities).
+func (s *Sink) Members() []domain.LedgerSinkMemberInfo {
+	out := make([]domain.LedgerSinkMemberInfo, 0, len(s.members))
+	for _, m := range s.members {
+		st := domain.LedgerSinkMemberInfo{Name: m.Name, Required: m.Required, QueueSize: m.QueueSize, OnFull: m.OnFull}
+		if m.queue != nil {
+			st.QueueDepth = len(m.queue)
+		}
+		m.mu.Lock()
+		st.Dropped = m.dropped
+		m.mu.Unlock()
+		out = append(out, st)
+	}
+	return out
+}
+
+func (m *member) enqueue(r record) {
+	select {
+	case m.queue <- r:
+		metrics.LedgerSinkQueueDepth.Set(float64(len(m.queue)), m.Name)
+		return
+	default:
+	}
+	if m.OnFull == OnFullBlock {
+		timer := time.NewTimer(m.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case m.queue <- r:
+			metrics.LedgerSinkQueueDepth.Set(float64(len(m.queue)), m.Name)
+			return
+		case <-timer.C:
+		}
+	}
+	m.drop("queue full")
+}
+
+func (m *member) drop(why string) {
+	metrics.LedgerSinkDropped.Inc(m.Name)
+	m.mu.Lock()
+	m.dropped++
+	n := m.dropped
+	m.mu.Unlock()
+	if n == 1 || n%100 == 0 {
+		log.Printf("ledger sink %s: %s, dropped %d records so far", m.Name, why, n)
+	}
+}
+
+func (m *member) run() {
+	defer close(m.done)
+	for r := range m.queue {
+		metrics.LedgerSinkQueueDepth.Set(float64(len(m.queue)), m.Name)
+		if err := m.emit(context.Background(), r); err != nil {
+			log.Printf("ledger sink %s: %v", m.Name, err)
+		}
+	}
+}
+
+func (m *member) emit(ctx context.Context, r record) error {
+	var err error
+	op := "decision"
+	if r.decision != nil {
+		err = m.Sink.EmitDecision(ctx, r.decision)
+	} else {
+		op = "event"
+		err = m.Sink.EmitEvent(ctx, r.event)
+	}
+	if err != nil {
+		metrics.LedgerSinkErrors.Inc(m.Name, op)
+		return err
+	}
+	metrics.LedgerSinkEmitted.Inc(m.Name, op)
+	return nil
+}
+
+var (
+	_ sink.LedgerSink = (*Sink)(nil)
+	_ sink.Drainer    = (*Sink)(nil)
+)
@@ -0,0 +1,143 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/ledger/sink"
+)
+
+// fakeSink records decisions; it blocks on gate (if set) and returns err.
+type fakeSink struct {
+	mu        sync.Mutex
+	decisions []string
+	events    int
+	gate      chan struct{}
+	err       error
+	closed    bool
+}
+
+func (f *fakeSink) EmitDecision(ctx context.Context, d *sink.DecisionRecord) error {
+	if f.gate != nil {
+		<-f.gate
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.decisions = append(f.decisions, d.ID)
+	return f.err
+}
+
+func (f *fakeSink) EmitEvent(ctx context.Context, e *domain.Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events++
+	return f.err
+}
+
+func (f *fakeSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+func (f *fakeSink) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.decisions)
+}
+
+func TestAsyncMembersDoNotBlockAndDrain(t *testing.T) {
+	slow := &fakeSink{gate: make(chan struct{})}
+	required := &fakeSink{}
+	s := New([]Member{
+		{Name: "slow", Sink: slow, QueueSize: 10},
+		{Name: "req", Sink: required, Required: true},
+	}, time.Second)
+
+	ctx := context.Background()
+	start := time.Now()
+	for _, id := range []string{"d1", "d2", "d3"} {
+		if err := s.EmitDecision(ctx, &sink.DecisionRecord{ID: id}); err != nil {
+			t.Fatalf("emit: %v", err)
+		}
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("emit waited on an async member")
+	}
+	if required.count() != 3 {
+		t.Fatalf("required member should be emitted inline, got %d", required.count())
+	}
+
+	close(slow.gate)
+	if err := s.Drain(ctx); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if got := slow.decisions; len(got) != 3 || got[0] != "d1" || got[2] != "d3" {
+		t.Fatalf("async member did not receive queued records in order: %v", got)
+	}
+
+	// After drain, async records are dropped; required members still receive them.
+	s.EmitDecision(ctx, &sink.DecisionRecord{ID: "late"})
+	if slow.count() != 3 || required.count() != 4 {
+		t.Errorf("unexpected emit after drain: slow=%d required=%d", slow.count(), required.count())
+	}
+	if err := s.Close(); err != nil || !slow.closed || !required.closed {
+		t.Errorf("close should close members: %v", err)
+	}
+}
+
+func TestQueueFullPolicies(t *testing.T) {
+	dropGate := make(chan struct{})
+	dropper := &fakeSink{gate: dropGate}
+	blocker := &fakeSink{gate: make(chan struct{})}
+	s := New([]Member{
+		{Name: "dropper", Sink: dropper, QueueSize: 1},
+		{Name: "blocker", Sink: blocker, QueueSize: 1, OnFull: OnFullBlock, BlockTimeout: 50 * time.Millisecond},
+	}, time.Second)
+	ctx := context.Background()
+
+	// Worker holds one record, the queue holds one; the rest overflow.
+	for i := 0; i < 4; i++ {
+		s.EmitDecision(ctx, &sink.DecisionRecord{ID: "d"})
+		time.Sleep(5 * time.Millisecond)
+	}
+	members := s.Members()
+	if members[0].Dropped == 0 || members[1].Dropped == 0 {
+		t.Fatalf("expected drops once queues were full: %+v", members)
+	}
+
+	// A blocked enqueue succeeds if the worker frees space within the timeout.
+	before := members[1].Dropped
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		blocker.gate <- struct{}{}
+	}()
+	s.EmitDecision(ctx, &sink.DecisionRecord{ID: "waited"})
+	if s.Members()[1].Dropped != before {
+		t.Errorf("block policy should have waited for space")
+	}
+
+	close(dropGate)
+	close(blocker.gate)
+	if err := s.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequiredErrorsReturned(t *testing.T) {
+	s := New([]Member{
+		{Name: "async", Sink: &fakeSink{err: errors.New("ignored")}},
+		{Name: "kernel", Sink: &fakeSink{err: errors.New("unreachable")}, Required: true},
+	}, time.Second)
+	defer s.Close()
+
+	err := s.EmitEvent(context.Background(), &domain.Event{EventID: "evt:1"})
+	if err == nil || err.Error() != "kernel: unreachable" {
+		t.Fatalf("expected required member error, got %v", err)
+	}
+}
@@ -65,6 +65,7 @@ type DecisionRecord struct {
 	SessionID     string                 `json:"session_id,omitempty"`
 	Timestamp     time.Time              `json:"timestamp"`
 	ActionType    string                 `json:"action_type"`
+	ActionTool    string                 `json:"action_tool,omitempty"` // proposal context.tool
 	ActionTarget  map[string]interface{} `json:"action_target"`  // redacted
 	ActionInputs  map[string]interface{} `json:"action_inputs"`  // redacted
 	Decision      domain.Decision        `json:"decision"`
@@ -76,6 +77,8 @@ type DecisionRecord struct {
 	ActionCount   int                    `json:"action_count,omitempty"`
 	ActionHash    string                 `json:"action_hash,omitempty"`
 	ExecutionTokenPresent bool           `json:"execution_token_present,omitempty"`
+	ResolutionTokenPresent bool          `json:"resolution_token_present,omitempty"` // proposal carried a resolution token
+	ConfigRev              int64         `json:"config_rev,omitempty"`               // config revision the decision was made under
 }
 
 // LedgerSink emits immutable decision (and optional event) records.
@@ -85,3 +88,9 @@ type LedgerSink interface {
 	EmitEvent(ctx context.Context, e *domain.Event) error
 	Close() error
 }
+
+// Drainer is implemented by sinks that emit asynchronously. Drain blocks until queued records
+// have been emitted or ctx is done; records emitted after Drain may be dropped.
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
@@ -0,0 +1,257 @@
+package syslog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/ledger/sink"
+)
+
+// Severities (RFC 5424 section 6.2.1) used by the sink.
+const (
+	SeverityError   = 3
+	SeverityWarning = 4
+	SeverityNotice  = 5
+	SeverityInfo    = 6
+)
+
+// sdID is the structured data element carrying ctrldot fields. 32473 is the private
+// enterprise number RFC 5612 reserves for documentation and examples.
+const sdID = "ctrldot@32473"
+
+const writeTimeout = 2 * time.Second
+
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// localSockets are tried in order when no address is configured (Linux, macOS, BSD).
+var localSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// Sink writes decision records and events to syslog as RFC 5424 messages. The message
+// body is the redacted record as JSON; agent, decision and action type are also sent as
+// structured data so collectors can filter without parsing the body.
+type Sink struct {
+	network  string
+	address  string
+	facility int
+	appName  string
+	hostname string
+	procID   string
+	now      func() time.Time
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSink connects to the configured syslog endpoint.
+func NewSink(cfg config.LedgerSyslogConfig) (*Sink, error) {
+	facilityName := cfg.Facility
+	if facilityName == "" {
+		facilityName = "local0"
+	}
+	facility, ok := facilities[strings.ToLower(facilityName)]
+	if !ok {
+		return nil, fmt.Errorf("ledger_sink.syslog.facility: unknown facility %q", cfg.Facility)
+	}
+	s := &Sink{
+		network:  cfg.Network,
+		address:  cfg.Address,
+		facility: facility,
+		appName:  headerField(cfg.AppName, 48),
+		procID:   strconv.Itoa(os.Getpid()),
+		now:      time.Now,
+	}
+	if s.appName == "-" {
+		s.appName = "ctrldot"
+	}
+	if s.network == "" {
+		s.network = "unixgram"
+	}
+	switch s.network {
+	case "unixgram", "unix", "udp", "tcp":
+	default:
+		return nil, fmt.Errorf("ledger_sink.syslog.network: unknown network %q", cfg.Network)
+	}
+	if host, err := os.Hostname(); err == nil {
+		s.hostname = headerField(host, 255)
+	} else {
+		s.hostname = "-"
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Sink) connect() error {
+	if s.address != "" {
+		conn, err := net.DialTimeout(s.network, s.address, writeTimeout)
+		if err != nil {
+			return fmt.Errorf("connect syslog %s %s: %w", s.network, s.address, err)
+		}
+		s.conn = conn
+		return nil
+	}
+	if s.network != "unixgram" && s.network != "unix" {
+		return fmt.Errorf("ledger_sink.syslog.address is required for network %q", s.network)
+	}
+	var lastErr error
+	for _, path := range localSockets {
+		conn, err := net.DialTimeout(s.network, path, writeTimeout)
+		if err == nil {
+			s.conn = conn
+			return nil
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("connect local syslog socket: %w", lastErr)
+}
+
+// EmitDecision sends d. DENY and STOP are sent as warnings, WARN and THROTTLE as notices.
+func (s *Sink) EmitDecision(ctx context.Context, d *sink.DecisionRecord) error {
+	body, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("marshal decision: %w", err)
+	}
+	severity := SeverityInfo
+	switch d.Decision {
+	case domain.DecisionDeny, domain.DecisionStop:
+		severity = SeverityWarning
+	case domain.DecisionWarn, domain.DecisionThrottle:
+		severity = SeverityNotice
+	}
+	sd := [][2]string{
+		{"id", d.ID},
+		{"agent_id", d.AgentID},
+		{"session_id", d.SessionID},
+		{"decision", string(d.Decision)},
+		{"action_type", d.ActionType},
+	}
+	return s.send(severity, d.Timestamp, "decision", sd, body)
+}
+
+// EmitEvent sends e with its payload redacted.
+func (s *Sink) EmitEvent(ctx context.Context, e *domain.Event) error {
+	ev := *e
+	ev.PayloadJSON = sink.RedactMap(e.PayloadJSON)
+	body, err := json.Marshal(&ev)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	severity := SeverityInfo
+	switch e.Severity {
+	case "warn":
+		severity = SeverityWarning
+	case "error":
+		severity = SeverityError
+	}
+	sd := [][2]string{
+		{"id", e.EventID},
+		{"agent_id", e.AgentID},
+		{"session_id", e.SessionID},
+	}
+	return s.send(severity, e.TS, e.Type, sd, body)
+}
+
+func (s *Sink) send(severity int, ts time.Time, msgID string, sd [][2]string, body []byte) error {
+	if ts.IsZero() {
+		ts = s.now()
+	}
+	msg := s.format(severity, ts, msgID, sd, body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.write(msg)
+	if err != nil {
+		// The syslog daemon may have restarted; reconnect once.
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		if cerr := s.connect(); cerr != nil {
+			return fmt.Errorf("syslog write: %v; reconnect: %w", err, cerr)
+		}
+		err = s.write(msg)
+	}
+	return err
+}
+
+func (s *Sink) write(msg []byte) error {
+	if s.conn == nil {
+		return fmt.Errorf("not connected")
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if s.network == "tcp" || s.network == "unix" {
+		// Stream transports use octet-counting framing (RFC 6587 section 3.4.1).
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// format renders an RFC 5424 message: <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG.
+func (s *Sink) format(severity int, ts time.Time, msgID string, sd [][2]string, body []byte) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s %s ",
+		s.facility*8+severity,
+		ts.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname, s.appName, s.procID, headerField(msgID, 32))
+	b.WriteString("[" + sdID)
+	for _, p := range sd {
+		if p[1] == "" {
+			continue
+		}
+		b.WriteString(" " + p[0] + `="` + escapeParam(p[1]) + `"`)
+	}
+	b.WriteString("] \ufeff") // BOM: MSG is UTF-8
+	b.Write(body)
+	return []byte(b.String())
+}
+
+// headerField restricts v to printable US-ASCII without spaces, at most n characters; "-" if empty.
+func headerField(v string, n int) string {
+	var b strings.Builder
+	for _, r := range v {
+		if r > 32 && r < 127 {
+			b.WriteRune(r)
+		}
+		if b.Len() == n {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "-"
+	}
+	return b.String()
+}
+
+// escapeParam escapes '"', '\' and ']' in a structured data parameter value.
+func escapeParam(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(v)
+}
+
+// Close closes the connection.
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+var _ sink.LedgerSink = (*Sink)(nil)
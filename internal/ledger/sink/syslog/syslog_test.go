@@ -0,0 +1,61 @@
+package syslog
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/ledger/sink"
+)
+
+func TestRFC5424OverUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	s, err := NewSink(config.LedgerSyslogConfig{Network: "udp", Address: pc.LocalAddr().String(), Facility: "local3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.hostname = "host"
+	s.procID = "42"
+
+	ts := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	if err := s.EmitDecision(ctx, &sink.DecisionRecord{ID: "d1", AgentID: `a"1]`, Timestamp: ts, Decision: domain.DecisionStop, ActionType: "exec"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EmitEvent(ctx, &domain.Event{EventID: "evt:1", TS: ts, Type: "agent.halted", Severity: "warn", PayloadJSON: map[string]interface{}{"token": "x"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 4096)
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// local3 (19) * 8 + warning (4) = 156
+	want := `<156>1 2026-03-01T12:00:00.000000Z host ctrldot 42 decision [ctrldot@32473 id="d1" agent_id="a\"1\]" decision="STOP" action_type="exec"] ` + "\ufeff" + `{"id":"d1"`
+	if got := string(buf[:n]); !strings.HasPrefix(got, want) {
+		t.Fatalf("unexpected message:\n got %s\nwant %s...", got, want)
+	}
+
+	n, _, err = pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(buf[:n])
+	if !regexp.MustCompile(`^<156>1 \S+ host ctrldot 42 agent\.halted \[ctrldot@32473 id="evt:1"\] `).MatchString(got) ||
+		!strings.Contains(got, `"token":"[redacted]"`) {
+		t.Errorf("unexpected event message: %s", got)
+	}
+}
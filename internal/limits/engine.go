@@ -3,6 +3,7 @@ package limits
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/futurematic/kernel/internal/config"
@@ -13,29 +14,44 @@ import (
 // Engine evaluates budget and limits
 type Engine struct {
 	store  runtime.RuntimeStore
-	config *config.Config
+	config atomic.Pointer[config.Config]
+	now    func() time.Time
 }
 
 // NewEngine creates a new limits engine
 func NewEngine(store runtime.RuntimeStore, cfg *config.Config) *Engine {
-	return &Engine{
-		store:  store,
-		config: cfg,
+	e := &Engine{
+		store: store,
+		now:   time.Now,
 	}
+	e.config.Store(cfg)
+	return e
+}
+
+// SetConfig swaps in a reloaded config for evaluations that do not pass their own.
+func (e *Engine) SetConfig(cfg *config.Config) {
+	e.config.Store(cfg)
+}
+
+// WithClock replaces the engine's clock, which picks the daily budget window (e.g. a simulated
+// clock for replay).
+func (e *Engine) WithClock(now func() time.Time) *Engine {
+	e.now = now
+	return e
 }
 
 // Evaluate evaluates limits and returns decision, warnings, and throttle info (uses engine config).
 func (e *Engine) Evaluate(ctx context.Context, proposal domain.ActionProposal, agent *domain.Agent) (domain.Decision, []domain.Warning, *domain.ThrottleInfo) {
-	return e.EvaluateWithConfig(ctx, proposal, agent, e.config)
+	return e.EvaluateWithConfig(ctx, proposal, agent, e.config.Load())
 }
 
 // EvaluateWithConfig evaluates limits using the given config (e.g. effective config when panic is on).
 func (e *Engine) EvaluateWithConfig(ctx context.Context, proposal domain.ActionProposal, agent *domain.Agent, cfg *config.Config) (domain.Decision, []domain.Warning, *domain.ThrottleInfo) {
 	if cfg == nil {
-		cfg = e.config
+		cfg = e.config.Load()
 	}
 	// Get current window (daily)
-	now := time.Now()
+	now := e.now()
 	windowStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	windowStartTS := windowStart.Unix() * 1000
 
@@ -82,11 +98,7 @@ func (e *Engine) EvaluateWithConfig(ctx context.Context, proposal domain.ActionP
 	}
 
 	if budgetPct >= defaults.ThrottlePct {
-		throttle = &domain.ThrottleInfo{
-			MaxParallelTasks: e.config.DegradeModes.Cheap.MaxParallelTasks,
-			ModelPolicy:      e.config.DegradeModes.Cheap.ModelPolicy,
-			ToolRestrictions: e.config.DegradeModes.Cheap.DenyTools,
-		}
+		throttle = &domain.ThrottleInfo{}
 		if cfg != nil {
 			throttle.MaxParallelTasks = cfg.DegradeModes.Cheap.MaxParallelTasks
 			throttle.ModelPolicy = cfg.DegradeModes.Cheap.ModelPolicy
@@ -111,3 +123,22 @@ func (e *Engine) EvaluateWithConfig(ctx context.Context, proposal domain.ActionP
 
 	return domain.DecisionAllow, warnings, nil
 }
+
+// Record adds an executed proposal's cost to the agent's daily window.
+func (e *Engine) Record(ctx context.Context, proposal domain.ActionProposal) error {
+	now := e.now()
+	windowStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	windowStartTS := windowStart.Unix() * 1000
+	state, _ := e.store.GetLimitsState(ctx, proposal.AgentID, windowStartTS, "daily")
+	if state == nil {
+		state = &domain.LimitsState{
+			AgentID:     proposal.AgentID,
+			WindowStart: windowStart,
+			WindowType:  "daily",
+		}
+	}
+	state.BudgetSpentGBP += proposal.Cost.EstimatedGBP
+	state.BudgetSpentTokens += proposal.Cost.EstimatedTokens
+	state.ActionCount++
+	return e.store.UpdateLimitsState(ctx, *state)
+}
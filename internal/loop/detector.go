@@ -5,6 +5,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/futurematic/kernel/internal/config"
@@ -15,27 +17,43 @@ import (
 // Detector detects action loops
 type Detector struct {
 	store  runtime.RuntimeStore
-	config *config.Config
+	config atomic.Pointer[config.Config]
+	now    func() time.Time
 }
 
 // NewDetector creates a new loop detector
 func NewDetector(store runtime.RuntimeStore, cfg *config.Config) *Detector {
-	return &Detector{
-		store:  store,
-		config: cfg,
+	d := &Detector{
+		store: store,
+		now:   time.Now,
 	}
+	d.config.Store(cfg)
+	return d
+}
+
+// SetConfig swaps in a reloaded config for detections that do not pass their own.
+func (d *Detector) SetConfig(cfg *config.Config) {
+	d.config.Store(cfg)
+}
+
+// WithClock replaces the detector's clock (e.g. a simulated clock for replay).
+func (d *Detector) WithClock(now func() time.Time) *Detector {
+	d.now = now
+	return d
 }
 
 // Detect detects if an action is part of a loop (uses engine config).
 func (d *Detector) Detect(ctx context.Context, proposal domain.ActionProposal) bool {
-	return d.DetectWithConfig(ctx, proposal, d.config)
+	return d.DetectWithConfig(ctx, proposal, d.config.Load())
 }
 
 // DetectWithConfig detects if an action is part of a loop using the given config.
-// When cfg.Loop is set (e.g. panic overlay), uses that window and repeat count; otherwise default 10min/25 and 60s/10.
+// A matching loop_detection rule sets the window and stop count (or exempts the action);
+// otherwise the default window and max_iterations_per_action apply, plus the safety net.
+// When cfg.Loop is set (panic overlay), stop counts are capped at the panic repeat count.
 func (d *Detector) DetectWithConfig(ctx context.Context, proposal domain.ActionProposal, cfg *config.Config) bool {
 	if cfg == nil {
-		cfg = d.config
+		cfg = d.config.Load()
 	}
 	// Compute action hash if not provided
 	actionHash := proposal.Context.Hash
@@ -43,60 +61,160 @@ func (d *Detector) DetectWithConfig(ctx context.Context, proposal domain.ActionP
 		actionHash = d.computeActionHash(proposal)
 	}
 
-	// When Loop overlay is set (panic mode), use that window and stop count
-	if cfg.Loop != nil && cfg.Loop.WindowSeconds > 0 && cfg.Loop.StopRepeats > 0 {
-		sinceTS := time.Now().Add(-time.Duration(cfg.Loop.WindowSeconds) * time.Second).Unix() * 1000
-		events, err := d.store.ListEvents(ctx, runtime.EventFilter{AgentID: &proposal.AgentID, SinceTS: &sinceTS, Limit: 100})
-		if err != nil {
+	if rule := MatchRule(cfg, proposal); rule != nil {
+		if rule.Exempt {
 			return false
 		}
-		count := 0
-		for _, event := range events {
-			if event.ActionHash == actionHash {
-				count++
-			}
-		}
-		return count >= cfg.Loop.StopRepeats
+		window, stop := ruleLimits(cfg, rule)
+		return d.repeated(ctx, proposal.AgentID, actionHash, window, stop)
 	}
 
-	// Default: check events in last 10 minutes
-	sinceTS := time.Now().Add(-10 * time.Minute).Unix() * 1000
-	events, err := d.store.ListEvents(ctx, runtime.EventFilter{AgentID: &proposal.AgentID, SinceTS: &sinceTS, Limit: 100})
-	if err != nil {
-		return false // Can't check, allow
+	window, stop := defaultLimits(cfg)
+	if d.repeated(ctx, proposal.AgentID, actionHash, window, stop) {
+		return true
 	}
 
-	count := 0
-	for _, event := range events {
-		if event.ActionHash == actionHash {
-			count++
+	if net, ok := safetyNet(cfg); ok {
+		if d.repeated(ctx, proposal.AgentID, actionHash, time.Duration(net.WindowSeconds)*time.Second, net.StopRepeats) {
+			return true
 		}
 	}
 
-	maxIterations := cfg.Agents.Default.MaxIterationsPerAction
-	if maxIterations <= 0 {
-		maxIterations = 25
+	return false
+}
+
+// EffectiveSettings reports the loop settings that DetectWithConfig applies for cfg
+// (e.g. the panic-effective config). Used by GET /v1/capabilities.
+func (d *Detector) EffectiveSettings(cfg *config.Config) domain.LoopSettingsInfo {
+	if cfg == nil {
+		cfg = d.config.Load()
 	}
-	if count >= maxIterations {
+	window, stop := defaultLimits(cfg)
+	out := domain.LoopSettingsInfo{
+		Default: domain.LoopInfo{WindowSeconds: int(window / time.Second), StopRepeats: stop},
+	}
+	if net, ok := safetyNet(cfg); ok {
+		out.SafetyNet = &domain.LoopInfo{WindowSeconds: net.WindowSeconds, StopRepeats: net.StopRepeats}
+	}
+	if cfg == nil {
+		return out
+	}
+	for i := range cfg.LoopDetection.Rules {
+		rule := &cfg.LoopDetection.Rules[i]
+		info := domain.LoopRuleInfo{ActionType: rule.ActionType, Tool: rule.Tool, Exempt: rule.Exempt}
+		if !rule.Exempt {
+			w, s := ruleLimits(cfg, rule)
+			info.WindowSeconds = int(w / time.Second)
+			info.StopRepeats = s
+		}
+		out.Rules = append(out.Rules, info)
+	}
+	return out
+}
+
+// MatchRule returns the first loop_detection rule matching the proposal's action type and tool, or nil.
+func MatchRule(cfg *config.Config, proposal domain.ActionProposal) *config.LoopRule {
+	if cfg == nil {
+		return nil
+	}
+	for i := range cfg.LoopDetection.Rules {
+		rule := &cfg.LoopDetection.Rules[i]
+		if rule.ActionType == "" && rule.Tool == "" {
+			continue
+		}
+		if rule.ActionType != "" && !matchActionType(rule.ActionType, proposal.Action.Type) {
+			continue
+		}
+		if rule.Tool != "" && rule.Tool != proposal.Context.Tool {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+// matchActionType reports whether actionType is pattern or below it in the dotted hierarchy.
+// A pattern ending in "." matches only below itself.
+func matchActionType(pattern, actionType string) bool {
+	if pattern == actionType {
 		return true
 	}
+	if strings.HasSuffix(pattern, ".") {
+		return strings.HasPrefix(actionType, pattern)
+	}
+	return strings.HasPrefix(actionType, pattern+".")
+}
 
-	// Hard-coded safety net: 10 within 60s
-	sinceTS60s := time.Now().Add(-60 * time.Second).Unix() * 1000
-	events60s, err := d.store.ListEvents(ctx, runtime.EventFilter{AgentID: &proposal.AgentID, SinceTS: &sinceTS60s, Limit: 100})
-	if err == nil {
-		count60s := 0
-		for _, event := range events60s {
-			if event.ActionHash == actionHash {
-				count60s++
-			}
+// defaultLimits returns the window and stop count for actions without a rule.
+func defaultLimits(cfg *config.Config) (time.Duration, int) {
+	windowSec := 600
+	stop := 25
+	if cfg != nil {
+		if cfg.LoopDetection.WindowSeconds > 0 {
+			windowSec = cfg.LoopDetection.WindowSeconds
 		}
-		if count60s >= 10 {
-			return true
+		if cfg.Agents.Default.MaxIterationsPerAction > 0 {
+			stop = cfg.Agents.Default.MaxIterationsPerAction
+		}
+		// Panic overlay replaces the default window and repeat count
+		if cfg.Loop != nil && cfg.Loop.WindowSeconds > 0 && cfg.Loop.StopRepeats > 0 {
+			windowSec = cfg.Loop.WindowSeconds
+			stop = cfg.Loop.StopRepeats
 		}
 	}
+	return time.Duration(windowSec) * time.Second, stop
+}
 
-	return false
+// ruleLimits returns the window and stop count for a non-exempt rule; unset fields fall back to defaults.
+func ruleLimits(cfg *config.Config, rule *config.LoopRule) (time.Duration, int) {
+	window, stop := defaultLimits(cfg)
+	if rule.WindowSeconds > 0 {
+		window = time.Duration(rule.WindowSeconds) * time.Second
+	}
+	if rule.StopRepeats > 0 {
+		stop = rule.StopRepeats
+		if cfg.Loop != nil && cfg.Loop.StopRepeats > 0 && cfg.Loop.StopRepeats < stop {
+			stop = cfg.Loop.StopRepeats
+		}
+	}
+	return window, stop
+}
+
+// safetyNet returns the short-window burst check, if enabled. Not applied under the panic overlay,
+// which already uses a short window.
+func safetyNet(cfg *config.Config) (config.LoopSafetyNet, bool) {
+	net := config.LoopSafetyNet{Enabled: true, WindowSeconds: 60, StopRepeats: 10}
+	if cfg != nil {
+		if cfg.Loop != nil && cfg.Loop.WindowSeconds > 0 && cfg.Loop.StopRepeats > 0 {
+			return net, false
+		}
+		net.Enabled = cfg.LoopDetection.SafetyNet.Enabled
+		if cfg.LoopDetection.SafetyNet.WindowSeconds > 0 {
+			net.WindowSeconds = cfg.LoopDetection.SafetyNet.WindowSeconds
+		}
+		if cfg.LoopDetection.SafetyNet.StopRepeats > 0 {
+			net.StopRepeats = cfg.LoopDetection.SafetyNet.StopRepeats
+		}
+	}
+	return net, net.Enabled
+}
+
+// repeated reports whether the agent has at least stop decisions on actionHash inside the window.
+// The hash, type and limit go to the store, so large stop counts and long windows are not cut
+// short by the agent's other traffic.
+func (d *Detector) repeated(ctx context.Context, agentID, actionHash string, window time.Duration, stop int) bool {
+	sinceTS := d.now().Add(-window).UnixMilli()
+	events, err := d.store.ListEvents(ctx, runtime.EventFilter{
+		AgentID:    &agentID,
+		ActionHash: &actionHash,
+		Types:      []string{domain.EventTypeDecisionIssued},
+		SinceTS:    &sinceTS,
+		Limit:      stop,
+	})
+	if err != nil {
+		return false // Can't check, allow
+	}
+	return len(events) >= stop
 }
 
 func (d *Detector) computeActionHash(proposal domain.ActionProposal) string {
@@ -0,0 +1,175 @@
+package loop_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/loop"
+	"github.com/futurematic/kernel/internal/runtime/memory"
+)
+
+var t0 = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func proposal(actionType, tool, hash string) domain.ActionProposal {
+	return domain.ActionProposal{
+		AgentID: "agent-1",
+		Action:  domain.Action{Type: actionType},
+		Context: domain.ActionContext{Tool: tool, Hash: hash},
+	}
+}
+
+var seq int
+
+// record appends n events of type typ for hash, one per second ending at t0.
+func record(t *testing.T, st *memory.Store, typ, hash string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		seq++
+		e := domain.Event{
+			EventID:    fmt.Sprintf("evt:%d", seq),
+			TS:         t0.Add(-time.Duration(n-i) * time.Second),
+			Type:       typ,
+			AgentID:    "agent-1",
+			Severity:   domain.EventSeverityInfo,
+			ActionHash: hash,
+		}
+		if err := st.AppendEvent(context.Background(), &e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newDetector(cfg *config.Config) (*loop.Detector, *memory.Store) {
+	st := memory.New(func() time.Time { return t0 })
+	return loop.NewDetector(st, cfg).WithClock(func() time.Time { return t0 }), st
+}
+
+func TestMatchRule(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.LoopDetection.Rules = []config.LoopRule{
+		{ActionType: "tool.call", Tool: "search", StopRepeats: 50},
+		{ActionType: "filesystem.", StopRepeats: 100},
+		{ActionType: "git", StopRepeats: 3},
+		{ActionType: "network.fetch", Exempt: true},
+		{Tool: "poll"},
+	}
+	tests := []struct {
+		actionType, tool string
+		want             int // index into Rules, -1 for none
+	}{
+		{"tool.call", "search", 0},
+		{"tool.call", "other", -1}, // tool does not match
+		{"filesystem.read", "", 1},
+		{"filesystem", "", -1}, // "filesystem." matches only below the prefix
+		{"git.push", "", 2},    // "git" matches itself and git.*
+		{"git", "", 2},
+		{"gitx", "", -1},
+		{"network.fetch", "", 3},
+		{"network.fetch.extra", "", 3}, // as does a rule with a dot in it
+		{"network.fetcher", "", -1},
+		{"exec.command", "poll", 4},
+	}
+	for _, tt := range tests {
+		got := loop.MatchRule(cfg, proposal(tt.actionType, tt.tool, ""))
+		want := (*config.LoopRule)(nil)
+		if tt.want >= 0 {
+			want = &cfg.LoopDetection.Rules[tt.want]
+		}
+		if got != want {
+			t.Errorf("MatchRule(%s, %s) = %+v, want %+v", tt.actionType, tt.tool, got, want)
+		}
+	}
+}
+
+func TestDetectRules(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Default.MaxIterationsPerAction = 5
+	cfg.LoopDetection.SafetyNet.Enabled = false
+	cfg.LoopDetection.Rules = []config.LoopRule{
+		{ActionType: "filesystem.read", StopRepeats: 120, WindowSeconds: 3600},
+		{ActionType: "network.fetch", Exempt: true},
+	}
+	d, st := newDetector(cfg)
+	ctx := context.Background()
+
+	// Other traffic of the agent must not hide repeats from a large stop count
+	record(t, st, domain.EventTypeDecisionIssued, "other", 150)
+	record(t, st, domain.EventTypeDecisionIssued, "read", 119)
+	if d.Detect(ctx, proposal("filesystem.read", "", "read")) {
+		t.Error("filesystem.read stopped after 119 repeats, want 120")
+	}
+	record(t, st, domain.EventTypeDecisionIssued, "read", 1)
+	if !d.Detect(ctx, proposal("filesystem.read", "", "read")) {
+		t.Error("filesystem.read not stopped after 120 repeats")
+	}
+
+	// Only decisions count as repeats
+	record(t, st, domain.EventTypeDecisionShadowDiverged, "exec", 10)
+	record(t, st, domain.EventTypeDecisionIssued, "exec", 4)
+	if d.Detect(ctx, proposal("exec.command", "", "exec")) {
+		t.Error("non-decision events counted as repeats")
+	}
+	record(t, st, domain.EventTypeDecisionIssued, "exec", 1)
+	if !d.Detect(ctx, proposal("exec.command", "", "exec")) {
+		t.Error("default max_iterations_per_action not applied")
+	}
+
+	record(t, st, domain.EventTypeDecisionIssued, "fetch", 50)
+	if d.Detect(ctx, proposal("network.fetch", "", "fetch")) {
+		t.Error("exempt action stopped")
+	}
+}
+
+func TestDetectRuleCoversSubActions(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Default.MaxIterationsPerAction = 50
+	cfg.LoopDetection.SafetyNet.Enabled = false
+	cfg.LoopDetection.Rules = []config.LoopRule{{ActionType: "git.push", StopRepeats: 2}}
+	d, st := newDetector(cfg)
+	ctx := context.Background()
+
+	// git.push.force is below git.push, so the rule's stop count applies to it
+	record(t, st, domain.EventTypeDecisionIssued, "force", 2)
+	if !d.Detect(ctx, proposal("git.push.force", "", "force")) {
+		t.Error("git.push rule not applied to git.push.force")
+	}
+	// git.pushx is a sibling, not a sub-action, and keeps the default
+	record(t, st, domain.EventTypeDecisionIssued, "pushx", 2)
+	if d.Detect(ctx, proposal("git.pushx", "", "pushx")) {
+		t.Error("git.push rule applied to git.pushx")
+	}
+}
+
+func TestDetectSafetyNetAndPanicCap(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Default.MaxIterationsPerAction = 100
+	cfg.LoopDetection.SafetyNet = config.LoopSafetyNet{Enabled: true, WindowSeconds: 60, StopRepeats: 10}
+	cfg.LoopDetection.Rules = []config.LoopRule{{ActionType: "tool.call", StopRepeats: 30}}
+	d, st := newDetector(cfg)
+	ctx := context.Background()
+
+	// 10 repeats inside the safety net's 60s window trip it below the default count
+	record(t, st, domain.EventTypeDecisionIssued, "burst", 10)
+	if !d.Detect(ctx, proposal("exec.command", "", "burst")) {
+		t.Error("safety net did not stop a burst")
+	}
+	// The safety net does not apply to actions with a rule
+	record(t, st, domain.EventTypeDecisionIssued, "call", 20)
+	if d.Detect(ctx, proposal("tool.call", "", "call")) {
+		t.Error("safety net applied to a rule's action")
+	}
+
+	// The panic overlay caps the rule's stop count
+	panicCfg := *cfg
+	panicCfg.Loop = &config.LoopOverlay{WindowSeconds: 300, StopRepeats: 15}
+	if !d.DetectWithConfig(ctx, proposal("tool.call", "", "call"), &panicCfg) {
+		t.Error("panic overlay did not cap the rule's stop_repeats")
+	}
+	if d.DetectWithConfig(ctx, proposal("exec.command", "", "burst"), &panicCfg) {
+		t.Error("safety net applied under the panic overlay")
+	}
+}
@@ -0,0 +1,213 @@
+// Package merkle implements the RFC 6962 Merkle tree hash with inclusion and consistency proofs.
+//
+// Leaves are hashed as SHA-256(0x00 || data) and interior nodes as SHA-256(0x01 || left || right),
+// so a leaf can never be passed off as a node. Verification follows RFC 9162 section 2.1.
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+)
+
+// LeafHash returns the hash of a leaf with the given data.
+func LeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// NodeHash returns the hash of an interior node.
+func NodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// split returns the largest power of two smaller than n (n >= 2).
+func split(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// Root returns the tree hash of the given leaf hashes (SHA-256 of nothing for an empty tree).
+func Root(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	case 1:
+		return leaves[0]
+	}
+	k := split(len(leaves))
+	return NodeHash(Root(leaves[:k]), Root(leaves[k:]))
+}
+
+// InclusionProof returns the audit path for leaf index in the tree of the given leaf hashes.
+func InclusionProof(leaves [][]byte, index int) ([][]byte, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, errors.New("leaf index out of range")
+	}
+	return inclusion(leaves, index), nil
+}
+
+func inclusion(leaves [][]byte, m int) [][]byte {
+	if len(leaves) <= 1 {
+		return nil
+	}
+	k := split(len(leaves))
+	if m < k {
+		return append(inclusion(leaves[:k], m), Root(leaves[k:]))
+	}
+	return append(inclusion(leaves[k:], m-k), Root(leaves[:k]))
+}
+
+// ConsistencyProof proves that the tree of the first size leaves is a prefix of the tree of all leaves.
+func ConsistencyProof(leaves [][]byte, size int) ([][]byte, error) {
+	if size < 0 || size > len(leaves) {
+		return nil, errors.New("tree size out of range")
+	}
+	if size == 0 || size == len(leaves) {
+		return nil, nil
+	}
+	return subproof(leaves, size, true), nil
+}
+
+func subproof(leaves [][]byte, m int, complete bool) [][]byte {
+	n := len(leaves)
+	if m == n {
+		if complete {
+			return nil
+		}
+		return [][]byte{Root(leaves)}
+	}
+	k := split(n)
+	if m <= k {
+		return append(subproof(leaves[:k], m, complete), Root(leaves[k:]))
+	}
+	return append(subproof(leaves[k:], m-k, false), Root(leaves[:k]))
+}
+
+// Frontier is a tree kept for appending: its size and the roots of its perfect subtrees, largest
+// (leftmost) first. That is enough to append a leaf and prove its inclusion without the earlier
+// leaves.
+type Frontier struct {
+	Size  int64
+	Nodes [][]byte
+}
+
+// Root returns the tree hash.
+func (f *Frontier) Root() []byte {
+	if len(f.Nodes) == 0 {
+		return Root(nil)
+	}
+	r := f.Nodes[len(f.Nodes)-1]
+	for i := len(f.Nodes) - 2; i >= 0; i-- {
+		r = NodeHash(f.Nodes[i], r)
+	}
+	return r
+}
+
+// Append adds a leaf hash and returns its audit path in the new tree.
+func (f *Frontier) Append(leafHash []byte) [][]byte {
+	// The new leaf is the last, so its path is the subtree roots to its left, smallest first
+	proof := make([][]byte, len(f.Nodes))
+	for i, n := range f.Nodes {
+		proof[len(f.Nodes)-1-i] = n
+	}
+	f.Nodes = append(f.Nodes, leafHash)
+	f.Size++
+	for s := f.Size; s&1 == 0; s >>= 1 {
+		n := len(f.Nodes)
+		f.Nodes = append(f.Nodes[:n-2], NodeHash(f.Nodes[n-2], f.Nodes[n-1]))
+	}
+	return proof
+}
+
+// VerifyInclusion checks that leafHash is at index in the tree of size with the given root.
+func VerifyInclusion(leafHash []byte, index, size int64, proof [][]byte, root []byte) error {
+	if index < 0 || index >= size {
+		return errors.New("leaf index out of range")
+	}
+	fn, sn := index, size-1
+	r := leafHash
+	for _, p := range proof {
+		if sn == 0 {
+			return errors.New("inclusion proof too long")
+		}
+		if fn&1 == 1 || fn == sn {
+			r = NodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = NodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return errors.New("inclusion proof too short")
+	}
+	if !bytes.Equal(r, root) {
+		return errors.New("inclusion proof does not match the root")
+	}
+	return nil
+}
+
+// VerifyConsistency checks that the tree (size1, root1) is a prefix of the tree (size2, root2).
+func VerifyConsistency(size1, size2 int64, root1, root2 []byte, proof [][]byte) error {
+	switch {
+	case size1 < 0 || size1 > size2:
+		return errors.New("tree sizes out of order")
+	case size1 == size2:
+		if len(proof) != 0 || !bytes.Equal(root1, root2) {
+			return errors.New("trees of the same size have different roots")
+		}
+		return nil
+	case size1 == 0:
+		return nil
+	case len(proof) == 0:
+		return errors.New("empty consistency proof")
+	}
+	if size1&(size1-1) == 0 {
+		proof = append([][]byte{root1}, proof...)
+	}
+	fn, sn := size1-1, size2-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return errors.New("consistency proof too long")
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = NodeHash(c, fr)
+			sr = NodeHash(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = NodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return errors.New("consistency proof too short")
+	}
+	if !bytes.Equal(fr, root1) || !bytes.Equal(sr, root2) {
+		return errors.New("consistency proof does not match the roots")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package merkle
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+func leaves(n int) [][]byte {
+	out := make([][]byte, n)
+	for i := range out {
+		out[i] = LeafHash([]byte(fmt.Sprintf("leaf %d", i)))
+	}
+	return out
+}
+
+func TestRootKnownValues(t *testing.T) {
+	// RFC 6962 empty tree hash
+	if got := hex.EncodeToString(Root(nil)); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("empty root = %s", got)
+	}
+	l := leaves(3)
+	if got, want := Root(l), NodeHash(NodeHash(l[0], l[1]), l[2]); hex.EncodeToString(got) != hex.EncodeToString(want) {
+		t.Errorf("root of 3 = %x, want %x", got, want)
+	}
+}
+
+func TestInclusion(t *testing.T) {
+	for n := 1; n <= 33; n++ {
+		all := leaves(n)
+		root := Root(all)
+		for i := 0; i < n; i++ {
+			proof, err := InclusionProof(all, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyInclusion(all[i], int64(i), int64(n), proof, root); err != nil {
+				t.Fatalf("n=%d i=%d: %v", n, i, err)
+			}
+			if err := VerifyInclusion(LeafHash([]byte("other")), int64(i), int64(n), proof, root); err == nil {
+				t.Fatalf("n=%d i=%d: wrong leaf verified", n, i)
+			}
+			if n > 1 {
+				if err := VerifyInclusion(all[i], int64((i+1)%n), int64(n), proof, root); err == nil {
+					t.Fatalf("n=%d i=%d: wrong index verified", n, i)
+				}
+			}
+		}
+	}
+}
+
+func TestFrontier(t *testing.T) {
+	all := leaves(33)
+	var f Frontier
+	if !bytes.Equal(f.Root(), Root(nil)) {
+		t.Errorf("empty frontier root = %x", f.Root())
+	}
+	for i, leaf := range all {
+		proof := f.Append(leaf)
+		want, _ := InclusionProof(all[:i+1], i)
+		if fmt.Sprintf("%x", proof) != fmt.Sprintf("%x", want) {
+			t.Fatalf("leaf %d: proof %x, want %x", i, proof, want)
+		}
+		if f.Size != int64(i+1) || !bytes.Equal(f.Root(), Root(all[:i+1])) {
+			t.Fatalf("after leaf %d: size %d, root %x, want %x", i, f.Size, f.Root(), Root(all[:i+1]))
+		}
+	}
+}
+
+func TestConsistency(t *testing.T) {
+	for n := 1; n <= 33; n++ {
+		all := leaves(n)
+		root := Root(all)
+		for m := 1; m <= n; m++ {
+			proof, err := ConsistencyProof(all, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyConsistency(int64(m), int64(n), Root(all[:m]), root, proof); err != nil {
+				t.Fatalf("m=%d n=%d: %v", m, n, err)
+			}
+			if m < n {
+				forked := append(append([][]byte{}, all[:m-1]...), LeafHash([]byte("forged")))
+				if err := VerifyConsistency(int64(m), int64(n), Root(forked), root, proof); err == nil {
+					t.Fatalf("m=%d n=%d: rewritten prefix verified", m, n)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+)
+
+// Default is the registry served on GET /metrics.
+var Default = NewRegistry()
+
+// Ctrl Dot metrics. Label values for agent, action_type and reason_code go through the
+// label toggles in Configure; use the helpers below rather than the vecs directly.
+var (
+	Decisions = Default.NewCounterVec("ctrldot_decisions_total",
+		"Decisions issued by the propose pipeline.", "decision", "reason_code", "agent", "action_type")
+	ProposeDuration = Default.NewHistogramVec("ctrldot_propose_duration_seconds",
+		"End-to-end latency of POST /v1/actions/propose evaluation.", nil)
+	EvaluatorDuration = Default.NewHistogramVec("ctrldot_propose_evaluator_duration_seconds",
+		"Latency of each propose evaluator (rules, loop, limits, shadow).", nil, "evaluator")
+	BudgetSpent = Default.NewGaugeVec("ctrldot_budget_spent_gbp",
+		"Budget spent in the current window (GBP).", "agent", "window")
+	PanicEnabled = Default.NewGaugeVec("ctrldot_panic_enabled",
+		"1 when panic mode is enabled.")
+	HaltedAgents = Default.NewGaugeVec("ctrldot_halted_agents",
+		"Number of halted agents.")
+	LedgerSinkErrors = Default.NewCounterVec("ctrldot_ledger_sink_errors_total",
+		"Ledger sink emit failures.", "sink", "op")
+	LedgerSinkEmitted = Default.NewCounterVec("ctrldot_ledger_sink_emitted_total",
+		"Records emitted by multi sink members.", "sink", "op")
+	LedgerSinkDropped = Default.NewCounterVec("ctrldot_ledger_sink_dropped_total",
+		"Records dropped because a multi sink member's queue was full.", "sink")
+	LedgerSinkQueueDepth = Default.NewGaugeVec("ctrldot_ledger_sink_queue_depth",
+		"Records waiting in a multi sink member's queue.", "sink")
+	LedgerOutboxDepth = Default.NewGaugeVec("ctrldot_ledger_outbox_depth",
+		"Decision records in the runtime store outbox awaiting delivery (pending) or rejected (dead).", "status")
+	ShadowEvaluations = Default.NewCounterVec("ctrldot_shadow_evaluations_total",
+		"Proposals also evaluated under the shadow config.")
+	ShadowDivergences = Default.NewCounterVec("ctrldot_shadow_divergences_total",
+		"Shadow decisions that differ from the live decision.", "live_decision", "shadow_decision", "shadow_rule")
+	AutobundleWrites = Default.NewCounterVec("ctrldot_autobundle_writes_total",
+		"Bundles written by autobundle.", "trigger")
+)
+
+// Evaluator names for EvaluatorDuration.
+const (
+	EvaluatorRules  = "rules"
+	EvaluatorLoop   = "loop"
+	EvaluatorLimits = "limits"
+	EvaluatorShadow = "shadow"
+)
+
+var (
+	labelsMu sync.RWMutex
+	enabled  = true
+	labels   = config.MetricsLabels{Agent: true, ActionType: true, ReasonCode: true}
+)
+
+// Configure applies metrics.enabled and the label toggles from cfg. Call it at startup, before traffic.
+func Configure(cfg config.MetricsConfig) {
+	labelsMu.Lock()
+	enabled = cfg.Enabled
+	labels = cfg.Labels
+	labelsMu.Unlock()
+}
+
+// Enabled reports whether GET /metrics should be served.
+func Enabled() bool {
+	labelsMu.RLock()
+	defer labelsMu.RUnlock()
+	return enabled
+}
+
+// AgentLabel returns agentID, or "" when per-agent labels are disabled.
+func AgentLabel(agentID string) string {
+	labelsMu.RLock()
+	defer labelsMu.RUnlock()
+	if !labels.Agent {
+		return ""
+	}
+	return agentID
+}
+
+// ObserveDecision counts one decision. The first reason code is used; "none" when there is none.
+func ObserveDecision(decision string, reasonCodes []string, agentID, actionType string) {
+	labelsMu.RLock()
+	l := labels
+	labelsMu.RUnlock()
+
+	reasonCode := "none"
+	if len(reasonCodes) > 0 {
+		reasonCode = reasonCodes[0]
+	}
+	if !l.ReasonCode {
+		reasonCode = ""
+	}
+	if !l.Agent {
+		agentID = ""
+	}
+	if !l.ActionType {
+		actionType = ""
+	}
+	Decisions.Inc(decision, reasonCode, agentID, actionType)
+}
+
+// Since returns the seconds elapsed since start, for histogram observations.
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
@@ -0,0 +1,313 @@
+// Package metrics is a small, dependency-free Prometheus instrumentation layer: counters,
+// gauges and histograms with labels, plus a text exposition (format 0.0.4) writer for GET /metrics.
+//
+// Labels whose value is empty are omitted from the output, which is how configurable label
+// cardinality works: when a label is turned off its value is recorded as "" and all series
+// collapse into one.
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the Content-Type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are latency buckets in seconds, from 100µs to 2.5s.
+var DefaultBuckets = []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// Collector refreshes gauges just before a scrape (e.g. from the runtime store).
+type Collector func(ctx context.Context)
+
+// Registry holds metric families and scrape-time collectors.
+type Registry struct {
+	mu         sync.Mutex
+	families   []family
+	collectors []Collector
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+type family interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.families {
+		if existing.name() == f.name() {
+			panic("metrics: duplicate metric " + f.name())
+		}
+	}
+	r.families = append(r.families, f)
+}
+
+// AddCollector registers fn to run before every WriteText.
+func (r *Registry) AddCollector(fn Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, fn)
+}
+
+// WriteText runs collectors and writes every family in registration order.
+func (r *Registry) WriteText(ctx context.Context, w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]Collector(nil), r.collectors...)
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		c(ctx)
+	}
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+// vec is the shared label bookkeeping of counters, gauges and histograms.
+type vec struct {
+	fqName     string
+	help       string
+	kind       string
+	labelNames []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// histogram only
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newVec(fqName, help, kind string, labelNames []string) *vec {
+	return &vec{fqName: fqName, help: help, kind: kind, labelNames: labelNames, series: make(map[string]*series)}
+}
+
+func (v *vec) name() string { return v.fqName }
+
+// get returns the series for labelValues; callers hold v.mu.
+func (v *vec) get(labelValues []string) *series {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.fqName, len(v.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		v.series[key] = s
+	}
+	return s
+}
+
+func (v *vec) sorted() []*series {
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]*series, len(keys))
+	for i, k := range keys {
+		out[i] = v.series[k]
+	}
+	return out
+}
+
+func (v *vec) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.fqName, escapeHelp(v.help), v.fqName, v.kind)
+}
+
+func (v *vec) write(w *bufio.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.writeHeader(w)
+	for _, s := range v.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", v.fqName, formatLabels(v.labelNames, s.labelValues, "", ""), formatFloat(s.value))
+	}
+}
+
+// CounterVec is a monotonically increasing value per label set.
+type CounterVec struct{ *vec }
+
+// NewCounterVec registers a counter family on r.
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{newVec(name, help, "counter", labelNames)}
+	r.register(c)
+	return c
+}
+
+// Add increments the counter for labelValues by delta (negative deltas are ignored).
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.get(labelValues).value += delta
+	c.mu.Unlock()
+}
+
+// Inc increments the counter for labelValues by one.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Value returns the current value for labelValues.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(labelValues).value
+}
+
+// GaugeVec is a value per label set that can go up and down.
+type GaugeVec struct{ *vec }
+
+// NewGaugeVec registers a gauge family on r.
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{newVec(name, help, "gauge", labelNames)}
+	r.register(g)
+	return g
+}
+
+// Set sets the gauge for labelValues.
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value = value
+	g.mu.Unlock()
+}
+
+// Add adds delta to the gauge for labelValues.
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value += delta
+	g.mu.Unlock()
+}
+
+// Value returns the current value for labelValues.
+func (g *GaugeVec) Value(labelValues ...string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.get(labelValues).value
+}
+
+// Reset removes every series (collectors call it before re-populating per-agent gauges).
+func (g *GaugeVec) Reset() {
+	g.mu.Lock()
+	g.series = make(map[string]*series)
+	g.mu.Unlock()
+}
+
+// HistogramVec counts observations into cumulative buckets per label set.
+type HistogramVec struct {
+	*vec
+	buckets []float64
+}
+
+// NewHistogramVec registers a histogram family on r. buckets must be sorted ascending; nil uses DefaultBuckets.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	h := &HistogramVec{vec: newVec(name, help, "histogram", labelNames), buckets: buckets}
+	r.register(h)
+	return h
+}
+
+// Observe records v for labelValues.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(labelValues)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.buckets))
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+// Count returns the number of observations for labelValues.
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.get(labelValues).count
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w)
+	for _, s := range h.sorted() {
+		for i, upper := range h.buckets {
+			var n uint64
+			if s.counts != nil {
+				n = s.counts[i]
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.fqName, formatLabels(h.labelNames, s.labelValues, "le", formatFloat(upper)), n)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.fqName, formatLabels(h.labelNames, s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.fqName, formatLabels(h.labelNames, s.labelValues, "", ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.fqName, formatLabels(h.labelNames, s.labelValues, "", ""), s.count)
+	}
+}
+
+// formatLabels renders {a="x",b="y"}, skipping empty values; extraName/extraValue (e.g. le) is appended when set.
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	var parts []string
+	for i, n := range names {
+		if values[i] == "" {
+			continue
+		}
+		parts = append(parts, n+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extraName != "" {
+		parts = append(parts, extraName+`="`+extraValue+`"`)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
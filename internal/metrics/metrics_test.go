@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/futurematic/kernel/internal/config"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_total", "A counter.", "kind", "agent")
+	h := r.NewHistogramVec("test_seconds", "A histogram.", []float64{0.1, 1}, "stage")
+	g := r.NewGaugeVec("test_gauge", "A gauge.")
+	r.AddCollector(func(context.Context) { g.Set(3) })
+
+	c.Inc("a", "agent-1")
+	c.Add(2, "b", "")
+	c.Inc("q\"uote", "x")
+	h.Observe(0.05, "rules")
+	h.Observe(0.5, "rules")
+
+	var buf bytes.Buffer
+	if err := r.WriteText(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_total A counter.
+# TYPE test_total counter
+test_total{kind="a",agent="agent-1"} 1
+test_total{kind="b"} 2
+test_total{kind="q\"uote",agent="x"} 1
+# HELP test_seconds A histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{stage="rules",le="0.1"} 1
+test_seconds_bucket{stage="rules",le="1"} 2
+test_seconds_bucket{stage="rules",le="+Inf"} 2
+test_seconds_sum{stage="rules"} 0.55
+test_seconds_count{stage="rules"} 2
+# HELP test_gauge A gauge.
+# TYPE test_gauge gauge
+test_gauge 3
+`
+	if buf.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestObserveDecisionLabelToggles(t *testing.T) {
+	defer Configure(config.MetricsConfig{Enabled: true, Labels: config.MetricsLabels{Agent: true, ActionType: true, ReasonCode: true}})
+
+	Configure(config.MetricsConfig{Labels: config.MetricsLabels{ActionType: true, ReasonCode: true}})
+	ObserveDecision("STOP", []string{"LOOP_STOP_THRESHOLD"}, "agent-1", "git.push")
+	ObserveDecision("STOP", []string{"LOOP_STOP_THRESHOLD"}, "agent-2", "git.push")
+	if v := Decisions.Value("STOP", "LOOP_STOP_THRESHOLD", "", "git.push"); v != 2 {
+		t.Errorf("expected agents to collapse into one series, got %v", v)
+	}
+
+	var buf bytes.Buffer
+	Default.WriteText(context.Background(), &buf)
+	if strings.Contains(buf.String(), `agent="agent-1"`) {
+		t.Errorf("agent label should be dropped:\n%s", buf.String())
+	}
+}
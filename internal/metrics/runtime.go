@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/runtime"
+)
+
+// RuntimeCollector refreshes panic, halted-agent, budget and outbox gauges from the runtime store at scrape time.
+func RuntimeCollector(store runtime.RuntimeStore) Collector {
+	return func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		if ps, err := store.GetPanicState(ctx); err == nil {
+			enabled := 0.0
+			if ps != nil && ps.Enabled {
+				enabled = 1
+			}
+			PanicEnabled.Set(enabled)
+		}
+
+		if counts, err := store.CountOutbox(ctx); err == nil {
+			LedgerOutboxDepth.Reset()
+			LedgerOutboxDepth.Set(float64(counts[domain.OutboxStatusPending]), domain.OutboxStatusPending)
+			LedgerOutboxDepth.Set(float64(counts[domain.OutboxStatusDead]), domain.OutboxStatusDead)
+		}
+
+		agents, err := store.ListAgents(ctx)
+		if err != nil {
+			log.Printf("metrics: list agents: %v", err)
+			return
+		}
+		// Same daily window the limits engine uses (local midnight, ms).
+		now := time.Now()
+		windowStartTS := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Unix() * 1000
+
+		halted := 0
+		BudgetSpent.Reset()
+		for _, a := range agents {
+			if h, err := store.IsAgentHalted(ctx, a.AgentID); err == nil && h {
+				halted++
+			}
+			state, err := store.GetLimitsState(ctx, a.AgentID, windowStartTS, "daily")
+			spent := 0.0
+			if err == nil && state != nil {
+				spent = state.BudgetSpentGBP
+			}
+			BudgetSpent.Add(spent, AgentLabel(a.AgentID), "daily")
+		}
+		HaltedAgents.Set(float64(halted))
+	}
+}
@@ -29,6 +29,10 @@ type Engine interface {
 	GetResolution(ctx context.Context, resolutionID string) (*domain.Resolution, error)
 	ListProposalSetsForNode(ctx context.Context, nodeID, namespaceID string, limit int) ([]domain.ProposalSet, error)
 	GetResolutionForProposalSet(ctx context.Context, proposalSetID string) (*domain.Resolution, error)
+
+	// Ctrl Dot decision records (received via POST /v1/ctrldot/decisions)
+	ListCtrlDotDecisions(ctx context.Context, q store.DecisionRecordQuery) ([]domain.CtrlDotDecisionRecord, error)
+	GetCtrlDotDecision(ctx context.Context, id string) (*domain.CtrlDotDecisionRecord, error)
 }
 
 // ExpandRequest contains parameters for expand
@@ -123,3 +127,13 @@ func (e *engine) ListProposalSetsForNode(ctx context.Context, nodeID, namespaceI
 func (e *engine) GetResolutionForProposalSet(ctx context.Context, proposalSetID string) (*domain.Resolution, error) {
 	return e.store.GetResolutionForProposalSet(ctx, proposalSetID)
 }
+
+// ListCtrlDotDecisions implements Engine
+func (e *engine) ListCtrlDotDecisions(ctx context.Context, q store.DecisionRecordQuery) ([]domain.CtrlDotDecisionRecord, error) {
+	return e.store.QueryCtrlDotDecisions(ctx, q)
+}
+
+// GetCtrlDotDecision implements Engine
+func (e *engine) GetCtrlDotDecision(ctx context.Context, id string) (*domain.CtrlDotDecisionRecord, error) {
+	return e.store.GetCtrlDotDecision(ctx, id)
+}
@@ -0,0 +1,329 @@
+// Package replay re-decides the proposals recorded in a bundle under a different config.
+//
+// Proposals are rebuilt from decision_records.jsonl and the decision.issued events in
+// events.jsonl and evaluated, in timestamp order, by fresh rules, loop and limits evaluators
+// backed by an in-memory runtime store. A simulated clock follows the recorded timestamps, so
+// loop windows and the daily budget window see the same history the daemon saw (budget state
+// starts empty at the first replayed proposal).
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/ctrldot"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/ledger/sink"
+	"github.com/futurematic/kernel/internal/ledger/sink/bundle"
+	"github.com/futurematic/kernel/internal/limits"
+	"github.com/futurematic/kernel/internal/loop"
+	"github.com/futurematic/kernel/internal/rules"
+	"github.com/futurematic/kernel/internal/runtime/memory"
+	"github.com/google/uuid"
+)
+
+// Proposal is a recorded proposal and the decision it got.
+type Proposal struct {
+	ID       string // decision event ID (= decision record ID)
+	TS       time.Time
+	Proposal domain.ActionProposal
+	Decision domain.Decision
+	Reason   string
+	// Partial proposals are known only from their decision.issued event: the bundle has no
+	// target, inputs or tool for them, so they keep their recorded rules outcome.
+	Partial bool
+	seq     int64
+}
+
+// panicChange is a panic.enabled or panic.disabled event.
+type panicChange struct {
+	TS    time.Time
+	State domain.PanicState
+	seq   int64
+}
+
+// Input is the timeline read from a bundle.
+type Input struct {
+	Proposals []Proposal
+	panic     []panicChange
+}
+
+// Load reads the proposals and panic toggles recorded in the bundle directory or archive at path.
+func Load(path string) (*Input, error) {
+	if _, err := bundle.ReadManifest(path); err != nil {
+		return nil, err
+	}
+	var records []sink.DecisionRecord
+	if err := readJSONL(path, "decision_records.jsonl", func(line []byte) error {
+		var r sink.DecisionRecord
+		if err := json.Unmarshal(line, &r); err != nil {
+			return err
+		}
+		records = append(records, r)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	events := map[string]domain.Event{}
+	var order []string
+	if err := readJSONL(path, "events.jsonl", func(line []byte) error {
+		var e domain.Event
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		if _, seen := events[e.EventID]; !seen {
+			order = append(order, e.EventID)
+		}
+		events[e.EventID] = e
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	in := &Input{}
+	recorded := map[string]bool{}
+	for _, r := range records {
+		recorded[r.ID] = true
+		p := Proposal{
+			ID:       r.ID,
+			TS:       r.Timestamp,
+			Decision: r.Decision,
+			Reason:   r.Reason,
+			Proposal: domain.ActionProposal{
+				AgentID:   r.AgentID,
+				SessionID: r.SessionID,
+				Action:    domain.Action{Type: r.ActionType, Target: r.ActionTarget, Inputs: r.ActionInputs},
+				Cost:      domain.CostEstimate{Currency: "GBP", EstimatedGBP: r.BudgetSpent},
+				Context:   domain.ActionContext{Tool: r.ActionTool, Hash: r.ActionHash},
+			},
+		}
+		if r.ResolutionTokenPresent {
+			// Rules only check that a token was given; it was validated when it was issued
+			p.Proposal.ResolutionToken = "recorded"
+		}
+		if e, ok := events[r.ID]; ok {
+			p.seq = e.ChainSeq
+			setCost(&p.Proposal, e)
+		}
+		in.Proposals = append(in.Proposals, p)
+	}
+	for _, id := range order {
+		e := events[id]
+		switch e.Type {
+		case domain.EventTypeDecisionIssued:
+			if recorded[e.EventID] {
+				continue
+			}
+			p := Proposal{
+				ID:       e.EventID,
+				TS:       e.TS,
+				Decision: domain.Decision(payloadString(e, "decision")),
+				Reason:   payloadString(e, "reason"),
+				Partial:  true,
+				seq:      e.ChainSeq,
+				Proposal: domain.ActionProposal{
+					AgentID:   e.AgentID,
+					SessionID: e.SessionID,
+					Action:    domain.Action{Type: payloadString(e, "action_type")},
+					Context:   domain.ActionContext{Hash: e.ActionHash},
+				},
+			}
+			setCost(&p.Proposal, e)
+			in.Proposals = append(in.Proposals, p)
+		case domain.EventTypePanicEnabled:
+			st := domain.PanicState{Enabled: true, EnabledAt: e.TS, Reason: payloadString(e, "reason")}
+			if ttl, ok := e.PayloadJSON["ttl_seconds"].(float64); ok && ttl > 0 {
+				st.TTLSeconds = int(ttl)
+				expires := e.TS.Add(time.Duration(ttl) * time.Second)
+				st.ExpiresAt = &expires
+			}
+			in.panic = append(in.panic, panicChange{TS: e.TS, State: st, seq: e.ChainSeq})
+		case domain.EventTypePanicDisabled:
+			in.panic = append(in.panic, panicChange{TS: e.TS, seq: e.ChainSeq})
+		}
+	}
+	// Timestamps have second precision; the chain position orders events within a second
+	sort.SliceStable(in.Proposals, func(i, j int) bool {
+		return before(in.Proposals[i].TS, in.Proposals[i].seq, in.Proposals[j].TS, in.Proposals[j].seq)
+	})
+	sort.SliceStable(in.panic, func(i, j int) bool {
+		return before(in.panic[i].TS, in.panic[i].seq, in.panic[j].TS, in.panic[j].seq)
+	})
+	return in, nil
+}
+
+func before(a time.Time, aSeq int64, b time.Time, bSeq int64) bool {
+	if !a.Equal(b) {
+		return a.Before(b)
+	}
+	return aSeq > 0 && bSeq > 0 && aSeq < bSeq
+}
+
+func setCost(p *domain.ActionProposal, e domain.Event) {
+	if e.CostGBP != nil {
+		p.Cost.EstimatedGBP = *e.CostGBP
+	}
+	if e.CostTokens != nil {
+		p.Cost.EstimatedTokens = *e.CostTokens
+	}
+}
+
+func payloadString(e domain.Event, key string) string {
+	v, _ := e.PayloadJSON[key].(string)
+	return v
+}
+
+// readJSONL calls fn for each line of the bundle file name; a missing file has no lines.
+func readJSONL(path, name string, fn func(line []byte) error) error {
+	data, err := bundle.ReadFile(path, name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		if err := fn(sc.Bytes()); err != nil {
+			return fmt.Errorf("%s line %d: %w", name, n, err)
+		}
+	}
+	return sc.Err()
+}
+
+// Change is a proposal whose decision or reason codes differ under the candidate config.
+type Change struct {
+	ID             string          `json:"id"`
+	TS             time.Time       `json:"ts"`
+	AgentID        string          `json:"agent_id"`
+	SessionID      string          `json:"session_id,omitempty"`
+	ActionType     string          `json:"action_type"`
+	OldDecision    domain.Decision `json:"old_decision"`
+	OldReasonCodes []string        `json:"old_reason_codes,omitempty"`
+	NewDecision    domain.Decision `json:"new_decision"`
+	NewReasonCodes []string        `json:"new_reason_codes,omitempty"`
+	NewReason      string          `json:"new_reason,omitempty"`
+	Partial        bool            `json:"partial,omitempty"`
+}
+
+// Report is the result of a replay.
+type Report struct {
+	Proposals int      `json:"proposals"`
+	Partial   int      `json:"partial"`
+	Changed   []Change `json:"changed"`
+}
+
+// Run replays in under cfg and reports the decisions that change.
+func Run(ctx context.Context, cfg *config.Config, in *Input) (*Report, error) {
+	var now time.Time
+	clock := func() time.Time { return now }
+	store := memory.New(clock)
+	rulesEngine := rules.NewEngine(cfg)
+	loopDetector := loop.NewDetector(store, cfg).WithClock(clock)
+	limitsEngine := limits.NewEngine(store, cfg).WithClock(clock)
+
+	report := &Report{Changed: []Change{}}
+	panicIdx := 0
+	for _, p := range in.Proposals {
+		// The daemon's budget window is in local time
+		now = p.TS.Local()
+		for ; panicIdx < len(in.panic) && !before(p.TS, p.seq, in.panic[panicIdx].TS, in.panic[panicIdx].seq); panicIdx++ {
+			if err := store.SetPanicState(ctx, in.panic[panicIdx].State); err != nil {
+				return nil, err
+			}
+		}
+
+		agent, err := store.GetAgent(ctx, p.Proposal.AgentID)
+		if err != nil {
+			return nil, err
+		}
+		if agent == nil {
+			// Only registered agents get decision records
+			agent = &domain.Agent{AgentID: p.Proposal.AgentID, CreatedAt: now, DefaultMode: domain.AgentModeNormal}
+			if err := store.CreateAgent(ctx, *agent); err != nil {
+				return nil, err
+			}
+		}
+		panicState, err := store.GetPanicState(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if panicState.Enabled && panicState.ExpiresAt != nil && !now.Before(*panicState.ExpiresAt) {
+			panicState.Enabled = false
+		}
+		effective := config.Effective(cfg, panicState)
+
+		ruleDecision, ruleReason := domain.DecisionAllow, ""
+		if !p.Partial {
+			ruleDecision, ruleReason = rulesEngine.EvaluateWithConfig(ctx, p.Proposal, effective)
+		} else if p.Decision == domain.DecisionDeny {
+			ruleDecision, ruleReason = p.Decision, p.Reason
+		}
+		loopStop := loopDetector.DetectWithConfig(ctx, p.Proposal, effective)
+		limitDecision, _, _ := limitsEngine.EvaluateWithConfig(ctx, p.Proposal, agent, effective)
+		decision, reason := ctrldot.CombineDecision(ruleDecision, ruleReason, loopStop, limitDecision)
+
+		// Record the replayed decision as the daemon would, for later loop and budget checks
+		event := domain.Event{
+			EventID:   "evt:" + uuid.New().String(),
+			TS:        now,
+			Type:      domain.EventTypeDecisionIssued,
+			AgentID:   p.Proposal.AgentID,
+			SessionID: p.Proposal.SessionID,
+			Severity:  domain.EventSeverityInfo,
+			PayloadJSON: map[string]interface{}{
+				"decision":    string(decision),
+				"reason":      reason,
+				"action_type": p.Proposal.Action.Type,
+				"action_hash": p.Proposal.Context.Hash,
+			},
+			ActionHash: p.Proposal.Context.Hash,
+			CostGBP:    &p.Proposal.Cost.EstimatedGBP,
+			CostTokens: &p.Proposal.Cost.EstimatedTokens,
+		}
+		if err := store.AppendEvent(ctx, &event); err != nil {
+			return nil, err
+		}
+		if decision == domain.DecisionAllow || decision == domain.DecisionWarn || decision == domain.DecisionThrottle {
+			if err := limitsEngine.Record(ctx, p.Proposal); err != nil {
+				return nil, err
+			}
+		}
+
+		report.Proposals++
+		if p.Partial {
+			report.Partial++
+		}
+		oldCodes := ctrldot.ReasonCodes(p.Decision, p.Reason)
+		newCodes := ctrldot.ReasonCodes(decision, reason)
+		if decision != p.Decision || !slices.Equal(oldCodes, newCodes) {
+			report.Changed = append(report.Changed, Change{
+				ID:             p.ID,
+				TS:             p.TS,
+				AgentID:        p.Proposal.AgentID,
+				SessionID:      p.Proposal.SessionID,
+				ActionType:     p.Proposal.Action.Type,
+				OldDecision:    p.Decision,
+				OldReasonCodes: oldCodes,
+				NewDecision:    decision,
+				NewReasonCodes: newCodes,
+				NewReason:      reason,
+				Partial:        p.Partial,
+			})
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,121 @@
+package replay_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/ctrldot/recommendations"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/ledger/sink"
+	"github.com/futurematic/kernel/internal/ledger/sink/bundle"
+	"github.com/futurematic/kernel/internal/replay"
+)
+
+var t0 = time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+
+// writeBundle writes an unsigned bundle with a decision record (and event) per proposal, plus
+// one DENY known only from its event.
+func writeBundle(t *testing.T) string {
+	t.Helper()
+	var decisions []*sink.DecisionRecord
+	var events []*domain.Event
+	add := func(id string, at time.Duration, actionType string, target map[string]interface{}, hash string, cost float64) {
+		ts := t0.Add(at)
+		decisions = append(decisions, &sink.DecisionRecord{ID: id, AgentID: "agent-1", SessionID: "s1", Timestamp: ts,
+			ActionType: actionType, ActionTarget: target, Decision: domain.DecisionAllow, BudgetSpent: cost, ActionHash: hash})
+		events = append(events, &domain.Event{EventID: id, TS: ts, Type: domain.EventTypeDecisionIssued, AgentID: "agent-1",
+			SessionID: "s1", Severity: domain.EventSeverityInfo, ActionHash: hash, CostGBP: &cost,
+			PayloadJSON: map[string]interface{}{"decision": "ALLOW", "action_type": actionType}})
+	}
+	add("evt:fs", 0, "filesystem.write", map[string]interface{}{"path": "/etc/passwd"}, "", 0.1)
+	add("evt:net", time.Minute, "network.fetch", map[string]interface{}{"domain": "example.com"}, "", 0.1)
+	// Three quick repeats, then three spread beyond the loop window
+	for i, at := range []time.Duration{2 * time.Minute, 2*time.Minute + 5*time.Second, 2*time.Minute + 10*time.Second} {
+		add("evt:loop"+string(rune('a'+i)), at, "tool.call", map[string]interface{}{"name": "grep"}, "h-quick", 0.1)
+	}
+	for i, at := range []time.Duration{5 * time.Minute, 7 * time.Minute, 9 * time.Minute} {
+		add("evt:slow"+string(rune('a'+i)), at, "tool.call", map[string]interface{}{"name": "ls"}, "h-slow", 0.1)
+	}
+	events = append(events, &domain.Event{EventID: "evt:partial", TS: t0.Add(10 * time.Minute), Type: domain.EventTypeDecisionIssued,
+		AgentID: "agent-1", SessionID: "s1", Severity: domain.EventSeverityInfo,
+		PayloadJSON: map[string]interface{}{"decision": "DENY", "reason": "Filesystem access denied by rules", "action_type": "filesystem.delete"}})
+
+	dir, err := bundle.WriteOne(bundle.WriteOneOptions{OutputDir: t.TempDir(), SessionID: "s1", Decisions: decisions, Events: events})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// recordedConfig is the config the bundle's ALLOW decisions were made under.
+func recordedConfig() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Rules.Filesystem.AllowRoots = nil
+	cfg.Rules.Network.DenyAll = false
+	return cfg
+}
+
+func run(t *testing.T, dir string, cfg *config.Config) *replay.Report {
+	t.Helper()
+	in, err := replay.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, err := replay.Run(context.Background(), cfg, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rep
+}
+
+func TestReplaySameConfig(t *testing.T) {
+	rep := run(t, writeBundle(t), recordedConfig())
+	if rep.Proposals != 9 || rep.Partial != 1 {
+		t.Fatalf("replayed %d proposals (%d partial), want 9 (1)", rep.Proposals, rep.Partial)
+	}
+	if len(rep.Changed) != 0 {
+		t.Errorf("changes under the recorded config: %+v", rep.Changed)
+	}
+}
+
+func TestReplayCandidateConfig(t *testing.T) {
+	cfg := recordedConfig()
+	cfg.Rules.Filesystem.AllowRoots = []string{"/work"}
+	cfg.Rules.Network.DenyAll = true
+	cfg.Rules.Network.AllowDomains = []string{"example.com"}
+	cfg.LoopDetection.Rules = []config.LoopRule{{ActionType: "tool.call", WindowSeconds: 60, StopRepeats: 2}}
+
+	rep := run(t, writeBundle(t), cfg)
+	got := map[string]replay.Change{}
+	for _, c := range rep.Changed {
+		got[c.ID] = c
+	}
+	if len(got) != 2 {
+		t.Fatalf("changed = %+v, want evt:fs and evt:loopc", rep.Changed)
+	}
+	if c := got["evt:fs"]; c.NewDecision != domain.DecisionDeny || strings.Join(c.NewReasonCodes, ",") != recommendations.CodeFilesystemDenied || len(c.OldReasonCodes) != 0 {
+		t.Errorf("evt:fs = %+v", c)
+	}
+	// The third quick repeat hits stop_repeats; the slow ones fall outside the simulated window
+	if c := got["evt:loopc"]; c.NewDecision != domain.DecisionStop || strings.Join(c.NewReasonCodes, ",") != recommendations.CodeLoopStopThreshold {
+		t.Errorf("evt:loopc = %+v", c)
+	}
+}
+
+func TestReplayBudget(t *testing.T) {
+	cfg := recordedConfig()
+	cfg.Agents.Default.DailyBudgetGBP = 0.35
+
+	rep := run(t, writeBundle(t), cfg)
+	if len(rep.Changed) == 0 || rep.Changed[0].ID != "evt:loopb" {
+		t.Fatalf("changed = %+v, want the budget to stop from evt:loopb (4th proposal)", rep.Changed)
+	}
+	for _, c := range rep.Changed {
+		if c.NewDecision != domain.DecisionStop && c.NewDecision != domain.DecisionThrottle {
+			t.Errorf("%s: %s, want STOP or THROTTLE", c.ID, c.NewDecision)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync/atomic"
 
 	"github.com/futurematic/kernel/internal/config"
 	"github.com/futurematic/kernel/internal/domain"
@@ -12,25 +13,30 @@ import (
 
 // Engine evaluates domain rules
 type Engine struct {
-	config *config.Config
+	config atomic.Pointer[config.Config]
 }
 
 // NewEngine creates a new rules engine
 func NewEngine(cfg *config.Config) *Engine {
-	return &Engine{
-		config: cfg,
-	}
+	e := &Engine{}
+	e.config.Store(cfg)
+	return e
+}
+
+// SetConfig swaps in a reloaded config for evaluations that do not pass their own.
+func (e *Engine) SetConfig(cfg *config.Config) {
+	e.config.Store(cfg)
 }
 
 // Evaluate evaluates rules and returns decision and reason (uses engine config).
 func (e *Engine) Evaluate(ctx context.Context, proposal domain.ActionProposal) (domain.Decision, string) {
-	return e.EvaluateWithConfig(ctx, proposal, e.config)
+	return e.EvaluateWithConfig(ctx, proposal, e.config.Load())
 }
 
 // EvaluateWithConfig evaluates rules using the given config (e.g. effective config when panic is on).
 func (e *Engine) EvaluateWithConfig(ctx context.Context, proposal domain.ActionProposal, cfg *config.Config) (domain.Decision, string) {
 	if cfg == nil {
-		cfg = e.config
+		cfg = e.config.Load()
 	}
 	actionType := proposal.Action.Type
 
@@ -63,7 +69,7 @@ func (e *Engine) EvaluateWithConfig(ctx context.Context, proposal domain.ActionP
 }
 
 func (e *Engine) checkFilesystemRules(proposal domain.ActionProposal) bool {
-	return e.checkFilesystemRulesWithConfig(proposal, e.config)
+	return e.checkFilesystemRulesWithConfig(proposal, e.config.Load())
 }
 
 func (e *Engine) checkFilesystemRulesWithConfig(proposal domain.ActionProposal, cfg *config.Config) bool {
@@ -86,7 +92,7 @@ func (e *Engine) checkFilesystemRulesWithConfig(proposal domain.ActionProposal,
 }
 
 func (e *Engine) checkNetworkRules(proposal domain.ActionProposal) bool {
-	return e.checkNetworkRulesWithConfig(proposal, e.config)
+	return e.checkNetworkRulesWithConfig(proposal, e.config.Load())
 }
 
 func (e *Engine) checkNetworkRulesWithConfig(proposal domain.ActionProposal, cfg *config.Config) bool {
@@ -0,0 +1,100 @@
+package compactor
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/runtime"
+	"github.com/google/uuid"
+)
+
+// Compactor enforces events.retention_days and events.max_rows on the runtime event log.
+// Pruned events are rolled into hourly per-agent aggregates by the store; each run is
+// recorded as an events.compacted event.
+type Compactor struct {
+	store    runtime.RuntimeStore
+	cfg      atomic.Pointer[config.Config]
+	interval time.Duration
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New creates a compactor. It does nothing until Start is called.
+func New(store runtime.RuntimeStore, cfg *config.Config) *Compactor {
+	interval := time.Hour
+	if cfg != nil && cfg.Events.CompactIntervalSeconds > 0 {
+		interval = time.Duration(cfg.Events.CompactIntervalSeconds) * time.Second
+	}
+	c := &Compactor{
+		store:    store,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+	c.cfg.Store(cfg)
+	return c
+}
+
+// SetConfig swaps in a reloaded config for later runs. The run interval is fixed at New.
+func (c *Compactor) SetConfig(cfg *config.Config) {
+	c.cfg.Store(cfg)
+}
+
+// Start runs a compaction immediately and then every interval, until Stop.
+func (c *Compactor) Start() {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			if _, err := c.RunOnce(context.Background()); err != nil {
+				log.Printf("events compactor: %v", err)
+			}
+			select {
+			case <-c.stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the background loop and waits for an in-flight run to finish.
+func (c *Compactor) Stop() {
+	close(c.stopCh)
+	c.wg.Wait()
+}
+
+// RunOnce compacts the event log once. Returns nil, nil when neither retention_days nor max_rows is set.
+func (c *Compactor) RunOnce(ctx context.Context) (*domain.EventCompaction, error) {
+	cfg := c.cfg.Load()
+	if cfg == nil || (cfg.Events.RetentionDays <= 0 && cfg.Events.MaxRows <= 0) {
+		return nil, nil
+	}
+	var olderThanTS int64
+	if cfg.Events.RetentionDays > 0 {
+		olderThanTS = time.Now().AddDate(0, 0, -cfg.Events.RetentionDays).UnixMilli()
+	}
+	// The store appends the event with the results, in the transaction that moves the chain
+	// anchor, so the anchor is never ahead of the chained event that records it
+	event := domain.Event{
+		EventID:     "evt:" + uuid.New().String(),
+		TS:          time.Now(),
+		Type:        domain.EventTypeEventsCompacted,
+		Severity:    domain.EventSeverityInfo,
+		PayloadJSON: map[string]interface{}{"retention_days": cfg.Events.RetentionDays},
+	}
+	result, err := c.store.CompactEvents(ctx, olderThanTS, cfg.Events.MaxRows, &event)
+	if err != nil {
+		return nil, err
+	}
+	if result.Deleted > 0 {
+		log.Printf("events compactor: pruned %d events (%d rollups updated)", result.Deleted, result.RollupsUpserted)
+	}
+	return result, nil
+}
@@ -0,0 +1,666 @@
+// Package memory is an in-memory runtime.RuntimeStore. It keeps nothing across restarts and is
+// used where a throwaway store is wanted, e.g. `ctrldot replay`, which drives the evaluators
+// with a simulated clock.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/eventchain"
+	"github.com/futurematic/kernel/internal/runtime"
+)
+
+// Store implements runtime.RuntimeStore in memory. Timestamps are kept at second precision,
+// as in the SQL stores, so time filters behave the same way.
+type Store struct {
+	now func() time.Time
+
+	mu         sync.Mutex
+	agents     map[string]domain.Agent
+	halted     map[string]string // agent_id -> reason
+	sessions   map[string]domain.Session
+	limits     map[string]domain.LimitsState
+	events     []domain.Event // append order
+	chain      domain.EventChainHead
+	rollups    map[rollupKey]*domain.EventRollup
+	panicState *domain.PanicState
+	webhooks   map[string]domain.WebhookDelivery
+	outbox     []domain.OutboxRecord
+	outboxSeq  int64
+	revisions  []domain.ConfigRevision // rev order
+}
+
+type rollupKey struct {
+	agentID   string
+	hourStart int64
+	eventType string
+	decision  string
+}
+
+// New returns an empty store. now is the store's clock (session end, halt and compaction
+// times); nil means time.Now.
+func New(now func() time.Time) *Store {
+	if now == nil {
+		now = time.Now
+	}
+	return &Store{
+		now:      now,
+		agents:   map[string]domain.Agent{},
+		halted:   map[string]string{},
+		sessions: map[string]domain.Session{},
+		limits:   map[string]domain.LimitsState{},
+		rollups:  map[rollupKey]*domain.EventRollup{},
+		webhooks: map[string]domain.WebhookDelivery{},
+	}
+}
+
+// Migrate implements runtime.RuntimeStore (nothing to do).
+func (s *Store) Migrate(ctx context.Context) error { return nil }
+
+// Close implements runtime.RuntimeStore.
+func (s *Store) Close() error { return nil }
+
+// CreateAgent implements runtime.RuntimeStore. Existing agents are left unchanged.
+func (s *Store) CreateAgent(ctx context.Context, a domain.Agent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.agents[a.AgentID]; !ok {
+		a.CreatedAt = a.CreatedAt.UTC().Truncate(time.Second)
+		s.agents[a.AgentID] = a
+	}
+	return nil
+}
+
+// ListAgents implements runtime.RuntimeStore.
+func (s *Store) ListAgents(ctx context.Context) ([]domain.Agent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]domain.Agent, 0, len(s.agents))
+	for _, a := range s.agents {
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].AgentID < out[j].AgentID
+	})
+	return out, nil
+}
+
+// GetAgent implements runtime.RuntimeStore.
+func (s *Store) GetAgent(ctx context.Context, id string) (*domain.Agent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.agents[id]
+	if !ok {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+// IsAgentHalted implements runtime.RuntimeStore.
+func (s *Store) IsAgentHalted(ctx context.Context, agentID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.halted[agentID]
+	return ok, nil
+}
+
+// CreateSession implements runtime.RuntimeStore.
+func (s *Store) CreateSession(ctx context.Context, sess domain.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[sess.SessionID]; ok {
+		return fmt.Errorf("create session: %s already exists", sess.SessionID)
+	}
+	s.sessions[sess.SessionID] = sess
+	return nil
+}
+
+// GetSession implements runtime.RuntimeStore.
+func (s *Store) GetSession(ctx context.Context, sessionID string) (*domain.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	return &sess, nil
+}
+
+// EndSession implements runtime.RuntimeStore.
+func (s *Store) EndSession(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.sessions[sessionID]; ok {
+		ended := s.now().UTC().Truncate(time.Second)
+		sess.EndedAt = &ended
+		s.sessions[sessionID] = sess
+	}
+	return nil
+}
+
+func limitsKey(agentID string, windowStart int64, windowType string) string {
+	return fmt.Sprintf("%s|%d|%s", agentID, windowStart, windowType)
+}
+
+// GetLimitsState implements runtime.RuntimeStore (windowStart is unix ms).
+func (s *Store) GetLimitsState(ctx context.Context, agentID string, windowStart int64, windowType string) (*domain.LimitsState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.limits[limitsKey(agentID, windowStart, windowType)]
+	if !ok {
+		return nil, nil
+	}
+	return &st, nil
+}
+
+// UpdateLimitsState implements runtime.RuntimeStore.
+func (s *Store) UpdateLimitsState(ctx context.Context, state domain.LimitsState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits[limitsKey(state.AgentID, state.WindowStart.Unix()*1000, state.WindowType)] = state
+	return nil
+}
+
+// AppendEvent implements runtime.RuntimeStore. The event is linked to the chain head.
+func (s *Store) AppendEvent(ctx context.Context, e *domain.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appendLocked(e)
+}
+
+// appendLocked links e to the chain head and stores it. Caller holds mu.
+func (s *Store) appendLocked(e *domain.Event) error {
+	// Store what the SQL stores would read back: second precision, payload through JSON
+	stored := *e
+	stored.TS = e.TS.UTC().Truncate(time.Second)
+	if e.PayloadJSON != nil {
+		raw, err := json.Marshal(e.PayloadJSON)
+		if err != nil {
+			return fmt.Errorf("append event: %w", err)
+		}
+		stored.PayloadJSON = nil
+		if err := json.Unmarshal(raw, &stored.PayloadJSON); err != nil {
+			return fmt.Errorf("append event: %w", err)
+		}
+	}
+
+	if s.hasEvent(e.EventID) {
+		return fmt.Errorf("append event: %s already exists", e.EventID)
+	}
+	if err := eventchain.Link(e, s.chain.HeadSeq, s.chain.HeadHash); err != nil {
+		return fmt.Errorf("append event: %w", err)
+	}
+	stored.ChainSeq, stored.PrevHash, stored.Hash = e.ChainSeq, e.PrevHash, e.Hash
+	s.events = append(s.events, stored)
+	s.chain.HeadSeq, s.chain.HeadHash = e.ChainSeq, e.Hash
+	return nil
+}
+
+// hasEvent reports whether an event with this ID is stored. Caller holds mu.
+func (s *Store) hasEvent(eventID string) bool {
+	for _, e := range s.events {
+		if e.EventID == eventID {
+			return true
+		}
+	}
+	return false
+}
+
+// EventChainHead implements runtime.RuntimeStore.
+func (s *Store) EventChainHead(ctx context.Context) (*domain.EventChainHead, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := s.chain
+	return &h, nil
+}
+
+// ListChainedEvents implements runtime.RuntimeStore.
+func (s *Store) ListChainedEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []domain.Event
+	for _, e := range s.events {
+		if e.ChainSeq > afterSeq && (limit <= 0 || len(out) < limit) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// ListEvents implements runtime.RuntimeStore.
+func (s *Store) ListEvents(ctx context.Context, filter runtime.EventFilter) ([]domain.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.newestFirst()
+	if filter.OldestFirst {
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+	}
+	var out []domain.Event
+	for _, e := range events {
+		if matches(filter, e) {
+			out = append(out, e)
+			if filter.Limit > 0 && len(out) == filter.Limit {
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// newestFirst returns the events ordered by (ts second, chain_seq, event_id) descending, as
+// the SQL stores order them. Caller holds mu.
+func (s *Store) newestFirst() []domain.Event {
+	out := append([]domain.Event(nil), s.events...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return compareEvent(out[i], out[j].TS.Unix(), out[j].ChainSeq, out[j].EventID) > 0
+	})
+	return out
+}
+
+// compareEvent orders e against the position (sec, seq, eventID): -1 before, 0 equal, 1 after.
+func compareEvent(e domain.Event, sec, seq int64, eventID string) int {
+	if c := cmp.Compare(e.TS.Unix(), sec); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(e.ChainSeq, seq); c != 0 {
+		return c
+	}
+	return strings.Compare(e.EventID, eventID)
+}
+
+// matches applies filter with the SQL stores' second-precision time semantics: since_ts
+// includes the whole second it falls in.
+func matches(filter runtime.EventFilter, e domain.Event) bool {
+	fields := filter
+	fields.SinceTS, fields.UntilTS = nil, nil
+	if !fields.Matches(e) {
+		return false
+	}
+	sec := e.TS.Unix()
+	if filter.SinceTS != nil && sec < *filter.SinceTS/1000 {
+		return false
+	}
+	if filter.UntilTS != nil && sec*1000 >= *filter.UntilTS {
+		return false
+	}
+	if c := filter.Cursor; c != nil {
+		pos := compareEvent(e, c.TS.Unix(), c.Seq, c.EventID)
+		if (filter.OldestFirst && pos <= 0) || (!filter.OldestFirst && pos >= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetEvent implements runtime.RuntimeStore.
+func (s *Store) GetEvent(ctx context.Context, eventID string) (*domain.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.events {
+		if e.EventID == eventID {
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
+
+// CompactEvents implements runtime.RuntimeStore.
+func (s *Store) CompactEvents(ctx context.Context, olderThanTS int64, maxRows int, record *domain.Event) (*domain.EventCompaction, error) {
+	start := s.now()
+	out := &domain.EventCompaction{MaxRows: maxRows}
+	if olderThanTS > 0 {
+		cutoff := time.UnixMilli(olderThanTS)
+		out.Cutoff = &cutoff
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Fail before pruning anything if record cannot be appended
+	if record != nil && s.hasEvent(record.EventID) {
+		return nil, fmt.Errorf("compact events: record run: append event: %s already exists", record.EventID)
+	}
+	if olderThanTS <= 0 && maxRows <= 0 {
+		if record != nil {
+			out.Record(record)
+			return out, s.appendLocked(record)
+		}
+		return out, nil
+	}
+	prune := map[string]bool{}
+	for i, e := range s.newestFirst() {
+		if (olderThanTS > 0 && e.TS.Unix()*1000 < olderThanTS) || (maxRows > 0 && i >= maxRows) {
+			prune[e.EventID] = true
+		}
+	}
+	// The hash chain may only lose a prefix (see the SQL stores)
+	out.AnchorSeq, out.AnchorHash = s.chain.AnchorSeq, s.chain.AnchorHash
+	var anchor *domain.Event
+	for i := range s.events {
+		if prune[s.events[i].EventID] && s.events[i].ChainSeq > 0 && (anchor == nil || s.events[i].ChainSeq > anchor.ChainSeq) {
+			anchor = &s.events[i]
+		}
+	}
+	if anchor != nil {
+		out.AnchorSeq, out.AnchorHash = anchor.ChainSeq, anchor.Hash
+		s.chain.AnchorSeq, s.chain.AnchorHash = anchor.ChainSeq, anchor.Hash
+	}
+
+	kept := s.events[:0:0]
+	upserted := map[rollupKey]bool{}
+	for _, e := range s.events {
+		if !prune[e.EventID] && (anchor == nil || e.ChainSeq == 0 || e.ChainSeq > out.AnchorSeq) {
+			kept = append(kept, e)
+			continue
+		}
+		decision, _ := e.PayloadJSON["decision"].(string)
+		key := rollupKey{agentID: e.AgentID, hourStart: e.TS.Unix() / 3600 * 3600 * 1000, eventType: e.Type, decision: decision}
+		r := s.rollups[key]
+		if r == nil {
+			r = &domain.EventRollup{AgentID: key.agentID, HourStart: time.UnixMilli(key.hourStart).UTC(), EventType: key.eventType, Decision: key.decision}
+			s.rollups[key] = r
+		}
+		r.EventCount++
+		if e.CostGBP != nil {
+			r.CostGBP += *e.CostGBP
+		}
+		if e.CostTokens != nil {
+			r.CostTokens += *e.CostTokens
+		}
+		upserted[key] = true
+		out.Deleted++
+	}
+	s.events = kept
+	out.RollupsUpserted = int64(len(upserted))
+	out.DurationMs = s.now().Sub(start).Milliseconds()
+	if record != nil {
+		out.Record(record)
+		if err := s.appendLocked(record); err != nil {
+			return nil, fmt.Errorf("compact events: record run: %w", err)
+		}
+	}
+	return out, nil
+}
+
+// ListEventRollups implements runtime.RuntimeStore.
+func (s *Store) ListEventRollups(ctx context.Context, filter runtime.EventFilter) ([]domain.EventRollup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []domain.EventRollup
+	for _, r := range s.rollups {
+		if filter.AgentID != nil && r.AgentID != *filter.AgentID {
+			continue
+		}
+		if filter.SinceTS != nil && r.HourStart.UnixMilli() < *filter.SinceTS {
+			continue
+		}
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if !a.HourStart.Equal(b.HourStart) {
+			return a.HourStart.After(b.HourStart)
+		}
+		if a.AgentID != b.AgentID {
+			return a.AgentID < b.AgentID
+		}
+		if a.EventType != b.EventType {
+			return a.EventType < b.EventType
+		}
+		return a.Decision < b.Decision
+	})
+	if filter.Limit > 0 && len(out) > filter.Limit {
+		out = out[:filter.Limit]
+	}
+	return out, nil
+}
+
+// EventStats implements runtime.RuntimeStore.
+func (s *Store) EventStats(ctx context.Context) (*domain.EventStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := &domain.EventStats{EventCount: int64(len(s.events)), RollupCount: int64(len(s.rollups))}
+	for _, e := range s.newestFirst() {
+		if st.LastCompaction == nil && e.Type == domain.EventTypeEventsCompacted {
+			last := e
+			st.LastCompaction = &last
+		}
+		oldest := e.TS
+		st.OldestEventAt = &oldest
+	}
+	return st, nil
+}
+
+// HaltAgent implements runtime.RuntimeStore.
+func (s *Store) HaltAgent(ctx context.Context, agentID string, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.halted[agentID] = reason
+	return nil
+}
+
+// ResumeAgent implements runtime.RuntimeStore.
+func (s *Store) ResumeAgent(ctx context.Context, agentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.halted, agentID)
+	return nil
+}
+
+// GetPanicState implements runtime.RuntimeStore.
+func (s *Store) GetPanicState(ctx context.Context) (*domain.PanicState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.panicState == nil {
+		return &domain.PanicState{Enabled: false}, nil
+	}
+	st := *s.panicState
+	return &st, nil
+}
+
+// SetPanicState implements runtime.RuntimeStore.
+func (s *Store) SetPanicState(ctx context.Context, state domain.PanicState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.panicState = &state
+	return nil
+}
+
+// EnqueueWebhookDelivery implements runtime.RuntimeStore.
+func (s *Store) EnqueueWebhookDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.webhooks[d.DeliveryID]; ok {
+		return fmt.Errorf("enqueue webhook delivery: %s already exists", d.DeliveryID)
+	}
+	s.webhooks[d.DeliveryID] = d
+	return nil
+}
+
+// ListWebhookDeliveries implements runtime.RuntimeStore.
+func (s *Store) ListWebhookDeliveries(ctx context.Context, filter runtime.WebhookFilter) ([]domain.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []domain.WebhookDelivery
+	for _, d := range s.webhooks {
+		if filter.Status != "" && d.Status != filter.Status {
+			continue
+		}
+		if filter.DueBefore != nil && d.NextAttemptAt.After(*filter.DueBefore) {
+			continue
+		}
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].NextAttemptAt.Equal(out[j].NextAttemptAt) {
+			return out[i].NextAttemptAt.Before(out[j].NextAttemptAt)
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(out) > filter.Limit {
+		out = out[:filter.Limit]
+	}
+	return out, nil
+}
+
+// GetWebhookDelivery implements runtime.RuntimeStore.
+func (s *Store) GetWebhookDelivery(ctx context.Context, deliveryID string) (*domain.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.webhooks[deliveryID]
+	if !ok {
+		return nil, nil
+	}
+	return &d, nil
+}
+
+// UpdateWebhookDelivery implements runtime.RuntimeStore.
+func (s *Store) UpdateWebhookDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.webhooks[d.DeliveryID]
+	if !ok {
+		return nil
+	}
+	cur.Status, cur.Attempts, cur.NextAttemptAt = d.Status, d.Attempts, d.NextAttemptAt
+	cur.LastError, cur.LastStatusCode, cur.UpdatedAt = d.LastError, d.LastStatusCode, d.UpdatedAt
+	s.webhooks[d.DeliveryID] = cur
+	return nil
+}
+
+// DeleteWebhookDelivery implements runtime.RuntimeStore.
+func (s *Store) DeleteWebhookDelivery(ctx context.Context, deliveryID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.webhooks, deliveryID)
+	return nil
+}
+
+// CountWebhookDeliveries implements runtime.RuntimeStore.
+func (s *Store) CountWebhookDeliveries(ctx context.Context) (map[string]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int64)
+	for _, d := range s.webhooks {
+		out[d.Status]++
+	}
+	return out, nil
+}
+
+// EnqueueOutbox implements runtime.RuntimeStore. A record already queued for the sink is ignored.
+func (s *Store) EnqueueOutbox(ctx context.Context, r domain.OutboxRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.outbox {
+		if existing.Sink == r.Sink && existing.RecordID == r.RecordID {
+			return nil
+		}
+	}
+	s.outboxSeq++
+	r.Seq = s.outboxSeq
+	s.outbox = append(s.outbox, r)
+	return nil
+}
+
+// ListOutbox implements runtime.RuntimeStore.
+func (s *Store) ListOutbox(ctx context.Context, filter runtime.OutboxFilter) ([]domain.OutboxRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []domain.OutboxRecord
+	for _, r := range s.outbox {
+		if filter.Sink != "" && r.Sink != filter.Sink {
+			continue
+		}
+		if filter.Status != "" && r.Status != filter.Status {
+			continue
+		}
+		out = append(out, r)
+		if filter.Limit > 0 && len(out) == filter.Limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+// UpdateOutbox implements runtime.RuntimeStore.
+func (s *Store) UpdateOutbox(ctx context.Context, r domain.OutboxRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.outbox {
+		if cur := &s.outbox[i]; cur.Seq == r.Seq {
+			cur.Status, cur.Attempts, cur.NextAttemptAt = r.Status, r.Attempts, r.NextAttemptAt
+			cur.LastError, cur.UpdatedAt = r.LastError, r.UpdatedAt
+		}
+	}
+	return nil
+}
+
+// DeleteOutbox implements runtime.RuntimeStore.
+func (s *Store) DeleteOutbox(ctx context.Context, seq int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, r := range s.outbox {
+		if r.Seq == seq {
+			s.outbox = append(s.outbox[:i], s.outbox[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// CountOutbox implements runtime.RuntimeStore.
+func (s *Store) CountOutbox(ctx context.Context) (map[string]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int64)
+	for _, r := range s.outbox {
+		out[r.Status]++
+	}
+	return out, nil
+}
+
+// AppendConfigRevision implements runtime.RuntimeStore.
+func (s *Store) AppendConfigRevision(ctx context.Context, r *domain.ConfigRevision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r.Rev = int64(len(s.revisions)) + 1
+	r.CreatedAt = r.CreatedAt.Truncate(time.Second)
+	s.revisions = append(s.revisions, *r)
+	return nil
+}
+
+// ListConfigRevisions implements runtime.RuntimeStore.
+func (s *Store) ListConfigRevisions(ctx context.Context, limit int) ([]domain.ConfigRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []domain.ConfigRevision
+	for i := len(s.revisions) - 1; i >= 0 && (limit <= 0 || len(out) < limit); i-- {
+		out = append(out, s.revisions[i])
+	}
+	return out, nil
+}
+
+// GetConfigRevision implements runtime.RuntimeStore.
+func (s *Store) GetConfigRevision(ctx context.Context, rev int64) (*domain.ConfigRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rev < 1 || rev > int64(len(s.revisions)) {
+		return nil, nil
+	}
+	r := s.revisions[rev-1]
+	return &r, nil
+}
+
+var _ runtime.RuntimeStore = (*Store)(nil)
@@ -0,0 +1,73 @@
+package memory_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/eventchain"
+	"github.com/futurematic/kernel/internal/runtime"
+	"github.com/futurematic/kernel/internal/runtime/compactor"
+	"github.com/futurematic/kernel/internal/runtime/memory"
+)
+
+func TestEventsChainAndCompaction(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	st := memory.New(nil)
+	for i, ts := range []time.Time{now.AddDate(0, 0, -10), now.AddDate(0, 0, -9), now.AddDate(0, 0, -8), now.Add(-time.Hour), now} {
+		e := domain.Event{EventID: fmt.Sprintf("evt:%d", i), TS: ts, Type: domain.EventTypeDecisionIssued,
+			AgentID: "agent-1", Severity: domain.EventSeverityInfo, ActionHash: "h", PayloadJSON: map[string]interface{}{"decision": "ALLOW"}}
+		if err := st.AppendEvent(ctx, &e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	since := now.Add(-2 * time.Hour).UnixMilli()
+	list, err := st.ListEvents(ctx, runtime.EventFilter{SinceTS: &since})
+	if err != nil || len(list) != 2 || list[0].EventID != "evt:4" {
+		t.Fatalf("ListEvents since = %+v, %v; want evt:4, evt:3", list, err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Events.RetentionDays = 7
+	res, err := compactor.New(st, cfg).RunOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Deleted != 3 || res.AnchorSeq != 3 {
+		t.Fatalf("compaction = %+v, want 3 deleted and anchor 3", res)
+	}
+	rollups, _ := st.ListEventRollups(ctx, runtime.EventFilter{})
+	if len(rollups) != 3 || rollups[0].Decision != "ALLOW" {
+		t.Errorf("rollups = %+v", rollups)
+	}
+	if v, err := eventchain.Verify(ctx, st); err != nil || !v.OK || v.Checked != 3 {
+		t.Errorf("Verify after compaction = %+v, %v", v, err)
+	}
+}
+
+func TestCompactionRollsBackWhenItsRecordFails(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New(nil)
+	for i, ts := range []time.Time{time.Now().AddDate(0, 0, -10), time.Now()} {
+		e := domain.Event{EventID: fmt.Sprintf("evt:%d", i), TS: ts, Type: domain.EventTypeDecisionIssued, Severity: domain.EventSeverityInfo}
+		if err := st.AppendEvent(ctx, &e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	record := domain.Event{EventID: "evt:1", TS: time.Now(), Type: domain.EventTypeEventsCompacted, Severity: domain.EventSeverityInfo}
+	if _, err := st.CompactEvents(ctx, time.Now().AddDate(0, 0, -7).UnixMilli(), 0, &record); err == nil {
+		t.Fatal("CompactEvents succeeded with a record that cannot be appended")
+	}
+	if head, _ := st.EventChainHead(ctx); head.AnchorSeq != 0 {
+		t.Errorf("anchor moved to %d by a failed compaction", head.AnchorSeq)
+	}
+	if v, err := eventchain.Verify(ctx, st); err != nil || !v.OK || v.Checked != 2 {
+		t.Errorf("Verify after failed compaction = %+v, %v", v, err)
+	}
+}
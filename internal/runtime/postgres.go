@@ -75,12 +75,30 @@ func (s *PostgresStore) UpdateLimitsState(ctx context.Context, state domain.Limi
 
 // AppendEvent delegates to store.AppendEvent (runtime-only; op_seq NULL).
 func (s *PostgresStore) AppendEvent(ctx context.Context, e *domain.Event) error {
-	return s.st.AppendEvent(ctx, *e)
+	return s.st.AppendEvent(ctx, e)
 }
 
-// ListEvents delegates to store.GetEvents.
+// ListEvents delegates to store.QueryEvents.
 func (s *PostgresStore) ListEvents(ctx context.Context, filter EventFilter) ([]domain.Event, error) {
-	return s.st.GetEvents(ctx, filter.AgentID, filter.SinceTS, filter.Limit)
+	q := store.EventQuery{
+		AgentID:     filter.AgentID,
+		SessionID:   filter.SessionID,
+		Types:       filter.Types,
+		Severities:  filter.Severities,
+		Decision:    filter.Decision,
+		ActionType:  filter.ActionType,
+		ActionHash:  filter.ActionHash,
+		SinceTS:     filter.SinceTS,
+		UntilTS:     filter.UntilTS,
+		Limit:       filter.Limit,
+		OldestFirst: filter.OldestFirst,
+	}
+	if filter.Cursor != nil {
+		q.CursorTS = &filter.Cursor.TS
+		q.CursorSeq = filter.Cursor.Seq
+		q.CursorEventID = filter.Cursor.EventID
+	}
+	return s.st.QueryEvents(ctx, q)
 }
 
 // GetEvent delegates to store.GetEvent.
@@ -88,6 +106,31 @@ func (s *PostgresStore) GetEvent(ctx context.Context, eventID string) (*domain.E
 	return s.st.GetEvent(ctx, eventID)
 }
 
+// EventChainHead delegates to store.EventChainHead.
+func (s *PostgresStore) EventChainHead(ctx context.Context) (*domain.EventChainHead, error) {
+	return s.st.EventChainHead(ctx)
+}
+
+// ListChainedEvents delegates to store.ListChainedEvents.
+func (s *PostgresStore) ListChainedEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.Event, error) {
+	return s.st.ListChainedEvents(ctx, afterSeq, limit)
+}
+
+// CompactEvents delegates to store.CompactEvents.
+func (s *PostgresStore) CompactEvents(ctx context.Context, olderThanTS int64, maxRows int, record *domain.Event) (*domain.EventCompaction, error) {
+	return s.st.CompactEvents(ctx, olderThanTS, maxRows, record)
+}
+
+// ListEventRollups delegates to store.GetEventRollups.
+func (s *PostgresStore) ListEventRollups(ctx context.Context, filter EventFilter) ([]domain.EventRollup, error) {
+	return s.st.GetEventRollups(ctx, filter.AgentID, filter.SinceTS, filter.Limit)
+}
+
+// EventStats delegates to store.EventStats.
+func (s *PostgresStore) EventStats(ctx context.Context) (*domain.EventStats, error) {
+	return s.st.EventStats(ctx)
+}
+
 // HaltAgent delegates to store.HaltAgent.
 func (s *PostgresStore) HaltAgent(ctx context.Context, agentID string, reason string) error {
 	return s.st.HaltAgent(ctx, agentID, reason)
@@ -110,3 +153,73 @@ func (s *PostgresStore) SetPanicState(ctx context.Context, state domain.PanicSta
 
 // Ensure PostgresStore implements RuntimeStore.
 var _ RuntimeStore = (*PostgresStore)(nil)
+
+// EnqueueWebhookDelivery delegates to store.EnqueueWebhookDelivery.
+func (s *PostgresStore) EnqueueWebhookDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	return s.st.EnqueueWebhookDelivery(ctx, d)
+}
+
+// ListWebhookDeliveries delegates to store.ListWebhookDeliveries.
+func (s *PostgresStore) ListWebhookDeliveries(ctx context.Context, filter WebhookFilter) ([]domain.WebhookDelivery, error) {
+	return s.st.ListWebhookDeliveries(ctx, filter.Status, filter.DueBefore, filter.Limit)
+}
+
+// GetWebhookDelivery delegates to store.GetWebhookDelivery.
+func (s *PostgresStore) GetWebhookDelivery(ctx context.Context, deliveryID string) (*domain.WebhookDelivery, error) {
+	return s.st.GetWebhookDelivery(ctx, deliveryID)
+}
+
+// UpdateWebhookDelivery delegates to store.UpdateWebhookDelivery.
+func (s *PostgresStore) UpdateWebhookDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	return s.st.UpdateWebhookDelivery(ctx, d)
+}
+
+// DeleteWebhookDelivery delegates to store.DeleteWebhookDelivery.
+func (s *PostgresStore) DeleteWebhookDelivery(ctx context.Context, deliveryID string) error {
+	return s.st.DeleteWebhookDelivery(ctx, deliveryID)
+}
+
+// CountWebhookDeliveries delegates to store.CountWebhookDeliveries.
+func (s *PostgresStore) CountWebhookDeliveries(ctx context.Context) (map[string]int64, error) {
+	return s.st.CountWebhookDeliveries(ctx)
+}
+
+// EnqueueOutbox delegates to store.EnqueueOutbox.
+func (s *PostgresStore) EnqueueOutbox(ctx context.Context, r domain.OutboxRecord) error {
+	return s.st.EnqueueOutbox(ctx, r)
+}
+
+// ListOutbox delegates to store.ListOutbox.
+func (s *PostgresStore) ListOutbox(ctx context.Context, filter OutboxFilter) ([]domain.OutboxRecord, error) {
+	return s.st.ListOutbox(ctx, filter.Sink, filter.Status, filter.Limit)
+}
+
+// UpdateOutbox delegates to store.UpdateOutbox.
+func (s *PostgresStore) UpdateOutbox(ctx context.Context, r domain.OutboxRecord) error {
+	return s.st.UpdateOutbox(ctx, r)
+}
+
+// DeleteOutbox delegates to store.DeleteOutbox.
+func (s *PostgresStore) DeleteOutbox(ctx context.Context, seq int64) error {
+	return s.st.DeleteOutbox(ctx, seq)
+}
+
+// CountOutbox delegates to store.CountOutbox.
+func (s *PostgresStore) CountOutbox(ctx context.Context) (map[string]int64, error) {
+	return s.st.CountOutbox(ctx)
+}
+
+// AppendConfigRevision delegates to store.AppendConfigRevision.
+func (s *PostgresStore) AppendConfigRevision(ctx context.Context, r *domain.ConfigRevision) error {
+	return s.st.AppendConfigRevision(ctx, r)
+}
+
+// ListConfigRevisions delegates to store.ListConfigRevisions.
+func (s *PostgresStore) ListConfigRevisions(ctx context.Context, limit int) ([]domain.ConfigRevision, error) {
+	return s.st.ListConfigRevisions(ctx, limit)
+}
+
+// GetConfigRevision delegates to store.GetConfigRevision.
+func (s *PostgresStore) GetConfigRevision(ctx context.Context, rev int64) (*domain.ConfigRevision, error) {
+	return s.st.GetConfigRevision(ctx, rev)
+}
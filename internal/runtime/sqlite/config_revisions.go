@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+const configRevisionColumns = `rev, content_hash, author, trigger, rollback_of, changes, content, created_at`
+
+// AppendConfigRevision implements runtime.RuntimeStore.
+func (s *Store) AppendConfigRevision(ctx context.Context, r *domain.ConfigRevision) error {
+	changes, err := json.Marshal(r.Changes)
+	if err != nil {
+		return fmt.Errorf("append config revision: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO ctrldot_config_revisions (content_hash, author, trigger, rollback_of, changes, content, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		r.ContentHash, r.Author, r.Trigger, r.RollbackOf, string(changes), r.Content, r.CreatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("append config revision: %w", err)
+	}
+	r.Rev, err = res.LastInsertId()
+	return err
+}
+
+// ListConfigRevisions implements runtime.RuntimeStore.
+func (s *Store) ListConfigRevisions(ctx context.Context, limit int) ([]domain.ConfigRevision, error) {
+	query := `SELECT ` + configRevisionColumns + ` FROM ctrldot_config_revisions ORDER BY rev DESC`
+	args := []interface{}{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list config revisions: %w", err)
+	}
+	defer rows.Close()
+	var out []domain.ConfigRevision
+	for rows.Next() {
+		r, err := scanConfigRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+// GetConfigRevision implements runtime.RuntimeStore.
+func (s *Store) GetConfigRevision(ctx context.Context, rev int64) (*domain.ConfigRevision, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+configRevisionColumns+` FROM ctrldot_config_revisions WHERE rev = ?`, rev)
+	r, err := scanConfigRevision(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+func scanConfigRevision(row rowScanner) (*domain.ConfigRevision, error) {
+	var r domain.ConfigRevision
+	var changes string
+	var createdAt int64
+	if err := row.Scan(&r.Rev, &r.ContentHash, &r.Author, &r.Trigger, &r.RollbackOf, &changes, &r.Content, &createdAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(changes), &r.Changes); err != nil {
+		return nil, fmt.Errorf("config revision %d: changes: %w", r.Rev, err)
+	}
+	r.CreatedAt = time.UnixMilli(createdAt).UTC()
+	return &r, nil
+}
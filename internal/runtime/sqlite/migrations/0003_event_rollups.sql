@@ -0,0 +1,12 @@
+-- Hourly per-agent aggregates of compacted events (events.retention_days / events.max_rows)
+CREATE TABLE IF NOT EXISTS ctrldot_event_rollups (
+  agent_id TEXT NOT NULL DEFAULT '',
+  hour_start INTEGER NOT NULL, -- unix ms, truncated to the hour
+  event_type TEXT NOT NULL,
+  decision TEXT NOT NULL DEFAULT '',
+  event_count INTEGER NOT NULL DEFAULT 0,
+  cost_gbp REAL NOT NULL DEFAULT 0,
+  cost_tokens INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (agent_id, hour_start, event_type, decision)
+);
+CREATE INDEX IF NOT EXISTS idx_ctrldot_event_rollups_hour ON ctrldot_event_rollups(hour_start);
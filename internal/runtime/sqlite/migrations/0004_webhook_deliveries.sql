@@ -0,0 +1,16 @@
+-- Outbound webhook delivery queue (pending retries and dead letters)
+CREATE TABLE IF NOT EXISTS ctrldot_webhook_deliveries (
+  delivery_id TEXT PRIMARY KEY,
+  endpoint TEXT NOT NULL,
+  event_id TEXT NOT NULL,
+  event_type TEXT NOT NULL,
+  body TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at INTEGER NOT NULL, -- unix ms
+  last_error TEXT NOT NULL DEFAULT '',
+  last_status_code INTEGER NOT NULL DEFAULT 0,
+  created_at INTEGER NOT NULL, -- unix ms
+  updated_at INTEGER NOT NULL  -- unix ms
+);
+CREATE INDEX IF NOT EXISTS idx_ctrldot_webhook_deliveries_due ON ctrldot_webhook_deliveries(status, next_attempt_at);
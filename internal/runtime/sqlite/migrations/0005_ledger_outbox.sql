@@ -0,0 +1,15 @@
+-- Ledger sink outbox: records not yet delivered (e.g. to Kernel), delivered in seq order
+CREATE TABLE IF NOT EXISTS ctrldot_ledger_outbox (
+  seq INTEGER PRIMARY KEY AUTOINCREMENT,
+  sink TEXT NOT NULL,
+  record_id TEXT NOT NULL,
+  payload TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at INTEGER NOT NULL, -- unix ms
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at INTEGER NOT NULL, -- unix ms
+  updated_at INTEGER NOT NULL, -- unix ms
+  UNIQUE (sink, record_id)
+);
+CREATE INDEX IF NOT EXISTS idx_ctrldot_ledger_outbox_sink ON ctrldot_ledger_outbox(sink, status, seq);
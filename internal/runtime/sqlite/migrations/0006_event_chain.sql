@@ -0,0 +1,11 @@
+-- Event hash chain: ctrldot_events.chain_seq/prev_hash/hash are added by Store.Migrate (addColumns).
+-- The single row holds the chain head and the anchor left by compaction (last pruned event).
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ctrldot_events_chain_seq ON ctrldot_events(chain_seq);
+CREATE TABLE IF NOT EXISTS ctrldot_event_chain (
+  id INTEGER PRIMARY KEY CHECK (id = 1),
+  head_seq INTEGER NOT NULL DEFAULT 0,
+  head_hash TEXT NOT NULL DEFAULT '',
+  anchor_seq INTEGER NOT NULL DEFAULT 0,
+  anchor_hash TEXT NOT NULL DEFAULT ''
+);
+INSERT OR IGNORE INTO ctrldot_event_chain (id) VALUES (1);
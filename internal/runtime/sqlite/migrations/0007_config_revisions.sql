@@ -0,0 +1,11 @@
+-- Applied config revisions, numbered in apply order. changes is a JSON array of strings.
+CREATE TABLE IF NOT EXISTS ctrldot_config_revisions (
+  rev INTEGER PRIMARY KEY AUTOINCREMENT,
+  content_hash TEXT NOT NULL,
+  author TEXT NOT NULL DEFAULT '',
+  trigger TEXT NOT NULL,
+  rollback_of INTEGER NOT NULL DEFAULT 0,
+  changes TEXT NOT NULL DEFAULT '[]',
+  content TEXT NOT NULL,
+  created_at INTEGER NOT NULL -- unix ms
+);
@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/runtime"
+)
+
+const outboxColumns = `seq, sink, record_id, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at`
+
+// EnqueueOutbox implements runtime.RuntimeStore.
+func (s *Store) EnqueueOutbox(ctx context.Context, r domain.OutboxRecord) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT OR IGNORE INTO ctrldot_ledger_outbox (sink, record_id, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Sink, r.RecordID, r.Payload, r.Status, r.Attempts,
+		r.NextAttemptAt.UnixMilli(), r.LastError, r.CreatedAt.UnixMilli(), r.UpdatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("enqueue outbox record: %w", err)
+	}
+	return nil
+}
+
+// ListOutbox implements runtime.RuntimeStore.
+func (s *Store) ListOutbox(ctx context.Context, filter runtime.OutboxFilter) ([]domain.OutboxRecord, error) {
+	query := `SELECT ` + outboxColumns + ` FROM ctrldot_ledger_outbox WHERE 1=1`
+	args := []interface{}{}
+	if filter.Sink != "" {
+		query += " AND sink = ?"
+		args = append(args, filter.Sink)
+	}
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	query += " ORDER BY seq"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list outbox: %w", err)
+	}
+	defer rows.Close()
+	var out []domain.OutboxRecord
+	for rows.Next() {
+		var r domain.OutboxRecord
+		var nextAttemptAt, createdAt, updatedAt int64
+		if err := rows.Scan(&r.Seq, &r.Sink, &r.RecordID, &r.Payload, &r.Status, &r.Attempts,
+			&nextAttemptAt, &r.LastError, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		r.NextAttemptAt = time.UnixMilli(nextAttemptAt).UTC()
+		r.CreatedAt = time.UnixMilli(createdAt).UTC()
+		r.UpdatedAt = time.UnixMilli(updatedAt).UTC()
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// UpdateOutbox implements runtime.RuntimeStore.
+func (s *Store) UpdateOutbox(ctx context.Context, r domain.OutboxRecord) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE ctrldot_ledger_outbox SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, updated_at = ? WHERE seq = ?`,
+		r.Status, r.Attempts, r.NextAttemptAt.UnixMilli(), r.LastError, r.UpdatedAt.UnixMilli(), r.Seq,
+	)
+	if err != nil {
+		return fmt.Errorf("update outbox record: %w", err)
+	}
+	return nil
+}
+
+// DeleteOutbox implements runtime.RuntimeStore.
+func (s *Store) DeleteOutbox(ctx context.Context, seq int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM ctrldot_ledger_outbox WHERE seq = ?`, seq); err != nil {
+		return fmt.Errorf("delete outbox record: %w", err)
+	}
+	return nil
+}
+
+// CountOutbox implements runtime.RuntimeStore.
+func (s *Store) CountOutbox(ctx context.Context) (map[string]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM ctrldot_ledger_outbox GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("count outbox: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]int64)
+	for rows.Next() {
+		var status string
+		var n int64
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		out[status] = n
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,173 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/runtime"
+)
+
+// CompactEvents implements runtime.RuntimeStore. Pruned events are rolled up and deleted, the
+// chain anchor moved and record appended in one transaction.
+func (s *Store) CompactEvents(ctx context.Context, olderThanTS int64, maxRows int, record *domain.Event) (*domain.EventCompaction, error) {
+	start := time.Now()
+	var conds []string
+	var args []interface{}
+	if olderThanTS > 0 {
+		conds = append(conds, "CAST(strftime('%s', created_at) AS INTEGER) * 1000 < ?")
+		args = append(args, olderThanTS)
+	}
+	if maxRows > 0 {
+		conds = append(conds, "event_id IN (SELECT event_id FROM ctrldot_events ORDER BY "+createdAtEpoch+" DESC, event_id DESC LIMIT -1 OFFSET ?)")
+		args = append(args, maxRows)
+	}
+	out := &domain.EventCompaction{MaxRows: maxRows}
+	if olderThanTS > 0 {
+		cutoff := time.UnixMilli(olderThanTS)
+		out.Cutoff = &cutoff
+	}
+	if len(conds) == 0 {
+		if record != nil {
+			out.Record(record)
+			return out, s.AppendEvent(ctx, record)
+		}
+		return out, nil
+	}
+	where := "(" + strings.Join(conds, " OR ") + ")"
+
+	s.chainMu.Lock()
+	defer s.chainMu.Unlock()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("compact events: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The hash chain may only lose a prefix: also prune every chained event before the newest
+	// selected one, and keep that event's hash as the anchor verification starts from.
+	if err := tx.QueryRowContext(ctx, `SELECT anchor_seq, anchor_hash FROM ctrldot_event_chain WHERE id = 1`).Scan(&out.AnchorSeq, &out.AnchorHash); err != nil {
+		return nil, fmt.Errorf("compact events: read chain anchor: %w", err)
+	}
+	var anchorSeq sql.NullInt64
+	var anchorHash sql.NullString
+	err = tx.QueryRowContext(ctx,
+		`SELECT chain_seq, hash FROM ctrldot_events WHERE chain_seq = (SELECT MAX(chain_seq) FROM ctrldot_events WHERE `+where+`)`,
+		args...).Scan(&anchorSeq, &anchorHash)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("compact events: find chain anchor: %w", err)
+	}
+	if anchorSeq.Valid {
+		where = "(" + where + " OR chain_seq <= ?)"
+		args = append(args, anchorSeq.Int64)
+		out.AnchorSeq, out.AnchorHash = anchorSeq.Int64, anchorHash.String
+		if _, err := tx.ExecContext(ctx, `UPDATE ctrldot_event_chain SET anchor_seq = ?, anchor_hash = ? WHERE id = 1`, out.AnchorSeq, out.AnchorHash); err != nil {
+			return nil, fmt.Errorf("compact events: record chain anchor: %w", err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO ctrldot_event_rollups (agent_id, hour_start, event_type, decision, event_count, cost_gbp, cost_tokens)
+		 SELECT COALESCE(agent_id, ''),
+		        (CAST(strftime('%s', created_at) AS INTEGER) / 3600) * 3600 * 1000,
+		        event_type,
+		        COALESCE(json_extract(payload_json, '$.decision'), ''),
+		        COUNT(*), COALESCE(SUM(cost_gbp), 0), COALESCE(SUM(cost_tokens), 0)
+		 FROM ctrldot_events WHERE `+where+`
+		 GROUP BY 1, 2, 3, 4
+		 ON CONFLICT (agent_id, hour_start, event_type, decision) DO UPDATE SET
+		   event_count = event_count + excluded.event_count,
+		   cost_gbp = cost_gbp + excluded.cost_gbp,
+		   cost_tokens = cost_tokens + excluded.cost_tokens`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("roll up events: %w", err)
+	}
+	out.RollupsUpserted, _ = res.RowsAffected()
+
+	res, err = tx.ExecContext(ctx, `DELETE FROM ctrldot_events WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("delete events: %w", err)
+	}
+	out.Deleted, _ = res.RowsAffected()
+
+	out.DurationMs = time.Since(start).Milliseconds()
+	if record != nil {
+		out.Record(record)
+		if err := appendEventTx(ctx, tx, record); err != nil {
+			return nil, fmt.Errorf("compact events: record run: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("compact events: %w", err)
+	}
+	return out, nil
+}
+
+// ListEventRollups implements runtime.RuntimeStore.
+func (s *Store) ListEventRollups(ctx context.Context, filter runtime.EventFilter) ([]domain.EventRollup, error) {
+	query := `SELECT agent_id, hour_start, event_type, decision, event_count, cost_gbp, cost_tokens FROM ctrldot_event_rollups WHERE 1=1`
+	args := []interface{}{}
+	if filter.AgentID != nil {
+		query += " AND agent_id = ?"
+		args = append(args, *filter.AgentID)
+	}
+	if filter.SinceTS != nil {
+		query += " AND hour_start >= ?"
+		args = append(args, *filter.SinceTS)
+	}
+	query += " ORDER BY hour_start DESC, agent_id, event_type, decision"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list event rollups: %w", err)
+	}
+	defer rows.Close()
+	var out []domain.EventRollup
+	for rows.Next() {
+		var r domain.EventRollup
+		var hourStart int64
+		if err := rows.Scan(&r.AgentID, &hourStart, &r.EventType, &r.Decision, &r.EventCount, &r.CostGBP, &r.CostTokens); err != nil {
+			return nil, err
+		}
+		r.HourStart = time.UnixMilli(hourStart).UTC()
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// EventStats implements runtime.RuntimeStore.
+func (s *Store) EventStats(ctx context.Context) (*domain.EventStats, error) {
+	st := &domain.EventStats{}
+	var oldest sql.NullString
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*), (SELECT created_at FROM ctrldot_events ORDER BY `+createdAtEpoch+` LIMIT 1) FROM ctrldot_events`).Scan(&st.EventCount, &oldest); err != nil {
+		return nil, fmt.Errorf("event stats: %w", err)
+	}
+	if oldest.Valid {
+		t, _ := time.Parse(time.RFC3339, oldest.String)
+		st.OldestEventAt = &t
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM ctrldot_event_rollups`).Scan(&st.RollupCount); err != nil {
+		return nil, fmt.Errorf("event stats: %w", err)
+	}
+	var lastID string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT event_id FROM ctrldot_events WHERE event_type = ? ORDER BY `+createdAtEpoch+` DESC, event_id DESC LIMIT 1`,
+		domain.EventTypeEventsCompacted,
+	).Scan(&lastID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("event stats: %w", err)
+	}
+	if lastID != "" {
+		if st.LastCompaction, err = s.GetEvent(ctx, lastID); err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
@@ -8,9 +8,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/eventchain"
 	"github.com/futurematic/kernel/internal/runtime"
 	_ "modernc.org/sqlite"
 )
@@ -20,7 +23,9 @@ var migrationsFS embed.FS
 
 // Store implements runtime.RuntimeStore using SQLite.
 type Store struct {
-	db *sql.DB
+	db      *sql.DB
+	ownsDB  bool       // opened by Open, so Close closes it
+	chainMu sync.Mutex // serialises event appends (hash chain head)
 }
 
 // Open opens or creates the SQLite database at path, sets PRAGMAs, and runs migrations.
@@ -45,9 +50,21 @@ func Open(ctx context.Context, path string) (*Store, error) {
 			return nil, fmt.Errorf("%s: %w", s, err)
 		}
 	}
+	s, err := OpenDB(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s.ownsDB = true
+	return s, nil
+}
+
+// OpenDB wraps an already open database (e.g. one shared with the Kernel SQLite store)
+// and runs migrations. The caller keeps ownership of db and closes it; the Store's Close
+// does not.
+func OpenDB(ctx context.Context, db *sql.DB) (*Store, error) {
 	s := &Store{db: db}
 	if err := s.Migrate(ctx); err != nil {
-		db.Close()
 		return nil, err
 	}
 	return s, nil
@@ -63,22 +80,63 @@ func expandPath(p string) string {
 	return p
 }
 
-// Migrate runs embedded migrations.
+// Migrate runs embedded migrations. They are re-run on every open, so each must be idempotent;
+// columns are added by addColumns because ALTER TABLE ADD COLUMN is not.
 func (s *Store) Migrate(ctx context.Context) error {
-	for _, name := range []string{"migrations/0001_ctrldot_runtime.sql", "migrations/0002_panic_state.sql"} {
-		sqlBytes, err := migrationsFS.ReadFile(name)
-		if err != nil {
-			return fmt.Errorf("read migration %s: %w", name, err)
+	for _, name := range []string{
+		"migrations/0001_ctrldot_runtime.sql",
+		"migrations/0002_panic_state.sql",
+		"migrations/0003_event_rollups.sql",
+		"migrations/0004_webhook_deliveries.sql",
+		"migrations/0005_ledger_outbox.sql",
+	} {
+		if err := s.execMigration(ctx, name); err != nil {
+			return err
 		}
-		if _, err := s.db.ExecContext(ctx, string(sqlBytes)); err != nil {
-			return fmt.Errorf("migrate %s: %w", name, err)
+	}
+	if err := s.addColumns(ctx, "ctrldot_events", "chain_seq INTEGER", "prev_hash TEXT", "hash TEXT"); err != nil {
+		return err
+	}
+	if err := s.execMigration(ctx, "migrations/0006_event_chain.sql"); err != nil {
+		return err
+	}
+	return s.execMigration(ctx, "migrations/0007_config_revisions.sql")
+}
+
+func (s *Store) execMigration(ctx context.Context, name string) error {
+	sqlBytes, err := migrationsFS.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("read migration %s: %w", name, err)
+	}
+	if _, err := s.db.ExecContext(ctx, string(sqlBytes)); err != nil {
+		return fmt.Errorf("migrate %s: %w", name, err)
+	}
+	return nil
+}
+
+// addColumns adds each "name TYPE" column to table unless it already exists.
+func (s *Store) addColumns(ctx context.Context, table string, columns ...string) error {
+	for _, col := range columns {
+		name := strings.Fields(col)[0]
+		var n int
+		if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, name).Scan(&n); err != nil {
+			return fmt.Errorf("migrate %s.%s: %w", table, name, err)
+		}
+		if n > 0 {
+			continue
+		}
+		if _, err := s.db.ExecContext(ctx, `ALTER TABLE `+table+` ADD COLUMN `+col); err != nil {
+			return fmt.Errorf("migrate %s.%s: %w", table, name, err)
 		}
 	}
 	return nil
 }
 
-// Close closes the database.
+// Close closes the database if Open opened it; a database passed to OpenDB is left to its owner.
 func (s *Store) Close() error {
+	if !s.ownsDB {
+		return nil
+	}
 	return s.db.Close()
 }
 
@@ -226,8 +284,36 @@ func (s *Store) UpdateLimitsState(ctx context.Context, state domain.LimitsState)
 	return nil
 }
 
-// AppendEvent implements runtime.RuntimeStore.
+// AppendEvent implements runtime.RuntimeStore. The event is linked to the chain head
+// (e.ChainSeq, e.PrevHash and e.Hash are set) and the head advanced in one transaction.
 func (s *Store) AppendEvent(ctx context.Context, e *domain.Event) error {
+	// Serialise appends: a deferred SQLite transaction cannot upgrade to a writer safely under contention
+	s.chainMu.Lock()
+	defer s.chainMu.Unlock()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("append event: %w", err)
+	}
+	defer tx.Rollback()
+	if err := appendEventTx(ctx, tx, e); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("append event: %w", err)
+	}
+	return nil
+}
+
+// appendEventTx links e to the chain head and inserts it in tx. Caller holds chainMu.
+func appendEventTx(ctx context.Context, tx *sql.Tx, e *domain.Event) error {
+	var headSeq int64
+	var headHash string
+	if err := tx.QueryRowContext(ctx, `SELECT head_seq, head_hash FROM ctrldot_event_chain WHERE id = 1`).Scan(&headSeq, &headHash); err != nil {
+		return fmt.Errorf("append event: read chain head: %w", err)
+	}
+	if err := eventchain.Link(e, headSeq, headHash); err != nil {
+		return fmt.Errorf("append event: %w", err)
+	}
 	payload, _ := json.Marshal(e.PayloadJSON)
 	var sessionID interface{}
 	if e.SessionID != "" {
@@ -240,86 +326,83 @@ func (s *Store) AppendEvent(ctx context.Context, e *domain.Event) error {
 	if e.CostTokens != nil {
 		costTokens = *e.CostTokens
 	}
-	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO ctrldot_events (event_id, event_type, agent_id, session_id, severity, payload_json, action_hash, cost_gbp, cost_tokens, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO ctrldot_events (event_id, event_type, agent_id, session_id, severity, payload_json, action_hash, cost_gbp, cost_tokens, created_at, chain_seq, prev_hash, hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		e.EventID, e.Type, e.AgentID, sessionID, e.Severity, string(payload), e.ActionHash, costGBP, costTokens, e.TS.Format(time.RFC3339),
+		e.ChainSeq, e.PrevHash, e.Hash,
 	)
 	if err != nil {
 		return fmt.Errorf("append event: %w", err)
 	}
+	if _, err := tx.ExecContext(ctx, `UPDATE ctrldot_event_chain SET head_seq = ?, head_hash = ? WHERE id = 1`, e.ChainSeq, e.Hash); err != nil {
+		return fmt.Errorf("append event: advance chain head: %w", err)
+	}
 	return nil
 }
 
+// EventChainHead implements runtime.RuntimeStore.
+func (s *Store) EventChainHead(ctx context.Context) (*domain.EventChainHead, error) {
+	var h domain.EventChainHead
+	err := s.db.QueryRowContext(ctx,
+		`SELECT head_seq, head_hash, anchor_seq, anchor_hash, (SELECT COUNT(*) FROM ctrldot_events WHERE chain_seq IS NULL) FROM ctrldot_event_chain WHERE id = 1`,
+	).Scan(&h.HeadSeq, &h.HeadHash, &h.AnchorSeq, &h.AnchorHash, &h.Unchained)
+	if err != nil {
+		return nil, fmt.Errorf("event chain head: %w", err)
+	}
+	return &h, nil
+}
+
+// ListChainedEvents implements runtime.RuntimeStore.
+func (s *Store) ListChainedEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.Event, error) {
+	return s.queryEvents(ctx, "list chained events",
+		`SELECT `+eventColumns+` FROM ctrldot_events WHERE chain_seq > ? ORDER BY chain_seq LIMIT ?`, afterSeq, limit)
+}
+
 // ListEvents implements runtime.RuntimeStore.
 func (s *Store) ListEvents(ctx context.Context, filter runtime.EventFilter) ([]domain.Event, error) {
-	query := `SELECT event_id, event_type, agent_id, session_id, severity, payload_json, action_hash, cost_gbp, cost_tokens, created_at FROM ctrldot_events WHERE 1=1`
-	args := []interface{}{}
-	argIdx := 1
-	if filter.AgentID != nil {
-		query += fmt.Sprintf(" AND agent_id = ?")
-		args = append(args, *filter.AgentID)
-		argIdx++
+	where, args := eventFilterWhere(filter)
+	query := `SELECT ` + eventColumns + ` FROM ctrldot_events WHERE 1=1` + where
+	// Order on the same epoch the cursor and time filters compare, not the text, whose offset
+	// can differ between rows (e.g. across a DST change); within a second, in chain order
+	if filter.OldestFirst {
+		query += " ORDER BY " + createdAtEpoch + ", " + chainOrder + ", event_id"
+	} else {
+		query += " ORDER BY " + createdAtEpoch + " DESC, " + chainOrder + " DESC, event_id DESC"
 	}
-	if filter.SinceTS != nil {
-		// created_at is ISO8601 text; compare with datetime from unix ms
-		query += " AND created_at >= datetime(?/1000, 'unixepoch')"
-		args = append(args, *filter.SinceTS)
-		argIdx++
-	}
-	query += " ORDER BY created_at DESC"
 	if filter.Limit > 0 {
-		query += fmt.Sprintf(" LIMIT ?")
+		query += " LIMIT ?"
 		args = append(args, filter.Limit)
 	}
+	return s.queryEvents(ctx, "list events", query, args...)
+}
+
+const eventColumns = `event_id, event_type, agent_id, session_id, severity, payload_json, action_hash, cost_gbp, cost_tokens, created_at, chain_seq, prev_hash, hash`
+
+func (s *Store) queryEvents(ctx context.Context, op, query string, args ...interface{}) ([]domain.Event, error) {
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("list events: %w", err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	defer rows.Close()
 	var out []domain.Event
 	for rows.Next() {
-		var e domain.Event
-		var payloadJSON, sessionID sql.NullString
-		var costGBP sql.NullFloat64
-		var costTokens sql.NullInt64
-		var createdAt string
-		if err := rows.Scan(&e.EventID, &e.Type, &e.AgentID, &sessionID, &e.Severity, &payloadJSON, &e.ActionHash, &costGBP, &costTokens, &createdAt); err != nil {
+		e, err := scanEvent(rows)
+		if err != nil {
 			return nil, err
 		}
-		e.TS, _ = time.Parse(time.RFC3339, createdAt)
-		if sessionID.Valid {
-			e.SessionID = sessionID.String
-		}
-		if payloadJSON.Valid && payloadJSON.String != "" {
-			json.Unmarshal([]byte(payloadJSON.String), &e.PayloadJSON)
-		}
-		if costGBP.Valid {
-			e.CostGBP = &costGBP.Float64
-		}
-		if costTokens.Valid {
-			e.CostTokens = &costTokens.Int64
-		}
-		out = append(out, e)
+		out = append(out, *e)
 	}
 	return out, rows.Err()
 }
 
-// GetEvent implements runtime.RuntimeStore.
-func (s *Store) GetEvent(ctx context.Context, eventID string) (*domain.Event, error) {
+func scanEvent(row interface{ Scan(...interface{}) error }) (*domain.Event, error) {
 	var e domain.Event
-	var payloadJSON, sessionID sql.NullString
+	var payloadJSON, sessionID, prevHash, hash sql.NullString
 	var costGBP sql.NullFloat64
-	var costTokens sql.NullInt64
+	var costTokens, chainSeq sql.NullInt64
 	var createdAt string
-	err := s.db.QueryRowContext(ctx,
-		`SELECT event_id, event_type, agent_id, session_id, severity, payload_json, action_hash, cost_gbp, cost_tokens, created_at FROM ctrldot_events WHERE event_id = ?`,
-		eventID,
-	).Scan(&e.EventID, &e.Type, &e.AgentID, &sessionID, &e.Severity, &payloadJSON, &e.ActionHash, &costGBP, &costTokens, &createdAt)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
-	if err != nil {
-		return nil, fmt.Errorf("get event: %w", err)
+	if err := row.Scan(&e.EventID, &e.Type, &e.AgentID, &sessionID, &e.Severity, &payloadJSON, &e.ActionHash, &costGBP, &costTokens, &createdAt, &chainSeq, &prevHash, &hash); err != nil {
+		return nil, err
 	}
 	e.TS, _ = time.Parse(time.RFC3339, createdAt)
 	if sessionID.Valid {
@@ -334,9 +417,91 @@ func (s *Store) GetEvent(ctx context.Context, eventID string) (*domain.Event, er
 	if costTokens.Valid {
 		e.CostTokens = &costTokens.Int64
 	}
+	e.ChainSeq = chainSeq.Int64
+	e.PrevHash = prevHash.String
+	e.Hash = hash.String
 	return &e, nil
 }
 
+// createdAtEpoch is created_at (RFC3339 text, any offset) as unix seconds.
+const createdAtEpoch = "CAST(strftime('%s', created_at) AS INTEGER)"
+
+// chainOrder is the append order within a second; rows written before chaining sort first
+// and fall back to event_id.
+const chainOrder = "COALESCE(chain_seq, 0)"
+
+// eventFilterWhere builds the " AND ..." conditions for filter (everything except Limit).
+func eventFilterWhere(filter runtime.EventFilter) (string, []interface{}) {
+	var where strings.Builder
+	args := []interface{}{}
+	if filter.AgentID != nil {
+		where.WriteString(" AND agent_id = ?")
+		args = append(args, *filter.AgentID)
+	}
+	if filter.SessionID != nil {
+		where.WriteString(" AND session_id = ?")
+		args = append(args, *filter.SessionID)
+	}
+	if len(filter.Types) > 0 {
+		where.WriteString(" AND event_type IN (?" + strings.Repeat(", ?", len(filter.Types)-1) + ")")
+		for _, t := range filter.Types {
+			args = append(args, t)
+		}
+	}
+	if len(filter.Severities) > 0 {
+		where.WriteString(" AND severity IN (?" + strings.Repeat(", ?", len(filter.Severities)-1) + ")")
+		for _, sev := range filter.Severities {
+			args = append(args, sev)
+		}
+	}
+	if filter.Decision != nil {
+		where.WriteString(" AND json_extract(payload_json, '$.decision') = ?")
+		args = append(args, *filter.Decision)
+	}
+	if filter.ActionType != nil {
+		where.WriteString(" AND json_extract(payload_json, '$.action_type') = ?")
+		args = append(args, *filter.ActionType)
+	}
+	if filter.ActionHash != nil {
+		where.WriteString(" AND action_hash = ?")
+		args = append(args, *filter.ActionHash)
+	}
+	if filter.SinceTS != nil {
+		// created_at has second precision: include the whole second since_ts falls in
+		where.WriteString(" AND " + createdAtEpoch + " >= ?")
+		args = append(args, *filter.SinceTS/1000)
+	}
+	if filter.UntilTS != nil {
+		where.WriteString(" AND " + createdAtEpoch + " * 1000 < ?")
+		args = append(args, *filter.UntilTS)
+	}
+	if filter.Cursor != nil {
+		// created_at has second precision; ties are broken by chain position, then event_id
+		c := filter.Cursor
+		sec := c.TS.Unix()
+		op := "<"
+		if filter.OldestFirst {
+			op = ">"
+		}
+		where.WriteString(" AND (" + createdAtEpoch + " " + op + " ? OR (" + createdAtEpoch + " = ? AND (" +
+			chainOrder + " " + op + " ? OR (" + chainOrder + " = ? AND event_id " + op + " ?))))")
+		args = append(args, sec, sec, c.Seq, c.Seq, c.EventID)
+	}
+	return where.String(), args
+}
+
+// GetEvent implements runtime.RuntimeStore.
+func (s *Store) GetEvent(ctx context.Context, eventID string) (*domain.Event, error) {
+	e, err := scanEvent(s.db.QueryRowContext(ctx, `SELECT `+eventColumns+` FROM ctrldot_events WHERE event_id = ?`, eventID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get event: %w", err)
+	}
+	return e, nil
+}
+
 // HaltAgent implements runtime.RuntimeStore.
 func (s *Store) HaltAgent(ctx context.Context, agentID string, reason string) error {
 	_, err := s.db.ExecContext(ctx,
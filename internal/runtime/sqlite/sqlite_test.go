@@ -0,0 +1,492 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/eventchain"
+	"github.com/futurematic/kernel/internal/runtime"
+	"github.com/futurematic/kernel/internal/runtime/compactor"
+	"github.com/futurematic/kernel/internal/runtime/sqlite"
+)
+
+var t0 = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func open(t *testing.T) *sqlite.Store {
+	t.Helper()
+	st, err := sqlite.Open(context.Background(), filepath.Join(t.TempDir(), "ctrldot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+// openRaw returns a store and a second handle on its database, for tampering with rows.
+func openRaw(t *testing.T) (*sqlite.Store, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "ctrldot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	st, err := sqlite.OpenDB(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st, db
+}
+
+func verify(t *testing.T, st *sqlite.Store) *eventchain.Result {
+	t.Helper()
+	res, err := eventchain.Verify(context.Background(), st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+// decisions appends n decision.issued events for agent-1 at ts, costing 0.5 each.
+func decisions(t *testing.T, st *sqlite.Store, n int, ts time.Time, decision string) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		appendEvent(t, st, domain.Event{EventID: fmt.Sprintf("evt:%s:%d:%d", decision, ts.Unix(), i), TS: ts, Type: domain.EventTypeDecisionIssued,
+			AgentID: "agent-1", PayloadJSON: map[string]interface{}{"decision": decision}, CostGBP: ptr(0.5)})
+	}
+}
+
+func appendEvent(t *testing.T, st *sqlite.Store, e domain.Event) domain.Event {
+	t.Helper()
+	if e.Severity == "" {
+		e.Severity = domain.EventSeverityInfo
+	}
+	if err := st.AppendEvent(context.Background(), &e); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func ids(events []domain.Event) []string {
+	out := make([]string, len(events))
+	for i, e := range events {
+		out[i] = e.EventID
+	}
+	return out
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestListEventsFilters(t *testing.T) {
+	st := open(t)
+	ctx := context.Background()
+	decision := func(id, agent, d, actionType string, ts time.Time) domain.Event {
+		return domain.Event{EventID: id, TS: ts, Type: domain.EventTypeDecisionIssued, AgentID: agent, SessionID: "sess-" + agent,
+			ActionHash: "hash-" + actionType, PayloadJSON: map[string]interface{}{"decision": d, "action_type": actionType}}
+	}
+	appendEvent(t, st, decision("e1", "a1", "ALLOW", "tool.call", t0.Add(-3*time.Hour)))
+	appendEvent(t, st, decision("e2", "a1", "DENY", "exec.command", t0.Add(-2*time.Hour)))
+	appendEvent(t, st, decision("e3", "a2", "ALLOW", "tool.call", t0.Add(-time.Hour)))
+	appendEvent(t, st, domain.Event{EventID: "e4", TS: t0, Type: "agent.halted", AgentID: "a1", Severity: domain.EventSeverityWarn})
+
+	tests := []struct {
+		name   string
+		filter runtime.EventFilter
+		want   string
+	}{
+		{"all", runtime.EventFilter{}, "[e4 e3 e2 e1]"},
+		{"agent", runtime.EventFilter{AgentID: ptr("a1")}, "[e4 e2 e1]"},
+		{"session", runtime.EventFilter{SessionID: ptr("sess-a2")}, "[e3]"},
+		{"types", runtime.EventFilter{Types: []string{"agent.halted", "agent.resumed"}}, "[e4]"},
+		{"severities", runtime.EventFilter{Severities: []string{domain.EventSeverityWarn}}, "[e4]"},
+		{"decision", runtime.EventFilter{Decision: ptr("ALLOW")}, "[e3 e1]"},
+		{"action type", runtime.EventFilter{ActionType: ptr("exec.command")}, "[e2]"},
+		{"action hash", runtime.EventFilter{ActionHash: ptr("hash-tool.call"), AgentID: ptr("a1")}, "[e1]"},
+		{"since inclusive", runtime.EventFilter{SinceTS: ptr(t0.Add(-2 * time.Hour).UnixMilli())}, "[e4 e3 e2]"},
+		{"until exclusive", runtime.EventFilter{UntilTS: ptr(t0.Add(-time.Hour).UnixMilli())}, "[e2 e1]"},
+		{"limit", runtime.EventFilter{Limit: 2}, "[e4 e3]"},
+	}
+	for _, tt := range tests {
+		got, err := st.ListEvents(ctx, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if s := fmt.Sprint(ids(got)); s != tt.want {
+			t.Errorf("%s: ListEvents = %s, want %s", tt.name, s, tt.want)
+		}
+	}
+}
+
+func TestListEventsCursorPaging(t *testing.T) {
+	st := open(t)
+	ctx := context.Background()
+	// created_at keeps each event's offset; order and cursor must follow the instant, not the text.
+	// e2 is 45 minutes after e1 but its text ("08:30-02:00") sorts before e1's ("09:45Z").
+	zone := time.FixedZone("", -2*60*60)
+	appendEvent(t, st, domain.Event{EventID: "e1", TS: t0.Add(-135 * time.Minute), Type: "x", AgentID: "a"})
+	appendEvent(t, st, domain.Event{EventID: "e2", TS: t0.Add(-90 * time.Minute).In(zone), Type: "x", AgentID: "a"})
+	// Three events in the same second, paged in append (chain) order, not by event_id
+	for _, id := range []string{"e3c", "e3b", "e3a"} {
+		appendEvent(t, st, domain.Event{EventID: id, TS: t0.Add(-time.Hour), Type: "x", AgentID: "a"})
+	}
+	appendEvent(t, st, domain.Event{EventID: "e4", TS: t0.In(zone), Type: "x", AgentID: "a"})
+
+	var pages []string
+	filter := runtime.EventFilter{Limit: 2}
+	for {
+		page, err := st.ListEvents(ctx, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		pages = append(pages, fmt.Sprint(ids(page)))
+		filter.Cursor = runtime.CursorAfter(page[len(page)-1])
+	}
+	if got, want := fmt.Sprint(pages), "[[e4 e3a] [e3b e3c] [e2 e1]]"; got != want {
+		t.Errorf("pages = %s, want %s", got, want)
+	}
+
+	pages = nil
+	filter = runtime.EventFilter{Limit: 4, OldestFirst: true}
+	for {
+		page, err := st.ListEvents(ctx, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		pages = append(pages, fmt.Sprint(ids(page)))
+		filter.Cursor = runtime.CursorAfter(page[len(page)-1])
+	}
+	if got, want := fmt.Sprint(pages), "[[e1 e2 e3c e3b] [e3a e4]]"; got != want {
+		t.Errorf("oldest first pages = %s, want %s", got, want)
+	}
+
+	// The time filters compare the same instant
+	since := t0.Add(-90 * time.Minute).UnixMilli()
+	got, err := st.ListEvents(ctx, runtime.EventFilter{SinceTS: &since, Cursor: &runtime.EventCursor{TS: t0, EventID: "e4"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := fmt.Sprint(ids(got)); s != "[e3a e3b e3c e2]" {
+		t.Errorf("since + cursor = %s, want [e3a e3b e3c e2]", s)
+	}
+}
+
+func TestListEventsUnchainedRowsSortFirst(t *testing.T) {
+	st, db := openRaw(t)
+	ctx := context.Background()
+	appendEvent(t, st, domain.Event{EventID: "a-chained", TS: t0, Type: "x", AgentID: "a"})
+	// A row written before chaining (chain_seq NULL) in the same second
+	if _, err := db.Exec(`INSERT INTO ctrldot_events (event_id, event_type, agent_id, severity, payload_json, action_hash, created_at) VALUES ('z-old', 'x', 'a', 'info', '{}', '', ?)`,
+		t0.Format(time.RFC3339)); err != nil {
+		t.Fatal(err)
+	}
+	all, err := st.ListEvents(ctx, runtime.EventFilter{OldestFirst: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := fmt.Sprint(ids(all)); s != "[z-old a-chained]" {
+		t.Fatalf("oldest first = %s, want [z-old a-chained]", s)
+	}
+
+	// A cursor encoded before cursors carried the chain position resumes as an unchained one
+	legacy := base64.RawURLEncoding.EncodeToString([]byte(t0.Format(time.RFC3339Nano) + "|z-old"))
+	cursor, err := runtime.ParseEventCursor(legacy)
+	if err != nil || cursor.Seq != 0 || cursor.EventID != "z-old" {
+		t.Fatalf("ParseEventCursor(legacy) = %+v, %v", cursor, err)
+	}
+	if got, _ := st.ListEvents(ctx, runtime.EventFilter{OldestFirst: true, Cursor: cursor}); fmt.Sprint(ids(got)) != "[a-chained]" {
+		t.Errorf("after the unchained row = %v, want [a-chained]", ids(got))
+	}
+	// Cursors round-trip the chain position
+	next, err := runtime.ParseEventCursor(runtime.CursorAfter(all[1]).Encode())
+	if err != nil || next.Seq != all[1].ChainSeq || next.EventID != "a-chained" {
+		t.Errorf("cursor round trip = %+v, %v", next, err)
+	}
+}
+
+func TestOpenDBLeavesDBToCaller(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "shared.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	st, err := sqlite.OpenDB(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		t.Errorf("shared db closed by the runtime store: %v", err)
+	}
+}
+
+func TestCompactionKeepsChainVerifiable(t *testing.T) {
+	st := open(t)
+	ctx := context.Background()
+	old := time.Now().AddDate(0, 0, -10).Truncate(time.Hour).Add(30 * time.Minute)
+	decisions(t, st, 3, old, "ALLOW")
+	decisions(t, st, 1, old, "DENY")
+	decisions(t, st, 2, time.Now(), "ALLOW")
+
+	cfg := config.DefaultConfig()
+	cfg.Events.RetentionDays = 7
+	result, err := compactor.New(st, cfg).RunOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Deleted != 4 || result.AnchorSeq != 4 {
+		t.Fatalf("compaction = %+v, want 4 deleted and the anchor at 4", result)
+	}
+	// The 2 kept events and the chained events.compacted record
+	if res := verify(t, st); !res.OK || res.Checked != 3 || res.Head.AnchorSeq != 4 {
+		t.Fatalf("Verify after compaction = %+v, break %+v", res, res.Break)
+	}
+
+	// Pruned events are rolled up per agent, hour, type and decision
+	rollups, err := st.ListEventRollups(ctx, runtime.EventFilter{AgentID: ptr("agent-1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]domain.EventRollup{}
+	for _, r := range rollups {
+		got[r.Decision] = r
+	}
+	if allow := got["ALLOW"]; len(rollups) != 2 || allow.EventCount != 3 || allow.CostGBP != 1.5 || !allow.HourStart.Equal(old.Truncate(time.Hour)) {
+		t.Errorf("rollups = %+v, want ALLOW x3 (1.5) and DENY x1 in the hour of %s", rollups, old)
+	}
+
+	// Old events appended later sit after the kept ones in the chain, so compacting them also
+	// prunes that prefix; rollups for the same group accumulate
+	decisions(t, st, 2, old.Add(time.Second), "ALLOW")
+	result, err = compactor.New(st, cfg).RunOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Deleted != 5 {
+		t.Errorf("second compaction deleted %d events, want 5 (the 2 old, the 2 kept before them and events.compacted)", result.Deleted)
+	}
+	if res := verify(t, st); !res.OK || res.Checked != 1 {
+		t.Fatalf("Verify after second compaction = %+v, break %+v", res, res.Break)
+	}
+	rollups, _ = st.ListEventRollups(ctx, runtime.EventFilter{AgentID: ptr("agent-1")})
+	var oldAllow domain.EventRollup
+	for _, r := range rollups {
+		if r.Decision == "ALLOW" && r.HourStart.Equal(old.Truncate(time.Hour)) {
+			oldAllow = r
+		}
+	}
+	if oldAllow.EventCount != 5 || oldAllow.CostGBP != 2.5 {
+		t.Errorf("old ALLOW rollup after second compaction = %+v, want 5 events costing 2.5", oldAllow)
+	}
+	stats, err := st.EventStats(ctx)
+	if err != nil || stats.EventCount != 1 || stats.LastCompaction == nil {
+		t.Errorf("EventStats = %+v, %v", stats, err)
+	}
+}
+
+func TestVerifyFindsTamperedRow(t *testing.T) {
+	for _, tc := range []struct {
+		name, sql, reason string
+	}{
+		{"edited", `UPDATE ctrldot_events SET payload_json = '{"decision":"ALLOW"}' WHERE chain_seq = 3`, "hash mismatch"},
+		{"deleted", `DELETE FROM ctrldot_events WHERE chain_seq = 3`, "missing"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st, db := openRaw(t)
+			decisions(t, st, 5, t0, "DENY")
+			if res := verify(t, st); !res.OK {
+				t.Fatalf("Verify before tampering: break %+v", res.Break)
+			}
+			if _, err := db.Exec(tc.sql); err != nil {
+				t.Fatal(err)
+			}
+			res := verify(t, st)
+			if res.OK || res.Break == nil || res.Break.Seq != 3 || !strings.Contains(res.Break.Reason, tc.reason) {
+				t.Errorf("Verify = %+v, break %+v; want a break at 3 (%s)", res, res.Break, tc.reason)
+			}
+		})
+	}
+}
+
+func TestVerifyFindsMovedAnchor(t *testing.T) {
+	st, db := openRaw(t)
+	decisions(t, st, 5, t0, "ALLOW")
+
+	// Deleting a prefix and moving the anchor past it, without compaction recording that anchor
+	if _, err := db.Exec(`DELETE FROM ctrldot_events WHERE chain_seq <= 2`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE ctrldot_event_chain SET anchor_seq = 2, anchor_hash = (SELECT prev_hash FROM ctrldot_events WHERE chain_seq = 3)`); err != nil {
+		t.Fatal(err)
+	}
+	res := verify(t, st)
+	if res.OK || res.Break == nil || res.Break.Seq != 2 || !strings.Contains(res.Break.Reason, "no events.compacted") {
+		t.Errorf("Verify = %+v, break %+v; want the anchor at 2 reported", res, res.Break)
+	}
+}
+
+func TestOutboxQueue(t *testing.T) {
+	st := open(t)
+	ctx := context.Background()
+	record := func(sink, id string) domain.OutboxRecord {
+		return domain.OutboxRecord{Sink: sink, RecordID: id, Payload: `{"id":"` + id + `"}`, Status: domain.OutboxStatusPending,
+			NextAttemptAt: t0, CreatedAt: t0, UpdatedAt: t0}
+	}
+	for _, r := range []domain.OutboxRecord{record("http", "d1"), record("http", "d2"), record("http", "d1"), record("otel", "d1")} {
+		if err := st.EnqueueOutbox(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A record is queued once per sink
+	list, err := st.ListOutbox(ctx, runtime.OutboxFilter{Sink: "http"})
+	if err != nil || len(list) != 2 || list[0].RecordID != "d1" || list[1].RecordID != "d2" {
+		t.Fatalf("ListOutbox(http) = %+v, %v; want d1, d2 in seq order", list, err)
+	}
+	if got, _ := st.ListOutbox(ctx, runtime.OutboxFilter{Limit: 1}); len(got) != 1 || got[0].Seq != list[0].Seq {
+		t.Errorf("ListOutbox(limit 1) = %+v", got)
+	}
+
+	dead := list[1]
+	dead.Status, dead.Attempts, dead.LastError, dead.UpdatedAt = domain.OutboxStatusDead, 4, "HTTP 500", t0.Add(time.Minute)
+	if err := st.UpdateOutbox(ctx, dead); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := st.ListOutbox(ctx, runtime.OutboxFilter{Status: domain.OutboxStatusDead}); len(got) != 1 || got[0].Attempts != 4 || got[0].LastError != "HTTP 500" || !got[0].UpdatedAt.Equal(dead.UpdatedAt) {
+		t.Errorf("dead records = %+v", got)
+	}
+	if err := st.DeleteOutbox(ctx, list[0].Seq); err != nil {
+		t.Fatal(err)
+	}
+	if counts, err := st.CountOutbox(ctx); err != nil || counts[domain.OutboxStatusPending] != 1 || counts[domain.OutboxStatusDead] != 1 {
+		t.Errorf("CountOutbox = %v, %v; want 1 pending (otel) and 1 dead", counts, err)
+	}
+}
+
+func TestWebhookQueueDueOrder(t *testing.T) {
+	st := open(t)
+	ctx := context.Background()
+	for i, id := range []string{"late", "early", "middle"} {
+		due := map[string]time.Time{"early": t0.Add(-time.Minute), "middle": t0, "late": t0.Add(time.Minute)}[id]
+		d := domain.WebhookDelivery{DeliveryID: id, Endpoint: "ops", EventID: fmt.Sprintf("evt:%d", i), EventType: "agent.halted", Body: `{}`,
+			Status: domain.WebhookStatusPending, NextAttemptAt: due, CreatedAt: t0, UpdatedAt: t0}
+		if err := st.EnqueueWebhookDelivery(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	due, err := st.ListWebhookDeliveries(ctx, runtime.WebhookFilter{Status: domain.WebhookStatusPending, DueBefore: &t0})
+	if err != nil || len(due) != 2 || due[0].DeliveryID != "early" || due[1].DeliveryID != "middle" {
+		t.Fatalf("due deliveries = %+v, %v; want early, middle", due, err)
+	}
+
+	d := due[0]
+	d.Status, d.Attempts, d.LastStatusCode, d.LastError = domain.WebhookStatusDead, 8, 503, "unavailable"
+	if err := st.UpdateWebhookDelivery(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+	got, err := st.GetWebhookDelivery(ctx, "early")
+	if err != nil || got.Status != domain.WebhookStatusDead || got.Attempts != 8 || got.LastStatusCode != 503 || !got.NextAttemptAt.Equal(t0.Add(-time.Minute)) {
+		t.Errorf("GetWebhookDelivery = %+v, %v", got, err)
+	}
+	if pending, _ := st.ListWebhookDeliveries(ctx, runtime.WebhookFilter{Status: domain.WebhookStatusPending, Limit: 1}); len(pending) != 1 || pending[0].DeliveryID != "middle" {
+		t.Errorf("first pending delivery = %+v, want middle", pending)
+	}
+	if err := st.DeleteWebhookDelivery(ctx, "late"); err != nil {
+		t.Fatal(err)
+	}
+	if counts, err := st.CountWebhookDeliveries(ctx); err != nil || counts[domain.WebhookStatusPending] != 1 || counts[domain.WebhookStatusDead] != 1 {
+		t.Errorf("CountWebhookDeliveries = %v, %v", counts, err)
+	}
+}
+
+func TestConfigRevisions(t *testing.T) {
+	st := open(t)
+	ctx := context.Background()
+	revs := []*domain.ConfigRevision{
+		{ContentHash: "sha256:a", Trigger: "startup", Changes: []string{}, Content: "a", CreatedAt: t0},
+		{ContentHash: "sha256:b", Author: "ops", Trigger: "api", Changes: []string{"agents.default.daily_budget_gbp: 5 -> 10"}, Content: "b", CreatedAt: t0.Add(time.Minute)},
+	}
+	for _, r := range revs {
+		if err := st.AppendConfigRevision(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rollback := &domain.ConfigRevision{ContentHash: "sha256:a", Trigger: "rollback", RollbackOf: revs[0].Rev, Changes: []string{"agents.default.daily_budget_gbp: 10 -> 5"},
+		Content: "a", CreatedAt: t0.Add(2 * time.Minute)}
+	if err := st.AppendConfigRevision(ctx, rollback); err != nil {
+		t.Fatal(err)
+	}
+	if revs[0].Rev != 1 || revs[1].Rev != 2 || rollback.Rev != 3 {
+		t.Fatalf("revs = %d, %d, %d; want 1, 2, 3", revs[0].Rev, revs[1].Rev, rollback.Rev)
+	}
+
+	list, err := st.ListConfigRevisions(ctx, 2)
+	if err != nil || len(list) != 2 || list[0].Rev != 3 || list[1].Rev != 2 {
+		t.Fatalf("ListConfigRevisions(2) = %+v, %v; want 3, 2", list, err)
+	}
+	if r := list[0]; r.RollbackOf != 1 || r.Trigger != "rollback" || r.Changes[0] != rollback.Changes[0] || !r.CreatedAt.Equal(rollback.CreatedAt) {
+		t.Errorf("rollback revision = %+v", r)
+	}
+	if r, err := st.GetConfigRevision(ctx, 2); err != nil || r == nil || r.Content != "b" || r.Author != "ops" {
+		t.Errorf("GetConfigRevision(2) = %+v, %v", r, err)
+	}
+	if r, err := st.GetConfigRevision(ctx, 9); err != nil || r != nil {
+		t.Errorf("GetConfigRevision(9) = %+v, %v; want nil", r, err)
+	}
+}
+
+func TestCompactionRollsBackWhenItsRecordFails(t *testing.T) {
+	st := open(t)
+	ctx := context.Background()
+	old := time.Now().AddDate(0, 0, -10)
+	decisions(t, st, 3, old, "ALLOW")
+	now := time.Now()
+	decisions(t, st, 1, now, "ALLOW")
+	cutoff := time.Now().AddDate(0, 0, -7).UnixMilli()
+	compacted := func(id string) *domain.Event {
+		return &domain.Event{EventID: id, TS: time.Now(), Type: domain.EventTypeEventsCompacted, Severity: domain.EventSeverityInfo}
+	}
+
+	// The record cannot be appended (its ID is taken by a kept event): nothing is pruned and the anchor stays put
+	taken := fmt.Sprintf("evt:ALLOW:%d:0", now.Unix())
+	if _, err := st.CompactEvents(ctx, cutoff, 0, compacted(taken)); err == nil {
+		t.Fatal("CompactEvents succeeded with a record that cannot be appended")
+	}
+	head, err := st.EventChainHead(ctx)
+	if err != nil || head.AnchorSeq != 0 {
+		t.Fatalf("chain head after failed compaction = %+v, %v; want no anchor", head, err)
+	}
+	if stats, _ := st.EventStats(ctx); stats.EventCount != 4 || stats.RollupCount != 0 {
+		t.Errorf("after failed compaction: %d events, %d rollups; want 4, 0", stats.EventCount, stats.RollupCount)
+	}
+	if res := verify(t, st); !res.OK {
+		t.Fatalf("Verify after failed compaction: break %+v", res.Break)
+	}
+
+	// Retried, the anchor and the chained record that vouches for it commit together
+	record := compacted("evt:compacted")
+	result, err := st.CompactEvents(ctx, cutoff, 0, record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AnchorSeq != 3 || record.ChainSeq != 5 || record.PayloadJSON["chain_anchor_seq"] != int64(3) || record.PayloadJSON["deleted"] != int64(3) {
+		t.Errorf("result %+v, record seq %d payload %v", result, record.ChainSeq, record.PayloadJSON)
+	}
+	if res := verify(t, st); !res.OK || res.Checked != 2 {
+		t.Fatalf("Verify after compaction = %+v, break %+v", res, res.Break)
+	}
+}
@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/runtime"
+)
+
+const webhookColumns = `delivery_id, endpoint, event_id, event_type, body, status, attempts, next_attempt_at, last_error, last_status_code, created_at, updated_at`
+
+// EnqueueWebhookDelivery implements runtime.RuntimeStore.
+func (s *Store) EnqueueWebhookDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO ctrldot_webhook_deliveries (`+webhookColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.DeliveryID, d.Endpoint, d.EventID, d.EventType, d.Body, d.Status, d.Attempts,
+		d.NextAttemptAt.UnixMilli(), d.LastError, d.LastStatusCode, d.CreatedAt.UnixMilli(), d.UpdatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("enqueue webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries implements runtime.RuntimeStore.
+func (s *Store) ListWebhookDeliveries(ctx context.Context, filter runtime.WebhookFilter) ([]domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookColumns + ` FROM ctrldot_webhook_deliveries WHERE 1=1`
+	args := []interface{}{}
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.DueBefore != nil {
+		query += " AND next_attempt_at <= ?"
+		args = append(args, filter.DueBefore.UnixMilli())
+	}
+	query += " ORDER BY next_attempt_at, created_at"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	var out []domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
+
+// GetWebhookDelivery implements runtime.RuntimeStore.
+func (s *Store) GetWebhookDelivery(ctx context.Context, deliveryID string) (*domain.WebhookDelivery, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM ctrldot_webhook_deliveries WHERE delivery_id = ?`, deliveryID)
+	d, err := scanWebhookDelivery(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
+
+// UpdateWebhookDelivery implements runtime.RuntimeStore.
+func (s *Store) UpdateWebhookDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE ctrldot_webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, last_status_code = ?, updated_at = ? WHERE delivery_id = ?`,
+		d.Status, d.Attempts, d.NextAttemptAt.UnixMilli(), d.LastError, d.LastStatusCode, d.UpdatedAt.UnixMilli(), d.DeliveryID,
+	)
+	if err != nil {
+		return fmt.Errorf("update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhookDelivery implements runtime.RuntimeStore.
+func (s *Store) DeleteWebhookDelivery(ctx context.Context, deliveryID string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM ctrldot_webhook_deliveries WHERE delivery_id = ?`, deliveryID); err != nil {
+		return fmt.Errorf("delete webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// CountWebhookDeliveries implements runtime.RuntimeStore.
+func (s *Store) CountWebhookDeliveries(ctx context.Context) (map[string]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM ctrldot_webhook_deliveries GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("count webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]int64)
+	for rows.Next() {
+		var status string
+		var n int64
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		out[status] = n
+	}
+	return out, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebhookDelivery(row rowScanner) (*domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	var nextAttemptAt, createdAt, updatedAt int64
+	if err := row.Scan(&d.DeliveryID, &d.Endpoint, &d.EventID, &d.EventType, &d.Body, &d.Status, &d.Attempts,
+		&nextAttemptAt, &d.LastError, &d.LastStatusCode, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	d.NextAttemptAt = time.UnixMilli(nextAttemptAt).UTC()
+	d.CreatedAt = time.UnixMilli(createdAt).UTC()
+	d.UpdatedAt = time.UnixMilli(updatedAt).UTC()
+	return &d, nil
+}
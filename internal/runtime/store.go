@@ -2,15 +2,126 @@ package runtime
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/futurematic/kernel/internal/domain"
 )
 
-// EventFilter filters events for ListEvents.
+// EventFilter filters events for ListEvents. All set fields must match.
+// Results are ordered newest first by (ts, chain_seq, event_id); see EventCursor.
 type EventFilter struct {
-	AgentID *string
-	SinceTS *int64 // unix milliseconds
-	Limit   int
+	AgentID    *string
+	SessionID  *string
+	Types      []string // event_type in list
+	Severities []string // severity in list
+	Decision   *string  // payload decision (decision.issued events)
+	ActionType *string  // payload action_type
+	ActionHash *string
+	SinceTS    *int64 // unix milliseconds, inclusive
+	UntilTS    *int64 // unix milliseconds, exclusive
+	Cursor     *EventCursor
+	Limit      int
+	// OldestFirst lists in ascending (ts, chain_seq, event_id) order; Cursor then returns events
+	// strictly newer than it.
+	OldestFirst bool
+}
+
+// Matches reports whether e satisfies the filter's field and time-range conditions
+// (Cursor and Limit are ignored). Used to filter live event streams the same way ListEvents does.
+func (f EventFilter) Matches(e domain.Event) bool {
+	if f.AgentID != nil && e.AgentID != *f.AgentID {
+		return false
+	}
+	if f.SessionID != nil && e.SessionID != *f.SessionID {
+		return false
+	}
+	if len(f.Types) > 0 && !contains(f.Types, e.Type) {
+		return false
+	}
+	if len(f.Severities) > 0 && !contains(f.Severities, e.Severity) {
+		return false
+	}
+	if f.Decision != nil && payloadString(e, "decision") != *f.Decision {
+		return false
+	}
+	if f.ActionType != nil && payloadString(e, "action_type") != *f.ActionType {
+		return false
+	}
+	if f.ActionHash != nil && e.ActionHash != *f.ActionHash {
+		return false
+	}
+	ts := e.TS.UnixMilli()
+	if f.SinceTS != nil && ts < *f.SinceTS {
+		return false
+	}
+	if f.UntilTS != nil && ts >= *f.UntilTS {
+		return false
+	}
+	return true
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func payloadString(e domain.Event, key string) string {
+	v, _ := e.PayloadJSON[key].(string)
+	return v
+}
+
+// EventCursor is a stable pagination position: ListEvents returns events strictly
+// older than (TS, Seq, EventID) in (ts DESC, chain_seq DESC, event_id DESC) order, or strictly
+// newer in ascending order when the filter is OldestFirst. Stores compare TS to the second;
+// within a second the chain position keeps append order. Seq is 0 for events written before
+// chaining, which sort before chained ones and fall back to event_id among themselves.
+type EventCursor struct {
+	TS      time.Time
+	Seq     int64
+	EventID string
+}
+
+// CursorAfter returns the cursor that continues a listing after e.
+func CursorAfter(e domain.Event) *EventCursor {
+	return &EventCursor{TS: e.TS, Seq: e.ChainSeq, EventID: e.EventID}
+}
+
+// Encode returns the opaque cursor string used by the API (?cursor=).
+func (c *EventCursor) Encode() string {
+	raw := c.TS.UTC().Format(time.RFC3339Nano) + "|" + strconv.FormatInt(c.Seq, 10) + "|" + c.EventID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseEventCursor decodes a cursor produced by EventCursor.Encode. Cursors encoded before
+// they carried the chain position ("ts|event_id") parse with Seq 0.
+func ParseEventCursor(s string) (*EventCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	ts, eventID, ok := strings.Cut(string(raw), "|")
+	if !ok || eventID == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	var seq int64
+	if s, id, ok := strings.Cut(eventID, "|"); ok && id != "" {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil && n >= 0 {
+			seq, eventID = n, id
+		}
+	}
+	return &EventCursor{TS: t, Seq: seq, EventID: eventID}, nil
 }
 
 // RuntimeStore holds mutable Ctrl Dot operational state (agents, sessions, limits, events, halt).
@@ -40,6 +151,20 @@ type RuntimeStore interface {
 	ListEvents(ctx context.Context, filter EventFilter) ([]domain.Event, error)
 	GetEvent(ctx context.Context, eventID string) (*domain.Event, error)
 
+	// Event hash chain: AppendEvent links each event to the head. ListChainedEvents returns
+	// events with chain_seq > afterSeq in chain order (see internal/eventchain).
+	EventChainHead(ctx context.Context) (*domain.EventChainHead, error)
+	ListChainedEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.Event, error)
+
+	// Event retention: CompactEvents rolls events older than olderThanTS (unix ms; 0 = no age limit)
+	// or beyond the newest maxRows (0 = no row limit) into hourly per-agent rollups, then deletes them.
+	// record, when set, is the run's events.compacted event: it gets the results
+	// (EventCompaction.Record) and is appended in the same transaction that moves the chain
+	// anchor, so the anchor is never ahead of the chained event recording it.
+	CompactEvents(ctx context.Context, olderThanTS int64, maxRows int, record *domain.Event) (*domain.EventCompaction, error)
+	ListEventRollups(ctx context.Context, filter EventFilter) ([]domain.EventRollup, error)
+	EventStats(ctx context.Context) (*domain.EventStats, error)
+
 	// Agent control
 	HaltAgent(ctx context.Context, agentID string, reason string) error
 	ResumeAgent(ctx context.Context, agentID string) error
@@ -47,4 +172,38 @@ type RuntimeStore interface {
 	// Panic mode (persisted)
 	GetPanicState(ctx context.Context) (*domain.PanicState, error)
 	SetPanicState(ctx context.Context, state domain.PanicState) error
+
+	// Webhook delivery queue. Delivered requests are deleted; exhausted ones are kept with status dead.
+	EnqueueWebhookDelivery(ctx context.Context, d domain.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, filter WebhookFilter) ([]domain.WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, deliveryID string) (*domain.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, d domain.WebhookDelivery) error
+	DeleteWebhookDelivery(ctx context.Context, deliveryID string) error
+	CountWebhookDeliveries(ctx context.Context) (map[string]int64, error) // by status
+
+	// Ledger sink outbox. Enqueue is idempotent per (sink, record_id); delivered records are deleted.
+	EnqueueOutbox(ctx context.Context, r domain.OutboxRecord) error
+	ListOutbox(ctx context.Context, filter OutboxFilter) ([]domain.OutboxRecord, error)
+	UpdateOutbox(ctx context.Context, r domain.OutboxRecord) error
+	DeleteOutbox(ctx context.Context, seq int64) error
+	CountOutbox(ctx context.Context) (map[string]int64, error) // by status
+
+	// Config revisions. AppendConfigRevision assigns Rev; List returns the newest first.
+	AppendConfigRevision(ctx context.Context, r *domain.ConfigRevision) error
+	ListConfigRevisions(ctx context.Context, limit int) ([]domain.ConfigRevision, error)
+	GetConfigRevision(ctx context.Context, rev int64) (*domain.ConfigRevision, error) // nil when not found
+}
+
+// OutboxFilter filters ListOutbox. Results are ordered by seq, oldest first.
+type OutboxFilter struct {
+	Sink   string // empty = any
+	Status string // pending | dead; empty = any
+	Limit  int
+}
+
+// WebhookFilter filters ListWebhookDeliveries. Results are ordered by next_attempt_at, oldest first.
+type WebhookFilter struct {
+	Status    string     // pending | dead; empty = any
+	DueBefore *time.Time // only deliveries with next_attempt_at <= DueBefore
+	Limit     int
 }
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/eventchain"
+	"github.com/lib/pq"
 )
 
 // Ctrl Dot: Agents (non-transactional)
@@ -138,48 +140,224 @@ func (s *PostgresStore) EndSession(ctx context.Context, sessionID string) error
 
 // AppendEvent appends an event without a Kernel operation (op_seq NULL).
 // Use this for runtime-only event log when not using Kernel Plan/Apply.
-// Requires migration 0008 (op_seq nullable).
-func (s *PostgresStore) AppendEvent(ctx context.Context, event domain.Event) error {
+// The event is linked to the hash chain head (event.ChainSeq, PrevHash and Hash are set);
+// the head row is locked so concurrent appends are serialised.
+// Requires migrations 0008 (op_seq nullable) and 0014 (event chain).
+func (s *PostgresStore) AppendEvent(ctx context.Context, event *domain.Event) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+	defer tx.Rollback()
+	if err := appendEventTx(ctx, tx, event); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+	return nil
+}
+
+// appendEventTx links event to the chain head, locking the head row, and inserts it in tx.
+func appendEventTx(ctx context.Context, tx *sql.Tx, event *domain.Event) error {
+	var headSeq int64
+	var headHash string
+	err := tx.QueryRowContext(ctx, `SELECT head_seq, head_hash FROM ctrldot_event_chain WHERE id = 1 FOR UPDATE`).Scan(&headSeq, &headHash)
+	if err != nil {
+		return fmt.Errorf("failed to read event chain head: %w", err)
+	}
+	if err := eventchain.Link(event, headSeq, headHash); err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
 	payloadJSON, _ := json.Marshal(event.PayloadJSON)
 	var sessionID interface{} = nil
 	if event.SessionID != "" {
 		sessionID = event.SessionID
 	}
-	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO ctrldot_events (event_id, op_seq, event_type, agent_id, session_id, severity, payload_json, action_hash, cost_gbp, cost_tokens, created_at)
-		 VALUES ($1, NULL, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO ctrldot_events (event_id, op_seq, event_type, agent_id, session_id, severity, payload_json, action_hash, cost_gbp, cost_tokens, created_at, chain_seq, prev_hash, hash)
+		 VALUES ($1, NULL, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
 		event.EventID, event.Type, event.AgentID, sessionID, event.Severity,
 		payloadJSON, event.ActionHash, event.CostGBP, event.CostTokens, event.TS,
+		event.ChainSeq, event.PrevHash, event.Hash,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to append event: %w", err)
 	}
+	_, err = tx.ExecContext(ctx, `UPDATE ctrldot_event_chain SET head_seq = $1, head_hash = $2 WHERE id = 1`, event.ChainSeq, event.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to advance event chain head: %w", err)
+	}
 	return nil
 }
 
+// EventChainHead returns the event hash chain head and compaction anchor
+func (s *PostgresStore) EventChainHead(ctx context.Context) (*domain.EventChainHead, error) {
+	var h domain.EventChainHead
+	err := s.db.QueryRowContext(ctx,
+		`SELECT head_seq, head_hash, anchor_seq, anchor_hash, (SELECT COUNT(*) FROM ctrldot_events WHERE chain_seq IS NULL)
+		 FROM ctrldot_event_chain WHERE id = 1`,
+	).Scan(&h.HeadSeq, &h.HeadHash, &h.AnchorSeq, &h.AnchorHash, &h.Unchained)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event chain head: %w", err)
+	}
+	return &h, nil
+}
+
+// ListChainedEvents retrieves chained events with chain_seq > afterSeq, in chain order
+func (s *PostgresStore) ListChainedEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.Event, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+eventColumns+` FROM ctrldot_events WHERE chain_seq > $1 ORDER BY chain_seq LIMIT $2`,
+		afterSeq, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chained events: %w", err)
+	}
+	defer rows.Close()
+	var events []domain.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, *event)
+	}
+	return events, rows.Err()
+}
+
+const eventColumns = `event_id, op_seq, event_type, agent_id, session_id, severity, payload_json, action_hash, cost_gbp, cost_tokens, created_at, chain_seq, prev_hash, hash`
+
+func scanEvent(row interface{ Scan(...interface{}) error }) (*domain.Event, error) {
+	var event domain.Event
+	var payloadJSON []byte
+	var actionHash, prevHash, hash sql.NullString
+	var costGBP sql.NullFloat64
+	var costTokens, chainSeq sql.NullInt64
+	var sessionID sql.NullString
+	var opSeq sql.NullInt64
+	err := row.Scan(&event.EventID, &opSeq, &event.Type, &event.AgentID, &sessionID,
+		&event.Severity, &payloadJSON, &actionHash, &costGBP, &costTokens, &event.TS, &chainSeq, &prevHash, &hash)
+	if err != nil {
+		return nil, err
+	}
+	if sessionID.Valid {
+		event.SessionID = sessionID.String
+	}
+	if len(payloadJSON) > 0 {
+		json.Unmarshal(payloadJSON, &event.PayloadJSON)
+	}
+	if actionHash.Valid {
+		event.ActionHash = actionHash.String
+	}
+	if costGBP.Valid {
+		event.CostGBP = &costGBP.Float64
+	}
+	if costTokens.Valid {
+		event.CostTokens = &costTokens.Int64
+	}
+	event.ChainSeq = chainSeq.Int64
+	event.PrevHash = prevHash.String
+	event.Hash = hash.String
+	return &event, nil
+}
+
+// EventQuery filters Ctrl Dot events for QueryEvents. All set fields must match.
+// Results are ordered newest first by (created_at, chain_seq, event_id), or oldest first with
+// OldestFirst; when CursorTS is set only events strictly older (newer) than (CursorTS,
+// CursorSeq, CursorEventID) are returned. Events written before chaining have chain_seq 0 here.
+type EventQuery struct {
+	AgentID       *string
+	SessionID     *string
+	Types         []string
+	Severities    []string
+	Decision      *string
+	ActionType    *string
+	ActionHash    *string
+	SinceTS       *int64 // unix milliseconds, inclusive
+	UntilTS       *int64 // unix milliseconds, exclusive
+	CursorTS      *time.Time
+	CursorSeq     int64
+	CursorEventID string
+	Limit         int
+	OldestFirst   bool
+}
+
 // GetEvents retrieves events with optional filtering
 func (s *PostgresStore) GetEvents(ctx context.Context, agentID *string, sinceTS *int64, limit int) ([]domain.Event, error) {
-	query := `SELECT event_id, op_seq, event_type, agent_id, session_id, severity, payload_json, action_hash, cost_gbp, cost_tokens, created_at
+	return s.QueryEvents(ctx, EventQuery{AgentID: agentID, SinceTS: sinceTS, Limit: limit})
+}
+
+// QueryEvents retrieves events matching q
+func (s *PostgresStore) QueryEvents(ctx context.Context, q EventQuery) ([]domain.Event, error) {
+	query := `SELECT ` + eventColumns + `
 			  FROM ctrldot_events
 			  WHERE 1=1`
 	args := []interface{}{}
 	argIdx := 1
 
-	if agentID != nil {
+	if q.AgentID != nil {
 		query += fmt.Sprintf(" AND agent_id = $%d", argIdx)
-		args = append(args, *agentID)
+		args = append(args, *q.AgentID)
+		argIdx++
+	}
+	if q.SessionID != nil {
+		query += fmt.Sprintf(" AND session_id = $%d", argIdx)
+		args = append(args, *q.SessionID)
+		argIdx++
+	}
+	if len(q.Types) > 0 {
+		query += fmt.Sprintf(" AND event_type = ANY($%d)", argIdx)
+		args = append(args, pq.Array(q.Types))
 		argIdx++
 	}
-	if sinceTS != nil {
+	if len(q.Severities) > 0 {
+		query += fmt.Sprintf(" AND severity = ANY($%d)", argIdx)
+		args = append(args, pq.Array(q.Severities))
+		argIdx++
+	}
+	if q.Decision != nil {
+		query += fmt.Sprintf(" AND payload_json->>'decision' = $%d", argIdx)
+		args = append(args, *q.Decision)
+		argIdx++
+	}
+	if q.ActionType != nil {
+		query += fmt.Sprintf(" AND payload_json->>'action_type' = $%d", argIdx)
+		args = append(args, *q.ActionType)
+		argIdx++
+	}
+	if q.ActionHash != nil {
+		query += fmt.Sprintf(" AND action_hash = $%d", argIdx)
+		args = append(args, *q.ActionHash)
+		argIdx++
+	}
+	if q.SinceTS != nil {
 		query += fmt.Sprintf(" AND EXTRACT(EPOCH FROM created_at) * 1000 >= $%d", argIdx)
-		args = append(args, *sinceTS)
+		args = append(args, *q.SinceTS)
+		argIdx++
+	}
+	if q.UntilTS != nil {
+		query += fmt.Sprintf(" AND EXTRACT(EPOCH FROM created_at) * 1000 < $%d", argIdx)
+		args = append(args, *q.UntilTS)
 		argIdx++
 	}
+	if q.CursorTS != nil {
+		op := "<"
+		if q.OldestFirst {
+			op = ">"
+		}
+		query += fmt.Sprintf(" AND (created_at, COALESCE(chain_seq, 0), event_id) %s ($%d, $%d, $%d)", op, argIdx, argIdx+1, argIdx+2)
+		args = append(args, *q.CursorTS, q.CursorSeq, q.CursorEventID)
+		argIdx += 3
+	}
 
-	query += " ORDER BY created_at DESC"
-	if limit > 0 {
+	if q.OldestFirst {
+		query += " ORDER BY created_at, COALESCE(chain_seq, 0), event_id"
+	} else {
+		query += " ORDER BY created_at DESC, COALESCE(chain_seq, 0) DESC, event_id DESC"
+	}
+	if q.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", argIdx)
-		args = append(args, limit)
+		args = append(args, q.Limit)
 	}
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
@@ -190,76 +368,30 @@ func (s *PostgresStore) GetEvents(ctx context.Context, agentID *string, sinceTS
 
 	var events []domain.Event
 	for rows.Next() {
-		var event domain.Event
-		var payloadJSON []byte
-		var actionHash sql.NullString
-		var costGBP sql.NullFloat64
-		var costTokens sql.NullInt64
-		var sessionID sql.NullString
-		var opSeq sql.NullInt64
-		err := rows.Scan(&event.EventID, &opSeq, &event.Type, &event.AgentID, &sessionID,
-			&event.Severity, &payloadJSON, &actionHash, &costGBP, &costTokens, &event.TS)
+		event, err := scanEvent(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan event: %w", err)
 		}
-		if sessionID.Valid {
-			event.SessionID = sessionID.String
-		}
-		if len(payloadJSON) > 0 {
-			json.Unmarshal(payloadJSON, &event.PayloadJSON)
-		}
-		if actionHash.Valid {
-			event.ActionHash = actionHash.String
-		}
-		if costGBP.Valid {
-			event.CostGBP = &costGBP.Float64
-		}
-		if costTokens.Valid {
-			event.CostTokens = &costTokens.Int64
-		}
-		events = append(events, event)
+		events = append(events, *event)
 	}
 	return events, nil
 }
 
 // GetEvent retrieves a single event
 func (s *PostgresStore) GetEvent(ctx context.Context, eventID string) (*domain.Event, error) {
-	var event domain.Event
-	var payloadJSON []byte
-	var actionHash sql.NullString
-	var costGBP sql.NullFloat64
-	var costTokens sql.NullInt64
-	var sessionID sql.NullString
-	var opSeq sql.NullInt64
-	err := s.db.QueryRowContext(ctx,
-		`SELECT event_id, op_seq, event_type, agent_id, session_id, severity, payload_json, action_hash, cost_gbp, cost_tokens, created_at
+	event, err := scanEvent(s.db.QueryRowContext(ctx,
+		`SELECT `+eventColumns+`
 		 FROM ctrldot_events
 		 WHERE event_id = $1`,
 		eventID,
-	).Scan(&event.EventID, &opSeq, &event.Type, &event.AgentID, &sessionID,
-		&event.Severity, &payloadJSON, &actionHash, &costGBP, &costTokens, &event.TS)
+	))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, fmt.Errorf("failed to get event: %w", err)
 	}
-	if sessionID.Valid {
-		event.SessionID = sessionID.String
-	}
-	if len(payloadJSON) > 0 {
-		json.Unmarshal(payloadJSON, &event.PayloadJSON)
-	}
-	if actionHash.Valid {
-		event.ActionHash = actionHash.String
-	}
-	if costGBP.Valid {
-		event.CostGBP = &costGBP.Float64
-	}
-	if costTokens.Valid {
-		event.CostTokens = &costTokens.Int64
-	}
-	return &event, nil
+	return event, nil
 }
 
 // Ctrl Dot: Limits State (non-transactional)
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+// Ctrl Dot: Config revisions (requires migration 0015)
+
+const configRevisionColumns = `rev, content_hash, author, trigger, rollback_of, changes, content, created_at`
+
+// AppendConfigRevision inserts an applied config revision and sets r.Rev
+func (s *PostgresStore) AppendConfigRevision(ctx context.Context, r *domain.ConfigRevision) error {
+	changes, err := json.Marshal(r.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to append config revision: %w", err)
+	}
+	err = s.db.QueryRowContext(ctx,
+		`INSERT INTO ctrldot_config_revisions (content_hash, author, trigger, rollback_of, changes, content, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING rev`,
+		r.ContentHash, r.Author, r.Trigger, r.RollbackOf, string(changes), r.Content, r.CreatedAt,
+	).Scan(&r.Rev)
+	if err != nil {
+		return fmt.Errorf("failed to append config revision: %w", err)
+	}
+	return nil
+}
+
+// ListConfigRevisions retrieves config revisions, newest first
+func (s *PostgresStore) ListConfigRevisions(ctx context.Context, limit int) ([]domain.ConfigRevision, error) {
+	query := `SELECT ` + configRevisionColumns + ` FROM ctrldot_config_revisions ORDER BY rev DESC`
+	args := []interface{}{}
+	if limit > 0 {
+		query += " LIMIT $1"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query config revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.ConfigRevision
+	for rows.Next() {
+		r, err := scanConfigRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+// GetConfigRevision retrieves a single config revision
+func (s *PostgresStore) GetConfigRevision(ctx context.Context, rev int64) (*domain.ConfigRevision, error) {
+	r, err := scanConfigRevision(s.db.QueryRowContext(ctx,
+		`SELECT `+configRevisionColumns+` FROM ctrldot_config_revisions WHERE rev = $1`, rev))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+func scanConfigRevision(row interface{ Scan(...interface{}) error }) (*domain.ConfigRevision, error) {
+	var r domain.ConfigRevision
+	var changes []byte
+	if err := row.Scan(&r.Rev, &r.ContentHash, &r.Author, &r.Trigger, &r.RollbackOf, &changes, &r.Content, &r.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan config revision: %w", err)
+	}
+	if err := json.Unmarshal(changes, &r.Changes); err != nil {
+		return nil, fmt.Errorf("failed to decode config revision changes: %w", err)
+	}
+	return &r, nil
+}
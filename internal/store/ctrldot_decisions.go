@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+// Ctrl Dot: decision records received from kernel_http sinks (requires migration 0013)
+
+const decisionRecordColumns = `id, agent_id, session_id, decision, action_type, source, ts, received_at, record`
+
+// DecisionRecordQuery filters QueryCtrlDotDecisions. Results are newest first.
+type DecisionRecordQuery struct {
+	AgentID    string
+	SessionID  string
+	Decision   string
+	ActionType string
+	Source     string
+	Since      *time.Time // inclusive
+	Until      *time.Time // exclusive
+	Limit      int
+}
+
+// StoreCtrlDotDecision inserts a decision record. A record whose ID is already stored is
+// left unchanged and inserted is false, so redelivered records are harmless.
+func (s *PostgresStore) StoreCtrlDotDecision(ctx context.Context, r domain.CtrlDotDecisionRecord) (inserted bool, err error) {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO ctrldot_decision_records (`+decisionRecordColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 ON CONFLICT (id) DO NOTHING`,
+		r.ID, r.AgentID, r.SessionID, r.Decision, r.ActionType, r.Source, r.Timestamp, r.ReceivedAt, []byte(r.Record),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to store decision record: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// GetCtrlDotDecision retrieves a decision record by ID
+func (s *PostgresStore) GetCtrlDotDecision(ctx context.Context, id string) (*domain.CtrlDotDecisionRecord, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+decisionRecordColumns+` FROM ctrldot_decision_records WHERE id = $1`, id)
+	r, err := scanDecisionRecord(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrCtrlDotDecisionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decision record: %w", err)
+	}
+	return r, nil
+}
+
+// QueryCtrlDotDecisions lists decision records matching q, newest first
+func (s *PostgresStore) QueryCtrlDotDecisions(ctx context.Context, q DecisionRecordQuery) ([]domain.CtrlDotDecisionRecord, error) {
+	query := `SELECT ` + decisionRecordColumns + ` FROM ctrldot_decision_records WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+	for _, f := range []struct{ col, val string }{
+		{"agent_id", q.AgentID},
+		{"session_id", q.SessionID},
+		{"decision", q.Decision},
+		{"action_type", q.ActionType},
+		{"source", q.Source},
+	} {
+		if f.val == "" {
+			continue
+		}
+		query += fmt.Sprintf(" AND %s = $%d", f.col, argIdx)
+		args = append(args, f.val)
+		argIdx++
+	}
+	if q.Since != nil {
+		query += fmt.Sprintf(" AND ts >= $%d", argIdx)
+		args = append(args, *q.Since)
+		argIdx++
+	}
+	if q.Until != nil {
+		query += fmt.Sprintf(" AND ts < $%d", argIdx)
+		args = append(args, *q.Until)
+		argIdx++
+	}
+	query += " ORDER BY ts DESC, id DESC"
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argIdx)
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query decision records: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.CtrlDotDecisionRecord
+	for rows.Next() {
+		r, err := scanDecisionRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan decision record: %w", err)
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+func scanDecisionRecord(row interface{ Scan(...interface{}) error }) (*domain.CtrlDotDecisionRecord, error) {
+	var r domain.CtrlDotDecisionRecord
+	var record []byte
+	if err := row.Scan(&r.ID, &r.AgentID, &r.SessionID, &r.Decision, &r.ActionType, &r.Source, &r.Timestamp, &r.ReceivedAt, &record); err != nil {
+		return nil, err
+	}
+	r.Record = record
+	return &r, nil
+}
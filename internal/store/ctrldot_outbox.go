@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+// Ctrl Dot: Ledger sink outbox (requires migration 0012)
+
+const outboxColumns = `seq, sink, record_id, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at`
+
+// EnqueueOutbox inserts an undelivered ledger record; a record already queued for the sink is left as is
+func (s *PostgresStore) EnqueueOutbox(ctx context.Context, r domain.OutboxRecord) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO ctrldot_ledger_outbox (sink, record_id, payload, status, attempts, next_attempt_at, last_error, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (sink, record_id) DO NOTHING`,
+		r.Sink, r.RecordID, r.Payload, r.Status, r.Attempts, r.NextAttemptAt, r.LastError, r.CreatedAt, r.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue outbox record: %w", err)
+	}
+	return nil
+}
+
+// ListOutbox retrieves outbox records in seq order. sink and status may be empty.
+func (s *PostgresStore) ListOutbox(ctx context.Context, sink, status string, limit int) ([]domain.OutboxRecord, error) {
+	query := `SELECT ` + outboxColumns + ` FROM ctrldot_ledger_outbox WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if sink != "" {
+		query += fmt.Sprintf(" AND sink = $%d", argIdx)
+		args = append(args, sink)
+		argIdx++
+	}
+	if status != "" {
+		query += fmt.Sprintf(" AND status = $%d", argIdx)
+		args = append(args, status)
+		argIdx++
+	}
+	query += " ORDER BY seq"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argIdx)
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.OutboxRecord
+	for rows.Next() {
+		var r domain.OutboxRecord
+		if err := rows.Scan(&r.Seq, &r.Sink, &r.RecordID, &r.Payload, &r.Status, &r.Attempts,
+			&r.NextAttemptAt, &r.LastError, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox record: %w", err)
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// UpdateOutbox updates the retry state of an outbox record
+func (s *PostgresStore) UpdateOutbox(ctx context.Context, r domain.OutboxRecord) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE ctrldot_ledger_outbox SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, updated_at = $5 WHERE seq = $6`,
+		r.Status, r.Attempts, r.NextAttemptAt, r.LastError, r.UpdatedAt, r.Seq,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update outbox record: %w", err)
+	}
+	return nil
+}
+
+// DeleteOutbox removes a delivered outbox record
+func (s *PostgresStore) DeleteOutbox(ctx context.Context, seq int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM ctrldot_ledger_outbox WHERE seq = $1`, seq); err != nil {
+		return fmt.Errorf("failed to delete outbox record: %w", err)
+	}
+	return nil
+}
+
+// CountOutbox returns outbox records by status
+func (s *PostgresStore) CountOutbox(ctx context.Context) (map[string]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM ctrldot_ledger_outbox GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count outbox: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]int64)
+	for rows.Next() {
+		var status string
+		var n int64
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox count: %w", err)
+		}
+		out[status] = n
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+// Ctrl Dot: Event retention (requires migrations 0010 and 0014)
+
+// CompactEvents rolls events older than olderThanTS (unix ms) or beyond the newest maxRows
+// into hourly per-agent rollups and deletes them, in a single statement. Only a prefix of the
+// hash chain is pruned; the newest pruned chained event becomes the chain anchor. record, when
+// set, gets the results and is appended in the same transaction.
+func (s *PostgresStore) CompactEvents(ctx context.Context, olderThanTS int64, maxRows int, record *domain.Event) (*domain.EventCompaction, error) {
+	start := time.Now()
+	var conds []string
+	var args []interface{}
+	argIdx := 1
+	if olderThanTS > 0 {
+		conds = append(conds, fmt.Sprintf("created_at < $%d", argIdx))
+		args = append(args, time.UnixMilli(olderThanTS))
+		argIdx++
+	}
+	if maxRows > 0 {
+		conds = append(conds, fmt.Sprintf("event_id IN (SELECT event_id FROM ctrldot_events ORDER BY created_at DESC OFFSET $%d)", argIdx))
+		args = append(args, maxRows)
+		argIdx++
+	}
+	out := &domain.EventCompaction{MaxRows: maxRows}
+	if olderThanTS > 0 {
+		cutoff := time.UnixMilli(olderThanTS)
+		out.Cutoff = &cutoff
+	}
+	if len(conds) == 0 {
+		if record != nil {
+			out.Record(record)
+			return out, s.AppendEvent(ctx, record)
+		}
+		return out, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compact events: %w", err)
+	}
+	defer tx.Rollback()
+	err = tx.QueryRowContext(ctx, `SELECT anchor_seq, anchor_hash FROM ctrldot_event_chain WHERE id = 1 FOR UPDATE`).Scan(&out.AnchorSeq, &out.AnchorHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event chain anchor: %w", err)
+	}
+	var anchorSeq sql.NullInt64
+	var anchorHash sql.NullString
+	err = tx.QueryRowContext(ctx,
+		`SELECT chain_seq, hash FROM ctrldot_events
+		 WHERE chain_seq = (SELECT MAX(chain_seq) FROM ctrldot_events WHERE `+strings.Join(conds, " OR ")+`)`,
+		args...,
+	).Scan(&anchorSeq, &anchorHash)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to find event chain anchor: %w", err)
+	}
+	if anchorSeq.Valid {
+		conds = append(conds, fmt.Sprintf("chain_seq <= $%d", argIdx))
+		args = append(args, anchorSeq.Int64)
+		out.AnchorSeq, out.AnchorHash = anchorSeq.Int64, anchorHash.String
+		_, err = tx.ExecContext(ctx, `UPDATE ctrldot_event_chain SET anchor_seq = $1, anchor_hash = $2 WHERE id = 1`, out.AnchorSeq, out.AnchorHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record event chain anchor: %w", err)
+		}
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`WITH pruned AS (
+		   DELETE FROM ctrldot_events WHERE `+strings.Join(conds, " OR ")+`
+		   RETURNING agent_id, created_at, event_type, payload_json, cost_gbp, cost_tokens
+		 ), upserted AS (
+		   INSERT INTO ctrldot_event_rollups (agent_id, hour_start, event_type, decision, event_count, cost_gbp, cost_tokens)
+		   SELECT COALESCE(agent_id, ''), date_trunc('hour', created_at), event_type,
+		          COALESCE(payload_json->>'decision', ''),
+		          COUNT(*), COALESCE(SUM(cost_gbp), 0), COALESCE(SUM(cost_tokens), 0)
+		   FROM pruned
+		   GROUP BY 1, 2, 3, 4
+		   ON CONFLICT (agent_id, hour_start, event_type, decision) DO UPDATE SET
+		     event_count = ctrldot_event_rollups.event_count + EXCLUDED.event_count,
+		     cost_gbp = ctrldot_event_rollups.cost_gbp + EXCLUDED.cost_gbp,
+		     cost_tokens = ctrldot_event_rollups.cost_tokens + EXCLUDED.cost_tokens
+		   RETURNING 1
+		 )
+		 SELECT (SELECT COUNT(*) FROM pruned), (SELECT COUNT(*) FROM upserted)`,
+		args...,
+	).Scan(&out.Deleted, &out.RollupsUpserted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compact events: %w", err)
+	}
+	out.DurationMs = time.Since(start).Milliseconds()
+	if record != nil {
+		out.Record(record)
+		if err := appendEventTx(ctx, tx, record); err != nil {
+			return nil, fmt.Errorf("failed to record compaction: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to compact events: %w", err)
+	}
+	return out, nil
+}
+
+// GetEventRollups retrieves hourly event rollups with optional filtering
+func (s *PostgresStore) GetEventRollups(ctx context.Context, agentID *string, sinceTS *int64, limit int) ([]domain.EventRollup, error) {
+	query := `SELECT agent_id, hour_start, event_type, decision, event_count, cost_gbp, cost_tokens
+			  FROM ctrldot_event_rollups
+			  WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if agentID != nil {
+		query += fmt.Sprintf(" AND agent_id = $%d", argIdx)
+		args = append(args, *agentID)
+		argIdx++
+	}
+	if sinceTS != nil {
+		query += fmt.Sprintf(" AND EXTRACT(EPOCH FROM hour_start) * 1000 >= $%d", argIdx)
+		args = append(args, *sinceTS)
+		argIdx++
+	}
+
+	query += " ORDER BY hour_start DESC, agent_id, event_type, decision"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argIdx)
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event rollups: %w", err)
+	}
+	defer rows.Close()
+
+	var rollups []domain.EventRollup
+	for rows.Next() {
+		var r domain.EventRollup
+		if err := rows.Scan(&r.AgentID, &r.HourStart, &r.EventType, &r.Decision, &r.EventCount, &r.CostGBP, &r.CostTokens); err != nil {
+			return nil, fmt.Errorf("failed to scan event rollup: %w", err)
+		}
+		rollups = append(rollups, r)
+	}
+	return rollups, rows.Err()
+}
+
+// EventStats summarises the event log and the most recent compaction run
+func (s *PostgresStore) EventStats(ctx context.Context) (*domain.EventStats, error) {
+	st := &domain.EventStats{}
+	var oldest sql.NullTime
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*), MIN(created_at) FROM ctrldot_events`).Scan(&st.EventCount, &oldest); err != nil {
+		return nil, fmt.Errorf("failed to get event stats: %w", err)
+	}
+	if oldest.Valid {
+		st.OldestEventAt = &oldest.Time
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM ctrldot_event_rollups`).Scan(&st.RollupCount); err != nil {
+		return nil, fmt.Errorf("failed to get event stats: %w", err)
+	}
+	var lastID string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT event_id FROM ctrldot_events WHERE event_type = $1 ORDER BY created_at DESC LIMIT 1`,
+		domain.EventTypeEventsCompacted,
+	).Scan(&lastID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get event stats: %w", err)
+	}
+	if lastID != "" {
+		if st.LastCompaction, err = s.GetEvent(ctx, lastID); err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
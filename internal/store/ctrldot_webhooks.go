@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+// Ctrl Dot: Webhook delivery queue (requires migration 0011)
+
+const webhookColumns = `delivery_id, endpoint, event_id, event_type, body, status, attempts, next_attempt_at, last_error, last_status_code, created_at, updated_at`
+
+// EnqueueWebhookDelivery inserts a webhook delivery into the queue
+func (s *PostgresStore) EnqueueWebhookDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO ctrldot_webhook_deliveries (`+webhookColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		d.DeliveryID, d.Endpoint, d.EventID, d.EventType, d.Body, d.Status, d.Attempts,
+		d.NextAttemptAt, d.LastError, d.LastStatusCode, d.CreatedAt, d.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries retrieves queued webhook deliveries, oldest next_attempt_at first.
+// status may be empty; dueBefore may be nil.
+func (s *PostgresStore) ListWebhookDeliveries(ctx context.Context, status string, dueBefore *time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookColumns + ` FROM ctrldot_webhook_deliveries WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if status != "" {
+		query += fmt.Sprintf(" AND status = $%d", argIdx)
+		args = append(args, status)
+		argIdx++
+	}
+	if dueBefore != nil {
+		query += fmt.Sprintf(" AND next_attempt_at <= $%d", argIdx)
+		args = append(args, *dueBefore)
+		argIdx++
+	}
+
+	query += " ORDER BY next_attempt_at, created_at"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argIdx)
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.WebhookDelivery
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		if err := rows.Scan(&d.DeliveryID, &d.Endpoint, &d.EventID, &d.EventType, &d.Body, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastError, &d.LastStatusCode, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// GetWebhookDelivery retrieves a single webhook delivery
+func (s *PostgresStore) GetWebhookDelivery(ctx context.Context, deliveryID string) (*domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	err := s.db.QueryRowContext(ctx,
+		`SELECT `+webhookColumns+` FROM ctrldot_webhook_deliveries WHERE delivery_id = $1`, deliveryID,
+	).Scan(&d.DeliveryID, &d.Endpoint, &d.EventID, &d.EventType, &d.Body, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastError, &d.LastStatusCode, &d.CreatedAt, &d.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return &d, nil
+}
+
+// UpdateWebhookDelivery updates the retry state of a webhook delivery
+func (s *PostgresStore) UpdateWebhookDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE ctrldot_webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, last_status_code = $5, updated_at = $6 WHERE delivery_id = $7`,
+		d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.LastStatusCode, d.UpdatedAt, d.DeliveryID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhookDelivery removes a webhook delivery from the queue
+func (s *PostgresStore) DeleteWebhookDelivery(ctx context.Context, deliveryID string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM ctrldot_webhook_deliveries WHERE delivery_id = $1`, deliveryID); err != nil {
+		return fmt.Errorf("failed to delete webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// CountWebhookDeliveries returns queued webhook deliveries by status
+func (s *PostgresStore) CountWebhookDeliveries(ctx context.Context) (map[string]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM ctrldot_webhook_deliveries GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]int64)
+	for rows.Next() {
+		var status string
+		var n int64
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook count: %w", err)
+		}
+		out[status] = n
+	}
+	return out, rows.Err()
+}
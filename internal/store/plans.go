@@ -101,14 +101,20 @@ func (t *PostgresTx) IsPlanApplied(ctx context.Context, planID string) (bool, er
 	return appliedSeq.Valid, nil
 }
 
-// MarkPlanApplied marks a plan as applied
+// MarkPlanApplied marks a plan as applied. Only an unapplied plan is marked, so of two
+// concurrent applies of the same plan one fails here and its transaction rolls back.
 func (t *PostgresTx) MarkPlanApplied(ctx context.Context, planID string, opID string, seq int64) error {
-	_, err := t.tx.ExecContext(ctx,
-		`UPDATE plans SET applied_op_id = $1, applied_seq = $2 WHERE plan_id = $3`,
+	res, err := t.tx.ExecContext(ctx,
+		`UPDATE plans SET applied_op_id = $1, applied_seq = $2 WHERE plan_id = $3 AND applied_seq IS NULL`,
 		opID, seq, planID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to mark plan as applied: %w", err)
 	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to mark plan as applied: %w", err)
+	} else if n != 1 {
+		return fmt.Errorf("plan %s already applied", planID)
+	}
 	return nil
 }
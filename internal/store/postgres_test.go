@@ -0,0 +1,26 @@
+package store_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/futurematic/kernel/internal/store"
+	"github.com/futurematic/kernel/internal/store/storetest"
+)
+
+// TestPostgresConformance runs the store conformance suite against a migrated database
+// (make migrate) when DB_URL is set.
+func TestPostgresConformance(t *testing.T) {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		t.Skip("DB_URL not set")
+	}
+	storetest.Run(t, func(t *testing.T) store.Store {
+		st, err := store.NewPostgresStore(dbURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { st.Close() })
+		return st
+	})
+}
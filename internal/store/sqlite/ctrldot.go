@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/runtime"
+	"github.com/futurematic/kernel/internal/store"
+)
+
+// The Ctrl Dot rows share the runtime SQLite formats (RFC3339 timestamps, unix-ms limit
+// windows), so the runtime store can read what a Kernel transaction wrote and vice versa.
+
+// Ctrl Dot: Events (store.Store signatures over the runtime store)
+
+// GetEvents retrieves events with optional filtering
+func (s *Store) GetEvents(ctx context.Context, agentID *string, sinceTS *int64, limit int) ([]domain.Event, error) {
+	return s.QueryEvents(ctx, store.EventQuery{AgentID: agentID, SinceTS: sinceTS, Limit: limit})
+}
+
+// QueryEvents retrieves events matching q
+func (s *Store) QueryEvents(ctx context.Context, q store.EventQuery) ([]domain.Event, error) {
+	filter := runtime.EventFilter{
+		AgentID:     q.AgentID,
+		SessionID:   q.SessionID,
+		Types:       q.Types,
+		Severities:  q.Severities,
+		Decision:    q.Decision,
+		ActionType:  q.ActionType,
+		ActionHash:  q.ActionHash,
+		SinceTS:     q.SinceTS,
+		UntilTS:     q.UntilTS,
+		Limit:       q.Limit,
+		OldestFirst: q.OldestFirst,
+	}
+	if q.CursorTS != nil {
+		filter.Cursor = &runtime.EventCursor{TS: *q.CursorTS, Seq: q.CursorSeq, EventID: q.CursorEventID}
+	}
+	return s.Store.ListEvents(ctx, filter)
+}
+
+// GetEventRollups lists compacted event rollups, newest first
+func (s *Store) GetEventRollups(ctx context.Context, agentID *string, sinceTS *int64, limit int) ([]domain.EventRollup, error) {
+	return s.Store.ListEventRollups(ctx, runtime.EventFilter{AgentID: agentID, SinceTS: sinceTS, Limit: limit})
+}
+
+// ListWebhookDeliveries lists queued webhook deliveries, oldest due first
+func (s *Store) ListWebhookDeliveries(ctx context.Context, status string, dueBefore *time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	return s.Store.ListWebhookDeliveries(ctx, runtime.WebhookFilter{Status: status, DueBefore: dueBefore, Limit: limit})
+}
+
+// ListOutbox lists outbox records in seq order
+func (s *Store) ListOutbox(ctx context.Context, sink, status string, limit int) ([]domain.OutboxRecord, error) {
+	return s.Store.ListOutbox(ctx, runtime.OutboxFilter{Sink: sink, Status: status, Limit: limit})
+}
+
+// Ctrl Dot: transactional variants
+
+// CreateAgentTx creates an agent within a transaction
+func (t *Tx) CreateAgentTx(ctx context.Context, agent domain.Agent) error {
+	_, err := t.tx.ExecContext(ctx,
+		`INSERT INTO ctrldot_agents (agent_id, display_name, created_at, default_mode) VALUES (?, ?, ?, ?) ON CONFLICT (agent_id) DO NOTHING`,
+		agent.AgentID, agent.DisplayName, agent.CreatedAt.Format(time.RFC3339), agent.DefaultMode,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create agent: %w", err)
+	}
+	return nil
+}
+
+// CreateSessionTx creates a session within a transaction
+func (t *Tx) CreateSessionTx(ctx context.Context, session domain.Session) error {
+	metadataJSON, _ := json.Marshal(session.Metadata)
+	var endedAt interface{}
+	if session.EndedAt != nil {
+		endedAt = session.EndedAt.Format(time.RFC3339)
+	}
+	_, err := t.tx.ExecContext(ctx,
+		`INSERT INTO ctrldot_sessions (session_id, agent_id, started_at, ended_at, metadata_json) VALUES (?, ?, ?, ?, ?)`,
+		session.SessionID, session.AgentID, session.StartedAt.Format(time.RFC3339), endedAt, string(metadataJSON),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// EndSessionTx ends a session within a transaction
+func (t *Tx) EndSessionTx(ctx context.Context, sessionID string) error {
+	_, err := t.tx.ExecContext(ctx,
+		`UPDATE ctrldot_sessions SET ended_at = ? WHERE session_id = ?`,
+		time.Now().UTC().Format(time.RFC3339), sessionID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to end session: %w", err)
+	}
+	return nil
+}
+
+// AppendEventTx appends an event recorded by the Kernel operation at opSeq
+func (t *Tx) AppendEventTx(ctx context.Context, event domain.Event, opSeq int64) error {
+	payloadJSON, _ := json.Marshal(event.PayloadJSON)
+	// Use NULL for empty session_id to avoid foreign key constraint violation
+	var sessionID interface{}
+	if event.SessionID != "" {
+		sessionID = event.SessionID
+	}
+	_, err := t.tx.ExecContext(ctx,
+		`INSERT INTO ctrldot_events (event_id, op_seq, event_type, agent_id, session_id, severity, payload_json, action_hash, cost_gbp, cost_tokens, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.EventID, opSeq, event.Type, event.AgentID, sessionID, event.Severity,
+		string(payloadJSON), event.ActionHash, event.CostGBP, event.CostTokens, event.TS.Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+	return nil
+}
+
+// UpdateLimitsStateTx updates limits state within a transaction
+func (t *Tx) UpdateLimitsStateTx(ctx context.Context, state domain.LimitsState) error {
+	_, err := t.tx.ExecContext(ctx,
+		`INSERT INTO ctrldot_limits_state (agent_id, window_start, window_type, budget_spent_gbp, budget_spent_tokens, action_count) VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (agent_id, window_start, window_type) DO UPDATE SET budget_spent_gbp = excluded.budget_spent_gbp, budget_spent_tokens = excluded.budget_spent_tokens, action_count = excluded.action_count`,
+		state.AgentID, state.WindowStart.Unix()*1000, state.WindowType, state.BudgetSpentGBP, state.BudgetSpentTokens, state.ActionCount,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update limits state: %w", err)
+	}
+	return nil
+}
+
+// HaltAgentTx halts an agent within a transaction
+func (t *Tx) HaltAgentTx(ctx context.Context, agentID string, reason string) error {
+	_, err := t.tx.ExecContext(ctx,
+		`INSERT INTO ctrldot_halted_agents (agent_id, reason, halted_at) VALUES (?, ?, ?)
+		 ON CONFLICT (agent_id) DO UPDATE SET reason = excluded.reason, halted_at = excluded.halted_at`,
+		agentID, reason, time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to halt agent: %w", err)
+	}
+	return nil
+}
+
+// ResumeAgentTx resumes a halted agent within a transaction
+func (t *Tx) ResumeAgentTx(ctx context.Context, agentID string) error {
+	_, err := t.tx.ExecContext(ctx, `DELETE FROM ctrldot_halted_agents WHERE agent_id = ?`, agentID)
+	if err != nil {
+		return fmt.Errorf("failed to resume agent: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/store"
+)
+
+const decisionRecordColumns = `id, agent_id, session_id, decision, action_type, source, ts, received_at, record`
+
+// StoreCtrlDotDecision inserts a decision record. A record whose ID is already stored is
+// left unchanged and inserted is false, so redelivered records are harmless.
+func (s *Store) StoreCtrlDotDecision(ctx context.Context, r domain.CtrlDotDecisionRecord) (inserted bool, err error) {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO ctrldot_decision_records (`+decisionRecordColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (id) DO NOTHING`,
+		r.ID, r.AgentID, r.SessionID, r.Decision, r.ActionType, r.Source, millis(r.Timestamp), millis(r.ReceivedAt), string(r.Record),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to store decision record: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// GetCtrlDotDecision retrieves a decision record by ID
+func (s *Store) GetCtrlDotDecision(ctx context.Context, id string) (*domain.CtrlDotDecisionRecord, error) {
+	r, err := scanDecisionRecord(s.db.QueryRowContext(ctx,
+		`SELECT `+decisionRecordColumns+` FROM ctrldot_decision_records WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrCtrlDotDecisionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decision record: %w", err)
+	}
+	return r, nil
+}
+
+// QueryCtrlDotDecisions lists decision records matching q, newest first
+func (s *Store) QueryCtrlDotDecisions(ctx context.Context, q store.DecisionRecordQuery) ([]domain.CtrlDotDecisionRecord, error) {
+	query := `SELECT ` + decisionRecordColumns + ` FROM ctrldot_decision_records WHERE 1=1`
+	args := []interface{}{}
+	for _, f := range []struct{ col, val string }{
+		{"agent_id", q.AgentID},
+		{"session_id", q.SessionID},
+		{"decision", q.Decision},
+		{"action_type", q.ActionType},
+		{"source", q.Source},
+	} {
+		if f.val == "" {
+			continue
+		}
+		query += " AND " + f.col + " = ?"
+		args = append(args, f.val)
+	}
+	if q.Since != nil {
+		query += " AND ts >= ?"
+		args = append(args, millis(*q.Since))
+	}
+	if q.Until != nil {
+		query += " AND ts < ?"
+		args = append(args, millis(*q.Until))
+	}
+	query += " ORDER BY ts DESC, id DESC"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query decision records: %w", err)
+	}
+	defer rows.Close()
+	var out []domain.CtrlDotDecisionRecord
+	for rows.Next() {
+		r, err := scanDecisionRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan decision record: %w", err)
+		}
+		out = append(out, *r)
+	}
+	return out, rows.Err()
+}
+
+func scanDecisionRecord(row interface{ Scan(...interface{}) error }) (*domain.CtrlDotDecisionRecord, error) {
+	var r domain.CtrlDotDecisionRecord
+	var ts, receivedAt int64
+	var record string
+	if err := row.Scan(&r.ID, &r.AgentID, &r.SessionID, &r.Decision, &r.ActionType, &r.Source, &ts, &receivedAt, &record); err != nil {
+		return nil, err
+	}
+	r.Timestamp, r.ReceivedAt = fromMillis(ts), fromMillis(receivedAt)
+	r.Record = []byte(record)
+	return &r, nil
+}
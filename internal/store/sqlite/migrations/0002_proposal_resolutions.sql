@@ -0,0 +1,27 @@
+-- Proposal sets and resolutions (equivalent to migrations/0006_proposal_resolutions.sql)
+
+CREATE TABLE IF NOT EXISTS proposal_sets (
+  proposal_set_id       TEXT PRIMARY KEY,
+  question              TEXT NOT NULL,
+  focused_node_id       TEXT NOT NULL,
+  namespace_id          TEXT NOT NULL REFERENCES namespaces(namespace_id),
+  options_json          TEXT NOT NULL,
+  recommended_option_id TEXT,
+  confidence            REAL,
+  raw_model_output      TEXT,
+  created_at            INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_proposal_sets_node ON proposal_sets(focused_node_id);
+CREATE INDEX IF NOT EXISTS idx_proposal_sets_namespace ON proposal_sets(namespace_id);
+
+CREATE TABLE IF NOT EXISTS resolutions (
+  resolution_id    TEXT PRIMARY KEY,
+  proposal_set_id  TEXT NOT NULL REFERENCES proposal_sets(proposal_set_id),
+  option_id        TEXT NOT NULL,
+  resolver         TEXT NOT NULL,
+  rationale        TEXT,
+  constraints      TEXT,
+  assumptions_snap TEXT,
+  created_at       INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_resolutions_proposal ON resolutions(proposal_set_id);
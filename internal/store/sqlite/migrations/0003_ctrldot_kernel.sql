@@ -0,0 +1,21 @@
+-- Ctrl Dot tables on the Kernel side (migrations/0007_ctrldot_tables.sql and 0013). The
+-- Ctrl Dot runtime tables come from the runtime SQLite migrations; events written by Apply
+-- also record the operation seq.
+
+ALTER TABLE ctrldot_events ADD COLUMN op_seq INTEGER REFERENCES operations(seq);
+CREATE INDEX IF NOT EXISTS idx_ctrldot_events_op_seq ON ctrldot_events(op_seq);
+
+CREATE TABLE IF NOT EXISTS ctrldot_decision_records (
+  id          TEXT PRIMARY KEY,
+  agent_id    TEXT NOT NULL,
+  session_id  TEXT NOT NULL DEFAULT '',
+  decision    TEXT NOT NULL,
+  action_type TEXT NOT NULL DEFAULT '',
+  source      TEXT NOT NULL DEFAULT '',
+  ts          INTEGER NOT NULL,
+  received_at INTEGER NOT NULL,
+  record      TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_ctrldot_decision_records_ts ON ctrldot_decision_records(ts DESC, id);
+CREATE INDEX IF NOT EXISTS idx_ctrldot_decision_records_agent ON ctrldot_decision_records(agent_id, ts DESC);
+CREATE INDEX IF NOT EXISTS idx_ctrldot_decision_records_session ON ctrldot_decision_records(session_id, ts DESC);
@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+const operationColumns = `seq, op_id, occurred_at, actor_id, capabilities, plan_id, plan_hash, class, changes_json`
+
+// AppendOperation appends an operation to the operations log
+func (t *Tx) AppendOperation(ctx context.Context, op domain.Operation) error {
+	capabilitiesJSON, err := json.Marshal(op.Capabilities)
+	if err != nil {
+		return fmt.Errorf("failed to marshal capabilities: %w", err)
+	}
+	changesJSON, err := json.Marshal(op.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal changes: %w", err)
+	}
+	_, err = t.tx.ExecContext(ctx,
+		`INSERT INTO operations (`+operationColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		op.Seq, op.ID, millis(op.OccurredAt), op.ActorID, string(capabilitiesJSON), op.PlanID, op.PlanHash, op.Class, string(changesJSON),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert operation: %w", err)
+	}
+	return nil
+}
+
+// GetOperation retrieves an operation by sequence number
+func (t *Tx) GetOperation(ctx context.Context, seq int64) (*domain.Operation, error) {
+	op, err := scanOperation(t.tx.QueryRowContext(ctx, `SELECT `+operationColumns+` FROM operations WHERE seq = ?`, seq))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("operation not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get operation: %w", err)
+	}
+	return op, nil
+}
+
+// GetOperationsForTarget retrieves operations whose changes mention target (node or namespace ID), newest first
+func (t *Tx) GetOperationsForTarget(ctx context.Context, target string, limit int) ([]domain.Operation, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := t.tx.QueryContext(ctx,
+		`SELECT `+operationColumns+` FROM operations
+		 WHERE instr(changes_json, ?) > 0
+		 ORDER BY seq DESC
+		 LIMIT ?`,
+		target, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operations: %w", err)
+	}
+	defer rows.Close()
+	var operations []domain.Operation
+	for rows.Next() {
+		op, err := scanOperation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan operation: %w", err)
+		}
+		operations = append(operations, *op)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating operations: %w", err)
+	}
+	return operations, nil
+}
+
+func scanOperation(row interface{ Scan(...interface{}) error }) (*domain.Operation, error) {
+	var op domain.Operation
+	var occurredAt int64
+	var capabilitiesJSON, changesJSON string
+	if err := row.Scan(&op.Seq, &op.ID, &occurredAt, &op.ActorID, &capabilitiesJSON, &op.PlanID, &op.PlanHash, &op.Class, &changesJSON); err != nil {
+		return nil, err
+	}
+	op.OccurredAt = fromMillis(occurredAt)
+	if err := json.Unmarshal([]byte(capabilitiesJSON), &op.Capabilities); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal capabilities: %w", err)
+	}
+	if err := json.Unmarshal([]byte(changesJSON), &op.Changes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal changes: %w", err)
+	}
+	return &op, nil
+}
@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+// StorePlan stores a plan; storing the same plan ID again is a no-op
+func (t *Tx) StorePlan(ctx context.Context, plan domain.Plan, policyHash string) error {
+	intentsJSON, err := json.Marshal(plan.Intents)
+	if err != nil {
+		return fmt.Errorf("failed to marshal intents: %w", err)
+	}
+	expandedJSON, err := json.Marshal(plan.Expanded)
+	if err != nil {
+		return fmt.Errorf("failed to marshal expanded: %w", err)
+	}
+	policyReportJSON, err := json.Marshal(plan.PolicyReport)
+	if err != nil {
+		return fmt.Errorf("failed to marshal policy report: %w", err)
+	}
+	_, err = t.tx.ExecContext(ctx,
+		`INSERT INTO plans (plan_id, created_at, actor_id, namespace_id, asof_seq, policy_hash, plan_hash, class, intents_json, expanded_json, policy_report_json)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (plan_id) DO NOTHING`,
+		plan.ID, millis(plan.CreatedAt), plan.ActorID, plan.NamespaceID, plan.AsOfSeq, policyHash, plan.Hash, plan.Class,
+		string(intentsJSON), string(expandedJSON), string(policyReportJSON),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert plan: %w", err)
+	}
+	return nil
+}
+
+// GetPlan retrieves a plan by ID
+func (t *Tx) GetPlan(ctx context.Context, planID string) (*domain.Plan, error) {
+	var plan domain.Plan
+	var createdAt int64
+	var namespaceID sql.NullString
+	var asofSeq sql.NullInt64
+	var intentsJSON, expandedJSON, policyReportJSON string
+	err := t.tx.QueryRowContext(ctx,
+		`SELECT plan_id, created_at, actor_id, namespace_id, asof_seq, plan_hash, class, intents_json, expanded_json, policy_report_json
+		 FROM plans WHERE plan_id = ?`,
+		planID,
+	).Scan(&plan.ID, &createdAt, &plan.ActorID, &namespaceID, &asofSeq, &plan.Hash, &plan.Class, &intentsJSON, &expandedJSON, &policyReportJSON)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("plan not found")
+		}
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+	plan.CreatedAt = fromMillis(createdAt)
+	if namespaceID.Valid {
+		plan.NamespaceID = &namespaceID.String
+	}
+	if asofSeq.Valid {
+		plan.AsOfSeq = &asofSeq.Int64
+	}
+	if err := json.Unmarshal([]byte(intentsJSON), &plan.Intents); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal intents: %w", err)
+	}
+	if err := json.Unmarshal([]byte(expandedJSON), &plan.Expanded); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal expanded: %w", err)
+	}
+	if err := json.Unmarshal([]byte(policyReportJSON), &plan.PolicyReport); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal policy report: %w", err)
+	}
+	return &plan, nil
+}
+
+// IsPlanApplied checks if a plan has already been applied (false for unknown plans)
+func (t *Tx) IsPlanApplied(ctx context.Context, planID string) (bool, error) {
+	var appliedSeq sql.NullInt64
+	err := t.tx.QueryRowContext(ctx, "SELECT applied_seq FROM plans WHERE plan_id = ?", planID).Scan(&appliedSeq)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check if plan is applied: %w", err)
+	}
+	return appliedSeq.Valid, nil
+}
+
+// MarkPlanApplied marks a plan as applied. Only an unapplied plan is marked; otherwise it
+// returns an "already applied" error.
+func (t *Tx) MarkPlanApplied(ctx context.Context, planID string, opID string, seq int64) error {
+	res, err := t.tx.ExecContext(ctx,
+		`UPDATE plans SET applied_op_id = ?, applied_seq = ? WHERE plan_id = ? AND applied_seq IS NULL`,
+		opID, seq, planID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark plan as applied: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to mark plan as applied: %w", err)
+	} else if n != 1 {
+		return fmt.Errorf("plan %s already applied", planID)
+	}
+	return nil
+}
+
+// GetActivePolicySet retrieves the active policy set for a namespace (longest prefix match)
+func (t *Tx) GetActivePolicySet(ctx context.Context, namespaceID string) (*domain.PolicySet, error) {
+	return getActivePolicySet(ctx, t.tx, namespaceID)
+}
+
+// StorePolicySet stores policySet as the only active policy set of its namespace
+func (t *Tx) StorePolicySet(ctx context.Context, policySet domain.PolicySet, seq int64) error {
+	_, err := t.tx.ExecContext(ctx,
+		`UPDATE policy_sets SET is_active = 0 WHERE namespace_id = ? AND is_active = 1`,
+		policySet.NamespaceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate old policy sets: %w", err)
+	}
+	_, err = t.tx.ExecContext(ctx,
+		`INSERT INTO policy_sets (policy_set_id, namespace_id, policy_yaml, policy_hash, created_at, created_seq, is_active)
+		 VALUES (?, ?, ?, ?, ?, ?, 1)
+		 ON CONFLICT (policy_set_id) DO UPDATE SET
+		   namespace_id = excluded.namespace_id,
+		   policy_yaml = excluded.policy_yaml,
+		   policy_hash = excluded.policy_hash,
+		   created_at = excluded.created_at,
+		   created_seq = excluded.created_seq,
+		   is_active = 1,
+		   retired_seq = NULL`,
+		policySet.ID, policySet.NamespaceID, policySet.PolicyYAML, policySet.PolicyHash, millis(time.Now()), seq,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert policy set: %w", err)
+	}
+	return nil
+}
+
+// getActivePolicySet matches the namespace exactly or as a prefix ("FinLedger" applies to
+// "FinLedger:/Kesteron/Treasury"), preferring the longest match.
+func getActivePolicySet(ctx context.Context, q queryer, namespaceID string) (*domain.PolicySet, error) {
+	var policySet domain.PolicySet
+	var createdAt int64
+	var retiredSeq sql.NullInt64
+	err := q.QueryRowContext(ctx,
+		`SELECT policy_set_id, namespace_id, policy_yaml, policy_hash, created_at, created_seq, retired_seq
+		 FROM policy_sets
+		 WHERE is_active = 1 AND retired_seq IS NULL
+		   AND (namespace_id = ?1 OR substr(?1, 1, length(namespace_id) + 1) IN (namespace_id || ':', namespace_id || '/'))
+		 ORDER BY length(namespace_id) DESC
+		 LIMIT 1`,
+		namespaceID,
+	).Scan(&policySet.ID, &policySet.NamespaceID, &policySet.PolicyYAML, &policySet.PolicyHash, &createdAt, &policySet.CreatedSeq, &retiredSeq)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No active policy set is valid
+		}
+		return nil, fmt.Errorf("failed to get active policy set: %w", err)
+	}
+	policySet.CreatedAt = fromMillis(createdAt)
+	if retiredSeq.Valid {
+		policySet.RetiredSeq = &retiredSeq.Int64
+	}
+	return &policySet, nil
+}
@@ -0,0 +1,443 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+// isVisibleAtSeq mirrors store.isVisibleAtSeq: a row is visible from its created seq up to
+// (but excluding) its retired seq.
+func isVisibleAtSeq(createdSeq, retiredSeq sql.NullInt64, asofSeq int64) bool {
+	if createdSeq.Int64 > asofSeq {
+		return false
+	}
+	if retiredSeq.Valid && retiredSeq.Int64 <= asofSeq {
+		return false
+	}
+	return true
+}
+
+// visibleAt is the SQL form of isVisibleAtSeq; bind the as-of seq twice.
+const visibleAt = `created_seq <= ? AND (retired_seq IS NULL OR retired_seq > ?)`
+
+func unmarshalMeta(metaJSON string) map[string]interface{} {
+	var meta map[string]interface{}
+	if err := json.Unmarshal([]byte(metaJSON), &meta); err != nil || meta == nil {
+		meta = make(map[string]interface{})
+	}
+	return meta
+}
+
+// ===== Nodes =====
+
+// CreateNode creates a node in the projection table
+func (t *Tx) CreateNode(ctx context.Context, node domain.Node, seq int64) error {
+	metaJSON, err := json.Marshal(node.Meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal meta: %w", err)
+	}
+	_, err = t.tx.ExecContext(ctx,
+		`INSERT INTO nodes (node_id, title, meta_json, created_seq)
+		 VALUES (?, ?, ?, ?)
+		 ON CONFLICT (node_id) DO UPDATE SET
+		   title = excluded.title,
+		   meta_json = excluded.meta_json,
+		   created_seq = excluded.created_seq,
+		   retired_seq = NULL`,
+		node.ID, node.Title, string(metaJSON), seq,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert node: %w", err)
+	}
+	return nil
+}
+
+// GetNode retrieves a node by ID at a given sequence
+func (t *Tx) GetNode(ctx context.Context, nodeID string, asofSeq int64) (*domain.Node, error) {
+	var node domain.Node
+	var metaJSON string
+	var createdSeq, retiredSeq sql.NullInt64
+	err := t.tx.QueryRowContext(ctx,
+		`SELECT node_id, title, meta_json, created_seq, retired_seq FROM nodes WHERE node_id = ?`,
+		nodeID,
+	).Scan(&node.ID, &node.Title, &metaJSON, &createdSeq, &retiredSeq)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("node not found")
+		}
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	if !isVisibleAtSeq(createdSeq, retiredSeq, asofSeq) {
+		return nil, fmt.Errorf("node not visible at seq %d", asofSeq)
+	}
+	node.Meta = unmarshalMeta(metaJSON)
+	return &node, nil
+}
+
+// RetireNode retires a node by setting retired_seq
+func (t *Tx) RetireNode(ctx context.Context, nodeID string, seq int64) error {
+	_, err := t.tx.ExecContext(ctx,
+		`UPDATE nodes SET retired_seq = ? WHERE node_id = ? AND retired_seq IS NULL`,
+		seq, nodeID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to retire node: %w", err)
+	}
+	return nil
+}
+
+// ===== Links =====
+
+const linkColumns = `link_id, from_node_id, to_node_id, type, namespace_id, meta_json, created_seq, retired_seq`
+
+func scanLink(row interface{ Scan(...interface{}) error }) (*domain.Link, sql.NullInt64, sql.NullInt64, error) {
+	var link domain.Link
+	var metaJSON string
+	var namespaceID sql.NullString
+	var createdSeq, retiredSeq sql.NullInt64
+	if err := row.Scan(&link.ID, &link.FromNodeID, &link.ToNodeID, &link.Type, &namespaceID, &metaJSON, &createdSeq, &retiredSeq); err != nil {
+		return nil, createdSeq, retiredSeq, err
+	}
+	if namespaceID.Valid {
+		link.NamespaceID = &namespaceID.String
+	}
+	link.Meta = unmarshalMeta(metaJSON)
+	return &link, createdSeq, retiredSeq, nil
+}
+
+// CreateLink creates a link in the projection table
+func (t *Tx) CreateLink(ctx context.Context, link domain.Link, seq int64) error {
+	metaJSON, err := json.Marshal(link.Meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal meta: %w", err)
+	}
+	_, err = t.tx.ExecContext(ctx,
+		`INSERT INTO links (link_id, from_node_id, to_node_id, type, namespace_id, meta_json, created_seq)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (link_id) DO UPDATE SET
+		   from_node_id = excluded.from_node_id,
+		   to_node_id = excluded.to_node_id,
+		   type = excluded.type,
+		   namespace_id = excluded.namespace_id,
+		   meta_json = excluded.meta_json,
+		   created_seq = excluded.created_seq,
+		   retired_seq = NULL`,
+		link.ID, link.FromNodeID, link.ToNodeID, link.Type, link.NamespaceID, string(metaJSON), seq,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert link: %w", err)
+	}
+	return nil
+}
+
+// GetLink retrieves a link by ID at a given sequence
+func (t *Tx) GetLink(ctx context.Context, linkID string, asofSeq int64) (*domain.Link, error) {
+	link, createdSeq, retiredSeq, err := scanLink(t.tx.QueryRowContext(ctx,
+		`SELECT `+linkColumns+` FROM links WHERE link_id = ?`, linkID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("link not found")
+		}
+		return nil, fmt.Errorf("failed to get link: %w", err)
+	}
+	if !isVisibleAtSeq(createdSeq, retiredSeq, asofSeq) {
+		return nil, fmt.Errorf("link not visible at seq %d", asofSeq)
+	}
+	return link, nil
+}
+
+// GetLinksFrom retrieves links from a node at a given sequence
+func (t *Tx) GetLinksFrom(ctx context.Context, fromNodeID string, namespaceID *string, linkType *string, asofSeq int64) ([]domain.Link, error) {
+	return t.getLinks(ctx, "from_node_id", fromNodeID, namespaceID, linkType, asofSeq)
+}
+
+// GetLinksTo retrieves links to a node at a given sequence
+func (t *Tx) GetLinksTo(ctx context.Context, toNodeID string, namespaceID *string, linkType *string, asofSeq int64) ([]domain.Link, error) {
+	return t.getLinks(ctx, "to_node_id", toNodeID, namespaceID, linkType, asofSeq)
+}
+
+// getLinks lists visible links whose endpoint column (from_node_id or to_node_id) is nodeID.
+func (t *Tx) getLinks(ctx context.Context, endpoint, nodeID string, namespaceID *string, linkType *string, asofSeq int64) ([]domain.Link, error) {
+	query := `SELECT ` + linkColumns + ` FROM links WHERE ` + endpoint + ` = ? AND ` + visibleAt
+	args := []interface{}{nodeID, asofSeq, asofSeq}
+	if namespaceID != nil {
+		query += " AND namespace_id = ?"
+		args = append(args, *namespaceID)
+	}
+	if linkType != nil {
+		query += " AND type = ?"
+		args = append(args, *linkType)
+	}
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links: %w", err)
+	}
+	defer rows.Close()
+	var links []domain.Link
+	for rows.Next() {
+		link, _, _, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+		links = append(links, *link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating links: %w", err)
+	}
+	return links, nil
+}
+
+// RetireLink retires a link by setting retired_seq
+func (t *Tx) RetireLink(ctx context.Context, linkID string, seq int64) error {
+	_, err := t.tx.ExecContext(ctx,
+		`UPDATE links SET retired_seq = ? WHERE link_id = ? AND retired_seq IS NULL`,
+		seq, linkID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to retire link: %w", err)
+	}
+	return nil
+}
+
+// ===== Materials =====
+
+const materialColumns = `material_id, node_id, content_ref, media_type, byte_size, hash, meta_json, created_seq, retired_seq`
+
+func scanMaterial(row interface{ Scan(...interface{}) error }) (*domain.Material, sql.NullInt64, sql.NullInt64, error) {
+	var material domain.Material
+	var metaJSON string
+	var hash sql.NullString
+	var createdSeq, retiredSeq sql.NullInt64
+	if err := row.Scan(&material.ID, &material.NodeID, &material.ContentRef, &material.MediaType, &material.ByteSize, &hash, &metaJSON, &createdSeq, &retiredSeq); err != nil {
+		return nil, createdSeq, retiredSeq, err
+	}
+	if hash.Valid {
+		material.Hash = &hash.String
+	}
+	material.Meta = unmarshalMeta(metaJSON)
+	return &material, createdSeq, retiredSeq, nil
+}
+
+// CreateMaterial creates a material in the projection table
+func (t *Tx) CreateMaterial(ctx context.Context, material domain.Material, seq int64) error {
+	metaJSON, err := json.Marshal(material.Meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal meta: %w", err)
+	}
+	_, err = t.tx.ExecContext(ctx,
+		`INSERT INTO materials (material_id, node_id, content_ref, media_type, byte_size, hash, meta_json, created_seq)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (material_id) DO UPDATE SET
+		   node_id = excluded.node_id,
+		   content_ref = excluded.content_ref,
+		   media_type = excluded.media_type,
+		   byte_size = excluded.byte_size,
+		   hash = excluded.hash,
+		   meta_json = excluded.meta_json,
+		   created_seq = excluded.created_seq,
+		   retired_seq = NULL`,
+		material.ID, material.NodeID, material.ContentRef, material.MediaType, material.ByteSize, material.Hash, string(metaJSON), seq,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert material: %w", err)
+	}
+	return nil
+}
+
+// GetMaterial retrieves a material by ID at a given sequence
+func (t *Tx) GetMaterial(ctx context.Context, materialID string, asofSeq int64) (*domain.Material, error) {
+	material, createdSeq, retiredSeq, err := scanMaterial(t.tx.QueryRowContext(ctx,
+		`SELECT `+materialColumns+` FROM materials WHERE material_id = ?`, materialID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("material not found")
+		}
+		return nil, fmt.Errorf("failed to get material: %w", err)
+	}
+	if !isVisibleAtSeq(createdSeq, retiredSeq, asofSeq) {
+		return nil, fmt.Errorf("material not visible at seq %d", asofSeq)
+	}
+	return material, nil
+}
+
+// GetMaterialsForNode retrieves all materials for a node at a given sequence
+func (t *Tx) GetMaterialsForNode(ctx context.Context, nodeID string, asofSeq int64) ([]domain.Material, error) {
+	rows, err := t.tx.QueryContext(ctx,
+		`SELECT `+materialColumns+` FROM materials WHERE node_id = ? AND `+visibleAt,
+		nodeID, asofSeq, asofSeq,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query materials: %w", err)
+	}
+	defer rows.Close()
+	var materials []domain.Material
+	for rows.Next() {
+		material, _, _, err := scanMaterial(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan material: %w", err)
+		}
+		materials = append(materials, *material)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating materials: %w", err)
+	}
+	return materials, nil
+}
+
+// RetireMaterial retires a material by setting retired_seq
+func (t *Tx) RetireMaterial(ctx context.Context, materialID string, seq int64) error {
+	_, err := t.tx.ExecContext(ctx,
+		`UPDATE materials SET retired_seq = ? WHERE material_id = ? AND retired_seq IS NULL`,
+		seq, materialID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to retire material: %w", err)
+	}
+	return nil
+}
+
+// ===== Role Assignments =====
+
+// CreateRoleAssignment creates a role assignment in the projection table
+func (t *Tx) CreateRoleAssignment(ctx context.Context, role domain.RoleAssignment, seq int64) error {
+	metaJSON, err := json.Marshal(role.Meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal meta: %w", err)
+	}
+	_, err = t.tx.ExecContext(ctx,
+		`INSERT INTO role_assignments (role_assignment_id, node_id, namespace_id, role, meta_json, created_seq)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (role_assignment_id) DO UPDATE SET
+		   node_id = excluded.node_id,
+		   namespace_id = excluded.namespace_id,
+		   role = excluded.role,
+		   meta_json = excluded.meta_json,
+		   created_seq = excluded.created_seq,
+		   retired_seq = NULL`,
+		role.ID, role.NodeID, role.NamespaceID, role.Role, string(metaJSON), seq,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert role assignment: %w", err)
+	}
+	return nil
+}
+
+// GetRoleAssignments retrieves role assignments for a node in a namespace at a given sequence
+func (t *Tx) GetRoleAssignments(ctx context.Context, nodeID string, namespaceID string, asofSeq int64) ([]domain.RoleAssignment, error) {
+	rows, err := t.tx.QueryContext(ctx,
+		`SELECT role_assignment_id, node_id, namespace_id, role, meta_json
+		 FROM role_assignments
+		 WHERE node_id = ? AND namespace_id = ? AND `+visibleAt,
+		nodeID, namespaceID, asofSeq, asofSeq,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query role assignments: %w", err)
+	}
+	defer rows.Close()
+	var roles []domain.RoleAssignment
+	for rows.Next() {
+		var role domain.RoleAssignment
+		var metaJSON string
+		if err := rows.Scan(&role.ID, &role.NodeID, &role.NamespaceID, &role.Role, &metaJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan role assignment: %w", err)
+		}
+		role.Meta = unmarshalMeta(metaJSON)
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating role assignments: %w", err)
+	}
+	return roles, nil
+}
+
+// RetireRoleAssignment retires a role assignment by setting retired_seq
+func (t *Tx) RetireRoleAssignment(ctx context.Context, roleAssignmentID string, seq int64) error {
+	_, err := t.tx.ExecContext(ctx,
+		`UPDATE role_assignments SET retired_seq = ? WHERE role_assignment_id = ? AND retired_seq IS NULL`,
+		seq, roleAssignmentID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to retire role assignment: %w", err)
+	}
+	return nil
+}
+
+// ListNamespaceIDsWithNodes returns distinct namespace_ids that have at least one role assignment visible at asofSeq.
+func (t *Tx) ListNamespaceIDsWithNodes(ctx context.Context, asofSeq int64) ([]string, error) {
+	rows, err := t.tx.QueryContext(ctx,
+		`SELECT DISTINCT namespace_id FROM role_assignments
+		 WHERE created_seq <= ?1 AND (retired_seq IS NULL OR retired_seq > ?1)
+		 ORDER BY namespace_id`,
+		asofSeq,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var ns string
+		if err := rows.Scan(&ns); err != nil {
+			return nil, err
+		}
+		out = append(out, ns)
+	}
+	return out, rows.Err()
+}
+
+// GetNamespaceRoot returns a root node for the namespace: one with no incoming CONTAINS link.
+// Prefers a node with role *.Domain. Returns nodeID, title, role.
+func (t *Tx) GetNamespaceRoot(ctx context.Context, namespaceID string, asofSeq int64) (nodeID, title, role string, err error) {
+	rows, err := t.tx.QueryContext(ctx,
+		`SELECT r.node_id, r.role FROM role_assignments r
+		 WHERE r.namespace_id = ?1 AND r.created_seq <= ?2 AND (r.retired_seq IS NULL OR r.retired_seq > ?2)
+		   AND NOT EXISTS (
+		     SELECT 1 FROM links l
+		     WHERE l.namespace_id = ?1 AND l.type = 'CONTAINS' AND l.to_node_id = r.node_id
+		       AND l.created_seq <= ?2 AND (l.retired_seq IS NULL OR l.retired_seq > ?2))
+		 ORDER BY r.node_id, r.role`,
+		namespaceID, asofSeq,
+	)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to list nodes in namespace: %w", err)
+	}
+	defer rows.Close()
+	var chosenNodeID, chosenRole string
+	for rows.Next() {
+		var nid, r string
+		if err := rows.Scan(&nid, &r); err != nil {
+			return "", "", "", err
+		}
+		if chosenNodeID == "" {
+			chosenNodeID, chosenRole = nid, r
+		}
+		if strings.HasSuffix(r, ".Domain") {
+			chosenNodeID, chosenRole = nid, r
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", "", "", err
+	}
+	rows.Close()
+	if chosenNodeID == "" {
+		return "", "", "", fmt.Errorf("no root node in namespace %s", namespaceID)
+	}
+	var nodeTitle string
+	err = t.tx.QueryRowContext(ctx,
+		`SELECT title FROM nodes WHERE node_id = ?1 AND created_seq <= ?2 AND (retired_seq IS NULL OR retired_seq > ?2)`,
+		chosenNodeID, asofSeq,
+	).Scan(&nodeTitle)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", "", fmt.Errorf("root node not visible: %s", chosenNodeID)
+		}
+		return "", "", "", err
+	}
+	return chosenNodeID, nodeTitle, chosenRole, nil
+}
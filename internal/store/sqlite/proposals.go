@@ -0,0 +1,145 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+const (
+	proposalSetColumns = `proposal_set_id, question, focused_node_id, namespace_id, options_json, recommended_option_id, confidence, raw_model_output, created_at`
+	resolutionColumns  = `resolution_id, proposal_set_id, option_id, resolver, rationale, constraints, assumptions_snap, created_at`
+)
+
+// StoreProposalSet stores a proposal set (call within a transaction if needed for consistency)
+func (t *Tx) StoreProposalSet(ctx context.Context, ps *domain.ProposalSet) error {
+	optionsJSON, err := json.Marshal(ps.Options)
+	if err != nil {
+		return fmt.Errorf("marshal options: %w", err)
+	}
+	_, err = t.tx.ExecContext(ctx,
+		`INSERT INTO proposal_sets (`+proposalSetColumns+`)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (proposal_set_id) DO NOTHING`,
+		ps.ProposalSetID, ps.Question, ps.FocusedNodeID, ps.NamespaceID, string(optionsJSON), ps.RecommendedOptionID, ps.Confidence, ps.RawModelOutput, millis(ps.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("insert proposal set: %w", err)
+	}
+	return nil
+}
+
+// StoreResolution stores a resolution (call within a transaction if needed)
+func (t *Tx) StoreResolution(ctx context.Context, r *domain.Resolution) error {
+	_, err := t.tx.ExecContext(ctx,
+		`INSERT INTO resolutions (`+resolutionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.ResolutionID, r.ProposalSetID, r.OptionID, r.Resolver, r.Rationale, r.Constraints, r.AssumptionsSnap, millis(r.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("insert resolution: %w", err)
+	}
+	return nil
+}
+
+// GetProposalSet retrieves a proposal set by ID (Store-level, no tx)
+func (s *Store) GetProposalSet(ctx context.Context, proposalSetID string) (*domain.ProposalSet, error) {
+	ps, err := scanProposalSet(s.db.QueryRowContext(ctx,
+		`SELECT `+proposalSetColumns+` FROM proposal_sets WHERE proposal_set_id = ?`, proposalSetID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrProposalSetNotFound
+		}
+		return nil, fmt.Errorf("get proposal set: %w", err)
+	}
+	return ps, nil
+}
+
+// GetResolution retrieves a resolution by ID (Store-level)
+func (s *Store) GetResolution(ctx context.Context, resolutionID string) (*domain.Resolution, error) {
+	r, err := scanResolution(s.db.QueryRowContext(ctx,
+		`SELECT `+resolutionColumns+` FROM resolutions WHERE resolution_id = ?`, resolutionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrResolutionNotFound
+		}
+		return nil, fmt.Errorf("get resolution: %w", err)
+	}
+	return r, nil
+}
+
+// ListProposalSetsForNode returns recent proposal sets for a node (Store-level)
+func (s *Store) ListProposalSetsForNode(ctx context.Context, nodeID, namespaceID string, limit int) ([]domain.ProposalSet, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+proposalSetColumns+` FROM proposal_sets
+		 WHERE focused_node_id = ? AND namespace_id = ?
+		 ORDER BY created_at DESC LIMIT ?`,
+		nodeID, namespaceID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list proposal sets: %w", err)
+	}
+	defer rows.Close()
+	var out []domain.ProposalSet
+	for rows.Next() {
+		ps, err := scanProposalSet(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *ps)
+	}
+	return out, rows.Err()
+}
+
+// GetResolutionForProposalSet returns the resolution for a proposal set if any (Store-level)
+func (s *Store) GetResolutionForProposalSet(ctx context.Context, proposalSetID string) (*domain.Resolution, error) {
+	r, err := scanResolution(s.db.QueryRowContext(ctx,
+		`SELECT `+resolutionColumns+` FROM resolutions WHERE proposal_set_id = ?`, proposalSetID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get resolution for proposal set: %w", err)
+	}
+	return r, nil
+}
+
+func scanProposalSet(row interface{ Scan(...interface{}) error }) (*domain.ProposalSet, error) {
+	var ps domain.ProposalSet
+	var optionsJSON string
+	var recOptID, rawModelOutput sql.NullString
+	var confidence sql.NullFloat64
+	var createdAt int64
+	if err := row.Scan(&ps.ProposalSetID, &ps.Question, &ps.FocusedNodeID, &ps.NamespaceID, &optionsJSON, &recOptID, &confidence, &rawModelOutput, &createdAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(optionsJSON), &ps.Options); err != nil {
+		return nil, fmt.Errorf("unmarshal options: %w", err)
+	}
+	if recOptID.Valid {
+		ps.RecommendedOptionID = &recOptID.String
+	}
+	if confidence.Valid {
+		ps.Confidence = &confidence.Float64
+	}
+	ps.RawModelOutput = rawModelOutput.String
+	ps.CreatedAt = fromMillis(createdAt)
+	return &ps, nil
+}
+
+func scanResolution(row interface{ Scan(...interface{}) error }) (*domain.Resolution, error) {
+	var r domain.Resolution
+	var rationale, constraints, assumptions sql.NullString
+	var createdAt int64
+	if err := row.Scan(&r.ResolutionID, &r.ProposalSetID, &r.OptionID, &r.Resolver, &rationale, &constraints, &assumptions, &createdAt); err != nil {
+		return nil, err
+	}
+	r.Rationale, r.Constraints, r.AssumptionsSnap = rationale.String, constraints.String, assumptions.String
+	r.CreatedAt = fromMillis(createdAt)
+	return &r, nil
+}
@@ -0,0 +1,196 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+	rtsqlite "github.com/futurematic/kernel/internal/runtime/sqlite"
+	"github.com/futurematic/kernel/internal/store"
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrations are applied in order, once each; applied names are recorded in kernel_migrations.
+var migrations = []string{
+	"0001_init.sql",
+	"0002_proposal_resolutions.sql",
+	"0003_ctrldot_kernel.sql",
+}
+
+// Store implements store.Store on a single SQLite file, so the Kernel (and dot) can run
+// without Postgres. The Ctrl Dot tables are shared with the runtime SQLite store, which
+// also serves the Ctrl Dot methods that have no transactional variant.
+type Store struct {
+	*rtsqlite.Store
+	db *sql.DB
+}
+
+// Open opens or creates the database at path (~ is expanded) and runs migrations.
+// Foreign keys are enforced, as in Postgres.
+func Open(ctx context.Context, path string) (*Store, error) {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("create db dir: %w", err)
+	}
+	// Pragmas in the DSN apply to every pooled connection.
+	dsn := "file:" + path + "?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	rt, err := rtsqlite.OpenDB(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s := &Store{Store: rt, db: db}
+	if err := s.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the database, which the embedded runtime store shares but does not own.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS kernel_migrations (name TEXT PRIMARY KEY, applied_at INTEGER NOT NULL)`); err != nil {
+		return fmt.Errorf("create kernel_migrations: %w", err)
+	}
+	for _, name := range migrations {
+		var n int
+		if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM kernel_migrations WHERE name = ?`, name).Scan(&n); err != nil {
+			return fmt.Errorf("check migration %s: %w", name, err)
+		}
+		if n > 0 {
+			continue
+		}
+		sqlBytes, err := migrationsFS.ReadFile("migrations/" + name)
+		if err != nil {
+			return fmt.Errorf("read migration %s: %w", name, err)
+		}
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, string(sqlBytes)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrate %s: %w", name, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO kernel_migrations (name, applied_at) VALUES (?, ?)`, name, time.Now().UnixMilli()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("record migration %s: %w", name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migrate %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// OpenTx opens a new transaction
+func (s *Store) OpenTx(ctx context.Context) (store.Tx, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return &Tx{tx: tx}, nil
+}
+
+// GetNextSeq returns the next sequence number
+func (s *Store) GetNextSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(seq), 0) + 1 FROM operations").Scan(&seq); err != nil {
+		return 0, fmt.Errorf("failed to get next seq: %w", err)
+	}
+	return seq, nil
+}
+
+// ResolveAsOf resolves an AsOf to a sequence number (latest seq if unset)
+func (s *Store) ResolveAsOf(ctx context.Context, asof domain.AsOf) (int64, error) {
+	if asof.Seq != nil {
+		return *asof.Seq, nil
+	}
+	var seq int64
+	if asof.Time != nil {
+		err := s.db.QueryRowContext(ctx,
+			"SELECT COALESCE(MAX(seq), 0) FROM operations WHERE occurred_at <= ?", asof.Time.UnixMilli(),
+		).Scan(&seq)
+		if err != nil {
+			return 0, fmt.Errorf("failed to resolve asof time: %w", err)
+		}
+		return seq, nil
+	}
+	if err := s.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(seq), 0) FROM operations").Scan(&seq); err != nil {
+		return 0, fmt.Errorf("failed to get latest seq: %w", err)
+	}
+	return seq, nil
+}
+
+// GetActivePolicySet retrieves the active policy set for a namespace (longest prefix match)
+func (s *Store) GetActivePolicySet(ctx context.Context, namespaceID string) (*domain.PolicySet, error) {
+	return getActivePolicySet(ctx, s.db, namespaceID)
+}
+
+// CreateNamespace creates a namespace
+func (s *Store) CreateNamespace(ctx context.Context, namespace domain.Namespace) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO namespaces (namespace_id, name) VALUES (?, ?) ON CONFLICT (namespace_id) DO NOTHING`,
+		namespace.ID, namespace.Name,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create namespace: %w", err)
+	}
+	return nil
+}
+
+// Tx implements store.Tx
+type Tx struct {
+	tx *sql.Tx
+}
+
+// Commit commits the transaction
+func (t *Tx) Commit() error {
+	return t.tx.Commit()
+}
+
+// Rollback rolls back the transaction
+func (t *Tx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// queryer is satisfied by *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+func fromMillis(ms int64) time.Time {
+	return time.UnixMilli(ms).UTC()
+}
+
+var (
+	_ store.Store = (*Store)(nil)
+	_ store.Tx    = (*Tx)(nil)
+)
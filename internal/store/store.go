@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/futurematic/kernel/internal/domain"
 )
@@ -43,9 +44,15 @@ type Store interface {
 	EndSession(ctx context.Context, sessionID string) error
 
 	// Ctrl Dot: Events
-	AppendEvent(ctx context.Context, event domain.Event) error
+	AppendEvent(ctx context.Context, event *domain.Event) error // links event to the hash chain head
 	GetEvents(ctx context.Context, agentID *string, sinceTS *int64, limit int) ([]domain.Event, error)
+	QueryEvents(ctx context.Context, q EventQuery) ([]domain.Event, error)
 	GetEvent(ctx context.Context, eventID string) (*domain.Event, error)
+	CompactEvents(ctx context.Context, olderThanTS int64, maxRows int, record *domain.Event) (*domain.EventCompaction, error) // record: see runtime.RuntimeStore
+	GetEventRollups(ctx context.Context, agentID *string, sinceTS *int64, limit int) ([]domain.EventRollup, error)
+	EventStats(ctx context.Context) (*domain.EventStats, error)
+	EventChainHead(ctx context.Context) (*domain.EventChainHead, error)
+	ListChainedEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.Event, error)
 
 	// Ctrl Dot: Limits State
 	GetLimitsState(ctx context.Context, agentID string, windowStart int64, windowType string) (*domain.LimitsState, error)
@@ -58,6 +65,31 @@ type Store interface {
 	// Ctrl Dot: Panic mode (single-row state)
 	GetPanicState(ctx context.Context) (*domain.PanicState, error)
 	SetPanicState(ctx context.Context, state domain.PanicState) error
+
+	// Ctrl Dot: Webhook delivery queue
+	EnqueueWebhookDelivery(ctx context.Context, d domain.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, status string, dueBefore *time.Time, limit int) ([]domain.WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, deliveryID string) (*domain.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, d domain.WebhookDelivery) error
+	DeleteWebhookDelivery(ctx context.Context, deliveryID string) error
+	CountWebhookDeliveries(ctx context.Context) (map[string]int64, error)
+
+	// Ctrl Dot: Ledger sink outbox
+	EnqueueOutbox(ctx context.Context, r domain.OutboxRecord) error
+	ListOutbox(ctx context.Context, sink, status string, limit int) ([]domain.OutboxRecord, error)
+	UpdateOutbox(ctx context.Context, r domain.OutboxRecord) error
+	DeleteOutbox(ctx context.Context, seq int64) error
+	CountOutbox(ctx context.Context) (map[string]int64, error)
+
+	// Ctrl Dot: Config revisions
+	AppendConfigRevision(ctx context.Context, r *domain.ConfigRevision) error
+	ListConfigRevisions(ctx context.Context, limit int) ([]domain.ConfigRevision, error)
+	GetConfigRevision(ctx context.Context, rev int64) (*domain.ConfigRevision, error)
+
+	// Ctrl Dot: Decision records received from kernel_http sinks
+	StoreCtrlDotDecision(ctx context.Context, r domain.CtrlDotDecisionRecord) (inserted bool, err error)
+	GetCtrlDotDecision(ctx context.Context, id string) (*domain.CtrlDotDecisionRecord, error)
+	QueryCtrlDotDecisions(ctx context.Context, q DecisionRecordQuery) ([]domain.CtrlDotDecisionRecord, error)
 }
 
 // Tx represents a database transaction
@@ -100,6 +132,7 @@ type Tx interface {
 	StorePlan(ctx context.Context, plan domain.Plan, policyHash string) error
 	GetPlan(ctx context.Context, planID string) (*domain.Plan, error)
 	IsPlanApplied(ctx context.Context, planID string) (bool, error)
+	// MarkPlanApplied fails with "already applied" unless the plan exists and is unapplied
 	MarkPlanApplied(ctx context.Context, planID string, opID string, seq int64) error
 
 	// Policy Sets
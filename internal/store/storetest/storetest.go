@@ -0,0 +1,660 @@
+// Package storetest is a conformance suite for store.Store implementations. Each backend's
+// tests call Run so Postgres and SQLite are held to the same semantics.
+//
+// The suite may run against a shared, non-empty database: every ID it writes is unique
+// and sequence numbers are taken relative to GetNextSeq.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/eventchain"
+	"github.com/futurematic/kernel/internal/store"
+	"github.com/google/uuid"
+)
+
+// Run runs the suite. open is called once per subtest and must return a migrated store;
+// the caller is responsible for closing it (e.g. via t.Cleanup).
+func Run(t *testing.T, open func(t *testing.T) store.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, st store.Store)
+	}{
+		{"Plans", testPlans},
+		{"Visibility", testVisibility},
+		{"MaterialsAndRoles", testMaterialsAndRoles},
+		{"PolicySets", testPolicySets},
+		{"Rollback", testRollback},
+		{"Proposals", testProposals},
+		{"Events", testEvents},
+		{"Decisions", testDecisions},
+		{"Outbox", testOutbox},
+		{"Webhooks", testWebhooks},
+		{"ConfigRevisions", testConfigRevisions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) { tt.fn(t, open(t)) })
+	}
+}
+
+func id(prefix string) string {
+	return prefix + ":" + uuid.New().String()
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func namespace(t *testing.T, st store.Store) string {
+	t.Helper()
+	ns := "StoreTest:/" + uuid.New().String()
+	if err := st.CreateNamespace(context.Background(), domain.Namespace{ID: ns, Name: ns}); err != nil {
+		t.Fatalf("CreateNamespace: %v", err)
+	}
+	return ns
+}
+
+// apply runs fn in a transaction that appends an operation (with changes) at the next seq,
+// and returns the seq.
+func apply(t *testing.T, st store.Store, fn func(tx store.Tx, seq int64) error, changes ...domain.Change) int64 {
+	t.Helper()
+	ctx := context.Background()
+	seq, err := st.GetNextSeq(ctx)
+	if err != nil {
+		t.Fatalf("GetNextSeq: %v", err)
+	}
+	tx, err := st.OpenTx(ctx)
+	if err != nil {
+		t.Fatalf("OpenTx: %v", err)
+	}
+	op := domain.Operation{
+		ID: id("op"), Seq: seq, OccurredAt: time.Now().UTC(), ActorID: "user:storetest",
+		Capabilities: []string{}, PlanID: id("plan"), PlanHash: "sha256:test", Class: 1, Changes: changes,
+	}
+	if err := tx.AppendOperation(ctx, op); err != nil {
+		tx.Rollback()
+		t.Fatalf("AppendOperation: %v", err)
+	}
+	if err := fn(tx, seq); err != nil {
+		tx.Rollback()
+		t.Fatalf("apply at seq %d: %v", seq, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	return seq
+}
+
+// read runs fn in a transaction that is rolled back afterwards.
+func read(t *testing.T, st store.Store, fn func(tx store.Tx)) {
+	t.Helper()
+	tx, err := st.OpenTx(context.Background())
+	if err != nil {
+		t.Fatalf("OpenTx: %v", err)
+	}
+	defer tx.Rollback()
+	fn(tx)
+}
+
+func testPlans(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ns := namespace(t, st)
+	plan := domain.Plan{
+		ID: id("plan"), CreatedAt: time.Now().UTC().Truncate(time.Millisecond), ActorID: "user:storetest",
+		NamespaceID: &ns, AsOfSeq: ptr(int64(7)), Class: 2, Hash: "sha256:plan",
+		Intents:  []domain.Intent{{Kind: domain.IntentCreateNode, Payload: map[string]interface{}{"node_id": "node:a"}}},
+		Expanded: []domain.Change{{Kind: domain.ChangeCreateNode, NamespaceID: &ns, Payload: map[string]interface{}{"node_id": "node:a"}}},
+	}
+	opID := id("op")
+	apply(t, st, func(tx store.Tx, seq int64) error {
+		if err := tx.StorePlan(ctx, plan, "sha256:policy"); err != nil {
+			return err
+		}
+		// Storing the same plan again is a no-op.
+		return tx.StorePlan(ctx, plan, "sha256:policy")
+	})
+	read(t, st, func(tx store.Tx) {
+		got, err := tx.GetPlan(ctx, plan.ID)
+		if err != nil {
+			t.Fatalf("GetPlan: %v", err)
+		}
+		if got.Hash != plan.Hash || got.Class != 2 || *got.NamespaceID != ns || *got.AsOfSeq != 7 || !got.CreatedAt.Equal(plan.CreatedAt) {
+			t.Errorf("GetPlan = %+v, want %+v", got, plan)
+		}
+		if len(got.Intents) != 1 || len(got.Expanded) != 1 || got.Expanded[0].Payload["node_id"] != "node:a" {
+			t.Errorf("GetPlan intents/expanded = %+v / %+v", got.Intents, got.Expanded)
+		}
+		if _, err := tx.GetPlan(ctx, id("plan")); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("GetPlan(unknown) error = %v, want not found", err)
+		}
+		if applied, err := tx.IsPlanApplied(ctx, plan.ID); err != nil || applied {
+			t.Errorf("IsPlanApplied before apply = %v, %v", applied, err)
+		}
+		if applied, err := tx.IsPlanApplied(ctx, id("plan")); err != nil || applied {
+			t.Errorf("IsPlanApplied(unknown) = %v, %v", applied, err)
+		}
+	})
+	seq := apply(t, st, func(tx store.Tx, seq int64) error {
+		return tx.MarkPlanApplied(ctx, plan.ID, opID, seq)
+	})
+	read(t, st, func(tx store.Tx) {
+		if applied, err := tx.IsPlanApplied(ctx, plan.ID); err != nil || !applied {
+			t.Errorf("IsPlanApplied after apply = %v, %v", applied, err)
+		}
+		// The marker itself guards single use, not only the IsPlanApplied check before it
+		if err := tx.MarkPlanApplied(ctx, plan.ID, id("op"), seq+1); err == nil || !strings.Contains(err.Error(), "already applied") {
+			t.Errorf("MarkPlanApplied twice error = %v, want already applied", err)
+		}
+		if err := tx.MarkPlanApplied(ctx, id("plan"), id("op"), seq+1); err == nil {
+			t.Error("MarkPlanApplied(unknown plan) succeeded")
+		}
+		op, err := tx.GetOperation(ctx, seq)
+		if err != nil || op.Seq != seq || op.ActorID != "user:storetest" {
+			t.Errorf("GetOperation(%d) = %+v, %v", seq, op, err)
+		}
+	})
+}
+
+func testVisibility(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ns := namespace(t, st)
+	a, b, link := id("node"), id("node"), id("link")
+	linkType := "PARENT_OF"
+
+	created := apply(t, st, func(tx store.Tx, seq int64) error {
+		for _, n := range []string{a, b} {
+			if err := tx.CreateNode(ctx, domain.Node{ID: n, Title: n, Meta: map[string]interface{}{"k": "v"}}, seq); err != nil {
+				return err
+			}
+		}
+		return tx.CreateLink(ctx, domain.Link{ID: link, FromNodeID: a, ToNodeID: b, Type: linkType, NamespaceID: &ns}, seq)
+	})
+	retired := apply(t, st, func(tx store.Tx, seq int64) error {
+		if err := tx.RetireLink(ctx, link, seq); err != nil {
+			return err
+		}
+		// Retiring twice keeps the first retired seq.
+		return tx.RetireLink(ctx, link, seq+100)
+	}, domain.Change{Kind: domain.ChangeRetireLink, NamespaceID: &ns, Payload: map[string]interface{}{"link_id": link}})
+	checkLinks := func(tx store.Tx, asof int64, want int) {
+		t.Helper()
+		from, err := tx.GetLinksFrom(ctx, a, &ns, &linkType, asof)
+		if err != nil || len(from) != want {
+			t.Errorf("GetLinksFrom at %d = %d links, %v; want %d", asof, len(from), err, want)
+		}
+		to, err := tx.GetLinksTo(ctx, b, nil, nil, asof)
+		if err != nil || len(to) != want {
+			t.Errorf("GetLinksTo at %d = %d links, %v; want %d", asof, len(to), err, want)
+		}
+		if _, err := tx.GetLink(ctx, link, asof); (err == nil) != (want == 1) {
+			t.Errorf("GetLink at %d error = %v", asof, err)
+		}
+	}
+
+	read(t, st, func(tx store.Tx) {
+		node, err := tx.GetNode(ctx, a, created)
+		if err != nil || node.Title != a || node.Meta["k"] != "v" {
+			t.Errorf("GetNode at created = %+v, %v", node, err)
+		}
+		if _, err := tx.GetNode(ctx, a, created-1); err == nil || !strings.Contains(err.Error(), "not visible") {
+			t.Errorf("GetNode before created error = %v, want not visible", err)
+		}
+		if _, err := tx.GetNode(ctx, id("node"), created); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("GetNode(unknown) error = %v, want not found", err)
+		}
+		checkLinks(tx, created-1, 0)
+		checkLinks(tx, created, 1)
+		checkLinks(tx, retired-1, 1)
+		checkLinks(tx, retired, 0)
+		if _, err := tx.GetLink(ctx, link, retired); err == nil || !strings.Contains(err.Error(), "not visible") {
+			t.Errorf("GetLink after retire error = %v, want not visible", err)
+		}
+		if got, err := tx.GetLinksFrom(ctx, a, &ns, ptr("CONTAINS"), created); err != nil || len(got) != 0 {
+			t.Errorf("GetLinksFrom with other type = %d links, %v", len(got), err)
+		}
+		ops, err := tx.GetOperationsForTarget(ctx, link, 10)
+		if err != nil || len(ops) != 1 || ops[0].Seq != retired || ops[0].Changes[0].Payload["link_id"] != link {
+			t.Errorf("GetOperationsForTarget = %+v, %v; want the retire operation", ops, err)
+		}
+	})
+
+	// Creating a retired row again makes it visible from the new seq.
+	recreated := apply(t, st, func(tx store.Tx, seq int64) error {
+		return tx.CreateLink(ctx, domain.Link{ID: link, FromNodeID: a, ToNodeID: b, Type: linkType, NamespaceID: &ns}, seq)
+	})
+	latest, err := st.ResolveAsOf(ctx, domain.AsOf{})
+	if err != nil || latest < recreated {
+		t.Fatalf("ResolveAsOf(latest) = %d, %v; want >= %d", latest, err, recreated)
+	}
+	if got, err := st.ResolveAsOf(ctx, domain.AsOf{Seq: ptr(created)}); err != nil || got != created {
+		t.Errorf("ResolveAsOf(seq) = %d, %v", got, err)
+	}
+	read(t, st, func(tx store.Tx) {
+		checkLinks(tx, recreated-1, 0)
+		checkLinks(tx, recreated, 1)
+		l, err := tx.GetLink(ctx, link, recreated)
+		if err != nil || l.FromNodeID != a || l.NamespaceID == nil || *l.NamespaceID != ns {
+			t.Errorf("GetLink = %+v, %v", l, err)
+		}
+	})
+}
+
+func testMaterialsAndRoles(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ns := namespace(t, st)
+	root, child, material, role := id("node"), id("node"), id("material"), id("role")
+	created := apply(t, st, func(tx store.Tx, seq int64) error {
+		for _, n := range []string{root, child} {
+			if err := tx.CreateNode(ctx, domain.Node{ID: n, Title: "title " + n}, seq); err != nil {
+				return err
+			}
+		}
+		if err := tx.CreateLink(ctx, domain.Link{ID: id("link"), FromNodeID: root, ToNodeID: child, Type: "CONTAINS", NamespaceID: &ns}, seq); err != nil {
+			return err
+		}
+		for _, r := range []domain.RoleAssignment{
+			{ID: role, NodeID: root, NamespaceID: ns, Role: "Test.Domain"},
+			{ID: id("role"), NodeID: child, NamespaceID: ns, Role: "Test.Item"},
+		} {
+			if err := tx.CreateRoleAssignment(ctx, r, seq); err != nil {
+				return err
+			}
+		}
+		return tx.CreateMaterial(ctx, domain.Material{ID: material, NodeID: child, ContentRef: "file:///x", MediaType: "text/plain", ByteSize: 3, Hash: ptr("sha256:x")}, seq)
+	})
+	retired := apply(t, st, func(tx store.Tx, seq int64) error {
+		if err := tx.RetireMaterial(ctx, material, seq); err != nil {
+			return err
+		}
+		return tx.RetireRoleAssignment(ctx, role, seq)
+	})
+
+	read(t, st, func(tx store.Tx) {
+		m, err := tx.GetMaterial(ctx, material, created)
+		if err != nil || m.ByteSize != 3 || m.Hash == nil || *m.Hash != "sha256:x" {
+			t.Errorf("GetMaterial = %+v, %v", m, err)
+		}
+		if _, err := tx.GetMaterial(ctx, material, retired); err == nil {
+			t.Error("GetMaterial after retire: want error")
+		}
+		if ms, err := tx.GetMaterialsForNode(ctx, child, created); err != nil || len(ms) != 1 {
+			t.Errorf("GetMaterialsForNode at created = %d, %v", len(ms), err)
+		}
+		if ms, err := tx.GetMaterialsForNode(ctx, child, retired); err != nil || len(ms) != 0 {
+			t.Errorf("GetMaterialsForNode at retired = %d, %v", len(ms), err)
+		}
+		if rs, err := tx.GetRoleAssignments(ctx, root, ns, created); err != nil || len(rs) != 1 || rs[0].Role != "Test.Domain" {
+			t.Errorf("GetRoleAssignments at created = %+v, %v", rs, err)
+		}
+		if rs, err := tx.GetRoleAssignments(ctx, root, ns, retired); err != nil || len(rs) != 0 {
+			t.Errorf("GetRoleAssignments at retired = %+v, %v", rs, err)
+		}
+
+		nodeID, title, r, err := tx.GetNamespaceRoot(ctx, ns, created)
+		if err != nil || nodeID != root || title != "title "+root || r != "Test.Domain" {
+			t.Errorf("GetNamespaceRoot = %q, %q, %q, %v", nodeID, title, r, err)
+		}
+		// With the root's role retired only the contained child has a role, so there is no root.
+		if _, _, _, err := tx.GetNamespaceRoot(ctx, ns, retired); err == nil {
+			t.Error("GetNamespaceRoot after retire: want error")
+		}
+		nss, err := tx.ListNamespaceIDsWithNodes(ctx, created)
+		if err != nil || !containsString(nss, ns) {
+			t.Errorf("ListNamespaceIDsWithNodes does not include %s: %v", ns, err)
+		}
+		if nss, _ := tx.ListNamespaceIDsWithNodes(ctx, created-1); containsString(nss, ns) {
+			t.Errorf("ListNamespaceIDsWithNodes before created includes %s", ns)
+		}
+	})
+}
+
+func testPolicySets(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	base := "Policy" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	nested := base + ":/Team"
+	for _, ns := range []string{base, nested} {
+		if err := st.CreateNamespace(ctx, domain.Namespace{ID: ns, Name: ns}); err != nil {
+			t.Fatalf("CreateNamespace: %v", err)
+		}
+	}
+	if ps, err := st.GetActivePolicySet(ctx, base); err != nil || ps != nil {
+		t.Fatalf("GetActivePolicySet before store = %+v, %v", ps, err)
+	}
+	first, second, team := id("ps"), id("ps"), id("ps")
+	seq := apply(t, st, func(tx store.Tx, seq int64) error {
+		return tx.StorePolicySet(ctx, domain.PolicySet{ID: first, NamespaceID: base, PolicyYAML: "rules: []", PolicyHash: "sha256:1"}, seq)
+	})
+	for _, ns := range []string{base, base + ":/Other/Child", base + "/x"} {
+		ps, err := st.GetActivePolicySet(ctx, ns)
+		if err != nil || ps == nil || ps.ID != first || ps.CreatedSeq != seq {
+			t.Errorf("GetActivePolicySet(%s) = %+v, %v; want %s", ns, ps, err, first)
+		}
+	}
+	// A namespace that merely starts with the same characters does not match.
+	if ps, err := st.GetActivePolicySet(ctx, base+"Suffix"); err != nil || ps != nil {
+		t.Errorf("GetActivePolicySet(prefix without separator) = %+v, %v", ps, err)
+	}
+
+	apply(t, st, func(tx store.Tx, seq int64) error {
+		if err := tx.StorePolicySet(ctx, domain.PolicySet{ID: second, NamespaceID: base, PolicyYAML: "rules: []", PolicyHash: "sha256:2"}, seq); err != nil {
+			return err
+		}
+		return tx.StorePolicySet(ctx, domain.PolicySet{ID: team, NamespaceID: nested, PolicyYAML: "rules: []", PolicyHash: "sha256:3"}, seq)
+	})
+	if ps, err := st.GetActivePolicySet(ctx, base); err != nil || ps == nil || ps.ID != second {
+		t.Errorf("GetActivePolicySet after replace = %+v, %v; want %s", ps, err, second)
+	}
+	read(t, st, func(tx store.Tx) {
+		// The longest matching namespace wins.
+		if ps, err := tx.GetActivePolicySet(ctx, nested+"/Project"); err != nil || ps == nil || ps.ID != team {
+			t.Errorf("GetActivePolicySet(nested) = %+v, %v; want %s", ps, err, team)
+		}
+	})
+}
+
+func testRollback(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	node := id("node")
+	seq, err := st.GetNextSeq(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := st.OpenTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.CreateNode(ctx, domain.Node{ID: node, Title: node}, seq); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	read(t, st, func(tx store.Tx) {
+		if _, err := tx.GetNode(ctx, node, seq); err == nil {
+			t.Error("rolled back node is visible")
+		}
+	})
+}
+
+func testProposals(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ns := namespace(t, st)
+	node := id("node")
+	ps := &domain.ProposalSet{
+		ProposalSetID: id("proposal_set"), Question: "Which?", FocusedNodeID: node, NamespaceID: ns,
+		Options:             []domain.ProposalOption{{ID: "a", Summary: "A"}, {ID: "b", Summary: "B"}, {ID: "c", Summary: "C"}},
+		RecommendedOptionID: ptr("b"), Confidence: ptr(0.75), CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	older := *ps
+	older.ProposalSetID, older.RecommendedOptionID, older.Confidence = id("proposal_set"), nil, nil
+	older.CreatedAt = ps.CreatedAt.Add(-time.Hour)
+	res := &domain.Resolution{
+		ResolutionID: id("resolution"), ProposalSetID: ps.ProposalSetID, OptionID: "b", Resolver: "user:storetest",
+		Rationale: "because", CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	apply(t, st, func(tx store.Tx, seq int64) error {
+		for _, p := range []*domain.ProposalSet{ps, &older} {
+			if err := tx.StoreProposalSet(ctx, p); err != nil {
+				return err
+			}
+		}
+		return tx.StoreResolution(ctx, res)
+	})
+
+	got, err := st.GetProposalSet(ctx, ps.ProposalSetID)
+	if err != nil || got.Question != "Which?" || len(got.Options) != 3 || *got.RecommendedOptionID != "b" || *got.Confidence != 0.75 || !got.CreatedAt.Equal(ps.CreatedAt) {
+		t.Errorf("GetProposalSet = %+v, %v", got, err)
+	}
+	if _, err := st.GetProposalSet(ctx, id("proposal_set")); !errors.Is(err, domain.ErrProposalSetNotFound) {
+		t.Errorf("GetProposalSet(unknown) error = %v", err)
+	}
+	list, err := st.ListProposalSetsForNode(ctx, node, ns, 0)
+	if err != nil || len(list) != 2 || list[0].ProposalSetID != ps.ProposalSetID || list[1].RecommendedOptionID != nil {
+		t.Errorf("ListProposalSetsForNode = %+v, %v", list, err)
+	}
+	r, err := st.GetResolution(ctx, res.ResolutionID)
+	if err != nil || r.OptionID != "b" || r.Rationale != "because" {
+		t.Errorf("GetResolution = %+v, %v", r, err)
+	}
+	if _, err := st.GetResolution(ctx, id("resolution")); !errors.Is(err, domain.ErrResolutionNotFound) {
+		t.Errorf("GetResolution(unknown) error = %v", err)
+	}
+	if r, err := st.GetResolutionForProposalSet(ctx, ps.ProposalSetID); err != nil || r == nil || r.ResolutionID != res.ResolutionID {
+		t.Errorf("GetResolutionForProposalSet = %+v, %v", r, err)
+	}
+	if r, err := st.GetResolutionForProposalSet(ctx, older.ProposalSetID); err != nil || r != nil {
+		t.Errorf("GetResolutionForProposalSet(unresolved) = %+v, %v", r, err)
+	}
+}
+
+func testEvents(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	agent, session := id("agent"), id("session")
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := st.CreateAgent(ctx, domain.Agent{AgentID: agent, DisplayName: "Test", CreatedAt: now, DefaultMode: domain.AgentModeNormal}); err != nil {
+		t.Fatalf("CreateAgent: %v", err)
+	}
+	if err := st.CreateSession(ctx, domain.Session{SessionID: session, AgentID: agent, StartedAt: now}); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	runtimeEvent := domain.Event{EventID: id("event"), TS: now.Add(-time.Minute), Type: "action.proposed", AgentID: agent, SessionID: session, Severity: "info",
+		PayloadJSON: map[string]interface{}{"decision": "ALLOW"}, CostGBP: ptr(0.5)}
+	if err := st.AppendEvent(ctx, &runtimeEvent); err != nil {
+		t.Fatalf("AppendEvent: %v", err)
+	}
+	kernelEvent := domain.Event{EventID: id("event"), TS: now, Type: "agent.halted", AgentID: agent, Severity: "warn", PayloadJSON: map[string]interface{}{}}
+	apply(t, st, func(tx store.Tx, seq int64) error {
+		if err := tx.HaltAgentTx(ctx, agent, "test"); err != nil {
+			return err
+		}
+		if err := tx.UpdateLimitsStateTx(ctx, domain.LimitsState{AgentID: agent, WindowStart: now, WindowType: "daily", BudgetSpentGBP: 1.5, ActionCount: 2}); err != nil {
+			return err
+		}
+		return tx.AppendEventTx(ctx, kernelEvent, seq)
+	})
+
+	if halted, err := st.IsAgentHalted(ctx, agent); err != nil || !halted {
+		t.Errorf("IsAgentHalted after HaltAgentTx = %v, %v", halted, err)
+	}
+	ls, err := st.GetLimitsState(ctx, agent, now.Unix()*1000, "daily")
+	if err != nil || ls == nil || ls.BudgetSpentGBP != 1.5 || ls.ActionCount != 2 {
+		t.Errorf("GetLimitsState = %+v, %v", ls, err)
+	}
+	events, err := st.QueryEvents(ctx, store.EventQuery{AgentID: &agent})
+	if err != nil || len(events) != 2 || events[0].EventID != kernelEvent.EventID {
+		t.Fatalf("QueryEvents = %+v, %v; want kernel event first", events, err)
+	}
+	if events[1].CostGBP == nil || *events[1].CostGBP != 0.5 || events[1].SessionID != session {
+		t.Errorf("runtime event = %+v", events[1])
+	}
+	page, err := st.QueryEvents(ctx, store.EventQuery{AgentID: &agent, CursorTS: &events[0].TS, CursorSeq: events[0].ChainSeq, CursorEventID: events[0].EventID})
+	if err != nil || len(page) != 1 || page[0].EventID != runtimeEvent.EventID {
+		t.Errorf("QueryEvents after cursor = %+v, %v", page, err)
+	}
+	if got, err := st.QueryEvents(ctx, store.EventQuery{AgentID: &agent, Decision: ptr("ALLOW")}); err != nil || len(got) != 1 {
+		t.Errorf("QueryEvents(decision) = %d events, %v", len(got), err)
+	}
+	since := now.UnixMilli()
+	if got, err := st.GetEvents(ctx, &agent, &since, 10); err != nil || len(got) != 1 || got[0].EventID != kernelEvent.EventID {
+		t.Errorf("GetEvents(since) = %+v, %v", got, err)
+	}
+	if e, err := st.GetEvent(ctx, runtimeEvent.EventID); err != nil || e == nil || e.Type != "action.proposed" {
+		t.Errorf("GetEvent = %+v, %v", e, err)
+	}
+	if _, err := st.GetEventRollups(ctx, &agent, nil, 10); err != nil {
+		t.Errorf("GetEventRollups: %v", err)
+	}
+
+	// Runtime events are hash chained; Kernel operation events are not
+	if runtimeEvent.ChainSeq == 0 || runtimeEvent.Hash == "" {
+		t.Errorf("AppendEvent did not link the event: chain_seq %d, hash %q", runtimeEvent.ChainSeq, runtimeEvent.Hash)
+	}
+	if events[1].ChainSeq != runtimeEvent.ChainSeq || events[1].Hash != runtimeEvent.Hash || events[0].ChainSeq != 0 {
+		t.Errorf("stored chain fields = (%d, %q), kernel event %d", events[1].ChainSeq, events[1].Hash, events[0].ChainSeq)
+	}
+	head, err := st.EventChainHead(ctx)
+	if err != nil || head.HeadSeq < runtimeEvent.ChainSeq || head.Unchained < 1 {
+		t.Errorf("EventChainHead = %+v, %v", head, err)
+	}
+	if res, err := eventchain.Verify(ctx, st); err != nil || !res.OK {
+		t.Errorf("eventchain.Verify = %+v, %v", res, err)
+	}
+}
+
+func testDecisions(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	agent := id("agent")
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	older := domain.CtrlDotDecisionRecord{ID: id("decision"), AgentID: agent, Decision: "DENY", ActionType: "exec",
+		Timestamp: now.Add(-time.Minute), ReceivedAt: now, Record: []byte(`{"decision":"DENY"}`)}
+	newer := domain.CtrlDotDecisionRecord{ID: id("decision"), AgentID: agent, SessionID: "s1", Decision: "ALLOW", ActionType: "exec",
+		Source: "host-a", Timestamp: now, ReceivedAt: now, Record: []byte(`{"decision":"ALLOW"}`)}
+	for _, r := range []domain.CtrlDotDecisionRecord{older, newer} {
+		if inserted, err := st.StoreCtrlDotDecision(ctx, r); err != nil || !inserted {
+			t.Fatalf("StoreCtrlDotDecision = %v, %v", inserted, err)
+		}
+	}
+	if inserted, err := st.StoreCtrlDotDecision(ctx, older); err != nil || inserted {
+		t.Errorf("StoreCtrlDotDecision(duplicate) = %v, %v; want false", inserted, err)
+	}
+	got, err := st.GetCtrlDotDecision(ctx, newer.ID)
+	if err != nil || got.Source != "host-a" || !got.Timestamp.Equal(now) || string(got.Record) != `{"decision":"ALLOW"}` {
+		t.Errorf("GetCtrlDotDecision = %+v, %v", got, err)
+	}
+	if _, err := st.GetCtrlDotDecision(ctx, id("decision")); !errors.Is(err, domain.ErrCtrlDotDecisionNotFound) {
+		t.Errorf("GetCtrlDotDecision(unknown) error = %v", err)
+	}
+	list, err := st.QueryCtrlDotDecisions(ctx, store.DecisionRecordQuery{AgentID: agent})
+	if err != nil || len(list) != 2 || list[0].ID != newer.ID {
+		t.Errorf("QueryCtrlDotDecisions = %+v, %v; want newest first", list, err)
+	}
+	until := now
+	list, err = st.QueryCtrlDotDecisions(ctx, store.DecisionRecordQuery{AgentID: agent, Until: &until})
+	if err != nil || len(list) != 1 || list[0].ID != older.ID {
+		t.Errorf("QueryCtrlDotDecisions(until) = %+v, %v", list, err)
+	}
+	list, err = st.QueryCtrlDotDecisions(ctx, store.DecisionRecordQuery{AgentID: agent, Decision: "ALLOW", Limit: 5})
+	if err != nil || len(list) != 1 || list[0].ID != newer.ID {
+		t.Errorf("QueryCtrlDotDecisions(decision) = %+v, %v", list, err)
+	}
+}
+
+func testOutbox(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	sink := id("sink")
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, recordID := range []string{"r1", "r2"} {
+		r := domain.OutboxRecord{Sink: sink, RecordID: recordID, Payload: `{}`, Status: domain.OutboxStatusPending, NextAttemptAt: now, CreatedAt: now, UpdatedAt: now}
+		if err := st.EnqueueOutbox(ctx, r); err != nil {
+			t.Fatalf("EnqueueOutbox: %v", err)
+		}
+	}
+	list, err := st.ListOutbox(ctx, sink, "", 0)
+	if err != nil || len(list) != 2 || list[0].RecordID != "r1" || list[0].Seq >= list[1].Seq {
+		t.Fatalf("ListOutbox = %+v, %v", list, err)
+	}
+	dead := list[1]
+	dead.Status, dead.Attempts, dead.LastError = domain.OutboxStatusDead, 3, "rejected"
+	if err := st.UpdateOutbox(ctx, dead); err != nil {
+		t.Fatalf("UpdateOutbox: %v", err)
+	}
+	if got, err := st.ListOutbox(ctx, sink, domain.OutboxStatusDead, 10); err != nil || len(got) != 1 || got[0].Attempts != 3 || got[0].LastError != "rejected" {
+		t.Errorf("ListOutbox(dead) = %+v, %v", got, err)
+	}
+	if err := st.DeleteOutbox(ctx, list[0].Seq); err != nil {
+		t.Fatalf("DeleteOutbox: %v", err)
+	}
+	if got, err := st.ListOutbox(ctx, sink, domain.OutboxStatusPending, 10); err != nil || len(got) != 0 {
+		t.Errorf("ListOutbox(pending) after delete = %+v, %v", got, err)
+	}
+	if counts, err := st.CountOutbox(ctx); err != nil || counts[domain.OutboxStatusDead] < 1 {
+		t.Errorf("CountOutbox = %v, %v", counts, err)
+	}
+}
+
+func testConfigRevisions(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	first := &domain.ConfigRevision{ContentHash: "sha256:a", Trigger: "startup", Changes: []string{}, Content: "rules: {}\n", CreatedAt: now}
+	second := &domain.ConfigRevision{ContentHash: "sha256:b", Author: "ops", Trigger: "api",
+		Changes: []string{"rules.network.deny_all: true -> false"}, Content: "rules:\n  network:\n    deny_all: false\n", CreatedAt: now}
+	for _, r := range []*domain.ConfigRevision{first, second} {
+		if err := st.AppendConfigRevision(ctx, r); err != nil {
+			t.Fatalf("AppendConfigRevision: %v", err)
+		}
+	}
+	if first.Rev == 0 || second.Rev <= first.Rev {
+		t.Fatalf("revs = %d, %d; want increasing", first.Rev, second.Rev)
+	}
+	list, err := st.ListConfigRevisions(ctx, 2)
+	if err != nil || len(list) != 2 || list[0].Rev != second.Rev || list[0].Changes[0] != second.Changes[0] {
+		t.Fatalf("ListConfigRevisions = %+v, %v", list, err)
+	}
+	got, err := st.GetConfigRevision(ctx, second.Rev)
+	if err != nil || got == nil || got.Content != second.Content || got.Author != "ops" || !got.CreatedAt.Equal(now) {
+		t.Errorf("GetConfigRevision = %+v, %v", got, err)
+	}
+	if got, err := st.GetConfigRevision(ctx, second.Rev+1000); err != nil || got != nil {
+		t.Errorf("GetConfigRevision(missing) = %+v, %v", got, err)
+	}
+}
+
+func testWebhooks(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	// Due long ago, so the delivery sorts ahead of any others in a shared database.
+	due := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := domain.WebhookDelivery{DeliveryID: id("delivery"), Endpoint: "test", EventID: id("event"), EventType: "agent.halted", Body: `{}`,
+		Status: domain.WebhookStatusPending, NextAttemptAt: due, CreatedAt: due, UpdatedAt: due}
+	if err := st.EnqueueWebhookDelivery(ctx, d); err != nil {
+		t.Fatalf("EnqueueWebhookDelivery: %v", err)
+	}
+	before := due.Add(time.Second)
+	list, err := st.ListWebhookDeliveries(ctx, domain.WebhookStatusPending, &before, 100)
+	if err != nil || !containsDelivery(list, d.DeliveryID) {
+		t.Errorf("ListWebhookDeliveries(due) does not include %s: %v", d.DeliveryID, err)
+	}
+	early := due.Add(-time.Second)
+	if list, err := st.ListWebhookDeliveries(ctx, domain.WebhookStatusPending, &early, 100); err != nil || containsDelivery(list, d.DeliveryID) {
+		t.Errorf("ListWebhookDeliveries(not yet due) includes %s: %v", d.DeliveryID, err)
+	}
+	d.Status, d.Attempts, d.LastStatusCode = domain.WebhookStatusDead, 5, 500
+	if err := st.UpdateWebhookDelivery(ctx, d); err != nil {
+		t.Fatalf("UpdateWebhookDelivery: %v", err)
+	}
+	got, err := st.GetWebhookDelivery(ctx, d.DeliveryID)
+	if err != nil || got == nil || got.Status != domain.WebhookStatusDead || got.Attempts != 5 || got.LastStatusCode != 500 {
+		t.Errorf("GetWebhookDelivery = %+v, %v", got, err)
+	}
+	if counts, err := st.CountWebhookDeliveries(ctx); err != nil || counts[domain.WebhookStatusDead] < 1 {
+		t.Errorf("CountWebhookDeliveries = %v, %v", counts, err)
+	}
+	if err := st.DeleteWebhookDelivery(ctx, d.DeliveryID); err != nil {
+		t.Fatalf("DeleteWebhookDelivery: %v", err)
+	}
+	if got, err := st.GetWebhookDelivery(ctx, d.DeliveryID); err != nil || got != nil {
+		t.Errorf("GetWebhookDelivery after delete = %+v, %v", got, err)
+	}
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDelivery(list []domain.WebhookDelivery, deliveryID string) bool {
+	for _, d := range list {
+		if d.DeliveryID == deliveryID {
+			return true
+		}
+	}
+	return false
+}
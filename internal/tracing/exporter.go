@@ -0,0 +1,305 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+)
+
+// DefaultEndpoint is the OTLP/HTTP traces endpoint of a local collector.
+const DefaultEndpoint = "http://127.0.0.1:4318/v1/traces"
+
+const (
+	queueSize     = 2048
+	batchSize     = 256
+	flushInterval = 5 * time.Second
+	scopeName     = "github.com/futurematic/kernel/internal/ctrldot"
+)
+
+// Exporter batches ended spans and posts them as OTLP/HTTP JSON. When the queue is full,
+// spans are dropped rather than slowing the propose path.
+type Exporter struct {
+	endpoint    string
+	serviceName string
+	headers     map[string]string
+	client      *http.Client
+
+	queue   chan *Span
+	flushCh chan chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+
+	mu      sync.Mutex
+	dropped int64
+}
+
+// NewExporter creates and starts an exporter for cfg.
+func NewExporter(cfg config.TracingConfig) *Exporter {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = "ctrldot"
+	}
+	timeout := time.Duration(cfg.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	e := &Exporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		headers:     cfg.Headers,
+		client:      &http.Client{Timeout: timeout},
+		queue:       make(chan *Span, queueSize),
+		flushCh:     make(chan chan struct{}),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+func (e *Exporter) enqueue(s *Span) {
+	select {
+	case e.queue <- s:
+	default:
+		e.mu.Lock()
+		e.dropped++
+		e.mu.Unlock()
+	}
+}
+
+// Dropped returns the number of spans dropped because the queue was full.
+func (e *Exporter) Dropped() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.dropped
+}
+
+// Flush exports everything queued so far.
+func (e *Exporter) Flush(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case e.flushCh <- ack:
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown exports queued spans and stops the background loop.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	e.once.Do(func() { close(e.stop) })
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	var batch []*Span
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.export(batch); err != nil {
+			log.Printf("tracing: export %d spans: %v", len(batch), err)
+		}
+		batch = nil
+	}
+	drain := func() {
+		for {
+			select {
+			case s := <-e.queue:
+				batch = append(batch, s)
+				if len(batch) >= batchSize {
+					send()
+				}
+			default:
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= batchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-e.flushCh:
+			drain()
+			send()
+			close(ack)
+		case <-e.stop:
+			drain()
+			send()
+			return
+		}
+	}
+}
+
+func (e *Exporter) export(spans []*Span) error {
+	body, err := json.Marshal(e.payload(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// OTLP JSON shapes (opentelemetry-proto ExportTraceServiceRequest, JSON mapping).
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"` // 0 unset, 2 error
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"` // int64 as decimal string
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+func (e *Exporter) payload(spans []*Span) otlpRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		os := otlpSpan{
+			TraceID:           hex.EncodeToString(s.sc.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.sc.SpanID[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		if s.parentID != [8]byte{} {
+			os.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		for _, a := range s.attrs {
+			os.Attributes = append(os.Attributes, otlpKeyValue{Key: a.Key, Value: anyValue(a.Value)})
+		}
+		if s.hasError {
+			os.Status = otlpStatus{Code: 2, Message: s.errMsg}
+		}
+		s.mu.Unlock()
+		out = append(out, os)
+	}
+	name := e.serviceName
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpKeyValue{{Key: "service.name", Value: otlpAnyValue{StringValue: &name}}}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: out}},
+	}}}
+}
+
+func anyValue(v interface{}) otlpAnyValue {
+	switch x := v.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &x}
+	case bool:
+		return otlpAnyValue{BoolValue: &x}
+	case int:
+		s := strconv.Itoa(x)
+		return otlpAnyValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(x, 10)
+		return otlpAnyValue{IntValue: &s}
+	case float64:
+		return otlpAnyValue{DoubleValue: &x}
+	case []string:
+		arr := &otlpArrayValue{Values: make([]otlpAnyValue, len(x))}
+		for i := range x {
+			arr.Values[i] = otlpAnyValue{StringValue: &x[i]}
+		}
+		return otlpAnyValue{ArrayValue: arr}
+	}
+	s := fmt.Sprint(v)
+	return otlpAnyValue{StringValue: &s}
+}
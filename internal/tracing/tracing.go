@@ -0,0 +1,270 @@
+// Package tracing is a small, dependency-free OpenTelemetry-compatible tracer for the propose
+// pipeline: spans with attributes, W3C traceparent propagation and OTLP/HTTP (JSON) export.
+//
+// Tracing is off until Configure is called with tracing.enabled; Start then returns nil spans,
+// and every *Span method is a no-op on nil, so call sites need no checks.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+)
+
+// TraceparentHeader is the W3C Trace Context header.
+const TraceparentHeader = "traceparent"
+
+// Span kinds (OTLP SpanKind values).
+const (
+	KindInternal = 1
+	KindServer   = 2
+)
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports whether both IDs are non-zero.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent formats sc as a version 00 traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceparent parses a W3C traceparent header ("00-<trace-id>-<parent-id>-<flags>").
+// Unknown future versions are accepted if the first four fields parse.
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, fmt.Errorf("malformed traceparent %q", s)
+	}
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, fmt.Errorf("unsupported traceparent version %q", parts[0])
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, fmt.Errorf("malformed trace-id: %w", err)
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, fmt.Errorf("malformed parent-id: %w", err)
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, fmt.Errorf("malformed trace-flags: %w", err)
+	}
+	sc.Sampled = flags[0]&0x01 == 1
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("traceparent has zero trace-id or parent-id")
+	}
+	return sc, nil
+}
+
+type remoteKey struct{}
+type spanKey struct{}
+
+// Extract returns ctx carrying the remote parent from the request's traceparent header, if valid.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	v := h.Get(TraceparentHeader)
+	if v == "" {
+		return ctx
+	}
+	sc, err := ParseTraceparent(v)
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Inject sets the traceparent header from the span in ctx, if any.
+func Inject(ctx context.Context, h http.Header) {
+	if s := SpanFromContext(ctx); s != nil {
+		h.Set(TraceparentHeader, s.sc.Traceparent())
+	}
+}
+
+// SpanFromContext returns the active span, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// Attr is a span attribute. Value may be string, bool, int, int64, float64 or []string.
+type Attr struct {
+	Key   string
+	Value interface{}
+}
+
+// String, Int, Bool and Strings build attributes.
+func String(k, v string) Attr           { return Attr{k, v} }
+func Int(k string, v int) Attr          { return Attr{k, int64(v)} }
+func Bool(k string, v bool) Attr        { return Attr{k, v} }
+func Strings(k string, v []string) Attr { return Attr{k, v} }
+
+// Span is one timed operation. A nil *Span is valid and records nothing.
+type Span struct {
+	tracer   *Tracer
+	name     string
+	kind     int
+	sc       SpanContext
+	parentID [8]byte
+	start    time.Time
+
+	mu       sync.Mutex
+	end      time.Time
+	attrs    []Attr
+	errMsg   string
+	hasError bool
+	ended    bool
+}
+
+// SpanContext returns the span's identifiers (zero for a nil span).
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetAttributes records attributes on the span.
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.attrs = append(s.attrs, attrs...)
+	s.mu.Unlock()
+}
+
+// RecordError marks the span as failed with err's message. nil errors are ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.hasError = true
+	s.errMsg = err.Error()
+	s.mu.Unlock()
+}
+
+// End finishes the span and hands it to the exporter. Subsequent calls are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.tracer.exporter.enqueue(s)
+}
+
+// Tracer creates spans and owns the exporter.
+type Tracer struct {
+	exporter    *Exporter
+	sampleRatio float64
+}
+
+var (
+	mu     sync.RWMutex
+	global *Tracer
+)
+
+// Configure starts the global tracer when cfg.Enabled; otherwise tracing stays off.
+// Call Shutdown to flush pending spans.
+func Configure(cfg config.TracingConfig) {
+	mu.Lock()
+	defer mu.Unlock()
+	if global != nil {
+		global.exporter.Shutdown(context.Background())
+		global = nil
+	}
+	if !cfg.Enabled {
+		return
+	}
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	global = &Tracer{exporter: NewExporter(cfg), sampleRatio: ratio}
+}
+
+// Shutdown flushes and stops the global tracer's exporter.
+func Shutdown(ctx context.Context) error {
+	mu.Lock()
+	t := global
+	global = nil
+	mu.Unlock()
+	if t == nil {
+		return nil
+	}
+	return t.exporter.Shutdown(ctx)
+}
+
+// Start starts a span named name as a child of the span (or remote parent) in ctx.
+// It returns ctx unchanged and a nil span when tracing is off or the trace is not sampled.
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	return StartKind(ctx, name, KindInternal, attrs...)
+}
+
+// StartKind is Start with an explicit span kind (KindServer for request entry points).
+func StartKind(ctx context.Context, name string, kind int, attrs ...Attr) (context.Context, *Span) {
+	mu.RLock()
+	t := global
+	mu.RUnlock()
+	if t == nil {
+		return ctx, nil
+	}
+
+	s := &Span{tracer: t, name: name, kind: kind, start: time.Now(), attrs: attrs}
+	if parent := SpanFromContext(ctx); parent != nil {
+		s.sc.TraceID = parent.sc.TraceID
+		s.parentID = parent.sc.SpanID
+		s.sc.Sampled = parent.sc.Sampled
+	} else if remote, ok := ctx.Value(remoteKey{}).(SpanContext); ok {
+		s.sc.TraceID = remote.TraceID
+		s.parentID = remote.SpanID
+		s.sc.Sampled = remote.Sampled
+	} else {
+		rand.Read(s.sc.TraceID[:])
+		s.sc.Sampled = t.sample(s.sc.TraceID)
+	}
+	rand.Read(s.sc.SpanID[:])
+	if !s.sc.Sampled {
+		// Carry the negative decision so children are not sampled as new roots.
+		return context.WithValue(ctx, remoteKey{}, s.sc), nil
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// sample decides root spans from the trace ID so the decision is stable per trace.
+func (t *Tracer) sample(id [16]byte) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	var n uint64
+	for _, b := range id[8:] {
+		n = n<<8 | uint64(b)
+	}
+	return float64(n>>11)/float64(uint64(1)<<53) < t.sampleRatio
+}
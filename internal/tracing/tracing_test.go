@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/futurematic/kernel/internal/config"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(tp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sc.Sampled || sc.Traceparent() != tp {
+		t.Errorf("round trip: got %s sampled=%v", sc.Traceparent(), sc.Sampled)
+	}
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestExportJoinsRemoteTrace(t *testing.T) {
+	var mu sync.Mutex
+	var got otlpRequest
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		var req otlpRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("collector got invalid JSON: %v", err)
+		}
+		got.ResourceSpans = append(got.ResourceSpans, req.ResourceSpans...)
+	}))
+	defer collector.Close()
+
+	Configure(config.TracingConfig{Enabled: true, Endpoint: collector.URL, ServiceName: "ctrldot-test"})
+	defer Configure(config.TracingConfig{})
+
+	h := http.Header{}
+	h.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, root := StartKind(Extract(context.Background(), h), "ctrldot.propose", KindServer)
+	_, child := Start(ctx, "ctrldot.rules")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	root.SetAttributes(String("ctrldot.decision", "STOP"), Strings("ctrldot.reason_codes", []string{"LOOP_STOP_THRESHOLD"}))
+	root.End()
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got.ResourceSpans) != 1 {
+		t.Fatalf("expected one export, got %d", len(got.ResourceSpans))
+	}
+	spans := got.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	rules, propose := spans[0], spans[1]
+	if propose.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || propose.ParentSpanID != "00f067aa0ba902b7" || propose.Kind != KindServer {
+		t.Errorf("root span did not join remote trace: %+v", propose)
+	}
+	if rules.TraceID != propose.TraceID || rules.ParentSpanID != propose.SpanID {
+		t.Errorf("child span not parented to root: %+v", rules)
+	}
+	if rules.Status.Code != 2 || rules.Status.Message != "boom" {
+		t.Errorf("expected error status, got %+v", rules.Status)
+	}
+	if len(propose.Attributes) != 2 || *propose.Attributes[0].Value.StringValue != "STOP" ||
+		*propose.Attributes[1].Value.ArrayValue.Values[0].StringValue != "LOOP_STOP_THRESHOLD" {
+		t.Errorf("unexpected attributes: %+v", propose.Attributes)
+	}
+}
+
+func TestDisabledAndUnsampled(t *testing.T) {
+	ctx, span := Start(context.Background(), "noop")
+	span.SetAttributes(String("k", "v"))
+	span.End()
+	if span != nil || SpanFromContext(ctx) != nil {
+		t.Fatalf("expected nil span when tracing is off")
+	}
+
+	Configure(config.TracingConfig{Enabled: true, Endpoint: "http://127.0.0.1:0"})
+	defer Configure(config.TracingConfig{})
+	h := http.Header{}
+	h.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	ctx, span = Start(Extract(context.Background(), h), "unsampled")
+	if span != nil {
+		t.Fatalf("expected caller's unsampled flag to be honoured")
+	}
+	if _, child := Start(ctx, "child"); child != nil {
+		t.Fatalf("child of unsampled span was sampled")
+	}
+}
@@ -170,7 +170,7 @@ func SetPanicOn(serverURL string) tea.Cmd {
 			return panicStateMsg{Err: err}
 		}
 		req.Header.Set("Content-Type", "application/json")
-		client := &http.Client{Timeout: 5 * time.Second}
+		client := &http.Client{Timeout: 5 * time.Second, Transport: http.DefaultClient.Transport}
 		resp, err := client.Do(req)
 		if err != nil {
 			return panicStateMsg{Err: err}
@@ -187,7 +187,7 @@ func SetPanicOn(serverURL string) tea.Cmd {
 func SetPanicOff(serverURL string) tea.Cmd {
 	return func() tea.Msg {
 		req, _ := http.NewRequest(http.MethodPost, serverURL+"/v1/panic/off", nil)
-		client := &http.Client{Timeout: 5 * time.Second}
+		client := &http.Client{Timeout: 5 * time.Second, Transport: http.DefaultClient.Transport}
 		resp, err := client.Do(req)
 		if err != nil {
 			return panicStateMsg{Err: err}
@@ -0,0 +1,156 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+// Built-in body formats (webhooks.endpoints[].format).
+const (
+	FormatGeneric = "generic"
+	FormatSlack   = "slack"
+)
+
+var builtinTemplates = map[string]string{
+	FormatGeneric: `{"delivery_id":{{json .DeliveryID}},"event_type":{{json .Event.Type}},"summary":{{json .Summary}},"event":{{json .Event}}}`,
+	FormatSlack:   `{"text":{{json .Summary}}}`,
+}
+
+// DefaultEvents and DefaultDecisions apply when an endpoint leaves events/decisions empty.
+var (
+	DefaultEvents = []string{
+		domain.EventTypeDecisionIssued,
+		domain.EventTypeAgentHalted,
+		domain.EventTypePanicEnabled,
+		domain.EventTypePanicDisabled,
+	}
+	DefaultDecisions = []string{string(domain.DecisionDeny), string(domain.DecisionStop)}
+)
+
+// TemplateData is the value passed to body templates.
+type TemplateData struct {
+	DeliveryID string
+	Event      domain.Event
+	Summary    string // one-line human-readable description
+	Decision   string // payload decision, if any
+	ActionType string // payload action_type, if any
+}
+
+// Matches reports whether ep subscribes to e. Events and decisions default to DefaultEvents and
+// DefaultDecisions; "*" matches anything. Decisions only constrain decision.issued events.
+func Matches(ep config.WebhookEndpoint, e domain.Event) bool {
+	if e.Type == EventTypeTest {
+		return false
+	}
+	if !matchList(eventsOrDefault(ep), e.Type) {
+		return false
+	}
+	if e.Type == domain.EventTypeDecisionIssued {
+		decision, _ := e.PayloadJSON["decision"].(string)
+		if !matchList(decisionsOrDefault(ep), decision) {
+			return false
+		}
+	}
+	if len(ep.Severities) > 0 && !matchList(ep.Severities, e.Severity) {
+		return false
+	}
+	return true
+}
+
+// Render produces the request body for e using ep's template or built-in format.
+func Render(ep config.WebhookEndpoint, e domain.Event, deliveryID string) ([]byte, error) {
+	text := ep.Template
+	if text == "" {
+		format := ep.Format
+		if format == "" {
+			format = FormatGeneric
+		}
+		var ok bool
+		if text, ok = builtinTemplates[format]; !ok {
+			return nil, fmt.Errorf("unknown webhook format %q (use generic or slack, or set template)", format)
+		}
+	}
+	tmpl, err := template.New(ep.Name).Funcs(template.FuncMap{"json": toJSON}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	data := TemplateData{DeliveryID: deliveryID, Event: e, Summary: Summary(e)}
+	data.Decision, _ = e.PayloadJSON["decision"].(string)
+	data.ActionType, _ = e.PayloadJSON["action_type"].(string)
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Summary describes e in one line, e.g. "Ctrl Dot: STOP for agent a1 (git.push)".
+func Summary(e domain.Event) string {
+	agent := e.AgentID
+	if agent == "" {
+		agent = "unknown"
+	}
+	reason, _ := e.PayloadJSON["reason"].(string)
+	withReason := func(s string) string {
+		if reason != "" {
+			return s + ": " + reason
+		}
+		return s
+	}
+	switch e.Type {
+	case domain.EventTypeDecisionIssued:
+		decision, _ := e.PayloadJSON["decision"].(string)
+		s := fmt.Sprintf("Ctrl Dot: %s for agent %s", decision, agent)
+		if at, _ := e.PayloadJSON["action_type"].(string); at != "" {
+			s += " (" + at + ")"
+		}
+		return withReason(s)
+	case domain.EventTypeAgentHalted:
+		return withReason("Ctrl Dot: agent " + agent + " halted")
+	case domain.EventTypePanicEnabled:
+		return withReason("Ctrl Dot: panic mode enabled")
+	case domain.EventTypePanicDisabled:
+		return "Ctrl Dot: panic mode disabled"
+	case EventTypeTest:
+		return "Ctrl Dot: webhook test"
+	}
+	if e.AgentID != "" {
+		return fmt.Sprintf("Ctrl Dot: %s (agent %s)", e.Type, e.AgentID)
+	}
+	return "Ctrl Dot: " + e.Type
+}
+
+func eventsOrDefault(ep config.WebhookEndpoint) []string {
+	if len(ep.Events) > 0 {
+		return ep.Events
+	}
+	return DefaultEvents
+}
+
+func decisionsOrDefault(ep config.WebhookEndpoint) []string {
+	if len(ep.Decisions) > 0 {
+		return ep.Decisions
+	}
+	return DefaultDecisions
+}
+
+func matchList(list []string, v string) bool {
+	for _, s := range list {
+		if s == "*" || strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
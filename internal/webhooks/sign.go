@@ -0,0 +1,39 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Request headers set on every webhook delivery.
+const (
+	HeaderEvent     = "X-Ctrldot-Event"
+	HeaderDelivery  = "X-Ctrldot-Delivery"
+	HeaderTimestamp = "X-Ctrldot-Timestamp"
+	HeaderSignature = "X-Ctrldot-Signature"
+)
+
+// Sign returns the X-Ctrldot-Signature value for body sent at timestamp (unix seconds):
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a received signature and rejects timestamps older than maxAge (0 = no age check).
+// Receivers written in Go can use this directly; others should reproduce Sign.
+func Verify(secret, timestamp, signature string, body []byte, maxAge time.Duration) bool {
+	if maxAge > 0 {
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil || time.Since(time.Unix(ts, 0)) > maxAge {
+			return false
+		}
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
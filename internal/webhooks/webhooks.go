@@ -0,0 +1,458 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/runtime"
+	"github.com/google/uuid"
+)
+
+// EventTypeTest is the event type of payloads sent by Dispatcher.Test.
+const EventTypeTest = "webhook.test"
+
+const (
+	pollInterval = time.Second
+	batchSize    = 50
+	// endpointWorkers bounds how many endpoints RunOnce delivers to at once.
+	endpointWorkers = 4
+)
+
+// Dispatcher queues matching events for the configured webhook endpoints and delivers them
+// from the runtime store with exponential backoff. Deliveries that fail max_attempts times
+// are kept as dead letters until retried.
+type Dispatcher struct {
+	store  runtime.RuntimeStore
+	cfg    atomic.Pointer[config.Config]
+	client *http.Client
+	now    func() time.Time
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New creates a dispatcher. It does nothing until Start is called.
+func New(store runtime.RuntimeStore, cfg *config.Config) *Dispatcher {
+	d := &Dispatcher{
+		store:  store,
+		client: &http.Client{},
+		now:    time.Now,
+		stopCh: make(chan struct{}),
+	}
+	d.cfg.Store(cfg)
+	return d
+}
+
+// SetConfig swaps in a reloaded config. Endpoints, filters and retry settings apply to events
+// appended and deliveries attempted from now on; queued deliveries to a removed endpoint fail
+// and end up as dead letters.
+func (d *Dispatcher) SetConfig(cfg *config.Config) {
+	d.cfg.Store(cfg)
+}
+
+// Wrap returns a RuntimeStore that enqueues webhook deliveries for every appended event
+// that matches an endpoint. Enqueue failures are logged and never fail the append.
+func (d *Dispatcher) Wrap(rs runtime.RuntimeStore) runtime.RuntimeStore {
+	return &notifyingStore{RuntimeStore: rs, d: d}
+}
+
+type notifyingStore struct {
+	runtime.RuntimeStore
+	d *Dispatcher
+}
+
+func (s *notifyingStore) AppendEvent(ctx context.Context, e *domain.Event) error {
+	if err := s.RuntimeStore.AppendEvent(ctx, e); err != nil {
+		return err
+	}
+	if err := s.d.Enqueue(ctx, *e); err != nil {
+		log.Printf("webhooks: enqueue %s: %v", e.EventID, err)
+	}
+	return nil
+}
+
+// CompactEvents queues deliveries for the run's events.compacted record, which the store
+// appends itself.
+func (s *notifyingStore) CompactEvents(ctx context.Context, olderThanTS int64, maxRows int, record *domain.Event) (*domain.EventCompaction, error) {
+	out, err := s.RuntimeStore.CompactEvents(ctx, olderThanTS, maxRows, record)
+	if err != nil || record == nil {
+		return out, err
+	}
+	if err := s.d.Enqueue(ctx, *record); err != nil {
+		log.Printf("webhooks: enqueue %s: %v", record.EventID, err)
+	}
+	return out, nil
+}
+
+// Start delivers due requests every second until Stop.
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.stopCh:
+				return
+			case <-ticker.C:
+				if _, err := d.RunOnce(context.Background()); err != nil {
+					log.Printf("webhooks: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the delivery loop and waits for an in-flight batch to finish.
+func (d *Dispatcher) Stop() {
+	close(d.stopCh)
+	d.wg.Wait()
+}
+
+// Enqueue renders and queues one delivery per endpoint that subscribes to e.
+func (d *Dispatcher) Enqueue(ctx context.Context, e domain.Event) error {
+	cfg := d.cfg.Load()
+	if cfg == nil {
+		return nil
+	}
+	now := d.now()
+	for _, ep := range cfg.Webhooks.Endpoints {
+		if !Matches(ep, e) {
+			continue
+		}
+		deliveryID := "whd:" + uuid.New().String()
+		body, err := Render(ep, e, deliveryID)
+		if err != nil {
+			return fmt.Errorf("endpoint %s: %w", ep.Name, err)
+		}
+		if err := d.store.EnqueueWebhookDelivery(ctx, domain.WebhookDelivery{
+			DeliveryID:    deliveryID,
+			Endpoint:      ep.Name,
+			EventID:       e.EventID,
+			EventType:     e.Type,
+			Body:          string(body),
+			Status:        domain.WebhookStatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunOnce attempts the due pending deliveries. Endpoints are served concurrently (up to
+// endpointWorkers at a time), each in queue order; after a failed send the endpoint's other
+// deliveries wait for the next run, so a slow or unreachable endpoint costs at most one
+// timeout per run and does not hold up the others. Returns the number delivered.
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	now := d.now()
+	due, err := d.store.ListWebhookDeliveries(ctx, runtime.WebhookFilter{
+		Status:    domain.WebhookStatusPending,
+		DueBefore: &now,
+		Limit:     batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+	var names []string
+	queues := make(map[string][]domain.WebhookDelivery)
+	for _, del := range due {
+		if _, ok := queues[del.Endpoint]; !ok {
+			names = append(names, del.Endpoint)
+		}
+		queues[del.Endpoint] = append(queues[del.Endpoint], del)
+	}
+
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		delivered int
+		firstErr  error
+	)
+	sem := make(chan struct{}, endpointWorkers)
+	for _, name := range names {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(queue []domain.WebhookDelivery) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			n, err := d.deliverQueue(ctx, queue)
+			mu.Lock()
+			defer mu.Unlock()
+			delivered += n
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}(queues[name])
+	}
+	wg.Wait()
+	return delivered, firstErr
+}
+
+// deliverQueue attempts one endpoint's due deliveries in order and stops at the first failed
+// send. Deliveries to an endpoint that is no longer configured are all dead-lettered.
+func (d *Dispatcher) deliverQueue(ctx context.Context, queue []domain.WebhookDelivery) (int, error) {
+	delivered := 0
+	for _, del := range queue {
+		ok, err := d.attempt(ctx, del)
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		} else if _, found := d.endpoint(del.Endpoint); found {
+			break
+		}
+	}
+	return delivered, nil
+}
+
+// attempt sends one delivery and records the outcome: delete on success, reschedule or dead-letter on failure.
+func (d *Dispatcher) attempt(ctx context.Context, del domain.WebhookDelivery) (bool, error) {
+	ep, found := d.endpoint(del.Endpoint)
+	var statusCode int
+	var sendErr error
+	if !found {
+		sendErr = fmt.Errorf("endpoint %q is no longer configured", del.Endpoint)
+	} else {
+		statusCode, sendErr = d.send(ctx, ep, del.DeliveryID, del.EventType, []byte(del.Body))
+	}
+	if sendErr == nil {
+		return true, d.store.DeleteWebhookDelivery(ctx, del.DeliveryID)
+	}
+
+	now := d.now()
+	del.Attempts++
+	del.LastError = sendErr.Error()
+	del.LastStatusCode = statusCode
+	del.UpdatedAt = now
+	if !found || del.Attempts >= d.maxAttempts() {
+		del.Status = domain.WebhookStatusDead
+		log.Printf("webhooks: %s to %s dead after %d attempts: %v", del.DeliveryID, del.Endpoint, del.Attempts, sendErr)
+	} else {
+		del.NextAttemptAt = now.Add(d.backoff(del.Attempts))
+	}
+	return false, d.store.UpdateWebhookDelivery(ctx, del)
+}
+
+// send POSTs body to ep with signature headers. Any 2xx response is success.
+func (d *Dispatcher) send(ctx context.Context, ep config.WebhookEndpoint, deliveryID, eventType string, body []byte) (int, error) {
+	timeout := 5 * time.Second
+	if ep.TimeoutMs > 0 {
+		timeout = time.Duration(ep.TimeoutMs) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	// Custom headers first, so they cannot replace the content type or the signed headers
+	for k, v := range ep.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ctrldot-webhooks")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, deliveryID)
+	ts := strconv.FormatInt(d.now().Unix(), 10)
+	req.Header.Set(HeaderTimestamp, ts)
+	if secret := endpointSecret(ep); secret != "" {
+		req.Header.Set(HeaderSignature, Sign(secret, ts, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+	return resp.StatusCode, nil
+}
+
+// Test sends a webhook.test event directly to each endpoint (or only the named one), bypassing the queue.
+func (d *Dispatcher) Test(ctx context.Context, name string) ([]domain.WebhookTestResult, error) {
+	cfg := d.cfg.Load()
+	var endpoints []config.WebhookEndpoint
+	if cfg != nil {
+		for _, ep := range cfg.Webhooks.Endpoints {
+			if name == "" || ep.Name == name {
+				endpoints = append(endpoints, ep)
+			}
+		}
+	}
+	if len(endpoints) == 0 {
+		if name != "" {
+			return nil, fmt.Errorf("webhook endpoint %q not configured", name)
+		}
+		return nil, fmt.Errorf("no webhook endpoints configured")
+	}
+
+	event := domain.Event{
+		EventID:     "evt:" + uuid.New().String(),
+		TS:          d.now(),
+		Type:        EventTypeTest,
+		Severity:    domain.EventSeverityInfo,
+		PayloadJSON: map[string]interface{}{"message": "Ctrl Dot webhook test"},
+	}
+	results := make([]domain.WebhookTestResult, 0, len(endpoints))
+	for _, ep := range endpoints {
+		res := domain.WebhookTestResult{Endpoint: ep.Name, URL: RedactURL(ep.URL)}
+		deliveryID := "whd:test-" + uuid.New().String()
+		body, err := Render(ep, event, deliveryID)
+		if err != nil {
+			res.Error = err.Error()
+			results = append(results, res)
+			continue
+		}
+		start := time.Now()
+		res.StatusCode, err = d.send(ctx, ep, deliveryID, event.Type, body)
+		res.DurationMs = time.Since(start).Milliseconds()
+		if err != nil {
+			res.Error = err.Error()
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// Status returns configured endpoints (without secrets) and queue counts.
+func (d *Dispatcher) Status(ctx context.Context) (*domain.WebhooksStatus, error) {
+	cfg := d.cfg.Load()
+	counts, err := d.store.CountWebhookDeliveries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st := &domain.WebhooksStatus{
+		Endpoints: []domain.WebhookEndpointInfo{},
+		Pending:   counts[domain.WebhookStatusPending],
+		Dead:      counts[domain.WebhookStatusDead],
+	}
+	if cfg != nil {
+		for _, ep := range cfg.Webhooks.Endpoints {
+			format := ep.Format
+			if ep.Template != "" {
+				format = "template"
+			} else if format == "" {
+				format = FormatGeneric
+			}
+			st.Endpoints = append(st.Endpoints, domain.WebhookEndpointInfo{
+				Name:       ep.Name,
+				URL:        RedactURL(ep.URL),
+				Format:     format,
+				Events:     eventsOrDefault(ep),
+				Decisions:  decisionsOrDefault(ep),
+				Severities: ep.Severities,
+				Signed:     endpointSecret(ep) != "",
+			})
+		}
+	}
+	return st, nil
+}
+
+// DeadLetters lists deliveries that exhausted their attempts.
+func (d *Dispatcher) DeadLetters(ctx context.Context, limit int) ([]domain.WebhookDelivery, error) {
+	return d.store.ListWebhookDeliveries(ctx, runtime.WebhookFilter{Status: domain.WebhookStatusDead, Limit: limit})
+}
+
+// Retry moves a dead letter back to the pending queue for immediate delivery.
+func (d *Dispatcher) Retry(ctx context.Context, deliveryID string) error {
+	del, err := d.store.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return err
+	}
+	if del == nil {
+		return fmt.Errorf("webhook delivery %q not found", deliveryID)
+	}
+	if del.Status != domain.WebhookStatusDead {
+		return fmt.Errorf("webhook delivery %q is %s, not dead", deliveryID, del.Status)
+	}
+	now := d.now()
+	del.Status = domain.WebhookStatusPending
+	del.Attempts = 0
+	del.NextAttemptAt = now
+	del.UpdatedAt = now
+	return d.store.UpdateWebhookDelivery(ctx, *del)
+}
+
+func (d *Dispatcher) endpoint(name string) (config.WebhookEndpoint, bool) {
+	cfg := d.cfg.Load()
+	if cfg != nil {
+		for _, ep := range cfg.Webhooks.Endpoints {
+			if ep.Name == name {
+				return ep, true
+			}
+		}
+	}
+	return config.WebhookEndpoint{}, false
+}
+
+func (d *Dispatcher) maxAttempts() int {
+	cfg := d.cfg.Load()
+	if cfg != nil && cfg.Webhooks.MaxAttempts > 0 {
+		return cfg.Webhooks.MaxAttempts
+	}
+	return 8
+}
+
+// backoff returns the delay before the next attempt after `attempts` failures:
+// initial_backoff_seconds doubled per failure, capped at max_backoff_seconds.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	cfg := d.cfg.Load()
+	initial, maxDelay := 5*time.Second, 900*time.Second
+	if cfg != nil && cfg.Webhooks.InitialBackoffSeconds > 0 {
+		initial = time.Duration(cfg.Webhooks.InitialBackoffSeconds) * time.Second
+	}
+	if cfg != nil && cfg.Webhooks.MaxBackoffSeconds > 0 {
+		maxDelay = time.Duration(cfg.Webhooks.MaxBackoffSeconds) * time.Second
+	}
+	delay := initial
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+func endpointSecret(ep config.WebhookEndpoint) string {
+	if ep.SecretEnv != "" {
+		if v := os.Getenv(ep.SecretEnv); v != "" {
+			return v
+		}
+	}
+	return ep.Secret
+}
+
+// RedactURL keeps scheme and host only; webhook URLs (e.g. Slack) often embed credentials in the path.
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "(invalid url)"
+	}
+	if u.Path == "" || u.Path == "/" {
+		return u.Scheme + "://" + u.Host
+	}
+	return u.Scheme + "://" + u.Host + "/…"
+}
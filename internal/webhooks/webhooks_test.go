@@ -0,0 +1,272 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/futurematic/kernel/internal/config"
+	"github.com/futurematic/kernel/internal/domain"
+	"github.com/futurematic/kernel/internal/runtime"
+	"github.com/futurematic/kernel/internal/runtime/sqlite"
+)
+
+// receiver is a local HTTP stand-in that records requests and fails the first `failures` of them.
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.bodies = append(rc.bodies, body)
+	rc.headers = append(rc.headers, r.Header.Clone())
+	if len(rc.bodies) <= rc.failures {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newTestDispatcher(t *testing.T, endpoints ...config.WebhookEndpoint) (*Dispatcher, *time.Time) {
+	t.Helper()
+	st, err := sqlite.Open(context.Background(), filepath.Join(t.TempDir(), "ctrldot.sqlite"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+
+	cfg := config.DefaultConfig()
+	cfg.Webhooks.Endpoints = endpoints
+	cfg.Webhooks.MaxAttempts = 3
+	cfg.Webhooks.InitialBackoffSeconds = 10
+	cfg.Webhooks.MaxBackoffSeconds = 15
+
+	now := time.Unix(1700000000, 0)
+	d := New(st, cfg)
+	d.now = func() time.Time { return now }
+	return d, &now
+}
+
+func decisionEvent(decision string) domain.Event {
+	return domain.Event{
+		EventID:  "evt:" + decision,
+		TS:       time.Unix(1700000000, 0),
+		Type:     domain.EventTypeDecisionIssued,
+		AgentID:  "agent-1",
+		Severity: domain.EventSeverityInfo,
+		PayloadJSON: map[string]interface{}{
+			"decision":    decision,
+			"reason":      "Loop detected: repeated action",
+			"action_type": "git.push",
+		},
+	}
+}
+
+func TestDeliverySignedAndDefaultFilters(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	d, _ := newTestDispatcher(t, config.WebhookEndpoint{Name: "ops", URL: server.URL, Secret: "s3cret",
+		Headers: map[string]string{"Authorization": "Bearer token", HeaderSignature: "sha256=forged", "content-type": "text/plain"}})
+	ctx := context.Background()
+	store := d.Wrap(d.store)
+
+	allow := decisionEvent("ALLOW")
+	stop := decisionEvent("STOP")
+	for _, e := range []*domain.Event{&allow, &stop} {
+		if err := store.AppendEvent(ctx, e); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	delivered, err := d.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if delivered != 1 || len(rc.bodies) != 1 {
+		t.Fatalf("expected only the STOP decision to be delivered, got %d deliveries", len(rc.bodies))
+	}
+
+	h := rc.headers[0]
+	if h.Get(HeaderEvent) != domain.EventTypeDecisionIssued {
+		t.Errorf("unexpected %s header %q", HeaderEvent, h.Get(HeaderEvent))
+	}
+	if !Verify("s3cret", h.Get(HeaderTimestamp), h.Get(HeaderSignature), rc.bodies[0], 0) {
+		t.Errorf("signature did not verify")
+	}
+	if Verify("wrong", h.Get(HeaderTimestamp), h.Get(HeaderSignature), rc.bodies[0], 0) {
+		t.Errorf("signature verified with the wrong secret")
+	}
+	// Custom headers are sent but cannot replace the ones the dispatcher sets
+	if h.Get("Authorization") != "Bearer token" || h.Get("Content-Type") != "application/json" || len(h.Values(HeaderSignature)) != 1 {
+		t.Errorf("custom headers: Authorization %q, Content-Type %q, signatures %q", h.Get("Authorization"), h.Get("Content-Type"), h.Values(HeaderSignature))
+	}
+
+	var body struct {
+		EventType string       `json:"event_type"`
+		Summary   string       `json:"summary"`
+		Event     domain.Event `json:"event"`
+	}
+	if err := json.Unmarshal(rc.bodies[0], &body); err != nil {
+		t.Fatalf("generic body is not JSON: %v", err)
+	}
+	if body.Event.EventID != stop.EventID || body.Summary != "Ctrl Dot: STOP for agent agent-1 (git.push): Loop detected: repeated action" {
+		t.Errorf("unexpected body: %s", rc.bodies[0])
+	}
+
+	counts, _ := d.store.CountWebhookDeliveries(ctx)
+	if counts[domain.WebhookStatusPending] != 0 {
+		t.Errorf("delivered request should be removed from the queue, got %v", counts)
+	}
+}
+
+func TestRetryBackoffAndDeadLetter(t *testing.T) {
+	rc := &receiver{failures: 100}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	d, now := newTestDispatcher(t, config.WebhookEndpoint{Name: "slack", URL: server.URL, Format: FormatSlack})
+	ctx := context.Background()
+	if err := d.Enqueue(ctx, decisionEvent("DENY")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	// Attempt 1 fails; the next attempt is 10s later.
+	d.RunOnce(ctx)
+	*now = now.Add(9 * time.Second)
+	d.RunOnce(ctx)
+	if len(rc.bodies) != 1 {
+		t.Fatalf("retried before backoff elapsed: %d requests", len(rc.bodies))
+	}
+	*now = now.Add(time.Second)
+	d.RunOnce(ctx) // attempt 2; next backoff 20s capped to 15s
+	*now = now.Add(15 * time.Second)
+	d.RunOnce(ctx) // attempt 3 exhausts max_attempts
+	if len(rc.bodies) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(rc.bodies))
+	}
+
+	var slack map[string]string
+	if err := json.Unmarshal(rc.bodies[0], &slack); err != nil || slack["text"] == "" {
+		t.Errorf("unexpected slack body: %s", rc.bodies[0])
+	}
+
+	dead, err := d.DeadLetters(ctx, 10)
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("expected one dead letter, got %d (%v)", len(dead), err)
+	}
+	if dead[0].Attempts != 3 || dead[0].LastStatusCode != http.StatusServiceUnavailable {
+		t.Errorf("unexpected dead letter: %+v", dead[0])
+	}
+
+	// Retry sends the identical body again.
+	rc.mu.Lock()
+	rc.failures = 0
+	rc.mu.Unlock()
+	if err := d.Retry(ctx, dead[0].DeliveryID); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if delivered, _ := d.RunOnce(ctx); delivered != 1 {
+		t.Fatalf("expected retried delivery to succeed")
+	}
+	if string(rc.bodies[3]) != string(rc.bodies[0]) {
+		t.Errorf("retried body changed")
+	}
+}
+
+func TestHangingEndpointDoesNotDelayOthers(t *testing.T) {
+	var mu sync.Mutex
+	hung := 0
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		mu.Lock()
+		hung++
+		mu.Unlock()
+		select {
+		case <-r.Context().Done(): // the dispatcher gave up
+		case <-release:
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+	rc := &receiver{}
+	fast := httptest.NewServer(rc)
+	defer fast.Close()
+
+	d, _ := newTestDispatcher(t,
+		config.WebhookEndpoint{Name: "slow", URL: slow.URL, TimeoutMs: 300},
+		config.WebhookEndpoint{Name: "fast", URL: fast.URL})
+	ctx := context.Background()
+	for _, id := range []string{"evt:1", "evt:2", "evt:3"} {
+		e := decisionEvent("DENY")
+		e.EventID = id
+		if err := d.Enqueue(ctx, e); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+
+	start := time.Now()
+	delivered, err := d.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	// One timeout for the hanging endpoint, not one per queued delivery
+	if elapsed := time.Since(start); elapsed > 550*time.Millisecond {
+		t.Errorf("RunOnce took %v with one hanging endpoint", elapsed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if delivered != 3 || len(rc.bodies) != 3 || hung != 1 {
+		t.Errorf("delivered %d (fast received %d), hanging endpoint tried %d times; want 3, 3, 1", delivered, len(rc.bodies), hung)
+	}
+
+	// The hanging endpoint's other deliveries wait, unattempted, for the next run
+	pending, _ := d.store.ListWebhookDeliveries(ctx, runtime.WebhookFilter{Status: domain.WebhookStatusPending})
+	attempts := 0
+	for _, del := range pending {
+		if del.Endpoint != "slow" {
+			t.Errorf("pending delivery to %s", del.Endpoint)
+		}
+		attempts += del.Attempts
+	}
+	if len(pending) != 3 || attempts != 1 {
+		t.Errorf("%d pending deliveries with %d attempts, want 3 with 1", len(pending), attempts)
+	}
+}
+
+func TestTestSendsToNamedEndpoint(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	d, _ := newTestDispatcher(t,
+		config.WebhookEndpoint{Name: "a", URL: server.URL},
+		config.WebhookEndpoint{Name: "b", URL: server.URL, Template: `{"msg":{{json .Summary}},"agent":{{json .Event.AgentID}}}`},
+	)
+	results, err := d.Test(context.Background(), "b")
+	if err != nil {
+		t.Fatalf("Test: %v", err)
+	}
+	if len(results) != 1 || results[0].Error != "" || results[0].StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if string(rc.bodies[0]) != `{"msg":"Ctrl Dot: webhook test","agent":""}` {
+		t.Errorf("unexpected templated body: %s", rc.bodies[0])
+	}
+	if _, err := d.Test(context.Background(), "missing"); err == nil {
+		t.Errorf("expected error for unknown endpoint")
+	}
+}
@@ -0,0 +1,16 @@
+-- Hourly per-agent aggregates of compacted Ctrl Dot events (events.retention_days / events.max_rows)
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS ctrldot_event_rollups (
+  agent_id TEXT NOT NULL DEFAULT '',
+  hour_start TIMESTAMPTZ NOT NULL,
+  event_type TEXT NOT NULL,
+  decision TEXT NOT NULL DEFAULT '',
+  event_count BIGINT NOT NULL DEFAULT 0,
+  cost_gbp DOUBLE PRECISION NOT NULL DEFAULT 0,
+  cost_tokens BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (agent_id, hour_start, event_type, decision)
+);
+CREATE INDEX IF NOT EXISTS idx_ctrldot_event_rollups_hour ON ctrldot_event_rollups(hour_start);
+
+COMMIT;
@@ -0,0 +1,20 @@
+-- Outbound webhook delivery queue for Ctrl Dot (pending retries and dead letters)
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS ctrldot_webhook_deliveries (
+  delivery_id TEXT PRIMARY KEY,
+  endpoint TEXT NOT NULL,
+  event_id TEXT NOT NULL,
+  event_type TEXT NOT NULL,
+  body TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NOT NULL,
+  last_error TEXT NOT NULL DEFAULT '',
+  last_status_code INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_ctrldot_webhook_deliveries_due ON ctrldot_webhook_deliveries(status, next_attempt_at);
+
+COMMIT;
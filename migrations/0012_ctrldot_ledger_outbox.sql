@@ -0,0 +1,19 @@
+-- Ledger sink outbox for Ctrl Dot: records not yet delivered (e.g. to Kernel), delivered in seq order
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS ctrldot_ledger_outbox (
+  seq BIGSERIAL PRIMARY KEY,
+  sink TEXT NOT NULL,
+  record_id TEXT NOT NULL,
+  payload TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NOT NULL,
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (sink, record_id)
+);
+CREATE INDEX IF NOT EXISTS idx_ctrldot_ledger_outbox_sink ON ctrldot_ledger_outbox(sink, status, seq);
+
+COMMIT;
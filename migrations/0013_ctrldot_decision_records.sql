@@ -0,0 +1,19 @@
+-- Ctrl Dot decision records received by Kernel (POST /v1/ctrldot/decisions), deduplicated by record ID
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS ctrldot_decision_records (
+  id TEXT PRIMARY KEY,
+  agent_id TEXT NOT NULL,
+  session_id TEXT NOT NULL DEFAULT '',
+  decision TEXT NOT NULL,
+  action_type TEXT NOT NULL DEFAULT '',
+  source TEXT NOT NULL DEFAULT '',
+  ts TIMESTAMPTZ NOT NULL,
+  received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  record JSONB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_ctrldot_decision_records_ts ON ctrldot_decision_records(ts DESC, id);
+CREATE INDEX IF NOT EXISTS idx_ctrldot_decision_records_agent ON ctrldot_decision_records(agent_id, ts DESC);
+CREATE INDEX IF NOT EXISTS idx_ctrldot_decision_records_session ON ctrldot_decision_records(session_id, ts DESC);
+
+COMMIT;
@@ -0,0 +1,20 @@
+-- Ctrl Dot event hash chain: each runtime event stores its chain position, the hash of the
+-- previous event and its own hash. Kernel operation events (op_seq set) stay unchained.
+-- The single ctrldot_event_chain row holds the head and the anchor left by compaction.
+BEGIN;
+
+ALTER TABLE ctrldot_events ADD COLUMN IF NOT EXISTS chain_seq BIGINT;
+ALTER TABLE ctrldot_events ADD COLUMN IF NOT EXISTS prev_hash TEXT;
+ALTER TABLE ctrldot_events ADD COLUMN IF NOT EXISTS hash TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ctrldot_events_chain_seq ON ctrldot_events(chain_seq);
+
+CREATE TABLE IF NOT EXISTS ctrldot_event_chain (
+  id INTEGER PRIMARY KEY CHECK (id = 1),
+  head_seq BIGINT NOT NULL DEFAULT 0,
+  head_hash TEXT NOT NULL DEFAULT '',
+  anchor_seq BIGINT NOT NULL DEFAULT 0,
+  anchor_hash TEXT NOT NULL DEFAULT ''
+);
+INSERT INTO ctrldot_event_chain (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,15 @@
+-- Applied Ctrl Dot config revisions, numbered in apply order. changes is a JSON array of strings.
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS ctrldot_config_revisions (
+  rev BIGSERIAL PRIMARY KEY,
+  content_hash TEXT NOT NULL,
+  author TEXT NOT NULL DEFAULT '',
+  trigger TEXT NOT NULL,
+  rollback_of BIGINT NOT NULL DEFAULT 0,
+  changes JSONB NOT NULL DEFAULT '[]',
+  content TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMIT;
@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/futurematic/kernel/internal/domain"
@@ -16,6 +19,8 @@ import (
 type Client struct {
 	BaseURL    string
 	HTTPClient *http.Client
+	// Token is sent as a bearer token when set (needed when the daemon has auth enabled)
+	Token string
 }
 
 // NewClient creates a new Ctrl Dot client
@@ -71,6 +76,16 @@ func (c *Client) ProposeAction(ctx context.Context, proposal domain.ActionPropos
 	return &decision, nil
 }
 
+// EvaluateActions asks for the decisions the proposals would get, in order, without recording
+// anything. Costs of proposals that would be allowed accumulate across the list.
+func (c *Client) EvaluateActions(ctx context.Context, proposals ...domain.ActionProposal) (*domain.EvaluateResponse, error) {
+	var result domain.EvaluateResponse
+	if err := c.postJSON(ctx, "/v1/actions/evaluate", domain.EvaluateRequest{Proposals: proposals}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 // GetEvents retrieves events
 func (c *Client) GetEvents(ctx context.Context, agentID *string, sinceTS *int64, limit int) ([]domain.Event, error) {
 	url := fmt.Sprintf("%s/v1/events?limit=%d", c.BaseURL, limit)
@@ -85,6 +100,7 @@ func (c *Client) GetEvents(ctx context.Context, agentID *string, sinceTS *int64,
 	if err != nil {
 		return nil, err
 	}
+	c.authorize(req)
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
@@ -104,6 +120,82 @@ func (c *Client) GetEvents(ctx context.Context, agentID *string, sinceTS *int64,
 	return events, nil
 }
 
+// EventQuery filters ListEvents. Zero values are ignored.
+type EventQuery struct {
+	AgentID    string
+	SessionID  string
+	Types      []string
+	Severities []string
+	Decision   string
+	ActionType string
+	ActionHash string
+	SinceTS    int64 // unix milliseconds, inclusive
+	UntilTS    int64 // unix milliseconds, exclusive
+	Cursor     string
+	Limit      int
+}
+
+// values encodes q as GET /v1/events query parameters.
+func (q EventQuery) values() url.Values {
+	params := url.Values{}
+	setParam := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	setParam("agent_id", q.AgentID)
+	setParam("session_id", q.SessionID)
+	setParam("type", strings.Join(q.Types, ","))
+	setParam("severity", strings.Join(q.Severities, ","))
+	setParam("decision", q.Decision)
+	setParam("action_type", q.ActionType)
+	setParam("action_hash", q.ActionHash)
+	setParam("cursor", q.Cursor)
+	if q.SinceTS > 0 {
+		params.Set("since_ts", strconv.FormatInt(q.SinceTS, 10))
+	}
+	if q.UntilTS > 0 {
+		params.Set("until_ts", strconv.FormatInt(q.UntilTS, 10))
+	}
+	if q.Limit > 0 {
+		params.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return params
+}
+
+// EventPage is one page of ListEvents results. NextCursor is empty on the last page.
+type EventPage struct {
+	Events     []domain.Event `json:"events"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// ListEvents retrieves one page of events matching q, newest first.
+// Pass the returned NextCursor as q.Cursor to fetch the next page.
+func (c *Client) ListEvents(ctx context.Context, q EventQuery) (*EventPage, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+"/v1/events?"+q.values().Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	c.authorize(req)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	page := &EventPage{NextCursor: resp.Header.Get("X-Next-Cursor")}
+	if err := json.NewDecoder(resp.Body).Decode(&page.Events); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
 // ListAgents lists all agents
 func (c *Client) ListAgents(ctx context.Context) ([]domain.Agent, error) {
 	var agents []domain.Agent
@@ -142,8 +234,23 @@ func (c *Client) ResumeAgent(ctx context.Context, agentID string) error {
 	return c.postJSON(ctx, "/v1/agents/"+agentID+"/resume", map[string]interface{}{}, nil)
 }
 
+// traceparentKey is the context key for WithTraceparent.
+type traceparentKey struct{}
+
+// WithTraceparent returns ctx carrying a W3C traceparent header value. Requests made with it
+// send the header, so the daemon's propose spans join the caller's trace.
+func WithTraceparent(ctx context.Context, traceparent string) context.Context {
+	return context.WithValue(ctx, traceparentKey{}, traceparent)
+}
+
 // Helper methods
 
+func (c *Client) authorize(req *http.Request) {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+}
+
 func (c *Client) postJSON(ctx context.Context, path string, body interface{}, result interface{}) error {
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
@@ -155,6 +262,10 @@ func (c *Client) postJSON(ctx context.Context, path string, body interface{}, re
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	c.authorize(req)
+	if tp, _ := ctx.Value(traceparentKey{}).(string); tp != "" {
+		req.Header.Set("traceparent", tp)
+	}
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
@@ -180,6 +291,7 @@ func (c *Client) getJSON(ctx context.Context, path string, result interface{}) e
 	if err != nil {
 		return err
 	}
+	c.authorize(req)
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
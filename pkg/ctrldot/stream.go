@@ -0,0 +1,214 @@
+package ctrldot
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/futurematic/kernel/internal/domain"
+)
+
+// EventStream iterates over live events from GET /v1/events/stream.
+// It reconnects automatically (resuming via Last-Event-ID) until its context is cancelled or Close is called.
+//
+//	stream := client.StreamEvents(ctx, ctrldot.EventQuery{AgentID: "agent-1"})
+//	defer stream.Close()
+//	for stream.Next() {
+//		e := stream.Event()
+//		...
+//	}
+//	if err := stream.Err(); err != nil { ... }
+type EventStream struct {
+	client *Client
+	http   *http.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+	params url.Values
+
+	body   io.ReadCloser
+	reader *bufio.Reader
+	lastID string
+	seen   map[string]bool
+	event  domain.Event
+	err    error
+}
+
+// StreamEvents opens a live event stream filtered by q. q.Cursor and q.Limit are ignored;
+// set q.Cursor to a previous stream's LastID to resume.
+func (c *Client) StreamEvents(ctx context.Context, q EventQuery) *EventStream {
+	ctx, cancel := context.WithCancel(ctx)
+	lastID := q.Cursor
+	q.Cursor, q.Limit = "", 0
+	return &EventStream{
+		client: c,
+		// The stream is long-lived, so the client's request timeout must not apply.
+		http:   &http.Client{Transport: c.HTTPClient.Transport},
+		ctx:    ctx,
+		cancel: cancel,
+		params: q.values(),
+		lastID: lastID,
+		seen:   make(map[string]bool),
+	}
+}
+
+// Next blocks until the next event is available. It returns false when the stream is closed
+// or a non-retryable error occurs (see Err).
+func (s *EventStream) Next() bool {
+	backoff := 500 * time.Millisecond
+	for {
+		if s.ctx.Err() != nil {
+			return false
+		}
+		if s.reader == nil {
+			if err := s.connect(); err != nil {
+				if s.ctx.Err() != nil {
+					return false
+				}
+				if !retryable(err) {
+					s.err = err
+					return false
+				}
+				select {
+				case <-s.ctx.Done():
+					return false
+				case <-time.After(backoff):
+				}
+				if backoff < 10*time.Second {
+					backoff *= 2
+				}
+				continue
+			}
+			backoff = 500 * time.Millisecond
+		}
+
+		e, id, err := s.readEvent()
+		if err != nil {
+			s.disconnect()
+			continue
+		}
+		if id != "" {
+			s.lastID = id
+		}
+		if s.seen[e.EventID] {
+			continue
+		}
+		if len(s.seen) > 4096 {
+			s.seen = make(map[string]bool)
+		}
+		s.seen[e.EventID] = true
+		s.event = e
+		return true
+	}
+}
+
+// Event returns the event read by the last successful Next.
+func (s *EventStream) Event() domain.Event {
+	return s.event
+}
+
+// LastID returns the id of the last received event; pass it as EventQuery.Cursor to resume later.
+func (s *EventStream) LastID() string {
+	return s.lastID
+}
+
+// Err returns the error that ended the stream, if any. Cancellation is not an error.
+func (s *EventStream) Err() error {
+	return s.err
+}
+
+// Close stops the stream.
+func (s *EventStream) Close() error {
+	s.cancel()
+	s.disconnect()
+	return nil
+}
+
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.code, e.body)
+}
+
+// retryable reports whether connecting again may succeed (network errors and 5xx).
+func retryable(err error) bool {
+	if se, ok := err.(*statusError); ok {
+		return se.code >= 500
+	}
+	return true
+}
+
+func (s *EventStream) connect() error {
+	req, err := http.NewRequestWithContext(s.ctx, "GET", s.client.BaseURL+"/v1/events/stream?"+s.params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	s.client.authorize(req)
+	if s.lastID != "" {
+		req.Header.Set("Last-Event-ID", s.lastID)
+	}
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return &statusError{code: resp.StatusCode, body: strings.TrimSpace(string(body))}
+	}
+	s.body = resp.Body
+	s.reader = bufio.NewReader(resp.Body)
+	return nil
+}
+
+func (s *EventStream) disconnect() {
+	if s.body != nil {
+		s.body.Close()
+	}
+	s.body, s.reader = nil, nil
+}
+
+// readEvent reads SSE lines until a complete event message is dispatched. Messages without an
+// id (such as stream.truncated, after which the server ends the stream) are skipped.
+func (s *EventStream) readEvent() (domain.Event, string, error) {
+	var id string
+	var data strings.Builder
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return domain.Event{}, "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if data.Len() == 0 || id == "" {
+				data.Reset()
+				continue
+			}
+			var e domain.Event
+			if err := json.Unmarshal([]byte(data.String()), &e); err != nil {
+				return domain.Event{}, "", err
+			}
+			return e, id, nil
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+}